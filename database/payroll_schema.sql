@@ -14,6 +14,15 @@ CREATE TABLE IF NOT EXISTS organizations (
   registration_number VARCHAR(100),
   pan VARCHAR(10),
   gst_number VARCHAR(15),
+  
+  -- Salary disbursement account
+  bank_name VARCHAR(100),
+  bank_account_number VARCHAR(20),
+  bank_ifsc_code VARCHAR(11),
+  
+  nps_registration_number VARCHAR(20), -- Corporate NPS registration (CHO/CBO number) with the POP
+  eligible_startup BOOLEAN DEFAULT FALSE, -- Section 80-IAC start-up: TDS on ESOP/RSU perquisites is deferred (Section 192(1C))
+  
   created_at TIMESTAMP DEFAULT NOW(),
   updated_at TIMESTAMP DEFAULT NOW(),
   is_active BOOLEAN DEFAULT TRUE
@@ -43,8 +52,11 @@ CREATE TABLE IF NOT EXISTS employees (
   
   -- Personal Info
   personal_pan VARCHAR(10),
+  pan_status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, verified, name_mismatch, inoperative; TDS at 20% or more without a valid PAN (Section 206AA)
+  pan_verified_at TIMESTAMP,
   aadhaar_number VARCHAR(12), -- Will be encrypted
   passport_number VARCHAR(20),
+  tax_regime VARCHAR(10) NOT NULL DEFAULT 'new', -- old, new (Section 115BAC)
   
   -- Bank Details
   bank_name VARCHAR(100),
@@ -62,7 +74,8 @@ CREATE TABLE IF NOT EXISTS employees (
   created_by UUID,
   updated_by UUID,
   
-  UNIQUE(org_id, employee_id)
+  UNIQUE(org_id, employee_id),
+  CHECK (tax_regime IN ('old', 'new'))
 );
 
 CREATE INDEX idx_employees_org ON employees(org_id);
@@ -86,6 +99,7 @@ CREATE TABLE IF NOT EXISTS salary_structures (
   monthly_da DECIMAL(15, 2) DEFAULT 0,
   monthly_hra DECIMAL(15, 2) DEFAULT 0,
   monthly_allowance DECIMAL(15, 2) DEFAULT 0,
+  monthly_superannuation DECIMAL(15, 2) DEFAULT 0, -- Employer contribution to a superannuation fund
   
   -- Additional Config
   is_template BOOLEAN DEFAULT TRUE,
@@ -186,10 +200,13 @@ CREATE TABLE IF NOT EXISTS payroll_runs (
   
   payroll_period_start DATE NOT NULL,
   payroll_period_end DATE NOT NULL,
-  payroll_month VARCHAR(7), -- YYYY-MM format, unique per org
+  payroll_month VARCHAR(7), -- YYYY-MM the period is attributed to (month of period end)
+  period_key VARCHAR(20), -- Pay group period (pay_group_periods.period_key); pay_group_id is added in section 19
+  run_type VARCHAR(20) NOT NULL DEFAULT 'regular', -- regular, supplementary, bonus, full_and_final, reversal
+  reverses_payroll_run_id UUID REFERENCES payroll_runs(id), -- Run whose components a reversal run negates
   
   -- Status tracking
-  status VARCHAR(50) DEFAULT 'draft', -- draft, in_progress, dry_run, finalized, locked, released
+  status VARCHAR(50) DEFAULT 'draft', -- draft, in_progress, dry_run, finalized, locked, reopened, released
   dry_run_count INT DEFAULT 0,
   
   -- Financial Summary
@@ -213,6 +230,7 @@ CREATE TABLE IF NOT EXISTS payroll_runs (
   approved_by UUID,
   released_at TIMESTAMP,
   released_by UUID,
+  bank_file_generated_at TIMESTAMP, -- Salaries can no longer be held once the main bank file exists
   
   -- Metadata
   created_at TIMESTAMP DEFAULT NOW(),
@@ -220,10 +238,12 @@ CREATE TABLE IF NOT EXISTS payroll_runs (
   created_by UUID,
   notes TEXT,
   
-  UNIQUE(org_id, payroll_month)
+  CHECK (run_type IN ('regular', 'supplementary', 'bonus', 'full_and_final', 'reversal')),
+  CHECK ((run_type = 'reversal') = (reverses_payroll_run_id IS NOT NULL))
 );
 
 CREATE INDEX idx_payroll_runs_org ON payroll_runs(org_id);
+CREATE INDEX idx_payroll_runs_month ON payroll_runs(org_id, payroll_month);
 CREATE INDEX idx_payroll_runs_status ON payroll_runs(status);
 CREATE INDEX idx_payroll_runs_period ON payroll_runs(payroll_period_start, payroll_period_end);
 
@@ -250,6 +270,16 @@ CREATE TABLE IF NOT EXISTS payroll_components (
   dearness_allowance DECIMAL(15, 2),
   house_rent_allowance DECIMAL(15, 2),
   other_allowances DECIMAL(15, 2),
+  shift_allowance DECIMAL(15, 2) DEFAULT 0, -- From shift_roster; not pro-rated
+  lop_days INT DEFAULT 0, -- Absent and unpaid leave days
+  lop_amount DECIMAL(15, 2) DEFAULT 0, -- Pay lost to lop_days, already left out of the earnings
+  lop_reversal DECIMAL(15, 2) DEFAULT 0, -- Paid back from lop_reversals
+  fbp_fixed DECIMAL(15, 2) DEFAULT 0, -- Fixed-payout flexible benefits
+  fbp_claims DECIMAL(15, 2) DEFAULT 0, -- Flexible benefit claims paid (fbp_claims)
+  fbp_unclaimed_payout DECIMAL(15, 2) DEFAULT 0, -- Year-end payout of unclaimed flexible benefits, taxable
+  fbp_accrued DECIMAL(15, 2) DEFAULT 0, -- Claim-based flexible benefits earned, not paid; not in gross
+  fbp_exempt DECIMAL(15, 2) DEFAULT 0, -- Flexible benefits exempt from tax (old regime)
+  one_time_payment DECIMAL(15, 2) DEFAULT 0, -- Bonus or settlement amount of an off-cycle run (payroll_run_employees)
   gross_amount DECIMAL(15, 2),
   
   -- Statutory Deductions
@@ -259,6 +289,18 @@ CREATE TABLE IF NOT EXISTS payroll_components (
   esi_employer DECIMAL(15, 2) DEFAULT 0,
   professional_tax DECIMAL(15, 2) DEFAULT 0,
   
+  -- Retirement Contributions (nps_enrolments, salary_structures.monthly_superannuation)
+  nps_employee DECIMAL(15, 2) DEFAULT 0,
+  nps_employer DECIMAL(15, 2) DEFAULT 0,
+  nps_80ccd2 DECIMAL(15, 2) DEFAULT 0, -- Employer NPS deducted from taxable income
+  superannuation_employer DECIMAL(15, 2) DEFAULT 0,
+  retirement_perquisite DECIMAL(15, 2) DEFAULT 0, -- Employer PF, NPS and superannuation above the yearly cap
+  
+  -- Equity Perquisites (equity_events), taxed but not paid; not in gross
+  equity_perquisite DECIMAL(15, 2) DEFAULT 0, -- ESOP exercises and RSU vestings
+  equity_perquisite_deferred DECIMAL(15, 2) DEFAULT 0, -- Part of equity_perquisite whose tax is deferred
+  equity_deferred_taxed DECIMAL(15, 2) DEFAULT 0, -- Earlier deferred perquisites taxed in this run
+  
   -- Income Tax
   tds DECIMAL(15, 2) DEFAULT 0,
   
@@ -266,6 +308,19 @@ CREATE TABLE IF NOT EXISTS payroll_components (
   advance_recovery DECIMAL(15, 2) DEFAULT 0,
   loan_recovery DECIMAL(15, 2) DEFAULT 0,
   other_deductions DECIMAL(15, 2) DEFAULT 0,
+  court_order_deduction DECIMAL(15, 2) DEFAULT 0,
+  
+  -- Deduction carry-forward (see deduction_ledger)
+  carry_forward_recovered DECIMAL(15, 2) DEFAULT 0, -- Recovered from earlier runs' shortfall
+  deduction_shortfall DECIMAL(15, 2) DEFAULT 0, -- Deferred to the next run (50% wage limit)
+  
+  -- Net pay rounding (see rounding_adjustments)
+  rounding_adjustment DECIMAL(15, 2) DEFAULT 0, -- Positive is an earning, negative a deduction
+  
+  -- Bases used for monthly limits across pay periods of the same month
+  pf_wage DECIMAL(15, 2) DEFAULT 0, -- Wage PF was calculated on, after the ceiling
+  esi_wage DECIMAL(15, 2) DEFAULT 0, -- Wage ESI was calculated on, after the ceiling
+  taxable_income DECIMAL(15, 2) DEFAULT 0, -- Income the TDS slab was applied to
   
   -- Net Calculation
   total_deductions DECIMAL(15, 2),
@@ -421,6 +476,907 @@ CREATE TABLE IF NOT EXISTS leave_summary (
   UNIQUE(org_id, employee_id, leave_month)
 );
 
+-- ============================================================================
+-- 13. PAYROLL RUN STATUTORY RULES (Rule rows each run was calculated with)
+-- ============================================================================
+-- Rows referenced by a locked or released run are immutable; corrections are
+-- made by superseding them with a new effective-dated version.
+CREATE TABLE IF NOT EXISTS payroll_run_statutory_rules (
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  statutory_rule_id UUID NOT NULL REFERENCES statutory_rules(id),
+  created_at TIMESTAMP DEFAULT NOW(),
+
+  PRIMARY KEY (payroll_run_id, statutory_rule_id)
+);
+
+CREATE INDEX idx_payroll_run_statutory_rules_rule ON payroll_run_statutory_rules(statutory_rule_id);
+
+-- ============================================================================
+-- 14. PAYROLL CALCULATION AUDITS (Step-by-step working per component)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS payroll_calculation_audits (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  payroll_component_id UUID NOT NULL UNIQUE REFERENCES payroll_components(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id),
+
+  inputs JSONB NOT NULL, -- Salary structure amounts and attendance
+  rule_versions JSONB NOT NULL, -- Statutory rule versions applied
+  steps JSONB NOT NULL, -- Ordered calculation steps
+  validations JSONB,
+
+  status VARCHAR(20) NOT NULL, -- valid, with_errors
+  calculated_by UUID,
+  calculated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_payroll_calculation_audits_run_employee ON payroll_calculation_audits(payroll_run_id, employee_id);
+
+-- ============================================================================
+-- 15. DEDUCTION LEDGER (Unrecovered deductions carried between runs)
+-- ============================================================================
+-- Balance per employee and category = SUM(deferred) - SUM(recovered)
+CREATE TABLE IF NOT EXISTS deduction_ledger (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  payroll_component_id UUID REFERENCES payroll_components(id) ON DELETE CASCADE,
+  
+  category VARCHAR(20) NOT NULL, -- court_order, loan, advance, other
+  entry_type VARCHAR(20) NOT NULL, -- deferred, recovered
+  amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+  
+  created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_deduction_ledger_employee ON deduction_ledger(employee_id, category);
+CREATE INDEX idx_deduction_ledger_run ON deduction_ledger(payroll_run_id);
+
+-- ============================================================================
+-- 16. PAYROLL SETTINGS (Organization-level payroll preferences)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS payroll_settings (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL UNIQUE REFERENCES organizations(id) ON DELETE CASCADE,
+  
+  -- Net pay rounding
+  rounding_mode VARCHAR(20) NOT NULL DEFAULT 'none', -- none, nearest, up, down
+  rounding_unit DECIMAL(5, 2) NOT NULL DEFAULT 1, -- 1, 5, 10
+  
+  -- Loss of pay: the days a month's salary is divided by
+  proration_basis VARCHAR(20) NOT NULL DEFAULT 'calendar_days', -- calendar_days, working_days, fixed_30
+  
+  -- Month-over-month variance: changes above either threshold are flagged; 0 turns a threshold off
+  variance_percent_threshold DECIMAL(6, 2) NOT NULL DEFAULT 10,
+  variance_amount_threshold DECIMAL(15, 2) NOT NULL DEFAULT 5000,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  updated_by UUID,
+  
+  CHECK (rounding_mode IN ('none', 'nearest', 'up', 'down')),
+  CHECK (rounding_unit IN (1, 5, 10)),
+  CHECK (proration_basis IN ('calendar_days', 'working_days', 'fixed_30')),
+  CHECK (variance_percent_threshold >= 0 AND variance_amount_threshold >= 0)
+);
+
+-- ============================================================================
+-- 17. ROUNDING ADJUSTMENTS (Net pay rounding carried between runs)
+-- ============================================================================
+-- Balance per employee = SUM(amount); the next run nets it off and the final
+-- settlement clears it
+CREATE TABLE IF NOT EXISTS rounding_adjustments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  payroll_component_id UUID NOT NULL UNIQUE REFERENCES payroll_components(id) ON DELETE CASCADE,
+  
+  amount DECIMAL(15, 2) NOT NULL, -- Net paid minus net calculated
+  
+  created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_rounding_adjustments_employee ON rounding_adjustments(employee_id);
+
+-- ============================================================================
+-- 18. PAYROLL HOLDS (Withhold an individual employee's salary)
+-- ============================================================================
+-- Held components stay in the run and its statutory reports but are left out
+-- of the bank file. Once released (or lapsed past expires_at) they are paid
+-- through a supplementary bank file.
+CREATE TABLE IF NOT EXISTS payroll_holds (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  payroll_component_id UUID NOT NULL REFERENCES payroll_components(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id),
+  
+  reason_code VARCHAR(30) NOT NULL, -- absconding, exit_clearance, bank_mismatch, other
+  reason TEXT,
+  status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, released, paid, withdrawn
+  
+  held_by UUID NOT NULL,
+  held_at TIMESTAMP DEFAULT NOW(),
+  expires_at DATE,
+  
+  released_by UUID,
+  released_at TIMESTAMP,
+  release_notes TEXT,
+  supplementary_file_reference VARCHAR(50),
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  
+  CHECK (status IN ('active', 'released', 'paid', 'withdrawn'))
+);
+
+-- Withdrawn holds (released before the run was locked) do not count
+CREATE UNIQUE INDEX idx_payroll_holds_component ON payroll_holds(payroll_component_id) WHERE status <> 'withdrawn';
+CREATE INDEX idx_payroll_holds_run ON payroll_holds(payroll_run_id);
+CREATE INDEX idx_payroll_holds_org_status ON payroll_holds(org_id, status);
+
+-- ============================================================================
+-- 19. PAY GROUPS (Employees paid on the same frequency)
+-- ============================================================================
+-- Employees without a pay group belong to the org's default group. Payroll
+-- runs are keyed by pay group and period rather than by month.
+CREATE TABLE IF NOT EXISTS pay_groups (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  name VARCHAR(100) NOT NULL,
+  
+  frequency VARCHAR(20) NOT NULL DEFAULT 'monthly', -- monthly, semi_monthly, fortnightly, weekly
+  anchor_date DATE, -- First day of any period; required for weekly and fortnightly
+  
+  is_default BOOLEAN DEFAULT FALSE,
+  is_active BOOLEAN DEFAULT TRUE,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(org_id, name),
+  CHECK (frequency IN ('monthly', 'semi_monthly', 'fortnightly', 'weekly'))
+);
+
+CREATE UNIQUE INDEX idx_pay_groups_default ON pay_groups(org_id) WHERE is_default;
+
+ALTER TABLE employees ADD COLUMN IF NOT EXISTS pay_group_id UUID REFERENCES pay_groups(id);
+ALTER TABLE payroll_runs ADD COLUMN IF NOT EXISTS pay_group_id UUID REFERENCES pay_groups(id);
+
+CREATE INDEX idx_employees_pay_group ON employees(pay_group_id);
+-- Off-cycle runs (section 41) share the period of the regular run
+CREATE UNIQUE INDEX idx_payroll_runs_pay_group_period ON payroll_runs(pay_group_id, period_key) WHERE run_type = 'regular';
+
+-- ============================================================================
+-- 20. PAY GROUP PERIODS (Period calendar of each pay group)
+-- ============================================================================
+-- payroll_month is the month the period ends in; PF, ESI, PT and TDS monthly
+-- limits are applied across all periods of that month
+CREATE TABLE IF NOT EXISTS pay_group_periods (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  pay_group_id UUID NOT NULL REFERENCES pay_groups(id) ON DELETE CASCADE,
+  
+  period_key VARCHAR(20) NOT NULL, -- 2024-01, 2024-01-2, 2024-W05, 2024-F03
+  period_start DATE NOT NULL,
+  period_end DATE NOT NULL,
+  payroll_month VARCHAR(7) NOT NULL,
+  payment_date DATE,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(pay_group_id, period_key),
+  CHECK (period_end >= period_start)
+);
+
+CREATE INDEX idx_pay_group_periods_dates ON pay_group_periods(pay_group_id, period_start);
+
+-- ============================================================================
+-- 21. SHIFT ROSTER (Shift assignments ingested from the roster)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS shift_roster (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  
+  shift_date DATE NOT NULL,
+  shift_type VARCHAR(100) NOT NULL,
+  start_time TIME NOT NULL,
+  end_time TIME NOT NULL, -- Earlier than start_time when the shift crosses midnight
+  shift_location VARCHAR(100),
+  status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, cancelled
+  source_reference VARCHAR(100), -- Roster shift assignment name
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(employee_id, shift_date, shift_type),
+  CHECK (status IN ('active', 'cancelled'))
+);
+
+CREATE INDEX idx_shift_roster_org_date ON shift_roster(org_id, shift_date);
+
+-- ============================================================================
+-- 22. SHIFT ALLOWANCE POLICIES (Allowance per shift type)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS shift_allowance_policies (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  shift_type VARCHAR(100) NOT NULL,
+  
+  amount_per_shift DECIMAL(10, 2) NOT NULL DEFAULT 0,
+  night_premium DECIMAL(10, 2) NOT NULL DEFAULT 0, -- Added for each night shift
+  min_shifts INT NOT NULL DEFAULT 0, -- Nothing is paid below this many shifts in a pay period
+  
+  effective_from DATE NOT NULL,
+  effective_till DATE,
+  is_active BOOLEAN DEFAULT TRUE,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  updated_by UUID,
+  
+  UNIQUE(org_id, shift_type, effective_from),
+  CHECK (amount_per_shift >= 0 AND night_premium >= 0 AND min_shifts >= 0)
+);
+
+-- ============================================================================
+-- 23. LOP REVERSALS (Loss of pay wrongly deducted in an earlier month)
+-- ============================================================================
+-- Pending reversals are paid back by the employee's next payroll run
+CREATE TABLE IF NOT EXISTS lop_reversals (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  
+  lop_month VARCHAR(7) NOT NULL, -- YYYY-MM the days were deducted in
+  days INT NOT NULL,
+  reason TEXT NOT NULL,
+  
+  status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, applied, cancelled
+  payroll_run_id UUID REFERENCES payroll_runs(id) ON DELETE SET NULL,
+  payroll_component_id UUID REFERENCES payroll_components(id) ON DELETE SET NULL,
+  amount DECIMAL(15, 2), -- Paid back, set when applied
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  CHECK (days > 0),
+  CHECK (status IN ('pending', 'applied', 'cancelled'))
+);
+
+CREATE INDEX idx_lop_reversals_employee ON lop_reversals(employee_id, status);
+
+-- ============================================================================
+-- 24. HOLIDAY CALENDARS (Holidays per location and year)
+-- ============================================================================
+-- location '' is the organization-wide calendar, used for locations without their own
+CREATE TABLE IF NOT EXISTS holiday_calendars (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  location VARCHAR(100) NOT NULL DEFAULT '',
+  calendar_year INT NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(org_id, location, calendar_year)
+);
+
+-- ============================================================================
+-- 25. HOLIDAYS (Days of a holiday calendar)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS holidays (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  calendar_id UUID NOT NULL REFERENCES holiday_calendars(id) ON DELETE CASCADE,
+  holiday_date DATE NOT NULL,
+  name VARCHAR(255) NOT NULL,
+  holiday_type VARCHAR(20) NOT NULL DEFAULT 'mandatory', -- mandatory, restricted (optional)
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(calendar_id, holiday_date),
+  CHECK (holiday_type IN ('mandatory', 'restricted'))
+);
+
+CREATE INDEX idx_holidays_date ON holidays(holiday_date);
+
+-- ============================================================================
+-- 26. WEEKLY OFF POLICIES (Weekly off pattern per location)
+-- ============================================================================
+-- location '' is the organization-wide policy; without one, Sundays are off
+CREATE TABLE IF NOT EXISTS weekly_off_policies (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  location VARCHAR(100) NOT NULL DEFAULT '',
+  
+  pattern VARCHAR(30) NOT NULL DEFAULT 'sunday_only', -- sunday_only, alternate_saturdays, rotating
+  saturday_weeks INT[], -- alternate_saturdays: e.g. {2,4}
+  rotation_anchor DATE, -- rotating: first day of a cycle
+  rotation_cycle INT, -- rotating: days in a cycle
+  rotation_off_days INT[], -- rotating: 0-based off days of the cycle
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  updated_by UUID,
+  
+  UNIQUE(org_id, location),
+  CHECK (pattern IN ('sunday_only', 'alternate_saturdays', 'rotating'))
+);
+
+-- ============================================================================
+-- 27. NPS ENROLMENTS (Corporate NPS, Section 80CCD(2))
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS nps_enrolments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  pran VARCHAR(12) NOT NULL, -- Permanent Retirement Account Number
+  
+  employer_rate DECIMAL(5, 2) NOT NULL, -- % of Basic + DA
+  employee_rate DECIMAL(5, 2) NOT NULL DEFAULT 0, -- % of Basic + DA, 0 when the employee does not contribute
+  
+  effective_from DATE NOT NULL,
+  effective_till DATE,
+  is_active BOOLEAN DEFAULT TRUE,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(employee_id, effective_from),
+  CHECK (employer_rate >= 0 AND employee_rate >= 0)
+);
+
+CREATE INDEX idx_nps_enrolments_org ON nps_enrolments(org_id);
+
+-- ============================================================================
+-- 28. FBP PLAN COMPONENTS (Flexible benefit basket of a salary structure)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS fbp_plan_components (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  salary_structure_id UUID NOT NULL REFERENCES salary_structures(id) ON DELETE CASCADE,
+  component VARCHAR(30) NOT NULL, -- meal_card, telephone, lta, books_periodicals, fuel
+  
+  annual_limit DECIMAL(15, 2) NOT NULL, -- Most an employee may allocate in a year
+  payout_type VARCHAR(10) NOT NULL DEFAULT 'claim', -- fixed (paid monthly), claim (paid against approved claims)
+  is_active BOOLEAN DEFAULT TRUE,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  updated_by UUID,
+  
+  UNIQUE(salary_structure_id, component),
+  CHECK (annual_limit >= 0),
+  CHECK (payout_type IN ('fixed', 'claim'))
+);
+
+-- ============================================================================
+-- 29. FBP DECLARATIONS (Employee allocation from special allowance per year)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS fbp_declarations (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  financial_year INT NOT NULL, -- Year the financial year starts in, e.g. 2024 for April 2024 - March 2025
+  component VARCHAR(30) NOT NULL,
+  
+  annual_amount DECIMAL(15, 2) NOT NULL,
+  payout_type VARCHAR(10) NOT NULL, -- Copied from the plan when declared
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(employee_id, financial_year, component),
+  CHECK (annual_amount >= 0)
+);
+
+-- ============================================================================
+-- 30. FBP CLAIMS (Bills claimed against claim-based FBP components)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS fbp_claims (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  financial_year INT NOT NULL,
+  component VARCHAR(30) NOT NULL,
+  
+  claim_date DATE NOT NULL,
+  amount DECIMAL(15, 2) NOT NULL,
+  bill_reference VARCHAR(100),
+  description TEXT,
+  
+  status VARCHAR(20) NOT NULL DEFAULT 'submitted', -- submitted, approved, rejected, paid
+  reviewed_by UUID,
+  reviewed_at TIMESTAMP,
+  
+  -- Set when paid
+  payroll_run_id UUID REFERENCES payroll_runs(id) ON DELETE SET NULL,
+  payroll_component_id UUID REFERENCES payroll_components(id) ON DELETE SET NULL,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  CHECK (amount > 0),
+  CHECK (status IN ('submitted', 'approved', 'rejected', 'paid'))
+);
+
+CREATE INDEX idx_fbp_claims_employee ON fbp_claims(employee_id, financial_year);
+CREATE INDEX idx_fbp_claims_status ON fbp_claims(org_id, status);
+
+-- ============================================================================
+-- 31. EQUITY GRANTS (ESOP and RSU awards)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS equity_grants (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  grant_number VARCHAR(50) NOT NULL,
+  grant_type VARCHAR(10) NOT NULL, -- esop, rsu
+  plan_name VARCHAR(100),
+  
+  grant_date DATE NOT NULL,
+  units DECIMAL(15, 4) NOT NULL,
+  exercise_price DECIMAL(15, 4) NOT NULL DEFAULT 0, -- Per unit; usually 0 for RSUs
+  status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, cancelled
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(org_id, grant_number),
+  CHECK (grant_type IN ('esop', 'rsu')),
+  CHECK (units > 0 AND exercise_price >= 0),
+  CHECK (status IN ('active', 'cancelled'))
+);
+
+CREATE INDEX idx_equity_grants_employee ON equity_grants(employee_id);
+
+-- ============================================================================
+-- 32. EQUITY EVENTS (Vesting, exercise and sale; perquisites taxed in payroll)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS equity_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  grant_id UUID NOT NULL REFERENCES equity_grants(id) ON DELETE CASCADE,
+  event_type VARCHAR(10) NOT NULL, -- vest, exercise, sale
+  event_date DATE NOT NULL,
+  units DECIMAL(15, 4) NOT NULL,
+  
+  -- Perquisite (ESOP exercise, RSU vesting): (fmv - exercise_price) x units
+  fmv DECIMAL(15, 4) NOT NULL DEFAULT 0, -- Fair market value per unit on event_date (Rule 3(8))
+  exercise_price DECIMAL(15, 4) NOT NULL DEFAULT 0,
+  perquisite_value DECIMAL(15, 2) NOT NULL DEFAULT 0,
+  tax_deferred BOOLEAN DEFAULT FALSE, -- Eligible start-up
+  tax_due_date DATE, -- 48 months from the end of the assessment year, or earlier sale or exit
+  
+  status VARCHAR(20) NOT NULL DEFAULT 'recorded', -- recorded (no perquisite), pending, deferred, taxed, cancelled
+  perquisite_run_id UUID REFERENCES payroll_runs(id) ON DELETE SET NULL, -- Run that reported the perquisite
+  perquisite_component_id UUID REFERENCES payroll_components(id) ON DELETE SET NULL,
+  tax_run_id UUID REFERENCES payroll_runs(id) ON DELETE SET NULL, -- Run that deducted tax on it
+  tax_component_id UUID REFERENCES payroll_components(id) ON DELETE SET NULL,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  CHECK (event_type IN ('vest', 'exercise', 'sale')),
+  CHECK (units > 0 AND fmv >= 0 AND exercise_price >= 0),
+  CHECK (status IN ('recorded', 'pending', 'deferred', 'taxed', 'cancelled'))
+);
+
+CREATE INDEX idx_equity_events_grant ON equity_events(grant_id);
+CREATE INDEX idx_equity_events_employee ON equity_events(employee_id, status);
+
+-- ============================================================================
+-- 33. PREVIOUS EMPLOYMENTS (Form 12B: earlier employers' salary and TDS)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS previous_employments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  financial_year INT NOT NULL, -- Year the financial year starts in
+  employer_name VARCHAR(255) NOT NULL,
+  employer_tan VARCHAR(10) NOT NULL,
+  period_from DATE NOT NULL,
+  period_to DATE NOT NULL,
+  
+  gross_salary DECIMAL(15, 2) NOT NULL DEFAULT 0, -- Including perquisites, Section 17
+  exemptions DECIMAL(15, 2) NOT NULL DEFAULT 0, -- Section 10
+  pf_employee DECIMAL(15, 2) NOT NULL DEFAULT 0,
+  professional_tax DECIMAL(15, 2) NOT NULL DEFAULT 0,
+  tds DECIMAL(15, 2) NOT NULL DEFAULT 0,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(employee_id, financial_year, employer_tan),
+  CHECK (period_to >= period_from),
+  CHECK (gross_salary >= 0 AND exemptions >= 0 AND pf_employee >= 0 AND professional_tax >= 0 AND tds >= 0)
+);
+
+-- ============================================================================
+-- 34. INCOME TAX SLABS (Annual slabs per financial year, regime and age)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS income_tax_slabs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  financial_year INT NOT NULL, -- Year the financial year starts in
+  tax_regime VARCHAR(10) NOT NULL, -- old, new (Section 115BAC)
+  min_age INT NOT NULL DEFAULT 0, -- 0, 60 (senior citizen) or 80 (super senior citizen); the highest one not above the employee's age applies
+  
+  income_from DECIMAL(15, 2) NOT NULL,
+  income_to DECIMAL(15, 2), -- NULL means no upper limit
+  rate DECIMAL(5, 2) NOT NULL, -- Percentage of the income between income_from and income_to
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(financial_year, tax_regime, min_age, income_from),
+  CHECK (tax_regime IN ('old', 'new')),
+  CHECK (min_age >= 0),
+  CHECK (income_to IS NULL OR income_to > income_from),
+  CHECK (rate >= 0 AND rate <= 100)
+);
+
+-- Surcharge on income tax above each income threshold, with marginal relief
+CREATE TABLE IF NOT EXISTS income_tax_surcharges (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  financial_year INT NOT NULL,
+  tax_regime VARCHAR(10) NOT NULL,
+  income_above DECIMAL(15, 2) NOT NULL,
+  rate DECIMAL(5, 2) NOT NULL, -- Percentage of income tax
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(financial_year, tax_regime, income_above),
+  CHECK (tax_regime IN ('old', 'new')),
+  CHECK (rate >= 0 AND rate <= 100)
+);
+
+-- ============================================================================
+-- 35. PAYROLL RUN TRANSITIONS (Status history of each run)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS payroll_run_transitions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  
+  from_status VARCHAR(50), -- NULL when the run was created
+  to_status VARCHAR(50) NOT NULL,
+  action VARCHAR(50) NOT NULL, -- create, initiate, dry_run, finalize, approve, reject, release, reopen, recalculate
+  actor VARCHAR(255) NOT NULL, -- User ID, or system for automated actions
+  comment TEXT,
+  
+  created_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX idx_payroll_run_transitions_run ON payroll_run_transitions(payroll_run_id, created_at);
+
+-- ============================================================================
+-- 36. PAYROLL APPROVAL LEVELS (Maker-checker approval chain per organization)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS payroll_approval_levels (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  
+  level INT NOT NULL, -- Approved in ascending order
+  name VARCHAR(100) NOT NULL, -- e.g. Payroll Ops, HR Head, Finance Controller
+  approver_ids TEXT[] NOT NULL, -- Users who may approve or reject at this level
+  min_net_amount DECIMAL(18, 2) NOT NULL DEFAULT 0, -- Level required only when the run's net pay exceeds this
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  UNIQUE(org_id, level),
+  CHECK (level > 0),
+  CHECK (min_net_amount >= 0)
+);
+
+CREATE INDEX idx_payroll_approval_levels_org ON payroll_approval_levels(org_id);
+
+-- ============================================================================
+-- 37. PAYROLL RUN APPROVALS (Decisions of each approval round)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS payroll_run_approvals (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  finalize_transition_id UUID NOT NULL REFERENCES payroll_run_transitions(id), -- Finalization that opened the round
+  
+  level INT NOT NULL, -- 0 when the organization has no approval chain
+  level_name VARCHAR(100) NOT NULL,
+  approver VARCHAR(255) NOT NULL,
+  decision VARCHAR(20) NOT NULL, -- approved, rejected
+  comment TEXT,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(finalize_transition_id, level),
+  CHECK (decision IN ('approved', 'rejected'))
+);
+
+CREATE INDEX idx_payroll_run_approvals_run ON payroll_run_approvals(payroll_run_id, created_at);
+
+-- ============================================================================
+-- 38. PAYROLL REOPEN REQUESTS (Approved corrections of locked runs)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS payroll_reopen_requests (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  
+  reason TEXT NOT NULL,
+  employee_ids TEXT[] NOT NULL, -- Employees whose components may be recalculated
+  status VARCHAR(20) NOT NULL DEFAULT 'pending', -- pending, approved, rejected, completed
+  
+  requested_by VARCHAR(255) NOT NULL,
+  decided_by VARCHAR(255),
+  decided_at TIMESTAMP,
+  decision_comment TEXT,
+  snapshot JSONB, -- Components as locked, taken when the request is approved
+  completed_at TIMESTAMP, -- Run approved and locked again
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  
+  CHECK (status IN ('pending', 'approved', 'rejected', 'completed'))
+);
+
+CREATE INDEX idx_payroll_reopen_requests_run ON payroll_reopen_requests(payroll_run_id);
+-- At most one open request per run
+CREATE UNIQUE INDEX idx_payroll_reopen_requests_open ON payroll_reopen_requests(payroll_run_id) WHERE status IN ('pending', 'approved');
+
+-- ============================================================================
+-- 39. PAYROLL LOCK EVENTS (Every lock and unlock of a run)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS payroll_lock_events (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  payroll_lock_id UUID NOT NULL REFERENCES payroll_locks(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  
+  action VARCHAR(20) NOT NULL, -- lock, unlock
+  actor VARCHAR(255) NOT NULL,
+  reason TEXT,
+  reopen_request_id UUID REFERENCES payroll_reopen_requests(id) ON DELETE SET NULL,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  
+  CHECK (action IN ('lock', 'unlock'))
+);
+
+CREATE INDEX idx_payroll_lock_events_run ON payroll_lock_events(payroll_run_id, created_at);
+
+-- ============================================================================
+-- 40. PAYROLL COMPONENT OVERRIDES (Manual inputs kept across recalculation)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS payroll_component_overrides (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  
+  -- NULL keeps the value the run would calculate
+  unpaid_leave_days INT,
+  advance_recovery DECIMAL(15, 2),
+  loan_recovery DECIMAL(15, 2),
+  other_deductions DECIMAL(15, 2),
+  court_order_deduction DECIMAL(15, 2),
+  
+  reason TEXT NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  updated_by UUID,
+  
+  UNIQUE(payroll_run_id, employee_id)
+);
+
+-- ============================================================================
+-- 41. PAYROLL RUN EMPLOYEES (Employee selection of off-cycle runs)
+-- ============================================================================
+-- Regular runs cover every active employee of the pay group; other run types
+-- cover only the employees listed here. PF, ESI, PT and TDS monthly limits
+-- are applied across every run attributed to the same payroll month.
+CREATE TABLE IF NOT EXISTS payroll_run_employees (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  
+  amount DECIMAL(15, 2), -- Paid as one_time_payment; required for bonus runs
+  description TEXT, -- e.g. Diwali bonus, leave encashment
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(payroll_run_id, employee_id),
+  CHECK (amount IS NULL OR amount > 0)
+);
+
+CREATE INDEX idx_payroll_run_employees_employee ON payroll_run_employees(employee_id);
+
+-- ============================================================================
+-- 42. PAYROLL RUN OUTCOMES (Per-employee result of calculating a run)
+-- ============================================================================
+-- Initiation and recalculation record whether each employee was calculated,
+-- skipped (not employed in the period) or failed, with the reason.
+-- Recalculating an employee replaces their outcome.
+CREATE TABLE IF NOT EXISTS payroll_run_outcomes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  
+  status VARCHAR(20) NOT NULL, -- success, skipped, failed
+  error_code VARCHAR(50), -- e.g. no_salary_structure, validation_failed
+  error_message TEXT,
+  payroll_component_id UUID REFERENCES payroll_components(id) ON DELETE SET NULL,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(payroll_run_id, employee_id),
+  CHECK (status IN ('success', 'skipped', 'failed')),
+  CHECK ((status = 'success') = (error_code IS NULL))
+);
+
+CREATE INDEX idx_payroll_run_outcomes_run_status ON payroll_run_outcomes(payroll_run_id, status);
+
+-- ============================================================================
+-- 43. JOBS (Background work queue)
+-- ============================================================================
+-- Long-running work such as payroll initiation and file generation is queued
+-- here and picked up by workers with SELECT ... FOR UPDATE SKIP LOCKED. A
+-- worker holds a lease it renews while running; a job whose lease expires is
+-- picked up again. Failed attempts are retried with backoff up to max_attempts.
+CREATE TABLE IF NOT EXISTS jobs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  job_type VARCHAR(50) NOT NULL, -- payroll_initiate, payroll_recalculate, bank_file, pf_ecr, esi_challan
+  payload JSONB NOT NULL DEFAULT '{}',
+  lock_key VARCHAR(100), -- Jobs with the same key do not queue twice, e.g. payroll_run:<id>
+  
+  status VARCHAR(20) NOT NULL DEFAULT 'queued', -- queued, running, completed, failed, cancelled
+  progress_done INTEGER NOT NULL DEFAULT 0,
+  progress_total INTEGER NOT NULL DEFAULT 0,
+  result JSONB,
+  error TEXT, -- Last error, kept across retries
+  
+  attempts INTEGER NOT NULL DEFAULT 0,
+  max_attempts INTEGER NOT NULL DEFAULT 3,
+  run_after TIMESTAMP NOT NULL DEFAULT NOW(),
+  lease_owner VARCHAR(100),
+  lease_expires_at TIMESTAMP,
+  cancel_requested BOOLEAN NOT NULL DEFAULT FALSE,
+  
+  created_by UUID,
+  created_at TIMESTAMP DEFAULT NOW(),
+  started_at TIMESTAMP,
+  finished_at TIMESTAMP,
+  updated_at TIMESTAMP DEFAULT NOW(),
+  
+  CHECK (status IN ('queued', 'running', 'completed', 'failed', 'cancelled')),
+  CHECK (max_attempts > 0)
+);
+
+CREATE INDEX idx_jobs_claim ON jobs(run_after) WHERE status = 'queued';
+CREATE INDEX idx_jobs_lease ON jobs(lease_expires_at) WHERE status = 'running';
+CREATE INDEX idx_jobs_org_created ON jobs(org_id, created_at DESC);
+CREATE UNIQUE INDEX idx_jobs_active_lock_key ON jobs(lock_key) WHERE status IN ('queued', 'running');
+
+-- ============================================================================
+-- 44. PAYROLL DRY RUNS (Sandboxed calculations of a run)
+-- ============================================================================
+-- A dry run calculates the whole run without storing components. Each keeps
+-- the components it calculated and the employees it could not calculate
+-- cleanly, so the next dry run can be compared with it. dry_run_number
+-- follows the run's dry_run_count.
+CREATE TABLE IF NOT EXISTS payroll_dry_runs (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  dry_run_number INTEGER NOT NULL,
+  
+  employees_succeeded INTEGER NOT NULL DEFAULT 0,
+  employees_skipped INTEGER NOT NULL DEFAULT 0,
+  employees_failed INTEGER NOT NULL DEFAULT 0,
+  total_gross_amount DECIMAL(15, 2) NOT NULL DEFAULT 0,
+  total_deductions DECIMAL(15, 2) NOT NULL DEFAULT 0,
+  total_net_pay DECIMAL(15, 2) NOT NULL DEFAULT 0,
+  snapshot JSONB NOT NULL DEFAULT '[]', -- Components calculated
+  findings JSONB NOT NULL DEFAULT '[]', -- Employees skipped, failed or with validation warnings
+  
+  requested_by VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(payroll_run_id, dry_run_number)
+);
+
+-- ============================================================================
+-- 45. PAYROLL VARIANCE ACKNOWLEDGEMENTS (Unexplained variances accepted for finalization)
+-- ============================================================================
+-- A run whose employees' pay changed beyond the variance thresholds from the
+-- previous released run, with no likely cause found, is not finalized until
+-- someone acknowledges each such employee. An acknowledgement holds for the
+-- net pay acknowledged; recalculating the employee to a different net pay
+-- needs a new one.
+CREATE TABLE IF NOT EXISTS payroll_variance_acknowledgements (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  
+  net_pay DECIMAL(15, 2) NOT NULL, -- Net pay of the run when acknowledged
+  reason TEXT NOT NULL,
+  acknowledged_by VARCHAR(255) NOT NULL,
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(payroll_run_id, employee_id)
+);
+
+-- ============================================================================
+-- 46. EMPLOYEE DEDUCTIONS (Loan, advance, court order and other recovery schedules)
+-- ============================================================================
+-- An active schedule raises its instalment in each regular payroll run from
+-- start_month until total_amount has been raised; court orders and other
+-- standing deductions without a total run until cancelled. What a run cannot
+-- recover within the wage limit is carried forward in the deduction ledger.
+CREATE TABLE IF NOT EXISTS employee_deductions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  org_id UUID NOT NULL REFERENCES organizations(id) ON DELETE CASCADE,
+  employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+  
+  category VARCHAR(20) NOT NULL, -- court_order, loan, advance, other
+  description TEXT NOT NULL,
+  instalment DECIMAL(15, 2) NOT NULL, -- Raised per payroll month
+  total_amount DECIMAL(15, 2), -- NULL runs until cancelled
+  start_month VARCHAR(7) NOT NULL, -- YYYY-MM of the first instalment
+  
+  status VARCHAR(20) NOT NULL DEFAULT 'active', -- active, cancelled
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  updated_at TIMESTAMP DEFAULT NOW(),
+  created_by UUID,
+  
+  CHECK (category IN ('court_order', 'loan', 'advance', 'other')),
+  CHECK (instalment > 0),
+  CHECK (total_amount IS NULL OR total_amount >= instalment),
+  CHECK (status IN ('active', 'cancelled'))
+);
+
+CREATE INDEX idx_employee_deductions_employee ON employee_deductions(employee_id, status);
+
+-- ============================================================================
+-- 47. EMPLOYEE DEDUCTION INSTALMENTS (Instalments raised by payroll components)
+-- ============================================================================
+-- Instalments of locked and released runs count towards a schedule's total
+CREATE TABLE IF NOT EXISTS employee_deduction_instalments (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  deduction_id UUID NOT NULL REFERENCES employee_deductions(id) ON DELETE CASCADE,
+  payroll_run_id UUID NOT NULL REFERENCES payroll_runs(id) ON DELETE CASCADE,
+  payroll_component_id UUID NOT NULL REFERENCES payroll_components(id) ON DELETE CASCADE,
+  
+  amount DECIMAL(15, 2) NOT NULL CHECK (amount > 0),
+  
+  created_at TIMESTAMP DEFAULT NOW(),
+  
+  UNIQUE(deduction_id, payroll_run_id)
+);
+
+CREATE INDEX idx_employee_deduction_instalments_component ON employee_deduction_instalments(payroll_component_id);
+
 -- ============================================================================
 -- SEED DATA: Default India Statutory Rules
 -- ============================================================================
@@ -428,10 +1384,20 @@ CREATE TABLE IF NOT EXISTS leave_summary (
 INSERT INTO statutory_rules (rule_type, state_code, effective_from, pf_employee_rate, pf_employer_rate, pf_ceiling, is_active)
 VALUES
   ('PF', NULL, '2024-01-01'::DATE, 12, 12, 15000, TRUE),
-  ('ESI', NULL, '2024-01-01'::DATE, 0.75, 3.25, 21000, TRUE),
   ('GRATUITY', NULL, '2024-01-01'::DATE, NULL, NULL, NULL, TRUE)
 ON CONFLICT (id) DO NOTHING;
 
+INSERT INTO statutory_rules (rule_type, state_code, effective_from, esi_employee_rate, esi_employer_rate, esi_wage_ceiling, is_active)
+VALUES
+  ('ESI', NULL, '2024-01-01'::DATE, 0.75, 3.25, 21000, TRUE)
+ON CONFLICT (id) DO NOTHING;
+
+-- ESI rows seeded by earlier versions of this file carried their rates in the PF columns
+UPDATE statutory_rules
+SET esi_employee_rate = pf_employee_rate, esi_employer_rate = pf_employer_rate, esi_wage_ceiling = pf_ceiling,
+    pf_employee_rate = NULL, pf_employer_rate = NULL, pf_ceiling = NULL
+WHERE rule_type = 'ESI' AND esi_employee_rate IS NULL AND pf_employee_rate IS NOT NULL;
+
 -- Professional Tax - Maharashtra (multiple slabs per state/date)
 INSERT INTO statutory_rules (rule_type, state_code, effective_from, pt_slab_min, pt_slab_max, pt_amount, is_active)
 VALUES
@@ -440,6 +1406,49 @@ VALUES
   ('PT', 'MH', '2024-01-01'::DATE, 20001, NULL, 200, TRUE)
 ON CONFLICT (id) DO NOTHING;
 
+-- TDS - simplified monthly slabs (global)
+INSERT INTO statutory_rules (rule_type, state_code, effective_from, tds_slab_min, tds_slab_max, tds_rate, is_active)
+VALUES
+  ('TDS', NULL, '2024-01-01'::DATE, 0, 50000, 0, TRUE),
+  ('TDS', NULL, '2024-01-01'::DATE, 50001, 100000, 5, TRUE),
+  ('TDS', NULL, '2024-01-01'::DATE, 100001, 250000, 10, TRUE),
+  ('TDS', NULL, '2024-01-01'::DATE, 250001, NULL, 15, TRUE)
+ON CONFLICT (id) DO NOTHING;
+
+-- Income tax slabs - FY 2024-25
+INSERT INTO income_tax_slabs (financial_year, tax_regime, min_age, income_from, income_to, rate)
+VALUES
+  (2024, 'old', 0, 0, 250000, 0),
+  (2024, 'old', 0, 250000, 500000, 5),
+  (2024, 'old', 0, 500000, 1000000, 20),
+  (2024, 'old', 0, 1000000, NULL, 30),
+  (2024, 'old', 60, 0, 300000, 0),
+  (2024, 'old', 60, 300000, 500000, 5),
+  (2024, 'old', 60, 500000, 1000000, 20),
+  (2024, 'old', 60, 1000000, NULL, 30),
+  (2024, 'old', 80, 0, 500000, 0),
+  (2024, 'old', 80, 500000, 1000000, 20),
+  (2024, 'old', 80, 1000000, NULL, 30),
+  (2024, 'new', 0, 0, 300000, 0),
+  (2024, 'new', 0, 300000, 700000, 5),
+  (2024, 'new', 0, 700000, 1000000, 10),
+  (2024, 'new', 0, 1000000, 1200000, 15),
+  (2024, 'new', 0, 1200000, 1500000, 20),
+  (2024, 'new', 0, 1500000, NULL, 30)
+ON CONFLICT (financial_year, tax_regime, min_age, income_from) DO NOTHING;
+
+-- Surcharge - FY 2024-25 (capped at 25% under the new regime)
+INSERT INTO income_tax_surcharges (financial_year, tax_regime, income_above, rate)
+VALUES
+  (2024, 'old', 5000000, 10),
+  (2024, 'old', 10000000, 15),
+  (2024, 'old', 20000000, 25),
+  (2024, 'old', 50000000, 37),
+  (2024, 'new', 5000000, 10),
+  (2024, 'new', 10000000, 15),
+  (2024, 'new', 20000000, 25)
+ON CONFLICT (financial_year, tax_regime, income_above) DO NOTHING;
+
 -- ============================================================================
 -- ROW LEVEL SECURITY (Optional - for multi-tenant security)
 -- ============================================================================
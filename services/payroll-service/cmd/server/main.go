@@ -1,10 +1,10 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"net"
 	"os"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
@@ -31,15 +31,41 @@ func main() {
 	// Initialize services
 	payrollService := service.NewPayrollService(db)
 	employeeService := service.NewEmployeeService(db)
+	statutoryRuleService := service.NewStatutoryRuleService(db, payrollService.CalculatorFactory())
+	payrollSettingsService := service.NewPayrollSettingsService(db)
+	paymentService := service.NewPaymentService(db)
+	payGroupService := service.NewPayGroupService(db)
+	rosterService := service.NewRosterService(db)
+	lopReversalService := service.NewLOPReversalService(db)
+	employeeDeductionService := service.NewEmployeeDeductionService(db)
+	workingCalendarService := service.NewWorkingCalendarService(db)
+	npsService := service.NewNPSService(db)
+	fbpService := service.NewFBPService(db)
+	equityService := service.NewEquityService(db)
+	taxReportService := service.NewTaxReportService(db)
+	previousEmploymentService := service.NewPreviousEmploymentService(db)
+	incomeTaxService := service.NewIncomeTaxService(db)
+	jobService := service.NewJobService(db)
+
+	// Start background job workers
+	jobService.RegisterJobHandlers(payrollService.JobHandlers())
+	jobService.RegisterJobHandlers(paymentService.JobHandlers())
+	jobService.RegisterJobHandlers(taxReportService.JobHandlers())
+	jobWorkers, err := strconv.Atoi(os.Getenv("PAYROLL_JOB_WORKERS"))
+	if err != nil || jobWorkers <= 0 {
+		jobWorkers = 2
+	}
+	jobService.Start(jobWorkers)
+	log.Printf("✓ Started %d job workers", jobWorkers)
 
 	// Start gRPC server (optional, for Phase 2.5)
 	go startGRPCServer(payrollService, employeeService)
 
 	// Start REST API server
-	startRESTServer(payrollService, employeeService)
+	startRESTServer(payrollService, employeeService, statutoryRuleService, payrollSettingsService, paymentService, payGroupService, rosterService, lopReversalService, employeeDeductionService, workingCalendarService, npsService, fbpService, equityService, taxReportService, previousEmploymentService, incomeTaxService, jobService)
 }
 
-func startRESTServer(payrollService *service.PayrollService, employeeService *service.EmployeeService) {
+func startRESTServer(payrollService *service.PayrollService, employeeService *service.EmployeeService, statutoryRuleService *service.StatutoryRuleService, payrollSettingsService *service.PayrollSettingsService, paymentService *service.PaymentService, payGroupService *service.PayGroupService, rosterService *service.RosterService, lopReversalService *service.LOPReversalService, employeeDeductionService *service.EmployeeDeductionService, workingCalendarService *service.WorkingCalendarService, npsService *service.NPSService, fbpService *service.FBPService, equityService *service.EquityService, taxReportService *service.TaxReportService, previousEmploymentService *service.PreviousEmploymentService, incomeTaxService *service.IncomeTaxService, jobService *service.JobService) {
 	router := gin.Default()
 
 	// Middleware
@@ -55,6 +81,21 @@ func startRESTServer(payrollService *service.PayrollService, employeeService *se
 	{
 		handler.RegisterPayrollRoutes(v1, payrollService)
 		handler.RegisterEmployeeRoutes(v1, employeeService)
+		handler.RegisterStatutoryRuleRoutes(v1, statutoryRuleService)
+		handler.RegisterPayrollSettingsRoutes(v1, payrollSettingsService)
+		handler.RegisterPaymentRoutes(v1, paymentService)
+		handler.RegisterPayGroupRoutes(v1, payGroupService)
+		handler.RegisterRosterRoutes(v1, rosterService)
+		handler.RegisterLOPReversalRoutes(v1, lopReversalService)
+		handler.RegisterEmployeeDeductionRoutes(v1, employeeDeductionService)
+		handler.RegisterWorkingCalendarRoutes(v1, workingCalendarService)
+		handler.RegisterNPSRoutes(v1, npsService)
+		handler.RegisterFBPRoutes(v1, fbpService)
+		handler.RegisterEquityRoutes(v1, equityService)
+		handler.RegisterTaxReportRoutes(v1, taxReportService)
+		handler.RegisterPreviousEmploymentRoutes(v1, previousEmploymentService)
+		handler.RegisterIncomeTaxRoutes(v1, incomeTaxService)
+		handler.RegisterJobRoutes(v1, jobService)
 	}
 
 	port := os.Getenv("PAYROLL_SERVICE_PORT")
@@ -82,8 +123,15 @@ func startGRPCServer(payrollService *service.PayrollService, employeeService *se
 	log.Printf("Starting gRPC server on port %s", port)
 
 	// TODO: Initialize gRPC server with payroll and employee services
-	// For now, just listen
-	<-make(chan bool)
+	// For now, hold the port and turn connections away
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Printf("gRPC listener stopped: %v", err)
+			return
+		}
+		conn.Close()
+	}
 }
 
 func corsMiddleware() gin.HandlerFunc {
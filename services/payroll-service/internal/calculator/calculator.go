@@ -8,7 +8,11 @@ import (
 
 // PayrollCalculator handles all payroll computations
 type PayrollCalculator struct {
-	rules *StatutoryRules
+	rules         *StatutoryRules
+	rounding      RoundingPolicy
+	proration     ProrationPolicy
+	shiftPolicies map[string]ShiftAllowancePolicy
+	incomeTax     map[string]*IncomeTaxTable // By tax regime
 }
 
 // NewPayrollCalculator creates a new calculator instance
@@ -18,15 +22,45 @@ func NewPayrollCalculator(rules *StatutoryRules) *PayrollCalculator {
 	}
 }
 
+// Rules returns the statutory rules the calculator applies
+func (pc *PayrollCalculator) Rules() *StatutoryRules {
+	return pc.rules
+}
+
 // CalculationResult contains detailed payroll calculation output
 type CalculationResult struct {
 	// Earnings
+	DaysPaid          int // Days worked, less any already paid earlier in the month and LOP days
+	LOPDays           int // Absent and unpaid leave days
 	BasicPay          float64
 	DeartnessAllowance float64
 	HouseRentAllowance float64
 	OtherAllowances    float64
+	ShiftAllowance     float64 // From the roster; not pro-rated
+	LOPAmount          float64 // Pay lost to LOP days, already left out of the earnings above
+	LOPReversal        float64 // LOP wrongly deducted in earlier months, paid back
+	LOPReversals       []LOPReversal
 	GrossAmount        float64
 
+	// Flexible benefit plan, allocated from the special allowance
+	FBPFixed           float64 // Fixed-payout components paid this period
+	FBPClaims          float64 // Approved claims paid this period
+	FBPClaimsPaid      []FBPClaim
+	FBPUnclaimedPayout float64 // Year-end payout of the unclaimed balance, taxable
+	FBPAccrued         float64 // Claim-based allocation earned this period, not yet paid
+	FBPExempt          float64 // Fixed and claimed amounts exempt from tax
+
+	OneTimePayment float64 // Bonus or settlement of an off-cycle run; taxed, outside PF and ESI wages
+
+	// ESOP and RSU perquisites, taxed but not paid in cash
+	EquityPerquisite          float64 // Exercises and vestings this period
+	EquityPerquisiteDeferred  float64 // Part of EquityPerquisite whose tax is deferred
+	EquityDeferredTaxed       float64 // Earlier deferred perquisites taxed this period
+	EquityPerquisitesReported []EquityPerquisite
+	EquityDeferredReleased    []EquityPerquisite
+
+	lopReversalPFWage float64 // Basic + DA share of LOPReversal
+
 	// Statutory Deductions
 	PFEmployee        float64
 	PFEmployer        float64
@@ -34,13 +68,35 @@ type CalculationResult struct {
 	ESIEmployer       float64
 	ProfessionalTax   float64
 
+	// Retirement contributions (corporate NPS, superannuation)
+	NPSEmployee            float64
+	NPSEmployer            float64
+	NPS80CCD2              float64 // Employer NPS deductible under Section 80CCD(2)
+	SuperannuationEmployer float64
+	RetirementPerquisite   float64 // Employer PF, NPS and superannuation above the yearly cap
+
 	// Income Tax
-	TDS float64
+	TDS           float64
+	TaxableIncome float64 // This period's income the TDS slab was applied to
+
+	// Wages contributions were calculated on, after monthly ceilings
+	PFWage  float64
+	ESIWage float64
+
+	// Other Deductions (amounts actually recovered this run)
+	CourtOrderDeduction float64
+	AdvanceRecovery     float64
+	LoanRecovery        float64
+	OtherDeductions     float64
 
-	// Other Deductions
-	AdvanceRecovery float64
-	LoanRecovery    float64
-	OtherDeductions float64
+	// Deduction Recovery
+	DeductionRecoveries   []DeductionRecovery
+	DeductionInstalments  []DeductionInstalment // Scheduled instalments raised this run
+	CarryForwardRecovered float64               // Recovered from earlier runs' shortfall
+	DeductionShortfall    float64               // Deferred to the next run
+
+	// Net pay rounding (positive is an earning, negative a deduction)
+	RoundingAdjustment float64
 
 	// Summary
 	TotalEmployeeDeductions float64
@@ -54,10 +110,10 @@ type CalculationResult struct {
 
 // CalculationStep represents a single calculation step for audit trail
 type CalculationStep struct {
-	Category    string  // earnings, pf, esi, pt, tds, etc.
-	Description string
-	Amount      float64
-	Rule        string
+	Category    string  `json:"category"` // earnings, pf, esi, pt, tds, etc.
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	Rule        string  `json:"rule"`
 }
 
 // CalculatePayroll computes complete payroll for an employee
@@ -75,19 +131,39 @@ func (pc *PayrollCalculator) CalculatePayroll(employee *models.Employee, salaryS
 	// Step 3: Calculate Income Tax (TDS)
 	pc.calculateIncomeTax(result, salaryStructure, attendance)
 
-	// Step 4: Calculate Other Deductions
-	pc.calculateOtherDeductions(result, attendance)
+	// Step 4: Recover other deductions by priority within the wage limit
+	pc.applyDeductionPriority(result, attendance)
 
 	// Step 5: Calculate Net Pay
 	pc.calculateNetPay(result)
 
+	// Step 6: Round Net Pay
+	pc.applyRounding(result, attendance)
+
 	return result, nil
 }
 
 // calculateEarnings computes salary components based on days worked
 func (pc *PayrollCalculator) calculateEarnings(result *CalculationResult, ss *models.SalaryStructure, input *PayrollInput) {
-	// Pro-ration factor based on days worked
-	proRateFactor := float64(input.DaysWorked) / float64(input.DaysInMonth)
+	// Days already paid by earlier periods of the month cannot be paid again
+	daysWorked := input.DaysWorked
+	if mtd := input.MonthToDate.orZero(); daysWorked+mtd.DaysPaid > input.DaysInMonth {
+		daysWorked = input.DaysInMonth - mtd.DaysPaid
+		if daysWorked < 0 {
+			daysWorked = 0
+		}
+	}
+
+	// Loss of pay is valued on the proration basis and taken off here only
+	lop := lopDays(input, daysWorked)
+	basisDays := pc.proration.BasisDays(input.DaysInMonth, input.WorkingDays)
+	lopFactor := float64(lop) / float64(basisDays)
+
+	result.LOPDays = lop
+	result.DaysPaid = daysWorked - lop
+
+	// Pro-ration factor based on days worked, less loss of pay
+	proRateFactor := float64(daysWorked)/float64(input.DaysInMonth) - lopFactor
 
 	// Ensure factor is between 0 and 1
 	if proRateFactor < 0 {
@@ -100,7 +176,7 @@ func (pc *PayrollCalculator) calculateEarnings(result *CalculationResult, ss *mo
 	result.BasicPay = round(ss.MonthlyBasic * proRateFactor, 2)
 	result.Calculations = append(result.Calculations, CalculationStep{
 		Category:    "earnings",
-		Description: fmt.Sprintf("Basic Pay (%d/%d days)", input.DaysWorked, input.DaysInMonth),
+		Description: fmt.Sprintf("Basic Pay (%d/%d days)", result.DaysPaid, input.DaysInMonth),
 		Amount:      result.BasicPay,
 		Rule:        fmt.Sprintf("%.2f × %.2f = %.2f", ss.MonthlyBasic, proRateFactor, result.BasicPay),
 	})
@@ -110,7 +186,7 @@ func (pc *PayrollCalculator) calculateEarnings(result *CalculationResult, ss *mo
 	if result.DeartnessAllowance > 0 {
 		result.Calculations = append(result.Calculations, CalculationStep{
 			Category:    "earnings",
-			Description: fmt.Sprintf("Dearness Allowance (%d/%d days)", input.DaysWorked, input.DaysInMonth),
+			Description: fmt.Sprintf("Dearness Allowance (%d/%d days)", result.DaysPaid, input.DaysInMonth),
 			Amount:      result.DeartnessAllowance,
 			Rule:        fmt.Sprintf("%.2f × %.2f", ss.MonthlyDA, proRateFactor),
 		})
@@ -121,34 +197,80 @@ func (pc *PayrollCalculator) calculateEarnings(result *CalculationResult, ss *mo
 	if result.HouseRentAllowance > 0 {
 		result.Calculations = append(result.Calculations, CalculationStep{
 			Category:    "earnings",
-			Description: fmt.Sprintf("House Rent Allowance (%d/%d days)", input.DaysWorked, input.DaysInMonth),
+			Description: fmt.Sprintf("House Rent Allowance (%d/%d days)", result.DaysPaid, input.DaysInMonth),
 			Amount:      result.HouseRentAllowance,
 			Rule:        fmt.Sprintf("%.2f × %.2f", ss.MonthlyHRA, proRateFactor),
 		})
 	}
 
-	// Other Allowances
-	result.OtherAllowances = round(ss.MonthlyAllowance * proRateFactor, 2)
+	// Other Allowances, less what is allocated to the flexible benefit plan
+	allowance := math.Max(ss.MonthlyAllowance-fbpMonthly(input), 0)
+	result.OtherAllowances = round(allowance * proRateFactor, 2)
 	if result.OtherAllowances > 0 {
 		result.Calculations = append(result.Calculations, CalculationStep{
 			Category:    "earnings",
-			Description: fmt.Sprintf("Other Allowances (%d/%d days)", input.DaysWorked, input.DaysInMonth),
+			Description: fmt.Sprintf("Other Allowances (%d/%d days)", result.DaysPaid, input.DaysInMonth),
 			Amount:      result.OtherAllowances,
-			Rule:        fmt.Sprintf("%.2f × %.2f", ss.MonthlyAllowance, proRateFactor),
+			Rule:        fmt.Sprintf("%.2f × %.2f", allowance, proRateFactor),
+		})
+	}
+
+	// Flexible Benefit Plan
+	pc.calculateFBP(result, input, proRateFactor)
+
+	// Loss of Pay
+	monthlyPay := ss.MonthlyBasic + ss.MonthlyDA + ss.MonthlyHRA + ss.MonthlyAllowance
+	fullPay := round(monthlyPay*float64(daysWorked)/float64(input.DaysInMonth), 2)
+	earned := result.BasicPay + result.DeartnessAllowance + result.HouseRentAllowance + result.OtherAllowances + result.FBPFixed + result.FBPAccrued
+	result.LOPAmount = round(math.Max(fullPay-earned, 0), 2)
+	pc.recordLossOfPay(result, monthlyPay, input, basisDays)
+
+	// LOP Reversals from earlier months
+	pc.calculateLOPReversals(result, monthlyPay, ss.MonthlyBasic+ss.MonthlyDA, input)
+
+	// Shift Allowance
+	pc.calculateShiftAllowance(result, input)
+
+	// ESOP and RSU perquisites (not part of gross)
+	pc.calculateEquityPerquisites(result, input)
+
+	// Bonus or settlement amount of an off-cycle run
+	if input.OneTimePayment > 0 {
+		result.OneTimePayment = round(input.OneTimePayment, 2)
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "earnings",
+			Description: "One-time Payment",
+			Amount:      result.OneTimePayment,
+			Rule:        "Off-cycle run payout, not pro-rated",
 		})
 	}
 
 	// Gross Amount
 	result.GrossAmount = round(
-		result.BasicPay+result.DeartnessAllowance+result.HouseRentAllowance+result.OtherAllowances,
+		result.BasicPay+result.DeartnessAllowance+result.HouseRentAllowance+result.OtherAllowances+result.LOPReversal+result.ShiftAllowance+
+			result.FBPFixed+result.FBPClaims+result.FBPUnclaimedPayout+result.OneTimePayment,
 		2,
 	)
 
+	grossRule := fmt.Sprintf("Basic (%.2f) + DA (%.2f) + HRA (%.2f) + Other (%.2f)", result.BasicPay, result.DeartnessAllowance, result.HouseRentAllowance, result.OtherAllowances)
+	if result.LOPReversal > 0 {
+		grossRule += fmt.Sprintf(" + LOP Reversal (%.2f)", result.LOPReversal)
+	}
+	if result.ShiftAllowance > 0 {
+		grossRule += fmt.Sprintf(" + Shift (%.2f)", result.ShiftAllowance)
+	}
+	if fbp := result.FBPFixed + result.FBPClaims + result.FBPUnclaimedPayout; fbp > 0 {
+		grossRule += fmt.Sprintf(" + FBP (%.2f)", fbp)
+	}
+	if result.OneTimePayment > 0 {
+		grossRule += fmt.Sprintf(" + One-time (%.2f)", result.OneTimePayment)
+	}
+
 	result.Calculations = append(result.Calculations, CalculationStep{
 		Category:    "summary",
 		Description: "Gross Amount",
 		Amount:      result.GrossAmount,
-		Rule:        fmt.Sprintf("Basic (%.2f) + DA (%.2f) + HRA (%.2f) + Other (%.2f)", result.BasicPay, result.DeartnessAllowance, result.HouseRentAllowance, result.OtherAllowances),
+		Rule:        grossRule,
 	})
 }
 
@@ -163,14 +285,17 @@ func (pc *PayrollCalculator) calculateStatutoryDeductions(result *CalculationRes
 	// Professional Tax (PT) - State-wise
 	pc.calculatePT(result, ss, input, employee)
 
+	// Corporate NPS and superannuation
+	pc.calculateNPS(result, ss, input)
+
 	// Total statutory deductions
 	result.TotalEmployeeDeductions = round(
-		result.PFEmployee+result.ESIEmployee+result.ProfessionalTax,
+		result.PFEmployee+result.ESIEmployee+result.ProfessionalTax+result.NPSEmployee,
 		2,
 	)
 
 	result.TotalEmployerDeductions = round(
-		result.PFEmployer+result.ESIEmployer,
+		result.PFEmployer+result.ESIEmployer+result.NPSEmployer,
 		2,
 	)
 }
@@ -181,13 +306,18 @@ func (pc *PayrollCalculator) calculatePF(result *CalculationResult, ss *models.S
 		return
 	}
 
-	// PF is calculated on Basic + DA
-	pfWage := result.BasicPay + result.DeartnessAllowance
+	// PF is calculated on Basic + DA, including any paid back as LOP reversal
+	pfWage := result.BasicPay + result.DeartnessAllowance + result.lopReversalPFWage
 
-	// Check if salary exceeds PF ceiling
-	if pc.rules.PF.Ceiling > 0 && pfWage > pc.rules.PF.Ceiling {
-		pfWage = pc.rules.PF.Ceiling
+	// Check if salary exceeds PF ceiling. The ceiling is monthly, so wages
+	// already contributed on earlier in the month use part of it up.
+	if pc.rules.PF.Ceiling > 0 {
+		ceiling := math.Max(pc.rules.PF.Ceiling-input.MonthToDate.orZero().PFWage, 0)
+		if pfWage > ceiling {
+			pfWage = ceiling
+		}
 	}
+	result.PFWage = round(pfWage, 2)
 
 	// Employee contribution (12%)
 	result.PFEmployee = round(pfWage*pc.rules.PF.EmployeeRate/100, 2)
@@ -214,18 +344,24 @@ func (pc *PayrollCalculator) calculateESI(result *CalculationResult, ss *models.
 		return
 	}
 
-	// Check if salary exceeds ESI threshold
-	if pc.rules.ESI.ThresholdSalary > 0 && result.GrossAmount > pc.rules.ESI.ThresholdSalary {
+	// ESI is calculated on Gross Amount; bonuses and settlements are not wages
+	esiWage := result.GrossAmount - result.OneTimePayment
+
+	// Check if salary exceeds ESI threshold. Coverage is decided on the month's
+	// wages; an employee already contributing this month stays covered.
+	mtd := input.MonthToDate.orZero()
+	if pc.rules.ESI.ThresholdSalary > 0 && mtd.ESIEmployee == 0 && esiWage+mtd.GrossAmount-mtd.OneTimePayment > pc.rules.ESI.ThresholdSalary {
 		return // ESI not applicable
 	}
 
-	// ESI is calculated on Gross Amount
-	esiWage := result.GrossAmount
-
-	// Check if salary exceeds ESI ceiling
-	if pc.rules.ESI.WageCeiling > 0 && esiWage > pc.rules.ESI.WageCeiling {
-		esiWage = pc.rules.ESI.WageCeiling
+	// Check if salary exceeds ESI ceiling (monthly, less wages already contributed on)
+	if pc.rules.ESI.WageCeiling > 0 {
+		ceiling := math.Max(pc.rules.ESI.WageCeiling-mtd.ESIWage, 0)
+		if esiWage > ceiling {
+			esiWage = ceiling
+		}
 	}
+	result.ESIWage = round(esiWage, 2)
 
 	// Employee contribution (0.75%)
 	result.ESIEmployee = round(esiWage*pc.rules.ESI.EmployeeRate/100, 2)
@@ -252,15 +388,27 @@ func (pc *PayrollCalculator) calculatePT(result *CalculationResult, ss *models.S
 		return
 	}
 
+	// PT slabs are monthly: the slab is found on the month's gross and the
+	// tax already deducted earlier in the month is netted off
+	mtd := input.MonthToDate.orZero()
+	monthlyGross := round(result.GrossAmount+mtd.GrossAmount, 2)
+
 	// Find applicable PT slab based on gross amount
 	for _, slab := range pc.rules.PT.Slabs {
-		if result.GrossAmount >= slab.Min && (slab.Max == nil || result.GrossAmount <= *slab.Max) {
-			result.ProfessionalTax = slab.Amount
+		if monthlyGross >= slab.Min && (slab.Max == nil || monthlyGross <= *slab.Max) {
+			result.ProfessionalTax = round(math.Max(slab.Amount-mtd.ProfessionalTax, 0), 2)
+
+			rule := fmt.Sprintf("Slab: %.2f to %.0f = %.2f", slab.Min, getMaxOrInfinity(slab.Max), slab.Amount)
+			if mtd.Periods > 0 {
+				rule = fmt.Sprintf("Month gross %.2f, slab %.2f to %.0f = %.2f - deducted earlier (%.2f) = %.2f",
+					monthlyGross, slab.Min, getMaxOrInfinity(slab.Max), slab.Amount, mtd.ProfessionalTax, result.ProfessionalTax)
+			}
+
 			result.Calculations = append(result.Calculations, CalculationStep{
 				Category:    "pt",
 				Description: fmt.Sprintf("Professional Tax (%.0f - %.0f)", slab.Min, getMaxOrInfinity(slab.Max)),
 				Amount:      result.ProfessionalTax,
-				Rule:        fmt.Sprintf("Slab: %.2f to %.0f = %.2f", slab.Min, getMaxOrInfinity(slab.Max), slab.Amount),
+				Rule:        rule,
 			})
 			break
 		}
@@ -277,78 +425,90 @@ func (pc *PayrollCalculator) calculateIncomeTax(result *CalculationResult, ss *m
 	// In a real system, this would be based on annual income with relief/exemptions
 	// For now, we calculate monthly TDS based on annual estimated income
 
-	monthlyTaxableIncome := result.GrossAmount - result.PFEmployee - result.ESIEmployee - result.ProfessionalTax
+	periodTaxableIncome := result.GrossAmount - result.PFEmployee - result.ESIEmployee - result.ProfessionalTax
+
+	// Employer NPS is salary, less what 80CCD(2) allows; employer retirement
+	// contributions above the yearly cap are a perquisite
+	periodTaxableIncome += result.NPSEmployer - result.NPS80CCD2 + result.RetirementPerquisite
+
+	// Fixed and claimed flexible benefits are exempt under the old regime
+	periodTaxableIncome -= result.FBPExempt
+
+	// Equity perquisites are taxed unless deferred
+	periodTaxableIncome += result.EquityPerquisite - result.EquityPerquisiteDeferred + result.EquityDeferredTaxed
+	result.TaxableIncome = round(periodTaxableIncome, 2)
+
+	// Slabs are monthly, so earlier periods of the month are added back and
+	// the tax they already deducted is netted off
+	mtd := input.MonthToDate.orZero()
+	monthlyTaxableIncome := round(periodTaxableIncome+mtd.TaxableIncome, 2)
+
+	// Spread the year's tax over the rest of the year when the year to date
+	// is known; income that will not recur is not projected forward
+	if input.TaxYear != nil {
+		oneOff := result.LOPReversal + result.FBPUnclaimedPayout + result.OneTimePayment + result.RetirementPerquisite +
+			result.EquityPerquisite - result.EquityPerquisiteDeferred + result.EquityDeferredTaxed
+		pc.calculateProjectedTax(result, input, monthlyTaxableIncome, oneOff)
+		return
+	}
 
 	// Find applicable TDS slab
 	for _, slab := range pc.rules.TDS.Slabs {
 		if monthlyTaxableIncome >= slab.Min && (slab.Max == nil || monthlyTaxableIncome <= *slab.Max) {
-			result.TDS = round(monthlyTaxableIncome*slab.Rate/100, 2)
+			rate := tdsRate(slab, input)
+			monthlyTDS := round(monthlyTaxableIncome*rate/100, 2)
+			result.TDS = round(math.Max(monthlyTDS-mtd.TDS, 0), 2)
+
+			rule := fmt.Sprintf("Taxable Income: %.2f × %.2f%% = %.2f", monthlyTaxableIncome, rate, result.TDS)
+			if mtd.Periods > 0 {
+				rule = fmt.Sprintf("Month taxable income: %.2f × %.2f%% = %.2f - deducted earlier (%.2f) = %.2f",
+					monthlyTaxableIncome, rate, monthlyTDS, mtd.TDS, result.TDS)
+			}
+			if rate != slab.Rate {
+				rule += " (no valid PAN, Section 206AA)"
+			}
+
 			result.Calculations = append(result.Calculations, CalculationStep{
 				Category:    "tds",
 				Description: fmt.Sprintf("TDS (%.0f - %.0f)", slab.Min, getMaxOrInfinity(slab.Max)),
 				Amount:      result.TDS,
-				Rule:        fmt.Sprintf("Taxable Income: %.2f × %.2f%% = %.2f", monthlyTaxableIncome, slab.Rate, result.TDS),
+				Rule:        rule,
 			})
 			break
 		}
 	}
 }
 
-// calculateOtherDeductions handles additional deductions
-func (pc *PayrollCalculator) calculateOtherDeductions(result *CalculationResult, input *PayrollInput) {
-	result.AdvanceRecovery = input.AdvanceRecovery
-	if result.AdvanceRecovery > 0 {
-		result.Calculations = append(result.Calculations, CalculationStep{
-			Category:    "deductions",
-			Description: "Advance Recovery",
-			Amount:      result.AdvanceRecovery,
-			Rule:        "Employee advance recovery",
-		})
-	}
-
-	result.LoanRecovery = input.LoanRecovery
-	if result.LoanRecovery > 0 {
-		result.Calculations = append(result.Calculations, CalculationStep{
-			Category:    "deductions",
-			Description: "Loan Recovery",
-			Amount:      result.LoanRecovery,
-			Rule:        "Employee loan recovery",
-		})
-	}
-
-	result.OtherDeductions = input.OtherDeductions
-	if result.OtherDeductions > 0 {
-		result.Calculations = append(result.Calculations, CalculationStep{
-			Category:    "deductions",
-			Description: "Other Deductions",
-			Amount:      result.OtherDeductions,
-			Rule:        "Other approved deductions",
-		})
-	}
-}
-
 // calculateNetPay computes final net amount
 func (pc *PayrollCalculator) calculateNetPay(result *CalculationResult) {
+	otherDeductions := result.CourtOrderDeduction + result.AdvanceRecovery + result.LoanRecovery + result.OtherDeductions
+
 	result.TotalDeductions = round(
-		result.PFEmployee+result.ESIEmployee+result.ProfessionalTax+result.TDS+
-			result.AdvanceRecovery+result.LoanRecovery+result.OtherDeductions,
+		result.PFEmployee+result.ESIEmployee+result.ProfessionalTax+result.NPSEmployee+result.TDS+otherDeductions,
 		2,
 	)
 
+	// Not clamped: non-statutory deductions are already capped, so a negative
+	// figure means statutory deductions alone exceed gross and is left for
+	// the validator to flag
 	result.NetPay = round(result.GrossAmount-result.TotalDeductions, 2)
 
-	// Ensure net pay is not negative
-	if result.NetPay < 0 {
-		result.NetPay = 0
-	}
-
 	result.Calculations = append(result.Calculations, CalculationStep{
 		Category:    "summary",
 		Description: "Total Deductions",
 		Amount:      result.TotalDeductions,
-		Rule:        fmt.Sprintf("PF (%.2f) + ESI (%.2f) + PT (%.2f) + TDS (%.2f) + Others (%.2f)", result.PFEmployee, result.ESIEmployee, result.ProfessionalTax, result.TDS, result.AdvanceRecovery+result.LoanRecovery+result.OtherDeductions),
+		Rule:        totalDeductionsRule(result, otherDeductions),
 	})
 
+	if result.DeductionShortfall > 0 {
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "summary",
+			Description: "Deductions Deferred to Next Run",
+			Amount:      result.DeductionShortfall,
+			Rule:        fmt.Sprintf("Unrecovered above the %.0f%% wage limit", MaxDeductionPercent),
+		})
+	}
+
 	result.Calculations = append(result.Calculations, CalculationStep{
 		Category:    "summary",
 		Description: "Net Pay",
@@ -357,6 +517,14 @@ func (pc *PayrollCalculator) calculateNetPay(result *CalculationResult) {
 	})
 }
 
+// totalDeductionsRule describes how total deductions add up
+func totalDeductionsRule(result *CalculationResult, otherDeductions float64) string {
+	if result.NPSEmployee > 0 {
+		return fmt.Sprintf("PF (%.2f) + ESI (%.2f) + PT (%.2f) + NPS (%.2f) + TDS (%.2f) + Others (%.2f)", result.PFEmployee, result.ESIEmployee, result.ProfessionalTax, result.NPSEmployee, result.TDS, otherDeductions)
+	}
+	return fmt.Sprintf("PF (%.2f) + ESI (%.2f) + PT (%.2f) + TDS (%.2f) + Others (%.2f)", result.PFEmployee, result.ESIEmployee, result.ProfessionalTax, result.TDS, otherDeductions)
+}
+
 // Helper functions
 
 func round(value float64, precision int) float64 {
@@ -2,42 +2,107 @@ package calculator
 
 import (
 	"fmt"
+	"strings"
+	"sync"
+	"time"
+
 	"payroll-service/internal/models"
 	"payroll-service/internal/repository"
 )
 
+// ruleCacheTTL bounds how long a resolved rule set is reused before the
+// database is consulted again
+const ruleCacheTTL = 15 * time.Minute
+
 // CalculatorFactory creates calculator instances with rules from database
 type CalculatorFactory struct {
 	repo *repository.PayrollRepository
+
+	mu    sync.RWMutex
+	cache map[string]cachedRules
+}
+
+type cachedRules struct {
+	rules    *StatutoryRules
+	loadedAt time.Time
 }
 
 // NewCalculatorFactory creates a new factory
 func NewCalculatorFactory(repo *repository.PayrollRepository) *CalculatorFactory {
 	return &CalculatorFactory{
-		repo: repo,
+		repo:  repo,
+		cache: make(map[string]cachedRules),
 	}
 }
 
-// CreateCalculator creates a calculator with rules for a specific organization/state
-func (f *CalculatorFactory) CreateCalculator(stateCode string) (*PayrollCalculator, error) {
-	// Fetch rules from database (for now, use defaults)
-	// In production, this would fetch rules by state and effective date
-	rules := GetDefaultIndiaRules()
+// ResolveRules returns the statutory rules in force for an organization and
+// state on the given date. Results are cached per org, state and day.
+func (f *CalculatorFactory) ResolveRules(orgID, stateCode string, asOf time.Time) (*StatutoryRules, error) {
+	key := fmt.Sprintf("%s|%s|%s", orgID, stateCode, asOf.Format("2006-01-02"))
+
+	f.mu.RLock()
+	entry, ok := f.cache[key]
+	f.mu.RUnlock()
+	if ok && time.Since(entry.loadedAt) < ruleCacheTTL {
+		return entry.rules, nil
+	}
+
+	dbRules, err := f.repo.GetEffectiveStatutoryRules(orgID, stateCode, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := ResolveStatutoryRules(dbRules)
+	if err != nil {
+		return nil, fmt.Errorf("invalid statutory rules: %w", err)
+	}
+
+	var missing []string
+	for _, ruleType := range RequiredRuleTypes {
+		if rules.SourceFor(ruleType) == nil {
+			missing = append(missing, ruleType)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("statutory rules %s not configured for org %s, state %s on %s",
+			strings.Join(missing, ", "), orgID, stateCode, asOf.Format("2006-01-02"))
+	}
 
-	// Validate rules
 	if err := ValidateRules(rules); err != nil {
 		return nil, fmt.Errorf("invalid statutory rules: %w", err)
 	}
 
-	return NewPayrollCalculator(rules), nil
+	f.mu.Lock()
+	f.cache[key] = cachedRules{rules: rules, loadedAt: time.Now()}
+	f.mu.Unlock()
+
+	return rules, nil
 }
 
-// CreateValidator creates a validator with rules
-func (f *CalculatorFactory) CreateValidator(stateCode string) (*PayrollValidator, error) {
-	rules := GetDefaultIndiaRules()
+// InvalidateRules drops every cached rule set
+func (f *CalculatorFactory) InvalidateRules() {
+	f.mu.Lock()
+	f.cache = make(map[string]cachedRules)
+	f.mu.Unlock()
+}
 
-	if err := ValidateRules(rules); err != nil {
-		return nil, fmt.Errorf("invalid statutory rules: %w", err)
+// CreateCalculator creates a calculator with the rules in force for an
+// organization and state on the given date
+func (f *CalculatorFactory) CreateCalculator(orgID, stateCode string, asOf time.Time) (*PayrollCalculator, error) {
+	rules, err := f.ResolveRules(orgID, stateCode, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPayrollCalculator(rules), nil
+}
+
+// CreateValidator creates a validator with the rules in force for an
+// organization and state on the given date
+func (f *CalculatorFactory) CreateValidator(orgID, stateCode string, asOf time.Time) (*PayrollValidator, error) {
+	rules, err := f.ResolveRules(orgID, stateCode, asOf)
+	if err != nil {
+		return nil, err
 	}
 
 	return NewPayrollValidator(rules), nil
@@ -49,6 +114,7 @@ func (f *CalculatorFactory) CalculateEmployeePayroll(
 	salaryStructure *models.SalaryStructure,
 	attendance *PayrollInput,
 	stateCode string,
+	asOf time.Time,
 ) (*CalculationResult, error) {
 	if employee == nil {
 		return nil, fmt.Errorf("employee is nil")
@@ -68,7 +134,7 @@ func (f *CalculatorFactory) CalculateEmployeePayroll(
 	}
 
 	// Create calculator
-	calc, err := f.CreateCalculator(stateCode)
+	calc, err := f.CreateCalculator(employee.OrgID, stateCode, asOf)
 	if err != nil {
 		return nil, err
 	}
@@ -103,16 +169,41 @@ func ConvertCalculationResultToComponent(
 		DAAmount:           result.DeartnessAllowance,
 		HRAAmount:          result.HouseRentAllowance,
 		OtherAllowances:    result.OtherAllowances,
+		ShiftAllowance:     result.ShiftAllowance,
+		LOPDays:            result.LOPDays,
+		LOPAmount:          result.LOPAmount,
+		LOPReversal:        result.LOPReversal,
+		FBPFixed:           result.FBPFixed,
+		FBPClaims:          result.FBPClaims,
+		FBPUnclaimedPayout: result.FBPUnclaimedPayout,
+		FBPAccrued:         result.FBPAccrued,
+		FBPExempt:          result.FBPExempt,
+		OneTimePayment:     result.OneTimePayment,
 		GrossAmount:        result.GrossAmount,
 		PFEmployee:         result.PFEmployee,
 		PFEmployer:         result.PFEmployer,
 		ESIEmployee:        result.ESIEmployee,
 		ESIEmployer:        result.ESIEmployer,
 		ProfessionalTax:    result.ProfessionalTax,
+		NPSEmployee:            result.NPSEmployee,
+		NPSEmployer:            result.NPSEmployer,
+		NPS80CCD2:              result.NPS80CCD2,
+		SuperannuationEmployer: result.SuperannuationEmployer,
+		RetirementPerquisite:   result.RetirementPerquisite,
+		EquityPerquisite:         result.EquityPerquisite,
+		EquityPerquisiteDeferred: result.EquityPerquisiteDeferred,
+		EquityDeferredTaxed:      result.EquityDeferredTaxed,
 		TDS:                result.TDS,
 		AdvanceRecovery:    result.AdvanceRecovery,
 		LoanRecovery:       result.LoanRecovery,
 		OtherDeductions:    result.OtherDeductions,
+		CourtOrderDeduction:   result.CourtOrderDeduction,
+		CarryForwardRecovered: result.CarryForwardRecovered,
+		DeductionShortfall:    result.DeductionShortfall,
+		RoundingAdjustment:    result.RoundingAdjustment,
+		PFWage:                result.PFWage,
+		ESIWage:               result.ESIWage,
+		TaxableIncome:         result.TaxableIncome,
 		TotalDeductions:    result.TotalDeductions,
 		NetPay:             result.NetPay,
 		IsValidated:        false,
@@ -122,13 +213,25 @@ func ConvertCalculationResultToComponent(
 
 // CalculationAuditTrail represents audit trail for a calculation
 type CalculationAuditTrail struct {
-	EmployeeID   string
-	PayrollMonth string
-	Steps        []CalculationStep
-	Validations  []ValidationError
-	Status       string
-	CalculatedAt string
-	CalculatedBy string
+	EmployeeID   string            `json:"employee_id"`
+	PayrollMonth string            `json:"payroll_month"`
+	Inputs       AuditInputs       `json:"inputs"`
+	RuleVersions []RuleSource      `json:"rule_versions"`
+	Steps        []CalculationStep `json:"steps"`
+	Validations  []ValidationError `json:"validations"`
+	Status       string            `json:"status"`
+	CalculatedAt string            `json:"calculated_at"`
+	CalculatedBy string            `json:"calculated_by"`
+}
+
+// AuditInputs captures everything a calculation was derived from
+type AuditInputs struct {
+	SalaryStructureID string       `json:"salary_structure_id"`
+	MonthlyBasic      float64      `json:"monthly_basic"`
+	MonthlyDA         float64      `json:"monthly_da"`
+	MonthlyHRA        float64      `json:"monthly_hra"`
+	MonthlyAllowance  float64      `json:"monthly_allowance"`
+	Payroll           PayrollInput `json:"payroll"`
 }
 
 // FormatCalculationAuditTrail creates an audit trail from calculation results
@@ -137,18 +240,88 @@ func FormatCalculationAuditTrail(
 	result *CalculationResult,
 	validations []ValidationError,
 	calculatedBy string,
+	salaryStructure *models.SalaryStructure,
+	input *PayrollInput,
+	rules *StatutoryRules,
 ) CalculationAuditTrail {
 	status := "valid"
 	if len(validations) > 0 {
 		status = "with_errors"
 	}
 
-	return CalculationAuditTrail{
+	trail := CalculationAuditTrail{
 		EmployeeID:   employeeID,
 		PayrollMonth: payrollMonth,
 		Steps:        result.Calculations,
 		Validations:  validations,
 		Status:       status,
+		CalculatedAt: time.Now().Format(time.RFC3339),
 		CalculatedBy: calculatedBy,
 	}
+
+	if salaryStructure != nil {
+		trail.Inputs.SalaryStructureID = salaryStructure.ID
+		trail.Inputs.MonthlyBasic = salaryStructure.MonthlyBasic
+		trail.Inputs.MonthlyDA = salaryStructure.MonthlyDA
+		trail.Inputs.MonthlyHRA = salaryStructure.MonthlyHRA
+		trail.Inputs.MonthlyAllowance = salaryStructure.MonthlyAllowance
+	}
+
+	if input != nil {
+		trail.Inputs.Payroll = *input
+	}
+
+	if rules != nil {
+		trail.RuleVersions = rules.Sources
+	}
+
+	return trail
+}
+
+// FormatAuditTrailText renders an audit trail as a step-by-step working
+func FormatAuditTrailText(trail *CalculationAuditTrail) string {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "CALCULATION WORKING - Employee %s, %s\n", trail.EmployeeID, trail.PayrollMonth)
+	fmt.Fprintf(&b, "Calculated at %s by %s (status: %s)\n\n", trail.CalculatedAt, trail.CalculatedBy, trail.Status)
+
+	b.WriteString("INPUTS:\n")
+	fmt.Fprintf(&b, "  Salary structure: %s\n", trail.Inputs.SalaryStructureID)
+	fmt.Fprintf(&b, "  Monthly Basic %.2f | DA %.2f | HRA %.2f | Allowance %.2f\n",
+		trail.Inputs.MonthlyBasic, trail.Inputs.MonthlyDA, trail.Inputs.MonthlyHRA, trail.Inputs.MonthlyAllowance)
+	fmt.Fprintf(&b, "  Days worked %d of %d | Absent %d | Leave %d (unpaid %d)\n",
+		trail.Inputs.Payroll.DaysWorked, trail.Inputs.Payroll.DaysInMonth, trail.Inputs.Payroll.DaysAbsent, trail.Inputs.Payroll.DaysLeave, trail.Inputs.Payroll.UnpaidLeaveDays)
+	if nps := trail.Inputs.Payroll.NPS; nps != nil {
+		fmt.Fprintf(&b, "  NPS PRAN %s | Employer %.2f%% | Employee %.2f%% | Tax regime %s\n",
+			nps.PRAN, nps.EmployerRate, nps.EmployeeRate, taxRegime(trail.Inputs.Payroll.TaxRegime))
+	}
+	b.WriteString("\n")
+
+	b.WriteString("RULES APPLIED:\n")
+	for _, src := range trail.RuleVersions {
+		scope := "global"
+		if src.OrgID != nil {
+			scope = "org " + *src.OrgID
+		}
+		state := "all states"
+		if src.StateCode != nil {
+			state = *src.StateCode
+		}
+		fmt.Fprintf(&b, "  %-4s effective from %s (%s, %s)\n", src.RuleType, src.EffectiveFrom.Format("2006-01-02"), scope, state)
+	}
+	b.WriteString("\n")
+
+	b.WriteString("STEPS:\n")
+	for i, step := range trail.Steps {
+		fmt.Fprintf(&b, "  %2d. [%s] %s: %.2f\n      %s\n", i+1, step.Category, step.Description, step.Amount, step.Rule)
+	}
+
+	if len(trail.Validations) > 0 {
+		b.WriteString("\nVALIDATIONS:\n")
+		for _, v := range trail.Validations {
+			fmt.Fprintf(&b, "  %s %s: %s\n", strings.ToUpper(v.Severity), v.Code, v.Message)
+		}
+	}
+
+	return b.String()
 }
@@ -0,0 +1,130 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// Non-statutory deduction categories. Statutory deductions (PF, ESI, PT, TDS)
+// are always taken first; these are recovered afterwards in DeductionPriority order.
+const (
+	DeductionCourtOrder = "court_order"
+	DeductionLoan       = "loan"
+	DeductionAdvance    = "advance"
+	DeductionOther      = "other"
+)
+
+// DeductionPriority is the order in which non-statutory deductions are recovered
+var DeductionPriority = []string{DeductionCourtOrder, DeductionLoan, DeductionAdvance, DeductionOther}
+
+// MaxDeductionPercent caps total deductions as a share of gross wages
+// (Payment of Wages Act, 1936, section 7(3))
+const MaxDeductionPercent = 50.0
+
+var deductionLabels = map[string]string{
+	DeductionCourtOrder: "Court Order Deduction",
+	DeductionLoan:       "Loan Recovery",
+	DeductionAdvance:    "Advance Recovery",
+	DeductionOther:      "Other Deductions",
+}
+
+// DeductionInstalment is the instalment of a loan, advance, court order or
+// other deduction schedule falling due in the period
+type DeductionInstalment struct {
+	ID       string  `json:"id"`
+	Category string  `json:"category"`
+	Amount   float64 `json:"amount"`
+}
+
+// DeductionRecovery records how one deduction category was recovered in a run
+type DeductionRecovery struct {
+	Category              string  `json:"category"`
+	Current               float64 `json:"current"`         // Raised for this period
+	CarriedForward        float64 `json:"carried_forward"` // Unrecovered from earlier runs
+	Recovered             float64 `json:"recovered"`
+	CarryForwardRecovered float64 `json:"carry_forward_recovered"` // Part of Recovered that cleared CarriedForward
+	Deferred              float64 `json:"deferred"`                // Part of Current left unrecovered
+}
+
+// Outstanding is the balance carried into the next run
+func (d DeductionRecovery) Outstanding() float64 {
+	return round(d.CarriedForward+d.Current-d.Recovered, 2)
+}
+
+// applyDeductionPriority recovers non-statutory deductions in priority order
+// within the wage limit left after statutory deductions. Instalments due are
+// added to the amounts raised for their category. Carried-forward
+// balances are cleared before the current period's amount; anything that does
+// not fit is deferred to the next run.
+func (pc *PayrollCalculator) applyDeductionPriority(result *CalculationResult, input *PayrollInput) {
+	statutory := result.PFEmployee + result.ESIEmployee + result.ProfessionalTax + result.NPSEmployee + result.TDS
+	limit := round(result.GrossAmount*MaxDeductionPercent/100, 2)
+
+	headroom := round(limit-statutory, 2)
+	if headroom < 0 {
+		headroom = 0
+	}
+
+	current := map[string]float64{
+		DeductionCourtOrder: input.CourtOrderDeduction,
+		DeductionLoan:       input.LoanRecovery,
+		DeductionAdvance:    input.AdvanceRecovery,
+		DeductionOther:      input.OtherDeductions,
+	}
+	for _, inst := range input.DeductionInstalments {
+		current[inst.Category] += inst.Amount
+		result.DeductionInstalments = append(result.DeductionInstalments, inst)
+	}
+
+	limitStepAdded := false
+	for _, category := range DeductionPriority {
+		rec := DeductionRecovery{
+			Category:       category,
+			Current:        round(current[category], 2),
+			CarriedForward: round(input.CarriedForward[category], 2),
+		}
+
+		due := round(rec.Current+rec.CarriedForward, 2)
+		if due <= 0 {
+			continue
+		}
+
+		if !limitStepAdded {
+			result.Calculations = append(result.Calculations, CalculationStep{
+				Category:    "deductions",
+				Description: "Deduction Limit",
+				Amount:      headroom,
+				Rule:        fmt.Sprintf("%.0f%% of Gross (%.2f) = %.2f - Statutory (%.2f) = %.2f", MaxDeductionPercent, result.GrossAmount, limit, statutory, headroom),
+			})
+			limitStepAdded = true
+		}
+
+		rec.Recovered = math.Min(due, headroom)
+		headroom = round(headroom-rec.Recovered, 2)
+		rec.CarryForwardRecovered = math.Min(rec.Recovered, rec.CarriedForward)
+		rec.Deferred = round(rec.Current-(rec.Recovered-rec.CarryForwardRecovered), 2)
+
+		switch category {
+		case DeductionCourtOrder:
+			result.CourtOrderDeduction = rec.Recovered
+		case DeductionLoan:
+			result.LoanRecovery = rec.Recovered
+		case DeductionAdvance:
+			result.AdvanceRecovery = rec.Recovered
+		case DeductionOther:
+			result.OtherDeductions = rec.Recovered
+		}
+
+		result.DeductionRecoveries = append(result.DeductionRecoveries, rec)
+		result.CarryForwardRecovered = round(result.CarryForwardRecovered+rec.CarryForwardRecovered, 2)
+		result.DeductionShortfall = round(result.DeductionShortfall+rec.Outstanding(), 2)
+
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "deductions",
+			Description: deductionLabels[category],
+			Amount:      rec.Recovered,
+			Rule: fmt.Sprintf("Due %.2f (current %.2f + carried forward %.2f), recovered %.2f, deferred %.2f",
+				due, rec.Current, rec.CarriedForward, rec.Recovered, rec.Outstanding()),
+		})
+	}
+}
@@ -0,0 +1,129 @@
+package calculator
+
+import "testing"
+
+func TestApplyDeductionPriority(t *testing.T) {
+	type recovery struct {
+		recovered             float64
+		carryForwardRecovered float64
+		outstanding           float64
+	}
+
+	tests := []struct {
+		name           string
+		statutory      float64 // PF only; the wage limit is 10000 on a gross of 20000
+		input          PayrollInput
+		want           map[string]recovery
+		wantShortfall  float64
+		wantCFRecovery float64
+	}{
+		{
+			name:      "everything fits",
+			statutory: 2000,
+			input:     PayrollInput{CourtOrderDeduction: 3000, LoanRecovery: 2000},
+			want: map[string]recovery{
+				DeductionCourtOrder: {recovered: 3000},
+				DeductionLoan:       {recovered: 2000},
+			},
+		},
+		{
+			name:      "lower priorities are deferred once the limit is reached",
+			statutory: 2000,
+			input:     PayrollInput{CourtOrderDeduction: 3000, LoanRecovery: 4000, AdvanceRecovery: 2000, OtherDeductions: 500},
+			want: map[string]recovery{
+				DeductionCourtOrder: {recovered: 3000},
+				DeductionLoan:       {recovered: 4000},
+				DeductionAdvance:    {recovered: 1000, outstanding: 1000},
+				DeductionOther:      {outstanding: 500},
+			},
+			wantShortfall: 1500,
+		},
+		{
+			name:      "carried-forward balances are cleared first",
+			statutory: 2000,
+			input: PayrollInput{
+				LoanRecovery:   1000,
+				CarriedForward: map[string]float64{DeductionLoan: 2000},
+			},
+			want: map[string]recovery{
+				DeductionLoan: {recovered: 3000, carryForwardRecovered: 2000},
+			},
+			wantCFRecovery: 2000,
+		},
+		{
+			name:      "carried-forward balance larger than the headroom",
+			statutory: 2000,
+			input: PayrollInput{
+				LoanRecovery:   1000,
+				CarriedForward: map[string]float64{DeductionLoan: 9000},
+			},
+			want: map[string]recovery{
+				DeductionLoan: {recovered: 8000, carryForwardRecovered: 8000, outstanding: 2000},
+			},
+			wantShortfall:  2000,
+			wantCFRecovery: 8000,
+		},
+		{
+			name:      "instalments due are added to their category",
+			statutory: 2000,
+			input: PayrollInput{
+				AdvanceRecovery: 500,
+				DeductionInstalments: []DeductionInstalment{
+					{ID: "loan-1", Category: DeductionLoan, Amount: 1500},
+					{ID: "advance-1", Category: DeductionAdvance, Amount: 1000},
+				},
+			},
+			want: map[string]recovery{
+				DeductionLoan:    {recovered: 1500},
+				DeductionAdvance: {recovered: 1500},
+			},
+		},
+		{
+			name:      "statutory deductions above the limit leave nothing to recover",
+			statutory: 11000,
+			input:     PayrollInput{CourtOrderDeduction: 500, AdvanceRecovery: 700},
+			want: map[string]recovery{
+				DeductionCourtOrder: {outstanding: 500},
+				DeductionAdvance:    {outstanding: 700},
+			},
+			wantShortfall: 1200,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := &CalculationResult{GrossAmount: 20000, PFEmployee: tt.statutory}
+			input := tt.input
+			(&PayrollCalculator{}).applyDeductionPriority(result, &input)
+
+			if len(result.DeductionRecoveries) != len(tt.want) {
+				t.Fatalf("got %d recoveries, want %d: %+v", len(result.DeductionRecoveries), len(tt.want), result.DeductionRecoveries)
+			}
+			for _, rec := range result.DeductionRecoveries {
+				want, ok := tt.want[rec.Category]
+				if !ok {
+					t.Errorf("unexpected recovery of %s", rec.Category)
+					continue
+				}
+				if rec.Recovered != want.recovered || rec.CarryForwardRecovered != want.carryForwardRecovered || rec.Outstanding() != want.outstanding {
+					t.Errorf("%s: recovered %.2f (carried forward %.2f), outstanding %.2f; want %.2f (%.2f), %.2f",
+						rec.Category, rec.Recovered, rec.CarryForwardRecovered, rec.Outstanding(),
+						want.recovered, want.carryForwardRecovered, want.outstanding)
+				}
+			}
+
+			if len(result.DeductionInstalments) != len(tt.input.DeductionInstalments) {
+				t.Errorf("got %d instalments raised, want %d", len(result.DeductionInstalments), len(tt.input.DeductionInstalments))
+			}
+			if result.DeductionShortfall != tt.wantShortfall {
+				t.Errorf("DeductionShortfall = %.2f, want %.2f", result.DeductionShortfall, tt.wantShortfall)
+			}
+			if result.CarryForwardRecovered != tt.wantCFRecovery {
+				t.Errorf("CarryForwardRecovered = %.2f, want %.2f", result.CarryForwardRecovered, tt.wantCFRecovery)
+			}
+			if got := result.CourtOrderDeduction + result.LoanRecovery + result.AdvanceRecovery + result.OtherDeductions; got+tt.statutory > 10000 && got > 0 {
+				t.Errorf("recovered %.2f on top of statutory %.2f, above the 50%% limit", got, tt.statutory)
+			}
+		})
+	}
+}
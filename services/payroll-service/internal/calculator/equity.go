@@ -0,0 +1,87 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// Equity grant types
+const (
+	GrantTypeESOP = "esop" // Options, taxed on exercise
+	GrantTypeRSU  = "rsu"  // Restricted stock units, taxed on vesting
+)
+
+// Equity event types
+const (
+	EquityEventVest     = "vest"
+	EquityEventExercise = "exercise"
+	EquityEventSale     = "sale"
+)
+
+// IsPerquisiteEvent reports whether an event of a grant type allots shares and
+// so gives rise to a perquisite under Section 17(2)(vi): exercising options,
+// or the vesting of RSUs
+func IsPerquisiteEvent(grantType, eventType string) bool {
+	return (grantType == GrantTypeESOP && eventType == EquityEventExercise) ||
+		(grantType == GrantTypeRSU && eventType == EquityEventVest)
+}
+
+// EquityPerquisiteValue returns the perquisite on shares allotted: the fair
+// market value on the date of exercise or vesting less the price paid
+func EquityPerquisiteValue(units, fmv, exercisePrice float64) float64 {
+	return round(math.Max((fmv-exercisePrice)*units, 0), 2)
+}
+
+// DeferredPerquisiteTaxDue returns the latest date tax on an eligible
+// start-up's equity perquisite may be deferred to under Section 192(1C): 48
+// months from the end of the assessment year. Sale of the shares or leaving
+// the employer ends the deferral earlier.
+func DeferredPerquisiteTaxDue(eventDate time.Time) time.Time {
+	return FinancialYearStart(eventDate).AddDate(6, 0, -1)
+}
+
+// EquityPerquisite is the perquisite on one exercise or vesting
+type EquityPerquisite struct {
+	ID          string  `json:"id"`
+	Description string  `json:"description"`
+	Amount      float64 `json:"amount"`
+	TaxDeferred bool    `json:"tax_deferred"` // Eligible start-up, tax deferred
+}
+
+// calculateEquityPerquisites adds ESOP and RSU perquisites to the income TDS
+// is applied to. They are not paid in cash, so are left out of gross pay. Tax
+// on an eligible start-up's perquisites is deferred, and deferred perquisites
+// whose deferral has ended are taxed now.
+func (pc *PayrollCalculator) calculateEquityPerquisites(result *CalculationResult, input *PayrollInput) {
+	for _, p := range input.EquityPerquisites {
+		result.EquityPerquisite += p.Amount
+		result.EquityPerquisitesReported = append(result.EquityPerquisitesReported, p)
+
+		rule := "FMV less exercise price, taxable, not paid"
+		if p.TaxDeferred {
+			result.EquityPerquisiteDeferred += p.Amount
+			rule = "FMV less exercise price, tax deferred (eligible start-up)"
+		}
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "perquisite",
+			Description: fmt.Sprintf("Equity Perquisite - %s", p.Description),
+			Amount:      p.Amount,
+			Rule:        rule,
+		})
+	}
+	result.EquityPerquisite = round(result.EquityPerquisite, 2)
+	result.EquityPerquisiteDeferred = round(result.EquityPerquisiteDeferred, 2)
+
+	for _, p := range input.DeferredPerquisites {
+		result.EquityDeferredTaxed += p.Amount
+		result.EquityDeferredReleased = append(result.EquityDeferredReleased, p)
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "perquisite",
+			Description: fmt.Sprintf("Deferred Equity Perquisite - %s", p.Description),
+			Amount:      p.Amount,
+			Rule:        "Deferral ended: taxed now",
+		})
+	}
+	result.EquityDeferredTaxed = round(result.EquityDeferredTaxed, 2)
+}
@@ -0,0 +1,132 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// Flexible benefit plan components
+const (
+	FBPMealCard         = "meal_card"
+	FBPTelephone        = "telephone"
+	FBPLTA              = "lta"
+	FBPBooksPeriodicals = "books_periodicals"
+	FBPFuel             = "fuel"
+)
+
+// FBP payout types
+const (
+	FBPPayoutFixed = "fixed" // Paid every month with salary
+	FBPPayoutClaim = "claim" // Accrues monthly, paid against approved claims
+)
+
+// FBPComponents lists the components a flexible benefit plan may include
+var FBPComponents = []string{FBPMealCard, FBPTelephone, FBPLTA, FBPBooksPeriodicals, FBPFuel}
+
+// IsFBPComponent reports whether component is a known FBP component
+func IsFBPComponent(component string) bool {
+	for _, c := range FBPComponents {
+		if c == component {
+			return true
+		}
+	}
+	return false
+}
+
+// FBPAllocation is the part of an employee's special allowance allocated to
+// one FBP component for the year
+type FBPAllocation struct {
+	Component     string  `json:"component"`
+	PayoutType    string  `json:"payout_type"`    // fixed, claim
+	MonthlyAmount float64 `json:"monthly_amount"` // Declared annual amount / 12
+}
+
+// FBPClaim is an approved claim paid in this period
+type FBPClaim struct {
+	ID        string  `json:"id"`
+	Component string  `json:"component"`
+	Amount    float64 `json:"amount"`
+}
+
+// fbpMonthly returns the monthly amount allocated to FBP
+func fbpMonthly(input *PayrollInput) float64 {
+	total := 0.0
+	for _, a := range input.FBP {
+		total += a.MonthlyAmount
+	}
+	return total
+}
+
+// calculateFBP pays the flexible benefit plan allocated from the special
+// allowance. Fixed components are paid each period and claim-based ones
+// accrue until claimed. At year end the unclaimed balance is paid out as
+// taxable pay. Fixed and claimed amounts are exempt under the old regime.
+func (pc *PayrollCalculator) calculateFBP(result *CalculationResult, input *PayrollInput, proRateFactor float64) {
+	for _, a := range input.FBP {
+		amount := round(a.MonthlyAmount*proRateFactor, 2)
+		if amount <= 0 {
+			continue
+		}
+
+		if a.PayoutType == FBPPayoutFixed {
+			result.FBPFixed += amount
+			result.Calculations = append(result.Calculations, CalculationStep{
+				Category:    "fbp",
+				Description: fmt.Sprintf("FBP - %s (fixed)", a.Component),
+				Amount:      amount,
+				Rule:        fmt.Sprintf("%.2f × %.2f", a.MonthlyAmount, proRateFactor),
+			})
+			continue
+		}
+
+		result.FBPAccrued += amount
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "fbp",
+			Description: fmt.Sprintf("FBP - %s (accrued for claims)", a.Component),
+			Amount:      amount,
+			Rule:        fmt.Sprintf("%.2f × %.2f, not paid until claimed", a.MonthlyAmount, proRateFactor),
+		})
+	}
+	result.FBPFixed = round(result.FBPFixed, 2)
+	result.FBPAccrued = round(result.FBPAccrued, 2)
+
+	for _, claim := range input.FBPClaims {
+		result.FBPClaims += claim.Amount
+		result.FBPClaimsPaid = append(result.FBPClaimsPaid, claim)
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "fbp",
+			Description: fmt.Sprintf("FBP Claim - %s", claim.Component),
+			Amount:      claim.Amount,
+			Rule:        "Approved claim",
+		})
+	}
+	result.FBPClaims = round(result.FBPClaims, 2)
+
+	// Whatever is left unclaimed at year end is paid out and taxed
+	if input.FBPYearEnd {
+		balance := round(input.FBPBalance+result.FBPAccrued-result.FBPClaims, 2)
+		result.FBPUnclaimedPayout = math.Max(balance, 0)
+		if result.FBPUnclaimedPayout > 0 {
+			result.Calculations = append(result.Calculations, CalculationStep{
+				Category:    "fbp",
+				Description: "FBP Unclaimed Balance (taxable)",
+				Amount:      result.FBPUnclaimedPayout,
+				Rule: fmt.Sprintf("Balance (%.2f) + accrued (%.2f) - claimed (%.2f)",
+					input.FBPBalance, result.FBPAccrued, result.FBPClaims),
+			})
+		}
+	}
+
+	// The new regime allows none of these exemptions
+	if taxRegime(input.TaxRegime) == TaxRegimeOld {
+		result.FBPExempt = round(result.FBPFixed+result.FBPClaims, 2)
+	}
+	if result.FBPExempt > 0 {
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "fbp",
+			Description: "FBP Exemption",
+			Amount:      result.FBPExempt,
+			Rule:        fmt.Sprintf("Fixed (%.2f) + claimed (%.2f), old regime", result.FBPFixed, result.FBPClaims),
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// Age groups with their own old regime slabs
+const (
+	SeniorCitizenAge      = 60
+	SuperSeniorCitizenAge = 80
+)
+
+// IncomeTaxSlab is one band of the annual slabs: income between From and To
+// is taxed at Rate
+type IncomeTaxSlab struct {
+	MinAge int // The slabs of the highest MinAge not above the employee's age apply
+	From   float64
+	To     *float64 // nil means no upper limit
+	Rate   float64  // Percentage
+}
+
+// Surcharge is levied on income tax once income exceeds IncomeAbove
+type Surcharge struct {
+	IncomeAbove float64
+	Rate        float64 // Percentage of income tax
+}
+
+// IncomeTaxTable is the annual income tax schedule of a financial year and
+// regime
+type IncomeTaxTable struct {
+	FinancialYear int
+	TaxRegime     string
+	Slabs         []IncomeTaxSlab
+	Surcharges    []Surcharge
+}
+
+// AnnualTax breaks down the income tax on a year's taxable income
+type AnnualTax struct {
+	SlabTax        float64 `json:"slab_tax"`
+	Surcharge      float64 `json:"surcharge"` // After marginal relief
+	MarginalRelief float64 `json:"marginal_relief"`
+	Total          float64 `json:"total"`
+}
+
+// SetIncomeTaxTables sets the annual income tax tables, at most one per
+// regime. TDS for a regime without a table falls back to the monthly TDS
+// slabs.
+func (pc *PayrollCalculator) SetIncomeTaxTables(tables []IncomeTaxTable) {
+	pc.incomeTax = map[string]*IncomeTaxTable{}
+	for i := range tables {
+		pc.incomeTax[taxRegime(tables[i].TaxRegime)] = &tables[i]
+	}
+}
+
+// AgeAtFinancialYearEnd is the age an employee reaches by 31 March, which
+// decides the old regime slabs for the whole year; 0 when the date of birth
+// is unknown
+func AgeAtFinancialYearEnd(dateOfBirth *time.Time, fyStart time.Time) int {
+	if dateOfBirth == nil {
+		return 0
+	}
+
+	end := fyStart.AddDate(1, 0, -1)
+	age := end.Year() - dateOfBirth.Year()
+	if end.Month() < dateOfBirth.Month() || (end.Month() == dateOfBirth.Month() && end.Day() < dateOfBirth.Day()) {
+		age--
+	}
+	if age < 0 {
+		return 0
+	}
+	return age
+}
+
+// Tax computes the income tax on a year's taxable income for an employee of
+// the given age. Surcharge is reduced by marginal relief so that crossing a
+// threshold never costs more in tax than the income above it.
+func (t *IncomeTaxTable) Tax(income float64, age int) AnnualTax {
+	slabs := t.slabsFor(age)
+	tax := AnnualTax{SlabTax: round(slabTax(slabs, income), 2)}
+
+	threshold, rate, rateBelow := t.surchargeFor(income)
+	if rate > 0 {
+		surcharge := tax.SlabTax * rate / 100
+
+		// Tax and surcharge at the threshold, plus all income above it
+		limit := slabTax(slabs, threshold)*(1+rateBelow/100) + income - threshold
+		if excess := tax.SlabTax + surcharge - limit; excess > 0 {
+			relief := math.Min(excess, surcharge)
+			tax.MarginalRelief = round(relief, 2)
+			surcharge -= relief
+		}
+		tax.Surcharge = round(surcharge, 2)
+	}
+
+	tax.Total = round(tax.SlabTax+tax.Surcharge, 2)
+	return tax
+}
+
+// slabsFor returns the slabs of the age group an employee falls in
+func (t *IncomeTaxTable) slabsFor(age int) []IncomeTaxSlab {
+	group := -1
+	for _, s := range t.Slabs {
+		if s.MinAge <= age && s.MinAge > group {
+			group = s.MinAge
+		}
+	}
+
+	var slabs []IncomeTaxSlab
+	for _, s := range t.Slabs {
+		if s.MinAge == group {
+			slabs = append(slabs, s)
+		}
+	}
+	sort.Slice(slabs, func(i, j int) bool { return slabs[i].From < slabs[j].From })
+	return slabs
+}
+
+// surchargeFor returns the highest surcharge threshold income exceeds, its
+// rate and the rate that applies at the threshold itself
+func (t *IncomeTaxTable) surchargeFor(income float64) (threshold, rate, rateBelow float64) {
+	surcharges := append([]Surcharge(nil), t.Surcharges...)
+	sort.Slice(surcharges, func(i, j int) bool { return surcharges[i].IncomeAbove < surcharges[j].IncomeAbove })
+
+	for _, s := range surcharges {
+		if income <= s.IncomeAbove {
+			break
+		}
+		threshold, rateBelow, rate = s.IncomeAbove, rate, s.Rate
+	}
+	return threshold, rate, rateBelow
+}
+
+func slabTax(slabs []IncomeTaxSlab, income float64) float64 {
+	var tax float64
+	for _, s := range slabs {
+		if income <= s.From {
+			continue
+		}
+		upper := income
+		if s.To != nil && *s.To < upper {
+			upper = *s.To
+		}
+		tax += (upper - s.From) * s.Rate / 100
+	}
+	return tax
+}
+
+// annualTaxRule describes an annual tax computation for the audit trail
+func (t *IncomeTaxTable) annualTaxRule(tax AnnualTax, age int) string {
+	rule := fmt.Sprintf("FY %d-%02d %s regime slabs", t.FinancialYear, (t.FinancialYear+1)%100, t.TaxRegime)
+	if group := t.slabsFor(age); len(group) > 0 && group[0].MinAge > 0 {
+		rule += fmt.Sprintf(" (age %d+)", group[0].MinAge)
+	}
+	rule += fmt.Sprintf(": %.2f", tax.SlabTax)
+	if tax.Surcharge > 0 || tax.MarginalRelief > 0 {
+		rule += fmt.Sprintf(" + surcharge %.2f", tax.Surcharge)
+		if tax.MarginalRelief > 0 {
+			rule += fmt.Sprintf(" (after marginal relief %.2f)", tax.MarginalRelief)
+		}
+	}
+	return rule
+}
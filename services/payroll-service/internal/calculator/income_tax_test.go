@@ -0,0 +1,180 @@
+package calculator
+
+import "testing"
+
+func floatPtr(f float64) *float64 {
+	return &f
+}
+
+// oldRegimeTable is the old regime schedule with separate slabs below 60,
+// from 60 and, unless withoutSuperSenior, from 80
+func oldRegimeTable(withoutSuperSenior bool) *IncomeTaxTable {
+	slabs := []IncomeTaxSlab{
+		{MinAge: 0, From: 0, To: floatPtr(250000), Rate: 0},
+		{MinAge: 0, From: 250000, To: floatPtr(500000), Rate: 5},
+		{MinAge: 0, From: 500000, To: floatPtr(1000000), Rate: 20},
+		{MinAge: 0, From: 1000000, Rate: 30},
+		{MinAge: SeniorCitizenAge, From: 0, To: floatPtr(300000), Rate: 0},
+		{MinAge: SeniorCitizenAge, From: 300000, To: floatPtr(500000), Rate: 5},
+		{MinAge: SeniorCitizenAge, From: 500000, To: floatPtr(1000000), Rate: 20},
+		{MinAge: SeniorCitizenAge, From: 1000000, Rate: 30},
+	}
+	if !withoutSuperSenior {
+		slabs = append(slabs,
+			IncomeTaxSlab{MinAge: SuperSeniorCitizenAge, From: 0, To: floatPtr(500000), Rate: 0},
+			IncomeTaxSlab{MinAge: SuperSeniorCitizenAge, From: 500000, To: floatPtr(1000000), Rate: 20},
+			IncomeTaxSlab{MinAge: SuperSeniorCitizenAge, From: 1000000, Rate: 30},
+		)
+	}
+
+	return &IncomeTaxTable{
+		FinancialYear: 2024,
+		TaxRegime:     "old",
+		Slabs:         slabs,
+		// Out of order on purpose; thresholds are sorted before use
+		Surcharges: []Surcharge{
+			{IncomeAbove: 10000000, Rate: 15},
+			{IncomeAbove: 5000000, Rate: 10},
+		},
+	}
+}
+
+func TestIncomeTaxTableTax(t *testing.T) {
+	tests := []struct {
+		name               string
+		withoutSuperSenior bool
+		income             float64
+		age                int
+		want               AnnualTax
+	}{
+		{
+			name:   "below the basic exemption",
+			income: 250000,
+			age:    30,
+			want:   AnnualTax{},
+		},
+		{
+			name:   "within the slabs",
+			income: 500000,
+			age:    30,
+			want:   AnnualTax{SlabTax: 12500, Total: 12500},
+		},
+		{
+			name:   "exactly on the first surcharge threshold",
+			income: 5000000,
+			age:    30,
+			want:   AnnualTax{SlabTax: 1312500, Total: 1312500},
+		},
+		{
+			name:   "just above the first threshold gets marginal relief",
+			income: 5010000,
+			age:    30,
+			want:   AnnualTax{SlabTax: 1315500, Surcharge: 7000, MarginalRelief: 124550, Total: 1322500},
+		},
+		{
+			name:   "well above the first threshold pays the full surcharge",
+			income: 6000000,
+			age:    30,
+			want:   AnnualTax{SlabTax: 1612500, Surcharge: 161250, Total: 1773750},
+		},
+		{
+			name:   "just above the second threshold is relieved down to the lower rate",
+			income: 10010000,
+			age:    30,
+			want:   AnnualTax{SlabTax: 2815500, Surcharge: 288250, MarginalRelief: 134075, Total: 3103750},
+		},
+		{
+			name:   "aged 59 uses the general slabs",
+			income: 500000,
+			age:    59,
+			want:   AnnualTax{SlabTax: 12500, Total: 12500},
+		},
+		{
+			name:   "senior citizen",
+			income: 500000,
+			age:    65,
+			want:   AnnualTax{SlabTax: 10000, Total: 10000},
+		},
+		{
+			name:   "super senior citizen",
+			income: 500000,
+			age:    85,
+			want:   AnnualTax{},
+		},
+		{
+			name:               "super senior citizen falls back to senior slabs",
+			withoutSuperSenior: true,
+			income:             500000,
+			age:                85,
+			want:               AnnualTax{SlabTax: 10000, Total: 10000},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := oldRegimeTable(tt.withoutSuperSenior).Tax(tt.income, tt.age)
+			if got != tt.want {
+				t.Errorf("Tax(%.0f, %d) = %+v, want %+v", tt.income, tt.age, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIncomeTaxTableSlabsFor(t *testing.T) {
+	tests := []struct {
+		name               string
+		withoutSuperSenior bool
+		age                int
+		wantMinAge         int
+		wantSlabs          int
+	}{
+		{name: "unknown age", age: 0, wantMinAge: 0, wantSlabs: 4},
+		{name: "below 60", age: 59, wantMinAge: 0, wantSlabs: 4},
+		{name: "exactly 60", age: 60, wantMinAge: SeniorCitizenAge, wantSlabs: 4},
+		{name: "exactly 80", age: 80, wantMinAge: SuperSeniorCitizenAge, wantSlabs: 3},
+		{name: "80 without super senior slabs", withoutSuperSenior: true, age: 80, wantMinAge: SeniorCitizenAge, wantSlabs: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			slabs := oldRegimeTable(tt.withoutSuperSenior).slabsFor(tt.age)
+			if len(slabs) != tt.wantSlabs {
+				t.Fatalf("slabsFor(%d) returned %d slabs, want %d", tt.age, len(slabs), tt.wantSlabs)
+			}
+			for i, s := range slabs {
+				if s.MinAge != tt.wantMinAge {
+					t.Errorf("slabsFor(%d)[%d].MinAge = %d, want %d", tt.age, i, s.MinAge, tt.wantMinAge)
+				}
+				if i > 0 && s.From < slabs[i-1].From {
+					t.Errorf("slabsFor(%d) is not sorted by From", tt.age)
+				}
+			}
+		})
+	}
+}
+
+func TestIncomeTaxTableSurchargeFor(t *testing.T) {
+	tests := []struct {
+		name          string
+		income        float64
+		wantThreshold float64
+		wantRate      float64
+		wantRateBelow float64
+	}{
+		{name: "below every threshold", income: 4000000},
+		{name: "exactly on the first threshold", income: 5000000},
+		{name: "above the first threshold", income: 5000001, wantThreshold: 5000000, wantRate: 10},
+		{name: "exactly on the second threshold", income: 10000000, wantThreshold: 5000000, wantRate: 10},
+		{name: "above the second threshold", income: 10000001, wantThreshold: 10000000, wantRate: 15, wantRateBelow: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			threshold, rate, rateBelow := oldRegimeTable(false).surchargeFor(tt.income)
+			if threshold != tt.wantThreshold || rate != tt.wantRate || rateBelow != tt.wantRateBelow {
+				t.Errorf("surchargeFor(%.0f) = (%.0f, %.0f, %.0f), want (%.0f, %.0f, %.0f)",
+					tt.income, threshold, rate, rateBelow, tt.wantThreshold, tt.wantRate, tt.wantRateBelow)
+			}
+		})
+	}
+}
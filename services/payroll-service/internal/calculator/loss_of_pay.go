@@ -0,0 +1,128 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// Proration bases: the days a month's salary is divided by to value one day
+const (
+	ProrationCalendarDays = "calendar_days"
+	ProrationWorkingDays  = "working_days"
+	ProrationFixed30      = "fixed_30"
+)
+
+// ProrationPolicy describes how an organization values a day of loss of pay
+type ProrationPolicy struct {
+	Basis string // calendar_days, working_days, fixed_30
+}
+
+// Validate checks the basis is supported
+func (p ProrationPolicy) Validate() error {
+	switch p.Basis {
+	case ProrationCalendarDays, ProrationWorkingDays, ProrationFixed30:
+		return nil
+	default:
+		return fmt.Errorf("proration basis must be one of calendar_days, working_days, fixed_30")
+	}
+}
+
+// BasisDays returns the days a month's salary is divided by. Months without
+// expected working days fall back to calendar days.
+func (p ProrationPolicy) BasisDays(daysInMonth, workingDays int) int {
+	switch p.Basis {
+	case ProrationFixed30:
+		return 30
+	case ProrationWorkingDays:
+		if workingDays > 0 {
+			return workingDays
+		}
+	}
+	return daysInMonth
+}
+
+// SetProrationPolicy sets the loss of pay proration policy. Calculators use
+// calendar days until a policy is set.
+func (pc *PayrollCalculator) SetProrationPolicy(policy ProrationPolicy) {
+	pc.proration = policy
+}
+
+// LOPReversal pays back loss of pay wrongly deducted in an earlier month
+type LOPReversal struct {
+	ID          string  `json:"id"`
+	Month       string  `json:"month"` // YYYY-MM the days were deducted in
+	Days        int     `json:"days"`
+	DaysInMonth int     `json:"days_in_month"`
+	WorkingDays int     `json:"working_days"`     // Expected working days of that month
+	Amount      float64 `json:"amount,omitempty"` // Set on the reversals a calculation paid
+}
+
+// lopDays returns the loss of pay days of the period: absences plus unpaid
+// leave, never more than the days being paid
+func lopDays(input *PayrollInput, periodDays int) int {
+	days := input.DaysAbsent + input.UnpaidLeaveDays
+	if days > periodDays {
+		days = periodDays
+	}
+	if days < 0 {
+		days = 0
+	}
+	return days
+}
+
+// recordLossOfPay adds the loss of pay line. The pay lost is already left out
+// of the earnings above; the line shows how much and why.
+func (pc *PayrollCalculator) recordLossOfPay(result *CalculationResult, monthlyPay float64, input *PayrollInput, basisDays int) {
+	if result.LOPDays == 0 {
+		return
+	}
+
+	result.Calculations = append(result.Calculations, CalculationStep{
+		Category:    "lop",
+		Description: fmt.Sprintf("Loss of Pay (%d days)", result.LOPDays),
+		Amount:      result.LOPAmount,
+		Rule: fmt.Sprintf("Absent %d + unpaid leave %d days; %.2f × %d/%d (%s) = %.2f",
+			input.DaysAbsent, input.UnpaidLeaveDays, monthlyPay, result.LOPDays, basisDays, pc.prorationBasis(), result.LOPAmount),
+	})
+}
+
+// calculateLOPReversals pays back loss of pay days reversed from earlier
+// months, valued at the current salary structure on that month's basis days.
+// The Basic + DA share counts towards this period's PF wage.
+func (pc *PayrollCalculator) calculateLOPReversals(result *CalculationResult, monthlyPay, monthlyPFPay float64, input *PayrollInput) {
+	var total, pfWage float64
+	for _, rev := range input.LOPReversals {
+		if rev.Days <= 0 {
+			continue
+		}
+
+		basisDays := pc.proration.BasisDays(rev.DaysInMonth, rev.WorkingDays)
+		if basisDays <= 0 {
+			continue
+		}
+
+		factor := math.Min(float64(rev.Days)/float64(basisDays), 1)
+		rev.Amount = round(monthlyPay*factor, 2)
+		total += rev.Amount
+		pfWage += monthlyPFPay * factor
+
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "lop",
+			Description: fmt.Sprintf("LOP Reversal (%s, %d days)", rev.Month, rev.Days),
+			Amount:      rev.Amount,
+			Rule:        fmt.Sprintf("%.2f × %d/%d (%s) = %.2f", monthlyPay, rev.Days, basisDays, pc.prorationBasis(), rev.Amount),
+		})
+		result.LOPReversals = append(result.LOPReversals, rev)
+	}
+
+	result.LOPReversal = round(total, 2)
+	result.lopReversalPFWage = round(pfWage, 2)
+}
+
+// prorationBasis returns the basis in use, calendar days by default
+func (pc *PayrollCalculator) prorationBasis() string {
+	if pc.proration.Basis == "" {
+		return ProrationCalendarDays
+	}
+	return pc.proration.Basis
+}
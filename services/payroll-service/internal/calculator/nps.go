@@ -0,0 +1,106 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+
+	"payroll-service/internal/models"
+)
+
+// Income tax regimes
+const (
+	TaxRegimeOld = "old"
+	TaxRegimeNew = "new" // Section 115BAC, the default
+)
+
+// Section 80CCD(2) limits on the employer's NPS contribution, as a
+// percentage of Basic + DA
+const (
+	NPS80CCD2OldRegimeLimit = 10.0
+	NPS80CCD2NewRegimeLimit = 14.0
+)
+
+// EmployerRetirementCap is the yearly limit on employer contributions to PF,
+// NPS and superannuation together; the excess is a taxable perquisite
+// (Section 17(2)(vii))
+const EmployerRetirementCap = 750000.0
+
+// NPSContribution is an employee's corporate NPS enrolment
+type NPSContribution struct {
+	PRAN         string  `json:"pran"`
+	EmployerRate float64 `json:"employer_rate"` // % of Basic + DA
+	EmployeeRate float64 `json:"employee_rate"` // % of Basic + DA, optional
+}
+
+// NPS80CCD2Limit returns the 80CCD(2) limit of a tax regime
+func NPS80CCD2Limit(taxRegime string) float64 {
+	if taxRegime == TaxRegimeOld {
+		return NPS80CCD2OldRegimeLimit
+	}
+	return NPS80CCD2NewRegimeLimit
+}
+
+// calculateNPS computes corporate NPS contributions on the Basic + DA earned,
+// the 80CCD(2) deduction, and the perquisite on employer retirement
+// contributions above the yearly cap
+func (pc *PayrollCalculator) calculateNPS(result *CalculationResult, ss *models.SalaryStructure, input *PayrollInput) {
+	// Employer superannuation follows the basic pay earned
+	if ss.MonthlySuperannuation > 0 && ss.MonthlyBasic > 0 {
+		result.SuperannuationEmployer = round(ss.MonthlySuperannuation*result.BasicPay/ss.MonthlyBasic, 2)
+	}
+
+	if input.NPS != nil {
+		npsWage := result.BasicPay + result.DeartnessAllowance + result.lopReversalPFWage
+
+		result.NPSEmployer = round(npsWage*input.NPS.EmployerRate/100, 2)
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "nps",
+			Description: "NPS - Employer Contribution",
+			Amount:      result.NPSEmployer,
+			Rule:        fmt.Sprintf("%.2f × %.2f%% = %.2f", npsWage, input.NPS.EmployerRate, result.NPSEmployer),
+		})
+
+		if input.NPS.EmployeeRate > 0 {
+			result.NPSEmployee = round(npsWage*input.NPS.EmployeeRate/100, 2)
+			result.Calculations = append(result.Calculations, CalculationStep{
+				Category:    "nps",
+				Description: "NPS - Employee Contribution",
+				Amount:      result.NPSEmployee,
+				Rule:        fmt.Sprintf("%.2f × %.2f%% = %.2f", npsWage, input.NPS.EmployeeRate, result.NPSEmployee),
+			})
+		}
+
+		limit := NPS80CCD2Limit(input.TaxRegime)
+		result.NPS80CCD2 = round(math.Min(result.NPSEmployer, npsWage*limit/100), 2)
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "nps",
+			Description: "Section 80CCD(2) Deduction",
+			Amount:      result.NPS80CCD2,
+			Rule:        fmt.Sprintf("Employer NPS (%.2f), up to %.0f%% of %.2f (%s regime)", result.NPSEmployer, limit, npsWage, taxRegime(input.TaxRegime)),
+		})
+	}
+
+	// Only the part of this period's contributions above the yearly cap is taxed now
+	contributions := result.PFEmployer + result.NPSEmployer + result.SuperannuationEmployer
+	excessBefore := math.Max(input.EmployerRetirementYTD-EmployerRetirementCap, 0)
+	excessAfter := math.Max(input.EmployerRetirementYTD+contributions-EmployerRetirementCap, 0)
+	result.RetirementPerquisite = round(excessAfter-excessBefore, 2)
+
+	if result.RetirementPerquisite > 0 {
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "nps",
+			Description: "Employer Retirement Contributions above Cap",
+			Amount:      result.RetirementPerquisite,
+			Rule: fmt.Sprintf("Year to date (%.2f) + PF (%.2f) + NPS (%.2f) + Superannuation (%.2f) above %.0f",
+				input.EmployerRetirementYTD, result.PFEmployer, result.NPSEmployer, result.SuperannuationEmployer, EmployerRetirementCap),
+		})
+	}
+}
+
+// taxRegime returns the regime in use, new by default
+func taxRegime(regime string) string {
+	if regime == "" {
+		return TaxRegimeNew
+	}
+	return regime
+}
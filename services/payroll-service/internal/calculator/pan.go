@@ -0,0 +1,56 @@
+package calculator
+
+import (
+	"regexp"
+
+	"payroll-service/internal/models"
+)
+
+// PAN verification statuses on the employee record
+const (
+	PANStatusPending      = "pending"       // Not yet checked against the income tax database
+	PANStatusVerified     = "verified"      // PAN exists and matches the employee's name
+	PANStatusNameMismatch = "name_mismatch" // PAN exists but is in another name
+	PANStatusInoperative  = "inoperative"   // Not linked with Aadhaar, treated as not furnished
+)
+
+// NoPANTDSRate is the minimum TDS rate, in percent, for an employee without
+// a valid PAN (Section 206AA)
+const NoPANTDSRate = 20.0
+
+// panPattern matches a Permanent Account Number, e.g. ABCPE1234F
+var panPattern = regexp.MustCompile(`^[A-Z]{5}[0-9]{4}[A-Z]$`)
+
+// IsValidPANFormat reports whether pan is shaped like a PAN
+func IsValidPANFormat(pan string) bool {
+	return panPattern.MatchString(pan)
+}
+
+// IsValidPANStatus reports whether status is a known PAN verification status
+func IsValidPANStatus(status string) bool {
+	switch status {
+	case PANStatusPending, PANStatusVerified, PANStatusNameMismatch, PANStatusInoperative:
+		return true
+	}
+	return false
+}
+
+// HasValidPAN reports whether an employee has furnished a PAN that TDS can
+// rely on. A missing or malformed PAN, one in another name and an
+// inoperative one all attract Section 206AA; a PAN awaiting verification
+// does not.
+func HasValidPAN(employee *models.Employee) bool {
+	if !employee.PersonalPAN.Valid || !IsValidPANFormat(employee.PersonalPAN.String) {
+		return false
+	}
+	return employee.PANStatus != PANStatusNameMismatch && employee.PANStatus != PANStatusInoperative
+}
+
+// tdsRate is the rate applied for a slab, raised to NoPANTDSRate when the
+// employee has no valid PAN
+func tdsRate(slab TDSSlab, input *PayrollInput) float64 {
+	if input.NoValidPAN && slab.Rate < NoPANTDSRate {
+		return NoPANTDSRate
+	}
+	return slab.Rate
+}
@@ -0,0 +1,58 @@
+package calculator
+
+import (
+	"database/sql"
+	"testing"
+
+	"payroll-service/internal/models"
+)
+
+func TestHasValidPAN(t *testing.T) {
+	tests := []struct {
+		name   string
+		pan    sql.NullString
+		status string
+		want   bool
+	}{
+		{name: "verified", pan: sql.NullString{String: "ABCPE1234F", Valid: true}, status: PANStatusVerified, want: true},
+		{name: "pending verification", pan: sql.NullString{String: "ABCPE1234F", Valid: true}, status: PANStatusPending, want: true},
+		{name: "name mismatch", pan: sql.NullString{String: "ABCPE1234F", Valid: true}, status: PANStatusNameMismatch, want: false},
+		{name: "inoperative", pan: sql.NullString{String: "ABCPE1234F", Valid: true}, status: PANStatusInoperative, want: false},
+		{name: "not furnished", pan: sql.NullString{}, status: PANStatusPending, want: false},
+		{name: "lowercase", pan: sql.NullString{String: "abcpe1234f", Valid: true}, status: PANStatusVerified, want: false},
+		{name: "too short", pan: sql.NullString{String: "ABCPE1234", Valid: true}, status: PANStatusVerified, want: false},
+		{name: "digits where letters belong", pan: sql.NullString{String: "ABC1E1234F", Valid: true}, status: PANStatusVerified, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			emp := &models.Employee{PersonalPAN: tt.pan, PANStatus: tt.status}
+			if got := HasValidPAN(emp); got != tt.want {
+				t.Errorf("HasValidPAN(%q, %s) = %v, want %v", tt.pan.String, tt.status, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTDSRate(t *testing.T) {
+	tests := []struct {
+		name       string
+		slabRate   float64
+		noValidPAN bool
+		want       float64
+	}{
+		{name: "valid PAN keeps the slab rate", slabRate: 5, want: 5},
+		{name: "no PAN raises a lower rate to 20%", slabRate: 5, noValidPAN: true, want: NoPANTDSRate},
+		{name: "no PAN raises a zero rate to 20%", slabRate: 0, noValidPAN: true, want: NoPANTDSRate},
+		{name: "no PAN keeps a higher rate", slabRate: 30, noValidPAN: true, want: 30},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tdsRate(TDSSlab{Rate: tt.slabRate}, &PayrollInput{NoValidPAN: tt.noValidPAN})
+			if got != tt.want {
+				t.Errorf("tdsRate(%.0f, noValidPAN=%v) = %.0f, want %.0f", tt.slabRate, tt.noValidPAN, got, tt.want)
+			}
+		})
+	}
+}
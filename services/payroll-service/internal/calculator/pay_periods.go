@@ -0,0 +1,158 @@
+package calculator
+
+import (
+	"fmt"
+	"time"
+)
+
+// Pay frequencies supported by pay groups
+const (
+	FrequencyMonthly     = "monthly"
+	FrequencySemiMonthly = "semi_monthly"
+	FrequencyFortnightly = "fortnightly"
+	FrequencyWeekly      = "weekly"
+)
+
+// PayPeriod is one period of a pay group's calendar. Periods are attributed
+// to the month they end in, which is the month their monthly limits count against.
+type PayPeriod struct {
+	Key          string
+	Start        time.Time
+	End          time.Time
+	PayrollMonth string // YYYY-MM
+}
+
+// ValidateFrequency checks a pay frequency and whether it needs an anchor date
+func ValidateFrequency(frequency string, anchor *time.Time) error {
+	switch frequency {
+	case FrequencyMonthly, FrequencySemiMonthly:
+		return nil
+	case FrequencyFortnightly, FrequencyWeekly:
+		if anchor == nil {
+			return fmt.Errorf("%s pay groups need an anchor date (the first day of any period)", frequency)
+		}
+		return nil
+	default:
+		return fmt.Errorf("frequency must be one of monthly, semi_monthly, fortnightly, weekly")
+	}
+}
+
+// GeneratePayPeriods lists the periods of a pay calendar that overlap [from, to].
+// Weekly and fortnightly calendars repeat from anchor; monthly and
+// semi-monthly calendars follow the calendar month.
+//
+// Period keys: monthly "2024-01", semi-monthly "2024-01-1" and "2024-01-2",
+// weekly "2024-W02" (ISO week of the start), fortnightly "2024-F01"
+// (n-th fortnight starting in the year).
+func GeneratePayPeriods(frequency string, anchor *time.Time, from, to time.Time) ([]PayPeriod, error) {
+	if err := ValidateFrequency(frequency, anchor); err != nil {
+		return nil, err
+	}
+
+	if to.Before(from) {
+		return nil, fmt.Errorf("from date must be before to date")
+	}
+
+	var periods []PayPeriod
+
+	switch frequency {
+	case FrequencyMonthly, FrequencySemiMonthly:
+		month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC)
+		for !month.After(to) {
+			monthEnd := month.AddDate(0, 1, -1)
+			key := month.Format("2006-01")
+
+			if frequency == FrequencyMonthly {
+				periods = append(periods, newPayPeriod(key, month, monthEnd))
+			} else {
+				mid := month.AddDate(0, 0, 14)
+				periods = append(periods,
+					newPayPeriod(key+"-1", month, mid),
+					newPayPeriod(key+"-2", mid.AddDate(0, 0, 1), monthEnd),
+				)
+			}
+
+			month = month.AddDate(0, 1, 0)
+		}
+
+	case FrequencyFortnightly, FrequencyWeekly:
+		length := 7
+		if frequency == FrequencyFortnightly {
+			length = 14
+		}
+
+		start := *anchor
+		offset := int(from.Sub(start).Hours()/24) / length
+		if from.Before(start) {
+			offset--
+		}
+		start = start.AddDate(0, 0, offset*length)
+
+		for !start.After(to) {
+			end := start.AddDate(0, 0, length-1)
+
+			var key string
+			if frequency == FrequencyWeekly {
+				year, week := start.ISOWeek()
+				key = fmt.Sprintf("%d-W%02d", year, week)
+			} else {
+				key = fmt.Sprintf("%d-F%02d", start.Year(), (start.YearDay()-1)/14+1)
+			}
+
+			periods = append(periods, newPayPeriod(key, start, end))
+			start = start.AddDate(0, 0, length)
+		}
+	}
+
+	// Drop periods that end before the requested range
+	var overlapping []PayPeriod
+	for _, p := range periods {
+		if !p.End.Before(from) && !p.Start.After(to) {
+			overlapping = append(overlapping, p)
+		}
+	}
+
+	return overlapping, nil
+}
+
+func newPayPeriod(key string, start, end time.Time) PayPeriod {
+	return PayPeriod{
+		Key:          key,
+		Start:        start,
+		End:          end,
+		PayrollMonth: end.Format("2006-01"),
+	}
+}
+
+// MonthToDate totals what earlier periods of the same month already paid an
+// employee, so monthly ceilings and slabs apply to the month as a whole
+type MonthToDate struct {
+	Periods         int     `json:"periods"`
+	DaysPaid        int     `json:"days_paid"` // Including LOP days, which count as settled
+	GrossAmount     float64 `json:"gross_amount"`
+	OneTimePayment  float64 `json:"one_time_payment"` // Part of GrossAmount from off-cycle bonuses and settlements
+	PFWage          float64 `json:"pf_wage"`
+	ESIWage         float64 `json:"esi_wage"`
+	ESIEmployee     float64 `json:"esi_employee"`
+	ProfessionalTax float64 `json:"professional_tax"`
+	TaxableIncome   float64 `json:"taxable_income"`
+	TDS             float64 `json:"tds"`
+}
+
+// orZero lets callers read totals without nil checks
+func (m *MonthToDate) orZero() MonthToDate {
+	if m == nil {
+		return MonthToDate{}
+	}
+	return *m
+}
+
+// FinancialYearStart returns the first day of the April-March financial
+// year t falls in
+func FinancialYearStart(t time.Time) time.Time {
+	year := t.Year()
+	if t.Month() < time.April {
+		year--
+	}
+	return time.Date(year, time.April, 1, 0, 0, 0, 0, time.UTC)
+}
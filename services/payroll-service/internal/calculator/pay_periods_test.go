@@ -0,0 +1,138 @@
+package calculator
+
+import (
+	"testing"
+	"time"
+)
+
+func mustDate(t *testing.T, s string) time.Time {
+	t.Helper()
+	d, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		t.Fatalf("invalid date %q: %v", s, err)
+	}
+	return d
+}
+
+func TestGeneratePayPeriods(t *testing.T) {
+	type period struct {
+		key, start, end, month string
+	}
+
+	tests := []struct {
+		name      string
+		frequency string
+		anchor    string
+		from, to  string
+		want      []period
+		wantErr   bool
+	}{
+		{
+			name:      "monthly across a leap February",
+			frequency: FrequencyMonthly,
+			from:      "2024-01-15", to: "2024-03-01",
+			want: []period{
+				{"2024-01", "2024-01-01", "2024-01-31", "2024-01"},
+				{"2024-02", "2024-02-01", "2024-02-29", "2024-02"},
+				{"2024-03", "2024-03-01", "2024-03-31", "2024-03"},
+			},
+		},
+		{
+			name:      "semi-monthly splits on the 15th",
+			frequency: FrequencySemiMonthly,
+			from:      "2024-02-01", to: "2024-02-29",
+			want: []period{
+				{"2024-02-1", "2024-02-01", "2024-02-15", "2024-02"},
+				{"2024-02-2", "2024-02-16", "2024-02-29", "2024-02"},
+			},
+		},
+		{
+			name:      "semi-monthly drops the half before the range",
+			frequency: FrequencySemiMonthly,
+			from:      "2024-04-20", to: "2024-04-30",
+			want: []period{
+				{"2024-04-2", "2024-04-16", "2024-04-30", "2024-04"},
+			},
+		},
+		{
+			name:      "weekly from the anchor",
+			frequency: FrequencyWeekly,
+			anchor:    "2024-01-01",
+			from:      "2024-01-10", to: "2024-01-20",
+			want: []period{
+				{"2024-W02", "2024-01-08", "2024-01-14", "2024-01"},
+				{"2024-W03", "2024-01-15", "2024-01-21", "2024-01"},
+			},
+		},
+		{
+			name:      "weekly range starting before the anchor",
+			frequency: FrequencyWeekly,
+			anchor:    "2024-01-15",
+			from:      "2024-01-10", to: "2024-01-16",
+			want: []period{
+				{"2024-W02", "2024-01-08", "2024-01-14", "2024-01"},
+				{"2024-W03", "2024-01-15", "2024-01-21", "2024-01"},
+			},
+		},
+		{
+			name:      "fortnightly period is attributed to the month it ends in",
+			frequency: FrequencyFortnightly,
+			anchor:    "2024-01-01",
+			from:      "2024-01-20", to: "2024-02-10",
+			want: []period{
+				{"2024-F02", "2024-01-15", "2024-01-28", "2024-01"},
+				{"2024-F03", "2024-01-29", "2024-02-11", "2024-02"},
+			},
+		},
+		{name: "weekly without an anchor", frequency: FrequencyWeekly, from: "2024-01-01", to: "2024-01-31", wantErr: true},
+		{name: "range ends before it starts", frequency: FrequencyMonthly, from: "2024-02-01", to: "2024-01-01", wantErr: true},
+		{name: "unknown frequency", frequency: "daily", from: "2024-01-01", to: "2024-01-31", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var anchor *time.Time
+			if tt.anchor != "" {
+				a := mustDate(t, tt.anchor)
+				anchor = &a
+			}
+
+			periods, err := GeneratePayPeriods(tt.frequency, anchor, mustDate(t, tt.from), mustDate(t, tt.to))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GeneratePayPeriods() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(periods) != len(tt.want) {
+				t.Fatalf("got %d periods, want %d: %+v", len(periods), len(tt.want), periods)
+			}
+			for i, p := range periods {
+				got := period{p.Key, p.Start.Format("2006-01-02"), p.End.Format("2006-01-02"), p.PayrollMonth}
+				if got != tt.want[i] {
+					t.Errorf("period %d = %+v, want %+v", i, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFinancialYearStart(t *testing.T) {
+	tests := []struct {
+		date, want string
+	}{
+		{"2024-03-31", "2023-04-01"},
+		{"2024-04-01", "2024-04-01"},
+		{"2024-12-31", "2024-04-01"},
+		{"2025-01-01", "2024-04-01"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.date, func(t *testing.T) {
+			if got := FinancialYearStart(mustDate(t, tt.date)).Format("2006-01-02"); got != tt.want {
+				t.Errorf("FinancialYearStart(%s) = %s, want %s", tt.date, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// Net pay rounding modes
+const (
+	RoundingNone    = "none"
+	RoundingNearest = "nearest"
+	RoundingUp      = "up"
+	RoundingDown    = "down"
+)
+
+// RoundingPolicy describes how an organization rounds net pay
+type RoundingPolicy struct {
+	Mode string  // none, nearest, up, down
+	Unit float64 // 1, 5 or 10 rupees
+}
+
+// Validate checks the mode and unit are supported
+func (p RoundingPolicy) Validate() error {
+	switch p.Mode {
+	case RoundingNone, RoundingNearest, RoundingUp, RoundingDown:
+	default:
+		return fmt.Errorf("rounding mode must be one of none, nearest, up, down")
+	}
+
+	if p.Mode != RoundingNone && p.Unit != 1 && p.Unit != 5 && p.Unit != 10 {
+		return fmt.Errorf("rounding unit must be 1, 5 or 10")
+	}
+
+	return nil
+}
+
+// Round rounds an amount according to the policy
+func (p RoundingPolicy) Round(amount float64) float64 {
+	if p.Mode == RoundingNone || p.Unit <= 0 {
+		return round(amount, 2)
+	}
+
+	// Rounded to paise first so binary noise cannot push a whole amount to the next unit
+	units := round(amount/p.Unit, 6)
+
+	switch p.Mode {
+	case RoundingUp:
+		units = math.Ceil(units)
+	case RoundingDown:
+		units = math.Floor(units)
+	default:
+		units = math.Round(units)
+	}
+
+	return round(units*p.Unit, 2)
+}
+
+// SetRoundingPolicy sets the net pay rounding policy. Calculators round
+// nothing until a policy is set.
+func (pc *PayrollCalculator) SetRoundingPolicy(policy RoundingPolicy) {
+	pc.rounding = policy
+}
+
+// applyRounding rounds net pay and records the difference as a signed
+// rounding adjustment (positive is an earning, negative a deduction).
+// The balance of earlier adjustments is netted off first so that the
+// cumulative difference never exceeds one rounding unit; a final settlement
+// pays the exact amount and clears the balance.
+func (pc *PayrollCalculator) applyRounding(result *CalculationResult, input *PayrollInput) {
+	if result.NetPay <= 0 {
+		return
+	}
+
+	target := round(result.NetPay-input.RoundingBalance, 2)
+	exact := input.FinalSettlement || pc.rounding.Mode == "" || pc.rounding.Mode == RoundingNone
+
+	rounded := target
+	if !exact {
+		rounded = pc.rounding.Round(target)
+	}
+
+	result.RoundingAdjustment = round(rounded-result.NetPay, 2)
+	if result.RoundingAdjustment == 0 {
+		return
+	}
+
+	rule := fmt.Sprintf("Net (%.2f) - earlier adjustments (%.2f) = %.2f, rounded %s to %.0f = %.2f",
+		result.NetPay, input.RoundingBalance, target, pc.rounding.Mode, pc.rounding.Unit, rounded)
+	if exact {
+		rule = fmt.Sprintf("Net (%.2f) - earlier adjustments (%.2f) = %.2f, paid exactly",
+			result.NetPay, input.RoundingBalance, rounded)
+	}
+
+	result.NetPay = rounded
+	result.Calculations = append(result.Calculations, CalculationStep{
+		Category:    "summary",
+		Description: "Rounding Adjustment",
+		Amount:      result.RoundingAdjustment,
+		Rule:        rule,
+	})
+}
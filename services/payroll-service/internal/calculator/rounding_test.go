@@ -0,0 +1,86 @@
+package calculator
+
+import "testing"
+
+func TestRoundingPolicyRound(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RoundingPolicy
+		amount float64
+		want   float64
+	}{
+		{name: "none keeps paise", policy: RoundingPolicy{Mode: RoundingNone}, amount: 1234.567, want: 1234.57},
+		{name: "nearest rupee rounds half up", policy: RoundingPolicy{Mode: RoundingNearest, Unit: 1}, amount: 1234.5, want: 1235},
+		{name: "nearest ten down", policy: RoundingPolicy{Mode: RoundingNearest, Unit: 10}, amount: 1234.56, want: 1230},
+		{name: "nearest ten on the midpoint", policy: RoundingPolicy{Mode: RoundingNearest, Unit: 10}, amount: 1235, want: 1240},
+		{name: "up to five", policy: RoundingPolicy{Mode: RoundingUp, Unit: 5}, amount: 1231, want: 1235},
+		{name: "up leaves a whole unit alone", policy: RoundingPolicy{Mode: RoundingUp, Unit: 5}, amount: 1235, want: 1235},
+		{name: "up ignores binary noise", policy: RoundingPolicy{Mode: RoundingUp, Unit: 1}, amount: 0.1 + 0.2 + 1233.7, want: 1234},
+		{name: "down to ten", policy: RoundingPolicy{Mode: RoundingDown, Unit: 10}, amount: 1239.99, want: 1230},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.Round(tt.amount); got != tt.want {
+				t.Errorf("Round(%v) = %v, want %v", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundingPolicyValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RoundingPolicy
+		wantErr bool
+	}{
+		{name: "none needs no unit", policy: RoundingPolicy{Mode: RoundingNone}},
+		{name: "nearest ten", policy: RoundingPolicy{Mode: RoundingNearest, Unit: 10}},
+		{name: "unsupported unit", policy: RoundingPolicy{Mode: RoundingUp, Unit: 2}, wantErr: true},
+		{name: "unknown mode", policy: RoundingPolicy{Mode: "ceiling", Unit: 1}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestApplyRounding(t *testing.T) {
+	nearestTen := RoundingPolicy{Mode: RoundingNearest, Unit: 10}
+
+	tests := []struct {
+		name            string
+		policy          RoundingPolicy
+		netPay          float64
+		balance         float64
+		finalSettlement bool
+		wantNetPay      float64
+		wantAdjustment  float64
+	}{
+		{name: "rounded down becomes a deduction", policy: nearestTen, netPay: 1234.56, wantNetPay: 1230, wantAdjustment: -4.56},
+		{name: "earlier adjustment is netted off", policy: nearestTen, netPay: 1234.56, balance: -4.56, wantNetPay: 1240, wantAdjustment: 5.44},
+		{name: "already whole", policy: nearestTen, netPay: 1230, wantNetPay: 1230},
+		{name: "final settlement pays exactly and clears the balance", policy: nearestTen, netPay: 1000, balance: 5.44, finalSettlement: true, wantNetPay: 994.56, wantAdjustment: -5.44},
+		{name: "no policy set", netPay: 1234.56, wantNetPay: 1234.56},
+		{name: "no net pay is left alone", policy: nearestTen, netPay: 0, balance: 5, wantNetPay: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pc := &PayrollCalculator{}
+			pc.SetRoundingPolicy(tt.policy)
+
+			result := &CalculationResult{NetPay: tt.netPay}
+			pc.applyRounding(result, &PayrollInput{RoundingBalance: tt.balance, FinalSettlement: tt.finalSettlement})
+
+			if result.NetPay != tt.wantNetPay || result.RoundingAdjustment != tt.wantAdjustment {
+				t.Errorf("net pay %.2f, adjustment %.2f; want %.2f, %.2f",
+					result.NetPay, result.RoundingAdjustment, tt.wantNetPay, tt.wantAdjustment)
+			}
+		})
+	}
+}
@@ -2,6 +2,9 @@ package calculator
 
 import (
 	"fmt"
+	"sort"
+	"time"
+
 	"payroll-service/internal/models"
 )
 
@@ -11,8 +14,25 @@ type StatutoryRules struct {
 	ESI *ESIRules
 	PT  *PTRules
 	TDS *TDSRules
+
+	// Sources records which statutory_rules rows each rule type was built from
+	Sources []RuleSource
 }
 
+// RuleSource identifies the database version a rule type was resolved from
+type RuleSource struct {
+	RuleType      string     `json:"rule_type"`
+	RuleIDs       []string   `json:"rule_ids"`
+	OrgID         *string    `json:"org_id"`
+	StateCode     *string    `json:"state_code"`
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTill *time.Time `json:"effective_till"`
+}
+
+// RequiredRuleTypes must resolve for every payroll calculation.
+// PT is optional because several states do not levy professional tax.
+var RequiredRuleTypes = []string{"PF", "ESI", "TDS"}
+
 // PFRules represents Provident Fund rules
 type PFRules struct {
 	EmployeeRate float64 // Default: 12%
@@ -54,38 +74,79 @@ type TDSSlab struct {
 
 // PayrollInput represents input data for payroll calculation
 type PayrollInput struct {
-	DaysWorked      int
-	DaysAbsent      int
-	DaysLeave       int
-	DaysInMonth     int
-	AdvanceRecovery float64
-	LoanRecovery    float64
-	OtherDeductions float64
+	DaysWorked      int     `json:"days_worked"` // Days of the period being paid, before loss of pay
+	DaysAbsent      int     `json:"days_absent"`
+	DaysLeave       int     `json:"days_leave"`
+	DaysInMonth     int     `json:"days_in_month"`
+	UnpaidLeaveDays int     `json:"unpaid_leave_days"`
+	WorkingDays     int     `json:"working_days"` // Expected working days of the month, for the working_days basis
+	AdvanceRecovery float64 `json:"advance_recovery"`
+	LoanRecovery    float64 `json:"loan_recovery"`
+	OtherDeductions float64 `json:"other_deductions"`
+
+	CourtOrderDeduction float64            `json:"court_order_deduction"`
+	OneTimePayment      float64            `json:"one_time_payment"` // Bonus or settlement paid by an off-cycle run; outside PF and ESI wages
+	CarriedForward      map[string]float64 `json:"carried_forward,omitempty"` // Unrecovered deductions from earlier runs, by category
+
+	DeductionInstalments []DeductionInstalment `json:"deduction_instalments,omitempty"` // Loan, advance and other schedules due this period
+
+	RoundingBalance float64 `json:"rounding_balance"` // Sum of earlier rounding adjustments
+	FinalSettlement bool    `json:"final_settlement"` // Pay exactly and clear the rounding balance
+
+	MonthToDate *MonthToDate `json:"month_to_date,omitempty"` // Earlier periods and off-cycle runs of the same month
+
+	Shifts []RosterShift `json:"shifts,omitempty"` // Rostered shifts worked in the period
+
+	LOPReversals []LOPReversal `json:"lop_reversals,omitempty"` // Loss of pay to pay back from earlier months
+
+	NPS                   *NPSContribution `json:"nps,omitempty"`           // Corporate NPS enrolment, nil when not enrolled
+	TaxRegime             string           `json:"tax_regime"`              // old or new (default)
+	NoValidPAN            bool             `json:"no_valid_pan"`            // TDS at no less than 20% (Section 206AA)
+	Age                   int              `json:"age"`                     // On 31 March of the financial year; picks the old regime senior citizen slabs
+	EmployerRetirementYTD float64          `json:"employer_retirement_ytd"` // Employer PF, NPS and superannuation earlier in the financial year
+
+	FBP        []FBPAllocation `json:"fbp,omitempty"`        // Flexible benefit plan declared for the year
+	FBPClaims  []FBPClaim      `json:"fbp_claims,omitempty"` // Approved claims to pay this period
+	FBPBalance float64         `json:"fbp_balance"`          // Claim-based allocation accrued earlier in the year and not yet claimed
+	FBPYearEnd bool            `json:"fbp_year_end"`         // Pay out the unclaimed balance (last period of the year or final settlement)
+
+	EquityPerquisites   []EquityPerquisite `json:"equity_perquisites,omitempty"`   // ESOP exercises and RSU vestings to tax this period
+	DeferredPerquisites []EquityPerquisite `json:"deferred_perquisites,omitempty"` // Earlier deferred perquisites whose deferral has ended
+
+	TaxYear *TaxYearToDate `json:"tax_year,omitempty"` // Project TDS over the financial year; nil applies the slab to the month alone
 }
 
-// BuildStatutoryRulesFromDB converts database rules to calculator rules
-func BuildStatutoryRulesFromDB(dbRules []models.StatutoryRule) *StatutoryRules {
+// BuildStatutoryRulesFromDB converts database rules to calculator rules.
+// Rate columns are mandatory for PF and ESI rows; empty wage ceilings fall
+// back to the statutory ₹15,000 (PF) and ₹21,000 (ESI).
+func BuildStatutoryRulesFromDB(dbRules []models.StatutoryRule) (*StatutoryRules, error) {
 	rules := &StatutoryRules{}
 
 	// Organize rules by type
 	for _, rule := range dbRules {
-		switch rule.RuleType {
+		switch ruleFamily(rule.RuleType) {
 		case "PF":
+			if rule.PFEmployeeRate == nil || rule.PFEmployerRate == nil {
+				return nil, fmt.Errorf("PF rule %s is missing contribution rates", rule.ID)
+			}
 			rules.PF = &PFRules{
-				EmployeeRate: defaultIfNil(rule.PFEmployeeRate, 12),
-				EmployerRate: defaultIfNil(rule.PFEmployerRate, 12),
+				EmployeeRate: *rule.PFEmployeeRate,
+				EmployerRate: *rule.PFEmployerRate,
 				Ceiling:      defaultIfNil(rule.PFCeiling, 15000),
 			}
 
 		case "ESI":
+			if rule.ESIEmployeeRate == nil || rule.ESIEmployerRate == nil {
+				return nil, fmt.Errorf("ESI rule %s is missing contribution rates", rule.ID)
+			}
 			rules.ESI = &ESIRules{
-				EmployeeRate:    defaultIfNil(rule.ESIEmployeeRate, 0.75),
-				EmployerRate:    defaultIfNil(rule.ESIEmployerRate, 3.25),
+				EmployeeRate:    *rule.ESIEmployeeRate,
+				EmployerRate:    *rule.ESIEmployerRate,
 				WageCeiling:     defaultIfNil(rule.ESIWageCeiling, 21000),
 				ThresholdSalary: defaultIfNil(rule.ESIThresholdSalary, 0),
 			}
 
-		case "PT", "PT_SLAB_1", "PT_SLAB_2", "PT_SLAB_3":
+		case "PT":
 			if rules.PT == nil {
 				rules.PT = &PTRules{Slabs: []PTSlab{}}
 			}
@@ -115,7 +176,113 @@ func BuildStatutoryRulesFromDB(dbRules []models.StatutoryRule) *StatutoryRules {
 		}
 	}
 
-	return rules
+	if rules.PT != nil {
+		sort.Slice(rules.PT.Slabs, func(i, j int) bool { return rules.PT.Slabs[i].Min < rules.PT.Slabs[j].Min })
+	}
+	if rules.TDS != nil {
+		sort.Slice(rules.TDS.Slabs, func(i, j int) bool { return rules.TDS.Slabs[i].Min < rules.TDS.Slabs[j].Min })
+	}
+
+	return rules, nil
+}
+
+// ResolveStatutoryRules picks, for each rule type, the single version that
+// applies out of all rows in force and builds calculator rules from it.
+//
+// A version is the set of rows sharing org, state and effective_from (PT and
+// TDS slabs span several rows). Org-specific versions win over global ones,
+// state-specific over state-less, and the latest effective_from breaks ties.
+func ResolveStatutoryRules(dbRules []models.StatutoryRule) (*StatutoryRules, error) {
+	versions := map[string]*RuleSource{}
+	rowsByVersion := map[string][]models.StatutoryRule{}
+	best := map[string]string{}
+
+	for _, rule := range dbRules {
+		family := ruleFamily(rule.RuleType)
+		key := ruleVersionKey(family, rule.OrgID, rule.StateCode, rule.EffectiveFrom)
+
+		src, ok := versions[key]
+		if !ok {
+			src = &RuleSource{
+				RuleType:      family,
+				OrgID:         rule.OrgID,
+				StateCode:     rule.StateCode,
+				EffectiveFrom: rule.EffectiveFrom,
+				EffectiveTill: rule.EffectiveTill,
+			}
+			versions[key] = src
+		}
+		src.RuleIDs = append(src.RuleIDs, rule.ID)
+		rowsByVersion[key] = append(rowsByVersion[key], rule)
+
+		if current, ok := best[family]; !ok || moreSpecific(src, versions[current]) {
+			best[family] = key
+		}
+	}
+
+	var selected []models.StatutoryRule
+	var sources []RuleSource
+	families := make([]string, 0, len(best))
+	for family := range best {
+		families = append(families, family)
+	}
+	sort.Strings(families)
+
+	for _, family := range families {
+		key := best[family]
+		selected = append(selected, rowsByVersion[key]...)
+		sources = append(sources, *versions[key])
+	}
+
+	rules, err := BuildStatutoryRulesFromDB(selected)
+	if err != nil {
+		return nil, err
+	}
+	rules.Sources = sources
+
+	return rules, nil
+}
+
+// SourceFor returns the resolved source of a rule type, if any
+func (r *StatutoryRules) SourceFor(ruleType string) *RuleSource {
+	for i := range r.Sources {
+		if r.Sources[i].RuleType == ruleType {
+			return &r.Sources[i]
+		}
+	}
+	return nil
+}
+
+// ruleFamily folds legacy per-slab rule types onto their family
+func ruleFamily(ruleType string) string {
+	switch ruleType {
+	case "PT", "PT_SLAB_1", "PT_SLAB_2", "PT_SLAB_3":
+		return "PT"
+	default:
+		return ruleType
+	}
+}
+
+func ruleVersionKey(family string, orgID, stateCode *string, effectiveFrom time.Time) string {
+	org, state := "*", "*"
+	if orgID != nil {
+		org = *orgID
+	}
+	if stateCode != nil {
+		state = *stateCode
+	}
+	return fmt.Sprintf("%s|%s|%s|%s", family, org, state, effectiveFrom.Format("2006-01-02"))
+}
+
+// moreSpecific reports whether version a should be preferred over b
+func moreSpecific(a, b *RuleSource) bool {
+	if (a.OrgID != nil) != (b.OrgID != nil) {
+		return a.OrgID != nil
+	}
+	if (a.StateCode != nil) != (b.StateCode != nil) {
+		return a.StateCode != nil
+	}
+	return a.EffectiveFrom.After(b.EffectiveFrom)
 }
 
 // GetDefaultIndiaRules returns default India statutory rules
@@ -164,54 +331,232 @@ func ValidateRules(rules *StatutoryRules) error {
 		return fmt.Errorf("PF rules not configured")
 	}
 
-	if rules.PF.EmployeeRate < 0 || rules.PF.EmployerRate < 0 {
-		return fmt.Errorf("PF rates cannot be negative")
+	if err := validatePFRules(rules.PF); err != nil {
+		return err
 	}
 
 	if rules.ESI == nil {
 		return fmt.Errorf("ESI rules not configured")
 	}
 
-	if rules.ESI.EmployeeRate < 0 || rules.ESI.EmployerRate < 0 {
-		return fmt.Errorf("ESI rates cannot be negative")
+	if err := validateESIRules(rules.ESI); err != nil {
+		return err
 	}
 
 	if rules.PT != nil {
-		if len(rules.PT.Slabs) == 0 {
-			return fmt.Errorf("PT slabs not configured")
+		if err := validatePTRules(rules.PT); err != nil {
+			return err
 		}
+	}
 
-		// Validate PT slabs are in ascending order
-		for i := 0; i < len(rules.PT.Slabs)-1; i++ {
-			if rules.PT.Slabs[i].Min >= rules.PT.Slabs[i+1].Min {
-				return fmt.Errorf("PT slabs must be in ascending order")
-			}
+	if rules.TDS != nil {
+		if err := validateTDSRules(rules.TDS); err != nil {
+			return err
 		}
 	}
 
-	if rules.TDS != nil {
-		if len(rules.TDS.Slabs) == 0 {
-			return fmt.Errorf("TDS slabs not configured")
+	return nil
+}
+
+// ValidateRuleVersion validates the rows of a single rule version (one PF or
+// ESI row, or the full slab set of a PT/TDS version) before it is saved.
+// Slab versions must start at zero and be continuous: no gaps, no overlaps
+// and only the top slab may be open-ended.
+func ValidateRuleVersion(rows []models.StatutoryRule) error {
+	if len(rows) == 0 {
+		return fmt.Errorf("rule version has no rows")
+	}
+
+	family := ruleFamily(rows[0].RuleType)
+	for _, row := range rows[1:] {
+		if ruleFamily(row.RuleType) != family {
+			return fmt.Errorf("rule version mixes %s and %s rows", family, row.RuleType)
 		}
+	}
+
+	if rows[0].EffectiveTill != nil && rows[0].EffectiveTill.Before(rows[0].EffectiveFrom) {
+		return fmt.Errorf("effective_till cannot be before effective_from")
+	}
 
-		// Validate TDS slabs are in ascending order
-		for i := 0; i < len(rules.TDS.Slabs)-1; i++ {
-			if rules.TDS.Slabs[i].Min >= rules.TDS.Slabs[i+1].Min {
-				return fmt.Errorf("TDS slabs must be in ascending order")
+	switch family {
+	case "PF", "ESI":
+		if len(rows) != 1 {
+			return fmt.Errorf("%s version must have exactly one row, got %d", family, len(rows))
+		}
+	case "PT":
+		for _, row := range rows {
+			if row.PTSlabMin == nil || row.PTAmount == nil {
+				return fmt.Errorf("PT slab rows need pt_slab_min and pt_amount")
 			}
 		}
-
-		// Validate TDS rates are between 0-100
-		for _, slab := range rules.TDS.Slabs {
-			if slab.Rate < 0 || slab.Rate > 100 {
-				return fmt.Errorf("TDS rate must be between 0-100, got %.2f", slab.Rate)
+	case "TDS":
+		for _, row := range rows {
+			if row.TDSSlabMin == nil || row.TDSRate == nil {
+				return fmt.Errorf("TDS slab rows need tds_slab_min and tds_rate")
 			}
 		}
+	case "GRATUITY":
+		return nil
+	default:
+		return fmt.Errorf("unknown rule type %s", rows[0].RuleType)
+	}
+
+	rules, err := BuildStatutoryRulesFromDB(rows)
+	if err != nil {
+		return err
+	}
+
+	switch family {
+	case "PF":
+		return validatePFRules(rules.PF)
+	case "ESI":
+		return validateESIRules(rules.ESI)
+	case "PT":
+		if err := validatePTRules(rules.PT); err != nil {
+			return err
+		}
+		bounds := make([]slabBounds, len(rules.PT.Slabs))
+		for i, slab := range rules.PT.Slabs {
+			bounds[i] = slabBounds{Min: slab.Min, Max: slab.Max}
+		}
+		return validateSlabContinuity("PT", bounds)
+	case "TDS":
+		if err := validateTDSRules(rules.TDS); err != nil {
+			return err
+		}
+		bounds := make([]slabBounds, len(rules.TDS.Slabs))
+		for i, slab := range rules.TDS.Slabs {
+			bounds[i] = slabBounds{Min: slab.Min, Max: slab.Max}
+		}
+		return validateSlabContinuity("TDS", bounds)
+	}
+
+	return nil
+}
+
+func validatePFRules(pf *PFRules) error {
+	if pf.EmployeeRate < 0 || pf.EmployerRate < 0 {
+		return fmt.Errorf("PF rates cannot be negative")
+	}
+	if pf.EmployeeRate > 100 || pf.EmployerRate > 100 {
+		return fmt.Errorf("PF rates must be between 0-100")
+	}
+	if pf.Ceiling < 0 {
+		return fmt.Errorf("PF ceiling cannot be negative")
+	}
+	return nil
+}
+
+func validateESIRules(esi *ESIRules) error {
+	if esi.EmployeeRate < 0 || esi.EmployerRate < 0 {
+		return fmt.Errorf("ESI rates cannot be negative")
+	}
+	if esi.EmployeeRate > 100 || esi.EmployerRate > 100 {
+		return fmt.Errorf("ESI rates must be between 0-100")
+	}
+	if esi.WageCeiling < 0 || esi.ThresholdSalary < 0 {
+		return fmt.Errorf("ESI ceiling and threshold cannot be negative")
+	}
+	return nil
+}
+
+func validatePTRules(pt *PTRules) error {
+	if len(pt.Slabs) == 0 {
+		return fmt.Errorf("PT slabs not configured")
+	}
+
+	// Validate PT slabs are in ascending order
+	for i := 0; i < len(pt.Slabs)-1; i++ {
+		if pt.Slabs[i].Min >= pt.Slabs[i+1].Min {
+			return fmt.Errorf("PT slabs must be in ascending order")
+		}
+	}
+
+	for _, slab := range pt.Slabs {
+		if slab.Amount < 0 {
+			return fmt.Errorf("PT amount cannot be negative, got %.2f", slab.Amount)
+		}
+	}
+
+	return nil
+}
+
+func validateTDSRules(tds *TDSRules) error {
+	if len(tds.Slabs) == 0 {
+		return fmt.Errorf("TDS slabs not configured")
+	}
+
+	// Validate TDS slabs are in ascending order
+	for i := 0; i < len(tds.Slabs)-1; i++ {
+		if tds.Slabs[i].Min >= tds.Slabs[i+1].Min {
+			return fmt.Errorf("TDS slabs must be in ascending order")
+		}
+	}
+
+	// Validate TDS rates are between 0-100
+	for _, slab := range tds.Slabs {
+		if slab.Rate < 0 || slab.Rate > 100 {
+			return fmt.Errorf("TDS rate must be between 0-100, got %.2f", slab.Rate)
+		}
+	}
+
+	return nil
+}
+
+// slabBounds is the range part of a PT or TDS slab
+type slabBounds struct {
+	Min float64
+	Max *float64
+}
+
+// validateSlabContinuity checks sorted slabs cover [0, ∞) without gaps or
+// overlaps. Slabs are in whole rupees, so 10000 followed by 10001 is continuous.
+func validateSlabContinuity(ruleType string, slabs []slabBounds) error {
+	if slabs[0].Min != 0 {
+		return fmt.Errorf("%s slabs must start at 0, first slab starts at %.2f", ruleType, slabs[0].Min)
+	}
+
+	for i, slab := range slabs {
+		if slab.Max != nil && *slab.Max < slab.Min {
+			return fmt.Errorf("%s slab %.2f has max %.2f below its min", ruleType, slab.Min, *slab.Max)
+		}
+
+		if i == len(slabs)-1 {
+			break
+		}
+
+		next := slabs[i+1]
+		if slab.Max == nil {
+			return fmt.Errorf("%s slab starting at %.2f is open-ended but is not the top slab", ruleType, slab.Min)
+		}
+		if next.Min <= *slab.Max {
+			return fmt.Errorf("%s slabs overlap: %.2f-%.2f and slab starting at %.2f", ruleType, slab.Min, *slab.Max, next.Min)
+		}
+		if next.Min-*slab.Max > 1 {
+			return fmt.Errorf("%s slabs leave a gap between %.2f and %.2f", ruleType, *slab.Max, next.Min)
+		}
 	}
 
 	return nil
 }
 
+// RuleFamilyTypes lists every rule_type value belonging to a rule family
+func RuleFamilyTypes(ruleType string) []string {
+	if ruleFamily(ruleType) == "PT" {
+		return []string{"PT", "PT_SLAB_1", "PT_SLAB_2", "PT_SLAB_3"}
+	}
+	return []string{ruleType}
+}
+
+// RuleIDs returns the ids of every statutory_rules row the set was built from
+func (r *StatutoryRules) RuleIDs() []string {
+	var ids []string
+	for _, src := range r.Sources {
+		ids = append(ids, src.RuleIDs...)
+	}
+	return ids
+}
+
 // Helper function
 func defaultIfNil(value *float64, defaultValue float64) float64 {
 	if value == nil {
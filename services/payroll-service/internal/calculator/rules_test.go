@@ -0,0 +1,194 @@
+package calculator
+
+import (
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"payroll-service/internal/models"
+)
+
+const schemaPath = "../../../../database/payroll_schema.sql"
+
+var (
+	seedInsertPattern = regexp.MustCompile(`(?s)INSERT INTO statutory_rules \(([^)]*)\)\s*VALUES(.*?)ON CONFLICT`)
+	seedTuplePattern  = regexp.MustCompile(`\(([^()]*)\)`)
+)
+
+// seedStatutoryRules reads the statutory_rules rows seeded by the schema file
+func seedStatutoryRules(t *testing.T) []models.StatutoryRule {
+	t.Helper()
+
+	schema, err := os.ReadFile(schemaPath)
+	if err != nil {
+		t.Fatalf("failed to read schema: %v", err)
+	}
+
+	var rows []models.StatutoryRule
+	for _, insert := range seedInsertPattern.FindAllStringSubmatch(string(schema), -1) {
+		columns := strings.Split(insert[1], ",")
+		for _, tuple := range seedTuplePattern.FindAllStringSubmatch(insert[2], -1) {
+			values := strings.Split(tuple[1], ",")
+			if len(values) != len(columns) {
+				t.Fatalf("seed row %q has %d values for %d columns", tuple[1], len(values), len(columns))
+			}
+
+			row := models.StatutoryRule{ID: strconv.Itoa(len(rows) + 1)}
+			for i, column := range columns {
+				setSeedColumn(t, &row, strings.TrimSpace(column), strings.TrimSpace(values[i]))
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	if len(rows) == 0 {
+		t.Fatal("schema seeds no statutory rules")
+	}
+	return rows
+}
+
+func setSeedColumn(t *testing.T, row *models.StatutoryRule, column, value string) {
+	t.Helper()
+
+	if value == "NULL" {
+		return
+	}
+	text := strings.Trim(strings.TrimSuffix(value, "::DATE"), "'")
+
+	number := func() *float64 {
+		f, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			t.Fatalf("column %s: %v", column, err)
+		}
+		return &f
+	}
+
+	switch column {
+	case "rule_type":
+		row.RuleType = text
+	case "state_code":
+		row.StateCode = &text
+	case "effective_from":
+		row.EffectiveFrom = mustDate(t, text)
+	case "is_active":
+		row.IsActive = text == "TRUE"
+	case "pf_employee_rate":
+		row.PFEmployeeRate = number()
+	case "pf_employer_rate":
+		row.PFEmployerRate = number()
+	case "pf_ceiling":
+		row.PFCeiling = number()
+	case "esi_employee_rate":
+		row.ESIEmployeeRate = number()
+	case "esi_employer_rate":
+		row.ESIEmployerRate = number()
+	case "esi_wage_ceiling":
+		row.ESIWageCeiling = number()
+	case "esi_threshold_salary":
+		row.ESIThresholdSalary = number()
+	case "pt_slab_min":
+		row.PTSlabMin = number()
+	case "pt_slab_max":
+		row.PTSlabMax = number()
+	case "pt_amount":
+		row.PTAmount = number()
+	case "tds_slab_min":
+		row.TDSSlabMin = number()
+	case "tds_slab_max":
+		row.TDSSlabMax = number()
+	case "tds_rate":
+		row.TDSRate = number()
+	default:
+		t.Fatalf("unexpected seed column %s", column)
+	}
+}
+
+func TestResolveStatutoryRulesFromSeed(t *testing.T) {
+	rules, err := ResolveStatutoryRules(seedStatutoryRules(t))
+	if err != nil {
+		t.Fatalf("ResolveStatutoryRules() error = %v", err)
+	}
+	if err := ValidateRules(rules); err != nil {
+		t.Fatalf("ValidateRules() error = %v", err)
+	}
+
+	want := GetDefaultIndiaRules()
+	if *rules.PF != *want.PF {
+		t.Errorf("PF = %+v, want %+v", *rules.PF, *want.PF)
+	}
+	if *rules.ESI != *want.ESI {
+		t.Errorf("ESI = %+v, want %+v", *rules.ESI, *want.ESI)
+	}
+	if rules.PT == nil || len(rules.PT.Slabs) != len(want.PT.Slabs) {
+		t.Errorf("PT = %+v, want %d slabs", rules.PT, len(want.PT.Slabs))
+	}
+	if rules.TDS == nil || len(rules.TDS.Slabs) != len(want.TDS.Slabs) {
+		t.Errorf("TDS = %+v, want %d slabs", rules.TDS, len(want.TDS.Slabs))
+	}
+
+	for _, ruleType := range RequiredRuleTypes {
+		if rules.SourceFor(ruleType) == nil {
+			t.Errorf("no source resolved for required rule %s", ruleType)
+		}
+	}
+}
+
+func TestBuildStatutoryRulesFromDB(t *testing.T) {
+	from := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		rule    models.StatutoryRule
+		wantPF  *PFRules
+		wantESI *ESIRules
+		wantErr bool
+	}{
+		{
+			name:   "PF without a ceiling uses the statutory wage limit",
+			rule:   models.StatutoryRule{RuleType: "PF", EffectiveFrom: from, PFEmployeeRate: floatPtr(12), PFEmployerRate: floatPtr(12)},
+			wantPF: &PFRules{EmployeeRate: 12, EmployerRate: 12, Ceiling: 15000},
+		},
+		{
+			name:   "PF with its own ceiling",
+			rule:   models.StatutoryRule{RuleType: "PF", EffectiveFrom: from, PFEmployeeRate: floatPtr(12), PFEmployerRate: floatPtr(12), PFCeiling: floatPtr(25000)},
+			wantPF: &PFRules{EmployeeRate: 12, EmployerRate: 12, Ceiling: 25000},
+		},
+		{
+			name:    "ESI without a ceiling uses the statutory wage limit",
+			rule:    models.StatutoryRule{RuleType: "ESI", EffectiveFrom: from, ESIEmployeeRate: floatPtr(0.75), ESIEmployerRate: floatPtr(3.25)},
+			wantESI: &ESIRules{EmployeeRate: 0.75, EmployerRate: 3.25, WageCeiling: 21000},
+		},
+		{
+			name:    "PF without rates",
+			rule:    models.StatutoryRule{RuleType: "PF", EffectiveFrom: from, PFCeiling: floatPtr(15000)},
+			wantErr: true,
+		},
+		{
+			name:    "ESI with its rates in the PF columns",
+			rule:    models.StatutoryRule{RuleType: "ESI", EffectiveFrom: from, PFEmployeeRate: floatPtr(0.75), PFEmployerRate: floatPtr(3.25), PFCeiling: floatPtr(21000)},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rules, err := BuildStatutoryRulesFromDB([]models.StatutoryRule{tt.rule})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildStatutoryRulesFromDB() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if tt.wantPF != nil && (rules.PF == nil || *rules.PF != *tt.wantPF) {
+				t.Errorf("PF = %+v, want %+v", rules.PF, *tt.wantPF)
+			}
+			if tt.wantESI != nil && (rules.ESI == nil || *rules.ESI != *tt.wantESI) {
+				t.Errorf("ESI = %+v, want %+v", rules.ESI, *tt.wantESI)
+			}
+		})
+	}
+}
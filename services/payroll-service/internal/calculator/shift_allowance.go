@@ -0,0 +1,135 @@
+package calculator
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// NightShiftCutoff is the time of day before which a shift that starts after
+// midnight counts as a night shift. Shifts that cross midnight always do.
+const NightShiftCutoff = "06:00"
+
+// RosterShift is one rostered shift an employee worked in the pay period
+type RosterShift struct {
+	Date      time.Time `json:"date"`
+	ShiftType string    `json:"shift_type"`
+	StartTime string    `json:"start_time"` // HH:MM
+	EndTime   string    `json:"end_time"`   // HH:MM
+}
+
+// ShiftAllowancePolicy is the allowance paid for shifts of one shift type
+type ShiftAllowancePolicy struct {
+	ShiftType      string
+	AmountPerShift float64
+	NightPremium   float64 // Added for each night shift
+	MinShifts      int     // Nothing is paid below this many shifts in the period
+}
+
+// SetShiftAllowancePolicies sets the shift allowance policies. Shifts of a
+// type without a policy earn no allowance.
+func (pc *PayrollCalculator) SetShiftAllowancePolicies(policies []ShiftAllowancePolicy) {
+	pc.shiftPolicies = map[string]ShiftAllowancePolicy{}
+	for _, p := range policies {
+		pc.shiftPolicies[p.ShiftType] = p
+	}
+}
+
+// ParseShiftTime parses an HH:MM (or HH:MM:SS) shift time
+func ParseShiftTime(value string) (time.Time, error) {
+	if t, err := time.Parse("15:04", value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse("15:04:05", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid shift time %q (use HH:MM)", value)
+	}
+	return t, nil
+}
+
+// IsNightShift reports whether a shift crosses midnight or starts after
+// midnight but before NightShiftCutoff
+func IsNightShift(startTime, endTime string) (bool, error) {
+	start, err := ParseShiftTime(startTime)
+	if err != nil {
+		return false, err
+	}
+
+	end, err := ParseShiftTime(endTime)
+	if err != nil {
+		return false, err
+	}
+
+	cutoff, _ := ParseShiftTime(NightShiftCutoff)
+	return !end.After(start) || start.Before(cutoff), nil
+}
+
+// calculateShiftAllowance turns the period's roster into allowance earnings,
+// one calculation step per shift. Shift allowance is not pro-rated and is
+// outside the PF wage.
+func (pc *PayrollCalculator) calculateShiftAllowance(result *CalculationResult, input *PayrollInput) {
+	if len(input.Shifts) == 0 || len(pc.shiftPolicies) == 0 {
+		return
+	}
+
+	shifts := make([]RosterShift, len(input.Shifts))
+	copy(shifts, input.Shifts)
+	sort.SliceStable(shifts, func(i, j int) bool { return shifts[i].Date.Before(shifts[j].Date) })
+
+	counts := map[string]int{}
+	for _, shift := range shifts {
+		counts[shift.ShiftType]++
+	}
+
+	belowMinimum := map[string]bool{}
+	for shiftType, count := range counts {
+		policy, ok := pc.shiftPolicies[shiftType]
+		if ok && count < policy.MinShifts {
+			belowMinimum[shiftType] = true
+		}
+	}
+
+	var total float64
+	for _, shift := range shifts {
+		policy, ok := pc.shiftPolicies[shift.ShiftType]
+		if !ok || belowMinimum[shift.ShiftType] {
+			continue
+		}
+
+		amount := policy.AmountPerShift
+		rule := fmt.Sprintf("%s %s-%s: %.2f per shift", shift.ShiftType, shift.StartTime, shift.EndTime, policy.AmountPerShift)
+
+		night, err := IsNightShift(shift.StartTime, shift.EndTime)
+		if err == nil && night && policy.NightPremium > 0 {
+			amount += policy.NightPremium
+			rule += fmt.Sprintf(" + night premium %.2f", policy.NightPremium)
+		}
+
+		amount = round(amount, 2)
+		total += amount
+
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "shift",
+			Description: fmt.Sprintf("Shift Allowance (%s, %s)", shift.Date.Format("2006-01-02"), shift.ShiftType),
+			Amount:      amount,
+			Rule:        rule,
+		})
+	}
+
+	shiftTypes := make([]string, 0, len(belowMinimum))
+	for shiftType := range belowMinimum {
+		shiftTypes = append(shiftTypes, shiftType)
+	}
+	sort.Strings(shiftTypes)
+
+	for _, shiftType := range shiftTypes {
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "shift",
+			Description: fmt.Sprintf("Shift Allowance (%s)", shiftType),
+			Amount:      0,
+			Rule:        fmt.Sprintf("%d shifts, below the minimum of %d", counts[shiftType], pc.shiftPolicies[shiftType].MinShifts),
+		})
+	}
+
+	result.ShiftAllowance = round(total, 2)
+}
@@ -0,0 +1,111 @@
+package calculator
+
+import (
+	"fmt"
+	"math"
+)
+
+// TaxYearToDate carries the financial year's income and tax outside this
+// month, so TDS can be spread over the rest of the year
+type TaxYearToDate struct {
+	PreviousEmployerIncome float64 `json:"previous_employer_income"` // Taxable salary from earlier employers this year (Form 12B)
+	PreviousEmployerTDS    float64 `json:"previous_employer_tds"`
+	TaxableIncome          float64 `json:"taxable_income"` // Earlier months with this employer
+	TDS                    float64 `json:"tds"`
+	RemainingMonths        int     `json:"remaining_months"` // This month through March; 1 on final settlement
+}
+
+// calculateProjectedTax projects the year's taxable income from earlier
+// employers, earlier months and this month's regular income repeated for the
+// rest of the year, and spreads the tax not yet deducted over the remaining
+// months. One-off income in oneOff is counted once.
+func (pc *PayrollCalculator) calculateProjectedTax(result *CalculationResult, input *PayrollInput, monthTaxableIncome, oneOff float64) {
+	ytd := input.TaxYear
+	remaining := ytd.RemainingMonths
+	if remaining < 1 {
+		remaining = 1
+	}
+
+	regular := monthTaxableIncome - oneOff
+	annualIncome := round(ytd.PreviousEmployerIncome+ytd.TaxableIncome+regular*float64(remaining)+oneOff, 2)
+
+	// Without an annual table for the regime, the monthly slabs place the
+	// annual income by its monthly average
+	table := pc.incomeTax[taxRegime(input.TaxRegime)]
+	var slab *TDSSlab
+	if table == nil {
+		if slab = pc.monthlySlab(annualIncome / 12); slab == nil {
+			return
+		}
+	}
+
+	result.Calculations = append(result.Calculations, CalculationStep{
+		Category:    "tds",
+		Description: "Projected Annual Taxable Income",
+		Amount:      annualIncome,
+		Rule: fmt.Sprintf("Previous employers (%.2f) + earlier months (%.2f) + %.2f × %d months + one-off (%.2f)",
+			ytd.PreviousEmployerIncome, ytd.TaxableIncome, regular, remaining, oneOff),
+	})
+
+	deducted := ytd.PreviousEmployerTDS + ytd.TDS
+	var annualTax float64
+	var description, rule string
+	if table != nil {
+		tax := table.Tax(annualIncome, input.Age)
+		annualTax = tax.Total
+		taxRule := table.annualTaxRule(tax, input.Age)
+
+		// Section 206AA: no less than 20% of the income without a valid PAN
+		if input.NoValidPAN {
+			if minimum := round(annualIncome*NoPANTDSRate/100, 2); minimum > annualTax {
+				annualTax = minimum
+				taxRule += fmt.Sprintf("; no valid PAN, %.0f%% of income (Section 206AA) = %.2f", NoPANTDSRate, minimum)
+			}
+		}
+
+		result.Calculations = append(result.Calculations, CalculationStep{
+			Category:    "tds",
+			Description: "Annual Income Tax",
+			Amount:      annualTax,
+			Rule:        taxRule,
+		})
+
+		description = "TDS"
+		rule = fmt.Sprintf("(%.2f - deducted (%.2f)) / %d months", annualTax, deducted, remaining)
+	} else {
+		rate := tdsRate(*slab, input)
+		annualTax = round(annualIncome*rate/100, 2)
+
+		description = fmt.Sprintf("TDS (%.0f - %.0f)", slab.Min, getMaxOrInfinity(slab.Max))
+		rule = fmt.Sprintf("(%.2f × %.2f%% = %.2f - deducted (%.2f)) / %d months",
+			annualIncome, rate, annualTax, deducted, remaining)
+		if rate != slab.Rate {
+			rule += " (no valid PAN, Section 206AA)"
+		}
+	}
+
+	monthTDS := round(math.Max(annualTax-deducted, 0)/float64(remaining), 2)
+
+	mtd := input.MonthToDate.orZero()
+	result.TDS = round(math.Max(monthTDS-mtd.TDS, 0), 2)
+	if mtd.Periods > 0 {
+		rule += fmt.Sprintf(" - deducted earlier this month (%.2f)", mtd.TDS)
+	}
+
+	result.Calculations = append(result.Calculations, CalculationStep{
+		Category:    "tds",
+		Description: description,
+		Amount:      result.TDS,
+		Rule:        rule,
+	})
+}
+
+// monthlySlab finds the monthly TDS slab an income falls in
+func (pc *PayrollCalculator) monthlySlab(income float64) *TDSSlab {
+	for i, slab := range pc.rules.TDS.Slabs {
+		if income >= slab.Min && (slab.Max == nil || income <= *slab.Max) {
+			return &pc.rules.TDS.Slabs[i]
+		}
+	}
+	return nil
+}
@@ -139,13 +139,13 @@ func (v *PayrollValidator) validateDays(component *models.PayrollComponent, erro
 		})
 	}
 
-	totalDaysDeducted := component.DaysAbsent + component.DaysLeave
-	if totalDaysDeducted+component.DaysWorked > component.DaysInMonth {
+	// Paid leave is paid; only loss of pay days are taken off the month
+	if component.LOPDays+component.DaysWorked > component.DaysInMonth {
 		*errors = append(*errors, ValidationError{
 			Code:       "DAYS_TOTAL_MISMATCH",
 			Severity:   "error",
 			Category:   "attendance",
-			Message:    fmt.Sprintf("Total days (worked: %d, absent: %d, leave: %d) do not match days in month (%d)", component.DaysWorked, component.DaysAbsent, component.DaysLeave, component.DaysInMonth),
+			Message:    fmt.Sprintf("Total days (paid: %d, loss of pay: %d) exceed days in month (%d)", component.DaysWorked, component.LOPDays, component.DaysInMonth),
 			EmployeeID: component.EmployeeID,
 		})
 	}
@@ -203,6 +203,18 @@ func (v *PayrollValidator) validateDeductions(component *models.PayrollComponent
 			EmployeeID: component.EmployeeID,
 		})
 	}
+
+	// Deductions above the wage limit are deferred, not lost (info)
+	if component.DeductionShortfall > 0 {
+		*errors = append(*errors, ValidationError{
+			Code:       "DEDUCTIONS_DEFERRED",
+			Severity:   "info",
+			Category:   "deductions",
+			Message:    fmt.Sprintf("Deductions of %.2f exceed the %.0f%% wage limit and are deferred to the next run", component.DeductionShortfall, MaxDeductionPercent),
+			Amount:     &component.DeductionShortfall,
+			EmployeeID: component.EmployeeID,
+		})
+	}
 }
 
 // validateEmployeeEligibility checks employee-specific eligibility rules
@@ -239,13 +251,29 @@ func (v *PayrollValidator) validateEmployeeEligibility(
 		})
 	}
 
-	// Check for missing PAN
+	// Check for missing or unusable PAN; TDS is deducted at 20% or more (Section 206AA)
 	if employee.PersonalPAN.Valid == false {
 		*errors = append(*errors, ValidationError{
 			Code:       "MISSING_PAN",
 			Severity:   "warning",
 			Category:   "employee",
-			Message:    "Employee does not have PAN - TDS calculation accuracy affected",
+			Message:    "Employee does not have PAN - TDS deducted at no less than 20% under Section 206AA",
+			EmployeeID: component.EmployeeID,
+		})
+	} else if !IsValidPANFormat(employee.PersonalPAN.String) {
+		*errors = append(*errors, ValidationError{
+			Code:       "INVALID_PAN",
+			Severity:   "warning",
+			Category:   "employee",
+			Message:    fmt.Sprintf("Employee PAN %q is not in the format AAAAA9999A - TDS deducted at no less than 20%% under Section 206AA", employee.PersonalPAN.String),
+			EmployeeID: component.EmployeeID,
+		})
+	} else if !HasValidPAN(employee) {
+		*errors = append(*errors, ValidationError{
+			Code:       "PAN_NOT_OPERATIVE",
+			Severity:   "warning",
+			Category:   "employee",
+			Message:    fmt.Sprintf("Employee PAN status is %s - TDS deducted at no less than 20%% under Section 206AA", employee.PANStatus),
 			EmployeeID: component.EmployeeID,
 		})
 	}
@@ -0,0 +1,216 @@
+package calculator
+
+import (
+	"fmt"
+	"time"
+)
+
+// Weekly-off patterns
+const (
+	WeeklyOffSundayOnly         = "sunday_only"
+	WeeklyOffAlternateSaturdays = "alternate_saturdays" // Sundays and the listed Saturdays of the month
+	WeeklyOffRotating           = "rotating"            // Fixed cycle of working and off days from an anchor date
+)
+
+// Holiday types. Restricted holidays are optional and stay working days
+// unless an employee takes one.
+const (
+	HolidayMandatory  = "mandatory"
+	HolidayRestricted = "restricted"
+)
+
+// Day types of a working calendar
+const (
+	DayWorking   = "working"
+	DayWeeklyOff = "weekly_off"
+	DayHoliday   = "holiday"
+)
+
+// WeeklyOffPolicy describes which days of the week are off at a location
+type WeeklyOffPolicy struct {
+	Pattern         string     `json:"pattern"`
+	SaturdayWeeks   []int      `json:"saturday_weeks,omitempty"`    // alternate_saturdays: e.g. 2, 4 for the 2nd and 4th Saturday
+	RotationAnchor  *time.Time `json:"rotation_anchor,omitempty"`   // rotating: first day of a cycle
+	RotationCycle   int        `json:"rotation_cycle,omitempty"`    // rotating: days in a cycle
+	RotationOffDays []int      `json:"rotation_off_days,omitempty"` // rotating: 0-based days of the cycle that are off
+}
+
+// Validate checks the pattern and its parameters
+func (p WeeklyOffPolicy) Validate() error {
+	switch p.Pattern {
+	case WeeklyOffSundayOnly:
+		return nil
+
+	case WeeklyOffAlternateSaturdays:
+		if len(p.SaturdayWeeks) == 0 {
+			return fmt.Errorf("saturday_weeks is required for alternate_saturdays")
+		}
+		for _, week := range p.SaturdayWeeks {
+			if week < 1 || week > 5 {
+				return fmt.Errorf("saturday_weeks must be between 1 and 5")
+			}
+		}
+		return nil
+
+	case WeeklyOffRotating:
+		if p.RotationAnchor == nil {
+			return fmt.Errorf("rotation_anchor is required for rotating")
+		}
+		if p.RotationCycle < 2 || p.RotationCycle > 28 {
+			return fmt.Errorf("rotation_cycle must be between 2 and 28 days")
+		}
+		if len(p.RotationOffDays) == 0 || len(p.RotationOffDays) >= p.RotationCycle {
+			return fmt.Errorf("rotation_off_days must list at least one and fewer than rotation_cycle days")
+		}
+		for _, day := range p.RotationOffDays {
+			if day < 0 || day >= p.RotationCycle {
+				return fmt.Errorf("rotation_off_days must be between 0 and %d", p.RotationCycle-1)
+			}
+		}
+		return nil
+
+	default:
+		return fmt.Errorf("pattern must be one of sunday_only, alternate_saturdays, rotating")
+	}
+}
+
+// IsWeeklyOff reports whether a date is a weekly off
+func (p WeeklyOffPolicy) IsWeeklyOff(date time.Time) bool {
+	switch p.Pattern {
+	case WeeklyOffAlternateSaturdays:
+		if date.Weekday() == time.Sunday {
+			return true
+		}
+		if date.Weekday() == time.Saturday {
+			week := (date.Day()-1)/7 + 1
+			for _, w := range p.SaturdayWeeks {
+				if w == week {
+					return true
+				}
+			}
+		}
+		return false
+
+	case WeeklyOffRotating:
+		if p.RotationAnchor == nil || p.RotationCycle <= 0 {
+			return false
+		}
+		offset := daysBetween(*p.RotationAnchor, date) % p.RotationCycle
+		if offset < 0 {
+			offset += p.RotationCycle
+		}
+		for _, day := range p.RotationOffDays {
+			if day == offset {
+				return true
+			}
+		}
+		return false
+
+	default:
+		return date.Weekday() == time.Sunday
+	}
+}
+
+// Holiday is one day of a holiday calendar
+type Holiday struct {
+	Date time.Time `json:"date"`
+	Name string    `json:"name"`
+	Type string    `json:"type"` // mandatory, restricted
+}
+
+// CalendarDay is one classified day of a working calendar
+type CalendarDay struct {
+	Date       time.Time `json:"date"`
+	Type       string    `json:"type"` // working, weekly_off, holiday
+	Name       string    `json:"name,omitempty"`
+	Restricted bool      `json:"restricted,omitempty"` // A working day with an optional restricted holiday
+}
+
+// WorkingDaysSummary counts the days of a period by type
+type WorkingDaysSummary struct {
+	From               time.Time     `json:"from"`
+	To                 time.Time     `json:"to"`
+	CalendarDays       int           `json:"calendar_days"`
+	WeeklyOffs         int           `json:"weekly_offs"`
+	Holidays           int           `json:"holidays"`            // Mandatory holidays not on a weekly off
+	RestrictedHolidays int           `json:"restricted_holidays"` // Optional; included in WorkingDays
+	WorkingDays        int           `json:"working_days"`
+	Days               []CalendarDay `json:"days"`
+}
+
+// WorkingCalendar combines a location's weekly offs and holidays
+type WorkingCalendar struct {
+	weeklyOff WeeklyOffPolicy
+	holidays  map[string]Holiday
+}
+
+// NewWorkingCalendar creates a working calendar
+func NewWorkingCalendar(weeklyOff WeeklyOffPolicy, holidays []Holiday) *WorkingCalendar {
+	wc := &WorkingCalendar{
+		weeklyOff: weeklyOff,
+		holidays:  map[string]Holiday{},
+	}
+	for _, h := range holidays {
+		wc.holidays[h.Date.Format("2006-01-02")] = h
+	}
+	return wc
+}
+
+// Day classifies a date. A weekly off takes precedence over a holiday on the same day.
+func (wc *WorkingCalendar) Day(date time.Time) CalendarDay {
+	day := CalendarDay{Date: date, Type: DayWorking}
+
+	if wc.weeklyOff.IsWeeklyOff(date) {
+		day.Type = DayWeeklyOff
+		return day
+	}
+
+	if h, ok := wc.holidays[date.Format("2006-01-02")]; ok {
+		day.Name = h.Name
+		if h.Type == HolidayRestricted {
+			day.Restricted = true
+		} else {
+			day.Type = DayHoliday
+		}
+	}
+
+	return day
+}
+
+// IsWorkingDay reports whether a date is neither a weekly off nor a mandatory holiday
+func (wc *WorkingCalendar) IsWorkingDay(date time.Time) bool {
+	return wc.Day(date).Type == DayWorking
+}
+
+// WorkingDays classifies and counts every day from from to to, inclusive
+func (wc *WorkingCalendar) WorkingDays(from, to time.Time) WorkingDaysSummary {
+	summary := WorkingDaysSummary{From: from, To: to}
+
+	for date := from; !date.After(to); date = date.AddDate(0, 0, 1) {
+		day := wc.Day(date)
+		summary.CalendarDays++
+
+		switch day.Type {
+		case DayWeeklyOff:
+			summary.WeeklyOffs++
+		case DayHoliday:
+			summary.Holidays++
+		default:
+			summary.WorkingDays++
+			if day.Restricted {
+				summary.RestrictedHolidays++
+			}
+		}
+
+		summary.Days = append(summary.Days, day)
+	}
+
+	return summary
+}
+
+// daysBetween returns the whole days from a to b, ignoring time of day
+func daysBetween(a, b time.Time) int {
+	a = time.Date(a.Year(), a.Month(), a.Day(), 0, 0, 0, 0, time.UTC)
+	b = time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC)
+	return int(b.Sub(a).Hours() / 24)
+}
@@ -0,0 +1,120 @@
+package calculator
+
+import (
+	"testing"
+	"time"
+)
+
+// fourOnTwoOff is a six-day rotation starting 1 January 2024 whose last two
+// days are off
+func fourOnTwoOff(t *testing.T) WeeklyOffPolicy {
+	anchor := mustDate(t, "2024-01-01")
+	return WeeklyOffPolicy{
+		Pattern:         WeeklyOffRotating,
+		RotationAnchor:  &anchor,
+		RotationCycle:   6,
+		RotationOffDays: []int{4, 5},
+	}
+}
+
+func TestWeeklyOffPolicyIsWeeklyOff(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy func(t *testing.T) WeeklyOffPolicy
+		date   string
+		want   bool
+	}{
+		{name: "rotating anchor day works", policy: fourOnTwoOff, date: "2024-01-01", want: false},
+		{name: "rotating fifth day is off", policy: fourOnTwoOff, date: "2024-01-05", want: true},
+		{name: "rotating sixth day is off", policy: fourOnTwoOff, date: "2024-01-06", want: true},
+		{name: "rotating next cycle starts working", policy: fourOnTwoOff, date: "2024-01-07", want: false},
+		{name: "rotating repeats in later cycles", policy: fourOnTwoOff, date: "2024-03-05", want: true},
+		{name: "rotating Sunday can be a working day", policy: fourOnTwoOff, date: "2024-01-14", want: false},
+		{name: "rotating day before the anchor", policy: fourOnTwoOff, date: "2023-12-31", want: true},
+		{name: "rotating two days before the anchor", policy: fourOnTwoOff, date: "2023-12-30", want: true},
+		{name: "rotating three days before the anchor", policy: fourOnTwoOff, date: "2023-12-29", want: false},
+		{name: "rotating ignores time of day", policy: func(t *testing.T) WeeklyOffPolicy {
+			p := fourOnTwoOff(t)
+			anchor := p.RotationAnchor.Add(15 * time.Hour)
+			p.RotationAnchor = &anchor
+			return p
+		}, date: "2024-01-05", want: true},
+		{name: "rotating without an anchor is never off", policy: func(t *testing.T) WeeklyOffPolicy {
+			return WeeklyOffPolicy{Pattern: WeeklyOffRotating, RotationCycle: 6, RotationOffDays: []int{4, 5}}
+		}, date: "2024-01-05", want: false},
+		{name: "second Saturday", policy: alternateSaturdays, date: "2024-01-13", want: true},
+		{name: "third Saturday", policy: alternateSaturdays, date: "2024-01-20", want: false},
+		{name: "Sunday with alternate Saturdays", policy: alternateSaturdays, date: "2024-01-21", want: true},
+		{name: "Sunday only", policy: sundayOnly, date: "2024-01-07", want: true},
+		{name: "Saturday with Sunday only", policy: sundayOnly, date: "2024-01-13", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy(t).IsWeeklyOff(mustDate(t, tt.date)); got != tt.want {
+				t.Errorf("IsWeeklyOff(%s) = %v, want %v", tt.date, got, tt.want)
+			}
+		})
+	}
+}
+
+func alternateSaturdays(t *testing.T) WeeklyOffPolicy {
+	return WeeklyOffPolicy{Pattern: WeeklyOffAlternateSaturdays, SaturdayWeeks: []int{2, 4}}
+}
+
+func sundayOnly(t *testing.T) WeeklyOffPolicy {
+	return WeeklyOffPolicy{Pattern: WeeklyOffSundayOnly}
+}
+
+func TestWeeklyOffPolicyValidateRotating(t *testing.T) {
+	anchor := mustDate(t, "2024-01-01")
+
+	tests := []struct {
+		name    string
+		policy  WeeklyOffPolicy
+		wantErr bool
+	}{
+		{name: "valid", policy: fourOnTwoOff(t)},
+		{name: "missing anchor", policy: WeeklyOffPolicy{Pattern: WeeklyOffRotating, RotationCycle: 6, RotationOffDays: []int{4}}, wantErr: true},
+		{name: "cycle too short", policy: WeeklyOffPolicy{Pattern: WeeklyOffRotating, RotationAnchor: &anchor, RotationCycle: 1, RotationOffDays: []int{0}}, wantErr: true},
+		{name: "cycle too long", policy: WeeklyOffPolicy{Pattern: WeeklyOffRotating, RotationAnchor: &anchor, RotationCycle: 29, RotationOffDays: []int{0}}, wantErr: true},
+		{name: "no off days", policy: WeeklyOffPolicy{Pattern: WeeklyOffRotating, RotationAnchor: &anchor, RotationCycle: 6}, wantErr: true},
+		{name: "every day off", policy: WeeklyOffPolicy{Pattern: WeeklyOffRotating, RotationAnchor: &anchor, RotationCycle: 2, RotationOffDays: []int{0, 1}}, wantErr: true},
+		{name: "off day outside the cycle", policy: WeeklyOffPolicy{Pattern: WeeklyOffRotating, RotationAnchor: &anchor, RotationCycle: 6, RotationOffDays: []int{6}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.policy.Validate(); (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWorkingCalendarWorkingDaysRotating(t *testing.T) {
+	holidays := []Holiday{
+		{Date: mustDate(t, "2024-01-03"), Name: "Founders Day", Type: HolidayMandatory},
+		{Date: mustDate(t, "2024-01-05"), Name: "Falls on a weekly off", Type: HolidayMandatory},
+		{Date: mustDate(t, "2024-01-08"), Name: "Optional festival", Type: HolidayRestricted},
+	}
+	wc := NewWorkingCalendar(fourOnTwoOff(t), holidays)
+
+	got := wc.WorkingDays(mustDate(t, "2024-01-01"), mustDate(t, "2024-01-12"))
+
+	// Off on the 5th, 6th, 11th and 12th; the holiday on the 5th counts as a weekly off
+	want := WorkingDaysSummary{CalendarDays: 12, WeeklyOffs: 4, Holidays: 1, RestrictedHolidays: 1, WorkingDays: 7}
+	if got.CalendarDays != want.CalendarDays || got.WeeklyOffs != want.WeeklyOffs || got.Holidays != want.Holidays ||
+		got.RestrictedHolidays != want.RestrictedHolidays || got.WorkingDays != want.WorkingDays {
+		t.Errorf("WorkingDays() = %d calendar, %d weekly offs, %d holidays, %d restricted, %d working; want %d, %d, %d, %d, %d",
+			got.CalendarDays, got.WeeklyOffs, got.Holidays, got.RestrictedHolidays, got.WorkingDays,
+			want.CalendarDays, want.WeeklyOffs, want.Holidays, want.RestrictedHolidays, want.WorkingDays)
+	}
+
+	if day := wc.Day(mustDate(t, "2024-01-05")); day.Type != DayWeeklyOff {
+		t.Errorf("Day(2024-01-05).Type = %s, want %s", day.Type, DayWeeklyOff)
+	}
+	if day := wc.Day(mustDate(t, "2024-01-08")); day.Type != DayWorking || !day.Restricted {
+		t.Errorf("Day(2024-01-08) = %s (restricted %v), want a working day with a restricted holiday", day.Type, day.Restricted)
+	}
+}
@@ -16,7 +16,6 @@ func NewConnection() (*sql.DB, error) {
 	// We can use the SUPABASE_URL and construct the connection string
 
 	supabaseURL := os.Getenv("NEXT_PUBLIC_SUPABASE_URL")
-	supabaseAnonKey := os.Getenv("NEXT_PUBLIC_SUPABASE_ANON_KEY")
 
 	// For direct database access, use service role key (more powerful than anon key)
 	dbUser := os.Getenv("DATABASE_USER")
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type EmployeeDeductionHandler struct {
+	service *service.EmployeeDeductionService
+}
+
+func NewEmployeeDeductionHandler(service *service.EmployeeDeductionService) *EmployeeDeductionHandler {
+	return &EmployeeDeductionHandler{service: service}
+}
+
+// RegisterEmployeeDeductionRoutes registers loan, advance and other deduction
+// schedule routes
+func RegisterEmployeeDeductionRoutes(router *gin.RouterGroup, service *service.EmployeeDeductionService) {
+	handler := NewEmployeeDeductionHandler(service)
+
+	deductions := router.Group("/payroll/employee-deductions")
+	{
+		deductions.GET("", handler.GetEmployeeDeductions)
+		deductions.POST("", handler.CreateEmployeeDeduction)
+		deductions.POST("/:id/cancel", handler.CancelEmployeeDeduction)
+	}
+}
+
+// GetEmployeeDeductions lists an organization's deduction schedules
+// @Summary Get employee deductions
+// @Param org_id query string true "Organization ID"
+// @Param employee_id query string false "Employee ID"
+// @Param category query string false "Category (court_order, loan, advance, other)"
+// @Param status query string false "Status (active, cancelled)"
+func (h *EmployeeDeductionHandler) GetEmployeeDeductions(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		filters["employee_id"] = employeeID
+	}
+	if category := c.Query("category"); category != "" {
+		filters["category"] = category
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	deductions, err := h.service.GetEmployeeDeductions(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(deductions),
+		"data":  deductions,
+	})
+}
+
+// CreateEmployeeDeduction schedules a loan, advance, court order or other
+// deduction for recovery in payroll
+// @Summary Create employee deduction
+func (h *EmployeeDeductionHandler) CreateEmployeeDeduction(c *gin.Context) {
+	var req struct {
+		OrgID       string   `json:"org_id" binding:"required"`
+		EmployeeID  string   `json:"employee_id" binding:"required"`
+		Category    string   `json:"category" binding:"required"`
+		Description string   `json:"description" binding:"required"`
+		Instalment  float64  `json:"instalment" binding:"required"`
+		TotalAmount *float64 `json:"total_amount"`                   // Omit to deduct until cancelled
+		StartMonth  string   `json:"start_month" binding:"required"` // YYYY-MM
+		CreatedBy   string   `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	d := &models.EmployeeDeduction{
+		OrgID:       req.OrgID,
+		EmployeeID:  req.EmployeeID,
+		Category:    req.Category,
+		Description: req.Description,
+		Instalment:  req.Instalment,
+		TotalAmount: req.TotalAmount,
+		StartMonth:  req.StartMonth,
+		CreatedBy:   &req.CreatedBy,
+	}
+
+	if err := h.service.CreateEmployeeDeduction(d); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, d)
+}
+
+// CancelEmployeeDeduction stops an active deduction schedule
+// @Summary Cancel employee deduction
+func (h *EmployeeDeductionHandler) CancelEmployeeDeduction(c *gin.Context) {
+	if err := h.service.CancelEmployeeDeduction(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Employee deduction cancelled"})
+}
@@ -24,6 +24,7 @@ func RegisterEmployeeRoutes(router *gin.RouterGroup, service *service.EmployeeSe
 		employees.GET("", handler.GetEmployees)
 		employees.GET("/:id", handler.GetEmployeeByID)
 		employees.GET("/:id/salary-structure", handler.GetSalaryStructure)
+		employees.PUT("/:id/pan", handler.UpdatePAN)
 		employees.GET("/:id/attendance/:month", handler.GetAttendanceSummary)
 		employees.GET("/:id/leave/:month", handler.GetLeaveSummary)
 	}
@@ -34,6 +35,7 @@ func RegisterEmployeeRoutes(router *gin.RouterGroup, service *service.EmployeeSe
 // @Param org_id query string true "Organization ID"
 // @Param employment_status query string false "Employment status"
 // @Param department query string false "Department"
+// @Param pay_group_id query string false "Pay group ID"
 func (h *EmployeeHandler) GetEmployees(c *gin.Context) {
 	orgID := c.Query("org_id")
 	if orgID == "" {
@@ -48,6 +50,9 @@ func (h *EmployeeHandler) GetEmployees(c *gin.Context) {
 	if dept := c.Query("department"); dept != "" {
 		filters["department"] = dept
 	}
+	if payGroupID := c.Query("pay_group_id"); payGroupID != "" {
+		filters["pay_group_id"] = payGroupID
+	}
 
 	employees, err := h.service.GetEmployees(orgID, filters)
 	if err != nil {
@@ -87,6 +92,28 @@ func (h *EmployeeHandler) GetSalaryStructure(c *gin.Context) {
 	c.JSON(http.StatusOK, ss)
 }
 
+// UpdatePAN records an employee's PAN and its verification status
+// @Summary Update employee PAN
+func (h *EmployeeHandler) UpdatePAN(c *gin.Context) {
+	var req struct {
+		PAN       string `json:"pan" binding:"required"`
+		PANStatus string `json:"pan_status"` // pending, verified, name_mismatch, inoperative
+		UpdatedBy string `json:"updated_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.UpdatePAN(c.Param("id"), req.PAN, req.PANStatus, req.UpdatedBy); err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "PAN updated"})
+}
+
 // GetAttendanceSummary gets attendance for a month
 // @Summary Get attendance summary
 // @Param month query string true "Month (YYYY-MM)"
@@ -0,0 +1,185 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type EquityHandler struct {
+	service *service.EquityService
+}
+
+func NewEquityHandler(service *service.EquityService) *EquityHandler {
+	return &EquityHandler{service: service}
+}
+
+// RegisterEquityRoutes registers ESOP and RSU grant and event routes
+func RegisterEquityRoutes(router *gin.RouterGroup, service *service.EquityService) {
+	handler := NewEquityHandler(service)
+
+	router.GET("/equity/grants", handler.GetEquityGrants)
+	router.POST("/equity/grants", handler.CreateEquityGrant)
+	router.GET("/equity/events", handler.GetEquityEvents)
+	router.POST("/equity/events", handler.RecordEquityEvent)
+}
+
+// GetEquityGrants lists an organization's ESOP and RSU grants
+// @Summary Get equity grants
+// @Param org_id query string true "Organization ID"
+// @Param employee_id query string false "Employee ID"
+// @Param status query string false "active, cancelled"
+func (h *EquityHandler) GetEquityGrants(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		filters["employee_id"] = employeeID
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	grants, err := h.service.GetEquityGrants(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(grants),
+		"data":  grants,
+	})
+}
+
+// CreateEquityGrant records an ESOP or RSU grant
+// @Summary Create equity grant
+func (h *EquityHandler) CreateEquityGrant(c *gin.Context) {
+	var req struct {
+		OrgID         string  `json:"org_id" binding:"required"`
+		EmployeeID    string  `json:"employee_id" binding:"required"`
+		GrantNumber   string  `json:"grant_number" binding:"required"`
+		GrantType     string  `json:"grant_type" binding:"required"` // esop, rsu
+		PlanName      string  `json:"plan_name"`
+		GrantDate     string  `json:"grant_date" binding:"required"` // YYYY-MM-DD
+		Units         float64 `json:"units" binding:"required"`
+		ExercisePrice float64 `json:"exercise_price"` // Per unit
+		CreatedBy     string  `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	grantDate, err := time.Parse("2006-01-02", req.GrantDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid grant_date format (use YYYY-MM-DD)"})
+		return
+	}
+
+	grant := &models.EquityGrant{
+		OrgID:         req.OrgID,
+		EmployeeID:    req.EmployeeID,
+		GrantNumber:   req.GrantNumber,
+		GrantType:     req.GrantType,
+		PlanName:      sql.NullString{String: req.PlanName, Valid: req.PlanName != ""},
+		GrantDate:     grantDate,
+		Units:         req.Units,
+		ExercisePrice: req.ExercisePrice,
+		CreatedBy:     &req.CreatedBy,
+	}
+
+	if err := h.service.CreateEquityGrant(grant); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, grant)
+}
+
+// GetEquityEvents lists an organization's vestings, exercises and sales
+// @Summary Get equity events
+// @Param org_id query string true "Organization ID"
+// @Param employee_id query string false "Employee ID"
+// @Param grant_id query string false "Grant ID"
+// @Param status query string false "recorded, pending, deferred, taxed, cancelled"
+func (h *EquityHandler) GetEquityEvents(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		filters["employee_id"] = employeeID
+	}
+	if grantID := c.Query("grant_id"); grantID != "" {
+		filters["grant_id"] = grantID
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+
+	events, err := h.service.GetEquityEvents(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(events),
+		"data":  events,
+	})
+}
+
+// RecordEquityEvent records a vesting, exercise or sale under a grant
+// @Summary Record equity event
+func (h *EquityHandler) RecordEquityEvent(c *gin.Context) {
+	var req struct {
+		OrgID     string  `json:"org_id" binding:"required"`
+		GrantID   string  `json:"grant_id" binding:"required"`
+		EventType string  `json:"event_type" binding:"required"` // vest, exercise, sale
+		EventDate string  `json:"event_date" binding:"required"` // YYYY-MM-DD
+		Units     float64 `json:"units" binding:"required"`
+		FMV       float64 `json:"fmv"` // Per unit; required for ESOP exercises and RSU vestings
+		CreatedBy string  `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	eventDate, err := time.Parse("2006-01-02", req.EventDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid event_date format (use YYYY-MM-DD)"})
+		return
+	}
+
+	event := &models.EquityEvent{
+		OrgID:     req.OrgID,
+		GrantID:   req.GrantID,
+		EventType: req.EventType,
+		EventDate: eventDate,
+		Units:     req.Units,
+		FMV:       req.FMV,
+		CreatedBy: &req.CreatedBy,
+	}
+
+	if err := h.service.RecordEquityEvent(event); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, event)
+}
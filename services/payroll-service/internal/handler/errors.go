@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/service"
+)
+
+// errorStatus maps service error kinds to HTTP status codes
+func errorStatus(err error, fallback int) int {
+	switch {
+	case errors.Is(err, service.ErrInvalidInput):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden
+	default:
+		return fallback
+	}
+}
+
+// errorBody builds an error response, listing the allowed next states when
+// a payroll run transition was rejected
+func errorBody(err error) gin.H {
+	body := gin.H{"error": err.Error()}
+
+	var transitionErr *service.TransitionError
+	if errors.As(err, &transitionErr) {
+		body["status"] = transitionErr.Status
+		body["allowed_next_states"] = transitionErr.Allowed
+	}
+
+	return body
+}
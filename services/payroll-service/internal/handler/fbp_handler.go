@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type FBPHandler struct {
+	service *service.FBPService
+}
+
+func NewFBPHandler(service *service.FBPService) *FBPHandler {
+	return &FBPHandler{service: service}
+}
+
+// RegisterFBPRoutes registers flexible benefit plan, declaration and claim routes
+func RegisterFBPRoutes(router *gin.RouterGroup, service *service.FBPService) {
+	handler := NewFBPHandler(service)
+
+	router.GET("/salary-structures/:id/fbp-plan", handler.GetFBPPlan)
+	router.PUT("/salary-structures/:id/fbp-plan", handler.SaveFBPPlan)
+	router.GET("/employees/:id/fbp-declarations", handler.GetFBPDeclarations)
+	router.PUT("/employees/:id/fbp-declarations", handler.DeclareFBP)
+	router.GET("/fbp/claims", handler.GetFBPClaims)
+	router.POST("/fbp/claims", handler.SubmitFBPClaim)
+	router.POST("/fbp/claims/:id/approve", handler.ApproveFBPClaim)
+	router.POST("/fbp/claims/:id/reject", handler.RejectFBPClaim)
+}
+
+// GetFBPPlan fetches the flexible benefit plan of a salary structure
+// @Summary Get FBP plan
+func (h *FBPHandler) GetFBPPlan(c *gin.Context) {
+	plan, err := h.service.GetFBPPlan(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(plan),
+		"data":  plan,
+	})
+}
+
+// SaveFBPPlan adds or updates components of a salary structure's flexible
+// benefit plan
+// @Summary Save FBP plan
+func (h *FBPHandler) SaveFBPPlan(c *gin.Context) {
+	var req struct {
+		Components []struct {
+			Component   string  `json:"component" binding:"required"`
+			AnnualLimit float64 `json:"annual_limit"`
+			PayoutType  string  `json:"payout_type"` // fixed, claim (default)
+			IsActive    *bool   `json:"is_active"`   // Defaults to true
+		} `json:"components" binding:"required"`
+		UpdatedBy string `json:"updated_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	components := make([]models.FBPPlanComponent, len(req.Components))
+	for i, rc := range req.Components {
+		components[i] = models.FBPPlanComponent{
+			Component:   rc.Component,
+			AnnualLimit: rc.AnnualLimit,
+			PayoutType:  rc.PayoutType,
+			IsActive:    rc.IsActive == nil || *rc.IsActive,
+		}
+	}
+
+	plan, err := h.service.SaveFBPPlan(c.Param("id"), components, req.UpdatedBy)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(plan),
+		"data":  plan,
+	})
+}
+
+// GetFBPDeclarations fetches an employee's FBP declarations for a financial year
+// @Summary Get FBP declarations
+// @Param financial_year query int true "Year the financial year starts in"
+func (h *FBPHandler) GetFBPDeclarations(c *gin.Context) {
+	financialYear, err := strconv.Atoi(c.Query("financial_year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "financial_year is required"})
+		return
+	}
+
+	declarations, err := h.service.GetFBPDeclarations(c.Param("id"), financialYear)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(declarations),
+		"data":  declarations,
+	})
+}
+
+// DeclareFBP replaces an employee's FBP allocation for a financial year
+// @Summary Declare FBP
+func (h *FBPHandler) DeclareFBP(c *gin.Context) {
+	var req struct {
+		OrgID         string `json:"org_id" binding:"required"`
+		FinancialYear int    `json:"financial_year" binding:"required"`
+		Components    []struct {
+			Component    string  `json:"component" binding:"required"`
+			AnnualAmount float64 `json:"annual_amount"`
+		} `json:"components"`
+		CreatedBy string `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	declarations := make([]models.FBPDeclaration, len(req.Components))
+	for i, rc := range req.Components {
+		declarations[i] = models.FBPDeclaration{
+			Component:    rc.Component,
+			AnnualAmount: rc.AnnualAmount,
+		}
+	}
+
+	declarations, err := h.service.DeclareFBP(req.OrgID, c.Param("id"), req.FinancialYear, declarations, req.CreatedBy)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(declarations),
+		"data":  declarations,
+	})
+}
+
+// GetFBPClaims lists an organization's FBP claims
+// @Summary Get FBP claims
+// @Param org_id query string true "Organization ID"
+// @Param employee_id query string false "Employee ID"
+// @Param status query string false "submitted, approved, rejected, paid"
+// @Param financial_year query int false "Year the financial year starts in"
+func (h *FBPHandler) GetFBPClaims(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		filters["employee_id"] = employeeID
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if fy := c.Query("financial_year"); fy != "" {
+		financialYear, err := strconv.Atoi(fy)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid financial_year"})
+			return
+		}
+		filters["financial_year"] = financialYear
+	}
+
+	claims, err := h.service.GetFBPClaims(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(claims),
+		"data":  claims,
+	})
+}
+
+// SubmitFBPClaim submits a bill against a claim-based FBP component
+// @Summary Submit FBP claim
+func (h *FBPHandler) SubmitFBPClaim(c *gin.Context) {
+	var req struct {
+		OrgID         string  `json:"org_id" binding:"required"`
+		EmployeeID    string  `json:"employee_id" binding:"required"`
+		Component     string  `json:"component" binding:"required"`
+		ClaimDate     string  `json:"claim_date" binding:"required"` // YYYY-MM-DD, date of the bill
+		Amount        float64 `json:"amount" binding:"required"`
+		BillReference string  `json:"bill_reference"`
+		Description   string  `json:"description"`
+		CreatedBy     string  `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claimDate, err := time.Parse("2006-01-02", req.ClaimDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid claim_date format (use YYYY-MM-DD)"})
+		return
+	}
+
+	claim := &models.FBPClaim{
+		OrgID:         req.OrgID,
+		EmployeeID:    req.EmployeeID,
+		Component:     req.Component,
+		ClaimDate:     claimDate,
+		Amount:        req.Amount,
+		BillReference: sql.NullString{String: req.BillReference, Valid: req.BillReference != ""},
+		Description:   sql.NullString{String: req.Description, Valid: req.Description != ""},
+		Status:        "submitted",
+		CreatedBy:     &req.CreatedBy,
+	}
+
+	if err := h.service.SubmitFBPClaim(claim); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, claim)
+}
+
+// ApproveFBPClaim approves a submitted claim for payment in the next payroll run
+// @Summary Approve FBP claim
+func (h *FBPHandler) ApproveFBPClaim(c *gin.Context) {
+	h.reviewFBPClaim(c, h.service.ApproveFBPClaim)
+}
+
+// RejectFBPClaim rejects a submitted claim
+// @Summary Reject FBP claim
+func (h *FBPHandler) RejectFBPClaim(c *gin.Context) {
+	h.reviewFBPClaim(c, h.service.RejectFBPClaim)
+}
+
+func (h *FBPHandler) reviewFBPClaim(c *gin.Context, review func(id, reviewedBy string) (*models.FBPClaim, error)) {
+	var req struct {
+		ReviewedBy string `json:"reviewed_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	claim, err := review(c.Param("id"), req.ReviewedBy)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, claim)
+}
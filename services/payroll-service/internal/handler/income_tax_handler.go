@@ -0,0 +1,108 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type IncomeTaxHandler struct {
+	service *service.IncomeTaxService
+}
+
+func NewIncomeTaxHandler(service *service.IncomeTaxService) *IncomeTaxHandler {
+	return &IncomeTaxHandler{service: service}
+}
+
+// RegisterIncomeTaxRoutes registers income tax slab administration routes
+func RegisterIncomeTaxRoutes(router *gin.RouterGroup, service *service.IncomeTaxService) {
+	handler := NewIncomeTaxHandler(service)
+
+	router.GET("/income-tax/slabs", handler.GetIncomeTaxTable)
+	router.PUT("/income-tax/slabs", handler.SaveIncomeTaxTable)
+}
+
+// GetIncomeTaxTable lists the slabs and surcharges of a financial year
+// @Summary Get income tax slabs
+// @Param financial_year query int true "Year the financial year starts in"
+// @Param tax_regime query string false "old or new (default both)"
+func (h *IncomeTaxHandler) GetIncomeTaxTable(c *gin.Context) {
+	financialYear, err := strconv.Atoi(c.Query("financial_year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "financial_year is required"})
+		return
+	}
+
+	slabs, surcharges, err := h.service.GetIncomeTaxTable(financialYear, c.Query("tax_regime"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"financial_year": financialYear,
+		"slabs":          slabs,
+		"surcharges":     surcharges,
+	})
+}
+
+// SaveIncomeTaxTable replaces the slabs and surcharges of a financial year
+// and regime
+// @Summary Save income tax slabs
+func (h *IncomeTaxHandler) SaveIncomeTaxTable(c *gin.Context) {
+	var req struct {
+		FinancialYear int    `json:"financial_year" binding:"required"`
+		TaxRegime     string `json:"tax_regime" binding:"required"` // old, new
+		Slabs         []struct {
+			MinAge     int      `json:"min_age"` // 0, 60 or 80
+			IncomeFrom float64  `json:"income_from"`
+			IncomeTo   *float64 `json:"income_to"`
+			Rate       float64  `json:"rate"`
+		} `json:"slabs" binding:"required"`
+		Surcharges []struct {
+			IncomeAbove float64 `json:"income_above"`
+			Rate        float64 `json:"rate"`
+		} `json:"surcharges"`
+		CreatedBy string `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	slabs := make([]models.IncomeTaxSlab, len(req.Slabs))
+	for i, s := range req.Slabs {
+		slabs[i] = models.IncomeTaxSlab{
+			MinAge:     s.MinAge,
+			IncomeFrom: s.IncomeFrom,
+			IncomeTo:   s.IncomeTo,
+			Rate:       s.Rate,
+			CreatedBy:  &req.CreatedBy,
+		}
+	}
+
+	surcharges := make([]models.IncomeTaxSurcharge, len(req.Surcharges))
+	for i, s := range req.Surcharges {
+		surcharges[i] = models.IncomeTaxSurcharge{
+			IncomeAbove: s.IncomeAbove,
+			Rate:        s.Rate,
+			CreatedBy:   &req.CreatedBy,
+		}
+	}
+
+	if err := h.service.SaveIncomeTaxTable(req.FinancialYear, req.TaxRegime, slabs, surcharges); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"financial_year": req.FinancialYear,
+		"tax_regime":     req.TaxRegime,
+		"slabs":          slabs,
+		"surcharges":     surcharges,
+	})
+}
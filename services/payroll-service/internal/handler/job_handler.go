@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type JobHandler struct {
+	service *service.JobService
+}
+
+func NewJobHandler(service *service.JobService) *JobHandler {
+	return &JobHandler{service: service}
+}
+
+// RegisterJobRoutes registers background job routes
+func RegisterJobRoutes(router *gin.RouterGroup, service *service.JobService) {
+	handler := NewJobHandler(service)
+
+	router.GET("/jobs", handler.GetJobs)
+	router.GET("/jobs/:id", handler.GetJob)
+	router.POST("/jobs/:id/cancel", handler.CancelJob)
+}
+
+// GetJobs lists an organization's most recent jobs
+// @Summary Get jobs
+// @Param org_id query string true "Organization ID"
+// @Param status query string false "queued, running, completed, failed or cancelled"
+// @Param job_type query string false "Job type"
+func (h *JobHandler) GetJobs(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if jobType := c.Query("job_type"); jobType != "" {
+		filters["job_type"] = jobType
+	}
+
+	jobs, err := h.service.GetJobs(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(jobs),
+		"data":  jobs,
+	})
+}
+
+// GetJob reports a job's status, progress, estimated time left and, once
+// completed, its result
+// @Summary Get job
+func (h *JobHandler) GetJob(c *gin.Context) {
+	job, err := h.service.GetJob(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// CancelJob cancels a queued job or asks a running one to stop
+// @Summary Cancel job
+func (h *JobHandler) CancelJob(c *gin.Context) {
+	job, err := h.service.CancelJob(c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// jobAccepted builds the response to a request that queued a job
+func jobAccepted(message string, job *models.Job) gin.H {
+	return gin.H{
+		"message": message,
+		"job_id":  job.ID,
+		"status":  job.Status,
+		"job_url": "/api/v1/jobs/" + job.ID,
+	}
+}
@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type LOPReversalHandler struct {
+	service *service.LOPReversalService
+}
+
+func NewLOPReversalHandler(service *service.LOPReversalService) *LOPReversalHandler {
+	return &LOPReversalHandler{service: service}
+}
+
+// RegisterLOPReversalRoutes registers loss of pay reversal routes
+func RegisterLOPReversalRoutes(router *gin.RouterGroup, service *service.LOPReversalService) {
+	handler := NewLOPReversalHandler(service)
+
+	reversals := router.Group("/payroll/lop-reversals")
+	{
+		reversals.GET("", handler.GetLOPReversals)
+		reversals.POST("", handler.CreateLOPReversal)
+		reversals.POST("/:id/cancel", handler.CancelLOPReversal)
+	}
+}
+
+// GetLOPReversals lists an organization's LOP reversals
+// @Summary Get LOP reversals
+// @Param org_id query string true "Organization ID"
+// @Param employee_id query string false "Employee ID"
+// @Param status query string false "Status (pending, applied, cancelled)"
+// @Param lop_month query string false "Month the LOP was deducted in (YYYY-MM)"
+func (h *LOPReversalHandler) GetLOPReversals(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		filters["employee_id"] = employeeID
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if month := c.Query("lop_month"); month != "" {
+		filters["lop_month"] = month
+	}
+
+	reversals, err := h.service.GetLOPReversals(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(reversals),
+		"data":  reversals,
+	})
+}
+
+// CreateLOPReversal reverses loss of pay wrongly deducted in an earlier month
+// @Summary Create LOP reversal
+func (h *LOPReversalHandler) CreateLOPReversal(c *gin.Context) {
+	var req struct {
+		OrgID      string `json:"org_id" binding:"required"`
+		EmployeeID string `json:"employee_id" binding:"required"`
+		LOPMonth   string `json:"lop_month" binding:"required"` // YYYY-MM
+		Days       int    `json:"days" binding:"required"`
+		Reason     string `json:"reason" binding:"required"`
+		CreatedBy  string `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rev := &models.LOPReversal{
+		OrgID:      req.OrgID,
+		EmployeeID: req.EmployeeID,
+		LOPMonth:   req.LOPMonth,
+		Days:       req.Days,
+		Reason:     req.Reason,
+		CreatedBy:  &req.CreatedBy,
+	}
+
+	if err := h.service.CreateLOPReversal(rev); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, rev)
+}
+
+// CancelLOPReversal cancels a reversal that has not been paid yet
+// @Summary Cancel LOP reversal
+func (h *LOPReversalHandler) CancelLOPReversal(c *gin.Context) {
+	if err := h.service.CancelLOPReversal(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "LOP reversal cancelled"})
+}
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type NPSHandler struct {
+	service *service.NPSService
+}
+
+func NewNPSHandler(service *service.NPSService) *NPSHandler {
+	return &NPSHandler{service: service}
+}
+
+// RegisterNPSRoutes registers NPS enrolment, tax regime and contribution file routes
+func RegisterNPSRoutes(router *gin.RouterGroup, service *service.NPSService) {
+	handler := NewNPSHandler(service)
+
+	router.GET("/nps/enrolments", handler.GetNPSEnrolments)
+	router.PUT("/nps/enrolments", handler.SaveNPSEnrolment)
+	router.PUT("/employees/:id/tax-regime", handler.SetTaxRegime)
+	router.GET("/payroll/runs/:id/nps-file", handler.GenerateContributionFile)
+}
+
+// GetNPSEnrolments lists an organization's NPS enrolments
+// @Summary Get NPS enrolments
+// @Param org_id query string true "Organization ID"
+// @Param employee_id query string false "Employee ID"
+// @Param as_of query string false "Only enrolments in force on this date (YYYY-MM-DD)"
+func (h *NPSHandler) GetNPSEnrolments(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		filters["employee_id"] = employeeID
+	}
+	if asOf := c.Query("as_of"); asOf != "" {
+		date, err := time.Parse("2006-01-02", asOf)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of format (use YYYY-MM-DD)"})
+			return
+		}
+		filters["as_of"] = date
+	}
+
+	enrolments, err := h.service.GetNPSEnrolments(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(enrolments),
+		"data":  enrolments,
+	})
+}
+
+// SaveNPSEnrolment enrols an employee in corporate NPS, or replaces the
+// enrolment starting on the same date
+// @Summary Save NPS enrolment
+func (h *NPSHandler) SaveNPSEnrolment(c *gin.Context) {
+	var req struct {
+		OrgID         string  `json:"org_id" binding:"required"`
+		EmployeeID    string  `json:"employee_id" binding:"required"`
+		PRAN          string  `json:"pran" binding:"required"`
+		EmployerRate  float64 `json:"employer_rate" binding:"required"`  // % of Basic + DA
+		EmployeeRate  float64 `json:"employee_rate"`                     // % of Basic + DA, optional
+		EffectiveFrom string  `json:"effective_from" binding:"required"` // YYYY-MM-DD
+		EffectiveTill *string `json:"effective_till"`                    // YYYY-MM-DD
+		CreatedBy     string  `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid effective_from format (use YYYY-MM-DD)"})
+		return
+	}
+
+	effectiveTill, err := parseOptionalDate(req.EffectiveTill)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid effective_till format (use YYYY-MM-DD)"})
+		return
+	}
+
+	enrolment := &models.NPSEnrolment{
+		OrgID:         req.OrgID,
+		EmployeeID:    req.EmployeeID,
+		PRAN:          req.PRAN,
+		EmployerRate:  req.EmployerRate,
+		EmployeeRate:  req.EmployeeRate,
+		EffectiveFrom: effectiveFrom,
+		EffectiveTill: effectiveTill,
+		CreatedBy:     &req.CreatedBy,
+	}
+
+	if err := h.service.SaveNPSEnrolment(enrolment); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, enrolment)
+}
+
+// SetTaxRegime records the income tax regime an employee opted for
+// @Summary Set employee tax regime
+func (h *NPSHandler) SetTaxRegime(c *gin.Context) {
+	var req struct {
+		TaxRegime string `json:"tax_regime" binding:"required"` // old, new
+		UpdatedBy string `json:"updated_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.service.SetTaxRegime(c.Param("id"), req.TaxRegime, req.UpdatedBy); err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Tax regime updated"})
+}
+
+// GenerateContributionFile generates a run's NPS contribution file for the POP
+// @Summary Generate NPS contribution file
+func (h *NPSHandler) GenerateContributionFile(c *gin.Context) {
+	file, err := h.service.GenerateContributionFile(c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"file_name":           file.FileName,
+		"contribution_month":  file.ContributionMonth,
+		"registration_number": file.RegistrationNumber,
+		"total_records":       file.TotalRecords,
+		"total_employee":      file.TotalEmployee,
+		"total_employer":      file.TotalEmployer,
+		"total_amount":        file.TotalAmount,
+		"content":             file.RawContent,
+	})
+}
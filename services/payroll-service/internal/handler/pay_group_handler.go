@@ -0,0 +1,186 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type PayGroupHandler struct {
+	service *service.PayGroupService
+}
+
+func NewPayGroupHandler(service *service.PayGroupService) *PayGroupHandler {
+	return &PayGroupHandler{service: service}
+}
+
+// RegisterPayGroupRoutes registers pay group and pay calendar routes
+func RegisterPayGroupRoutes(router *gin.RouterGroup, service *service.PayGroupService) {
+	handler := NewPayGroupHandler(service)
+
+	groups := router.Group("/pay-groups")
+	{
+		groups.GET("", handler.GetPayGroups)
+		groups.POST("", handler.CreatePayGroup)
+		groups.GET("/:id/periods", handler.GetPayGroupPeriods)
+		groups.POST("/:id/periods/generate", handler.GeneratePayGroupPeriods)
+	}
+
+	router.PUT("/employees/:id/pay-group", handler.AssignEmployee)
+}
+
+// GetPayGroups lists an organization's pay groups
+// @Summary Get pay groups
+// @Param org_id query string true "Organization ID"
+func (h *PayGroupHandler) GetPayGroups(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	groups, err := h.service.GetPayGroups(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(groups),
+		"data":  groups,
+	})
+}
+
+// CreatePayGroup creates a pay group
+// @Summary Create pay group
+func (h *PayGroupHandler) CreatePayGroup(c *gin.Context) {
+	var req struct {
+		OrgID      string  `json:"org_id" binding:"required"`
+		Name       string  `json:"name" binding:"required"`
+		Frequency  string  `json:"frequency" binding:"required"` // monthly, semi_monthly, fortnightly, weekly
+		AnchorDate *string `json:"anchor_date"`                  // YYYY-MM-DD, first day of any period
+		IsDefault  bool    `json:"is_default"`
+		CreatedBy  string  `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	anchorDate, err := parseOptionalDate(req.AnchorDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid anchor_date format (use YYYY-MM-DD)"})
+		return
+	}
+
+	group := &models.PayGroup{
+		OrgID:      req.OrgID,
+		Name:       req.Name,
+		Frequency:  req.Frequency,
+		AnchorDate: anchorDate,
+		IsDefault:  req.IsDefault,
+		CreatedBy:  &req.CreatedBy,
+	}
+
+	if err := h.service.CreatePayGroup(group); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, group)
+}
+
+// GetPayGroupPeriods lists a pay group's calendar
+// @Summary Get pay group periods
+// @Param from query string false "Periods ending on or after (YYYY-MM-DD)"
+// @Param to query string false "Periods starting on or before (YYYY-MM-DD)"
+func (h *PayGroupHandler) GetPayGroupPeriods(c *gin.Context) {
+	from, to := c.Query("from"), c.Query("to")
+
+	fromDate, err := parseOptionalDate(&from)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from format (use YYYY-MM-DD)"})
+		return
+	}
+
+	toDate, err := parseOptionalDate(&to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to format (use YYYY-MM-DD)"})
+		return
+	}
+
+	periods, err := h.service.GetPayGroupPeriods(c.Param("id"), fromDate, toDate)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(periods),
+		"data":  periods,
+	})
+}
+
+// GeneratePayGroupPeriods adds the periods between two dates to a pay group's calendar
+// @Summary Generate pay group periods
+func (h *PayGroupHandler) GeneratePayGroupPeriods(c *gin.Context) {
+	var req struct {
+		FromDate string `json:"from_date" binding:"required"` // YYYY-MM-DD
+		ToDate   string `json:"to_date" binding:"required"`   // YYYY-MM-DD
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	fromDate, err := time.Parse("2006-01-02", req.FromDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from_date format (use YYYY-MM-DD)"})
+		return
+	}
+
+	toDate, err := time.Parse("2006-01-02", req.ToDate)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to_date format (use YYYY-MM-DD)"})
+		return
+	}
+
+	periods, created, err := h.service.GeneratePayGroupPeriods(c.Param("id"), fromDate, toDate)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"created": created,
+		"count":   len(periods),
+		"data":    periods,
+	})
+}
+
+// AssignEmployee moves an employee to a pay group
+// @Summary Assign employee pay group
+func (h *PayGroupHandler) AssignEmployee(c *gin.Context) {
+	var req struct {
+		PayGroupID string `json:"pay_group_id" binding:"required"`
+		UpdatedBy  string `json:"updated_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	emp, err := h.service.AssignEmployee(c.Param("id"), req.PayGroupID, req.UpdatedBy)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, emp)
+}
@@ -0,0 +1,207 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/reports"
+	"payroll-service/internal/service"
+)
+
+type PaymentHandler struct {
+	service *service.PaymentService
+}
+
+func NewPaymentHandler(service *service.PaymentService) *PaymentHandler {
+	return &PaymentHandler{service: service}
+}
+
+// RegisterPaymentRoutes registers salary hold and bank file routes
+func RegisterPaymentRoutes(router *gin.RouterGroup, service *service.PaymentService) {
+	handler := NewPaymentHandler(service)
+
+	payroll := router.Group("/payroll")
+	{
+		payroll.GET("/holds", handler.GetPayrollHolds)
+		payroll.POST("/runs/:id/components/:employeeId/hold", handler.PlaceHold)
+		payroll.POST("/runs/:id/components/:employeeId/hold/release", handler.ReleaseHold)
+		payroll.GET("/runs/:id/bank-file", handler.GenerateBankFile)
+		payroll.POST("/runs/:id/bank-file", handler.QueueBankFile)
+		payroll.POST("/runs/:id/bank-file/supplementary", handler.GenerateSupplementaryBankFile)
+	}
+}
+
+// GetPayrollHolds lists salary holds
+// @Summary Get payroll holds
+// @Param org_id query string true "Organization ID"
+// @Param status query string false "Hold status (active, lapsed, released, paid, withdrawn)"
+// @Param payroll_run_id query string false "Payroll run ID"
+func (h *PaymentHandler) GetPayrollHolds(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if payrollRunID := c.Query("payroll_run_id"); payrollRunID != "" {
+		filters["payroll_run_id"] = payrollRunID
+	}
+
+	holds, err := h.service.GetPayrollHolds(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(holds),
+		"data":  holds,
+	})
+}
+
+// PlaceHold withholds an employee's salary in a payroll run
+// @Summary Hold employee salary
+func (h *PaymentHandler) PlaceHold(c *gin.Context) {
+	var req struct {
+		ReasonCode string  `json:"reason_code" binding:"required"` // absconding, exit_clearance, bank_mismatch, other
+		Reason     string  `json:"reason"`
+		HeldBy     string  `json:"held_by" binding:"required"`
+		ExpiresAt  *string `json:"expires_at"` // YYYY-MM-DD
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	expiresAt, err := parseOptionalDate(req.ExpiresAt)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid expires_at format (use YYYY-MM-DD)"})
+		return
+	}
+
+	hold, err := h.service.PlaceHold(c.Param("id"), c.Param("employeeId"), req.ReasonCode, req.Reason, req.HeldBy, expiresAt)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, hold)
+}
+
+// ReleaseHold releases a salary hold. Once the run is locked this returns a
+// supplementary bank file paying the held salary.
+// @Summary Release employee salary hold
+func (h *PaymentHandler) ReleaseHold(c *gin.Context) {
+	var req struct {
+		ReleasedBy string `json:"released_by" binding:"required"`
+		Notes      string `json:"notes"`
+		Format     string `json:"format"` // NEFT, RTGS, IMPS
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Format == "" {
+		req.Format = "NEFT"
+	}
+
+	file, err := h.service.ReleaseHold(c.Param("id"), c.Param("employeeId"), req.ReleasedBy, req.Notes, req.Format)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	if file == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "Hold withdrawn; salary will be paid with the payroll run"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Hold released",
+		"supplementary_file": bankFileResponse(file),
+	})
+}
+
+// GenerateBankFile generates the salary bank file, excluding held salaries
+// @Summary Generate bank file
+// @Param format query string false "File format (NEFT, RTGS, IMPS)"
+func (h *PaymentHandler) GenerateBankFile(c *gin.Context) {
+	file, err := h.service.GenerateBankFile(c.Param("id"), c.DefaultQuery("format", "NEFT"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bankFileResponse(file))
+}
+
+// QueueBankFile queues the generation of the salary bank file and returns
+// the job at once; the job's result is the file
+// @Summary Queue bank file
+func (h *PaymentHandler) QueueBankFile(c *gin.Context) {
+	var req struct {
+		Format      string `json:"format"` // NEFT, RTGS, IMPS
+		RequestedBy string `json:"requested_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Format == "" {
+		req.Format = "NEFT"
+	}
+
+	job, err := h.service.EnqueueBankFile(c.Param("id"), req.Format, req.RequestedBy)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, jobAccepted("Bank file generation queued", job))
+}
+
+// GenerateSupplementaryBankFile pays released and lapsed holds of a run
+// @Summary Generate supplementary bank file
+func (h *PaymentHandler) GenerateSupplementaryBankFile(c *gin.Context) {
+	var req struct {
+		Format string `json:"format"` // NEFT, RTGS, IMPS
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil && c.Request.ContentLength > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.Format == "" {
+		req.Format = "NEFT"
+	}
+
+	file, err := h.service.GenerateSupplementaryBankFile(c.Param("id"), req.Format)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, bankFileResponse(file))
+}
+
+func bankFileResponse(file *reports.BankPaymentFile) gin.H {
+	return gin.H{
+		"file_name":      file.FileName,
+		"file_reference": file.FileReference,
+		"file_format":    file.FileFormat,
+		"file_type":      file.FileType,
+		"total_records":  file.TotalRecords,
+		"total_amount":   file.TotalAmount,
+		"content":        file.RawContent,
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+)
+
+// GetApprovalProgress shows a run's approval round
+func (h *PayrollHandler) GetApprovalProgress(c *gin.Context) {
+	progress, err := h.service.GetApprovalProgress(c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, progress)
+}
+
+// ApprovalInbox lists finalized runs awaiting the approver's decision
+// @Summary Get approvals inbox
+// @Param org_id query string true "Organization ID"
+// @Param approver_id query string true "Approver user ID"
+func (h *PayrollHandler) ApprovalInbox(c *gin.Context) {
+	orgID := c.Query("org_id")
+	approverID := c.Query("approver_id")
+	if orgID == "" || approverID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id and approver_id are required"})
+		return
+	}
+
+	inbox, err := h.service.ApprovalInbox(orgID, approverID)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(inbox),
+		"data":  inbox,
+	})
+}
+
+// GetApprovalChain lists an organization's approval levels
+// @Summary Get payroll approval chain
+// @Param org_id query string true "Organization ID"
+func (h *PayrollHandler) GetApprovalChain(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	levels, err := h.service.GetApprovalChain(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(levels),
+		"data":  levels,
+	})
+}
+
+// SaveApprovalChain replaces an organization's approval levels
+// @Summary Save payroll approval chain
+func (h *PayrollHandler) SaveApprovalChain(c *gin.Context) {
+	var req struct {
+		OrgID  string `json:"org_id" binding:"required"`
+		Levels []struct {
+			Level        int      `json:"level"`
+			Name         string   `json:"name"`
+			ApproverIDs  []string `json:"approver_ids"`
+			MinNetAmount float64  `json:"min_net_amount"`
+		} `json:"levels"`
+		CreatedBy string `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	levels := make([]models.PayrollApprovalLevel, len(req.Levels))
+	for i, l := range req.Levels {
+		levels[i] = models.PayrollApprovalLevel{
+			OrgID:        req.OrgID,
+			Level:        l.Level,
+			Name:         l.Name,
+			ApproverIDs:  l.ApproverIDs,
+			MinNetAmount: l.MinNetAmount,
+			CreatedBy:    &req.CreatedBy,
+		}
+	}
+
+	if err := h.service.SaveApprovalChain(req.OrgID, levels); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(levels),
+		"data":  levels,
+	})
+}
@@ -1,10 +1,14 @@
 package handler
 
 import (
+	"errors"
+	"io"
 	"net/http"
-	"time"
+	"strconv"
 
 	"github.com/gin-gonic/gin"
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
 	"payroll-service/internal/service"
 )
 
@@ -25,13 +29,37 @@ func RegisterPayrollRoutes(router *gin.RouterGroup, service *service.PayrollServ
 		payroll.GET("/runs", handler.GetPayrollRuns)
 		payroll.POST("/runs", handler.CreatePayrollRun)
 		payroll.GET("/runs/:id", handler.GetPayrollRunDetail)
+		payroll.GET("/runs/:id/employees", handler.GetPayrollRunEmployees)
+		payroll.GET("/runs/:id/outcomes", handler.GetPayrollRunOutcomes)
 		payroll.POST("/runs/:id/initiate", handler.InitiatePayrollRun)
 		payroll.POST("/runs/:id/validate", handler.ValidatePayroll)
 		payroll.POST("/runs/:id/finalize", handler.FinalizePayroll)
 		payroll.POST("/runs/:id/approve", handler.ApprovePayroll)
+		payroll.POST("/runs/:id/reject", handler.RejectPayroll)
+		payroll.GET("/runs/:id/approvals", handler.GetApprovalProgress)
 		payroll.POST("/runs/:id/release", handler.ReleasePayroll)
 		payroll.POST("/runs/:id/dry-run", handler.DryRunPayroll)
+		payroll.GET("/runs/:id/dry-runs", handler.GetPayrollDryRuns)
+		payroll.GET("/runs/:id/dry-runs/:number", handler.GetPayrollDryRun)
+		payroll.GET("/runs/:id/transitions", handler.GetPayrollRunTransitions)
 		payroll.GET("/runs/:id/summary", handler.GetPayrollSummary)
+		payroll.GET("/runs/:id/variance", handler.GetVarianceReport)
+		payroll.GET("/runs/:id/variance/acknowledgements", handler.GetVarianceAcknowledgements)
+		payroll.POST("/runs/:id/variance/acknowledgements", handler.AcknowledgeVariances)
+		payroll.GET("/runs/:id/components/:employeeId/explain", handler.ExplainPayrollComponent)
+		payroll.POST("/runs/:id/reopen-requests", handler.RequestReopen)
+		payroll.GET("/runs/:id/reopen-requests", handler.GetReopenRequests)
+		payroll.POST("/runs/:id/recalculate", handler.RecalculateEmployees)
+		payroll.GET("/runs/:id/overrides", handler.GetComponentOverrides)
+		payroll.PUT("/runs/:id/components/:employeeId/override", handler.SaveComponentOverride)
+		payroll.DELETE("/runs/:id/components/:employeeId/override", handler.DeleteComponentOverride)
+		payroll.GET("/runs/:id/lock-history", handler.GetPayrollLockEvents)
+		payroll.POST("/reopen-requests/:requestId/approve", handler.ApproveReopenRequest)
+		payroll.POST("/reopen-requests/:requestId/reject", handler.RejectReopenRequest)
+		payroll.GET("/reopen-requests/:requestId/diff", handler.GetReopenDiff)
+		payroll.GET("/approvals/inbox", handler.ApprovalInbox)
+		payroll.GET("/approval-chain", handler.GetApprovalChain)
+		payroll.PUT("/approval-chain", handler.SaveApprovalChain)
 	}
 }
 
@@ -40,6 +68,8 @@ func RegisterPayrollRoutes(router *gin.RouterGroup, service *service.PayrollServ
 // @Param org_id query string true "Organization ID"
 // @Param status query string false "Payroll status"
 // @Param month query string false "Payroll month (YYYY-MM)"
+// @Param pay_group_id query string false "Pay group ID"
+// @Param run_type query string false "regular, supplementary, bonus, full_and_final or reversal"
 func (h *PayrollHandler) GetPayrollRuns(c *gin.Context) {
 	orgID := c.Query("org_id")
 	if orgID == "" {
@@ -54,6 +84,12 @@ func (h *PayrollHandler) GetPayrollRuns(c *gin.Context) {
 	if month := c.Query("month"); month != "" {
 		filters["month"] = month
 	}
+	if payGroupID := c.Query("pay_group_id"); payGroupID != "" {
+		filters["pay_group_id"] = payGroupID
+	}
+	if runType := c.Query("run_type"); runType != "" {
+		filters["run_type"] = runType
+	}
 
 	runs, err := h.service.GetPayrollRuns(orgID, filters)
 	if err != nil {
@@ -67,14 +103,24 @@ func (h *PayrollHandler) GetPayrollRuns(c *gin.Context) {
 	})
 }
 
-// CreatePayrollRun creates a new payroll cycle
+// CreatePayrollRun creates a new payroll cycle, or an off-cycle run for
+// selected employees in the same period
 // @Summary Create payroll run
 // @Param request body CreatePayrollRunRequest true "Payroll run details"
 func (h *PayrollHandler) CreatePayrollRun(c *gin.Context) {
 	var req struct {
-		OrgID     string `json:"org_id" binding:"required"`
-		StartDate string `json:"start_date" binding:"required"` // YYYY-MM-DD
-		EndDate   string `json:"end_date" binding:"required"`   // YYYY-MM-DD
+		OrgID         string  `json:"org_id" binding:"required"`
+		PayGroupID    string  `json:"pay_group_id"`            // Defaults to the org's default pay group
+		PeriodKey     string  `json:"period_key"`              // e.g. 2024-01, 2024-W05; or give the dates
+		StartDate     *string `json:"start_date"`              // YYYY-MM-DD
+		EndDate       *string `json:"end_date"`                // YYYY-MM-DD
+		RunType       string  `json:"run_type"`                // Defaults to regular
+		ReversesRunID string  `json:"reverses_payroll_run_id"` // Reversal runs only
+		Employees     []struct {
+			EmployeeID  string   `json:"employee_id" binding:"required"`
+			Amount      *float64 `json:"amount"` // Bonus or settlement amount
+			Description *string  `json:"description"`
+		} `json:"employees"` // Off-cycle runs only
 		CreatedBy string `json:"created_by" binding:"required"`
 	}
 
@@ -83,21 +129,39 @@ func (h *PayrollHandler) CreatePayrollRun(c *gin.Context) {
 		return
 	}
 
-	startDate, err := time.Parse("2006-01-02", req.StartDate)
+	startDate, err := parseOptionalDate(req.StartDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid start_date format (use YYYY-MM-DD)"})
 		return
 	}
 
-	endDate, err := time.Parse("2006-01-02", req.EndDate)
+	endDate, err := parseOptionalDate(req.EndDate)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid end_date format (use YYYY-MM-DD)"})
 		return
 	}
 
-	pr, err := h.service.CreatePayrollRun(req.OrgID, startDate, endDate, req.CreatedBy)
+	var pr *models.PayrollRun
+	if req.RunType == "" || req.RunType == service.RunTypeRegular {
+		if len(req.Employees) > 0 || req.ReversesRunID != "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "regular runs cover the whole pay group; set run_type to select employees"})
+			return
+		}
+		pr, err = h.service.CreatePayrollRun(req.OrgID, req.PayGroupID, req.PeriodKey, startDate, endDate, req.CreatedBy)
+	} else {
+		employees := make([]models.PayrollRunEmployee, len(req.Employees))
+		for i, e := range req.Employees {
+			employees[i] = models.PayrollRunEmployee{
+				EmployeeID:  e.EmployeeID,
+				Amount:      e.Amount,
+				Description: e.Description,
+			}
+		}
+		pr, err = h.service.CreateOffCyclePayrollRun(req.OrgID, req.PayGroupID, req.PeriodKey, startDate, endDate,
+			req.RunType, req.ReversesRunID, employees, req.CreatedBy)
+	}
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
 		return
 	}
 
@@ -117,7 +181,38 @@ func (h *PayrollHandler) GetPayrollRunDetail(c *gin.Context) {
 	c.JSON(http.StatusOK, detail)
 }
 
-// InitiatePayrollRun initializes payroll components for all employees
+// GetPayrollRunEmployees lists the employees selected for an off-cycle run
+func (h *PayrollHandler) GetPayrollRunEmployees(c *gin.Context) {
+	employees, err := h.service.GetPayrollRunEmployees(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(employees),
+		"data":  employees,
+	})
+}
+
+// GetPayrollRunOutcomes lists whether each employee of a run was calculated,
+// skipped or failed, and why
+// @Param status query string false "success, skipped or failed"
+func (h *PayrollHandler) GetPayrollRunOutcomes(c *gin.Context) {
+	outcomes, err := h.service.GetPayrollRunOutcomes(c.Param("id"), c.Query("status"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(outcomes),
+		"data":  outcomes,
+	})
+}
+
+// InitiatePayrollRun queues the calculation of payroll components for all
+// employees and returns the job at once; follow it at /jobs/:id
 func (h *PayrollHandler) InitiatePayrollRun(c *gin.Context) {
 	payrollRunID := c.Param("id")
 
@@ -125,6 +220,7 @@ func (h *PayrollHandler) InitiatePayrollRun(c *gin.Context) {
 		OrgID       string `json:"org_id" binding:"required"`
 		StateCode   string `json:"state_code"` // Optional, defaults to MH
 		InitiatedBy string `json:"initiated_by" binding:"required"`
+		Comment     string `json:"comment"`
 	}
 
 	if err := c.BindJSON(&req); err != nil {
@@ -136,15 +232,13 @@ func (h *PayrollHandler) InitiatePayrollRun(c *gin.Context) {
 		req.StateCode = "MH" // Default to Maharashtra
 	}
 
-	if err := h.service.InitiatePayrollRun(req.OrgID, payrollRunID, req.StateCode, req.InitiatedBy); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	job, err := h.service.EnqueuePayrollInitiation(req.OrgID, payrollRunID, req.StateCode, req.InitiatedBy, req.Comment)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
-		"message": "Payroll initiated successfully",
-		"payroll_run_id": payrollRunID,
-	})
+	c.JSON(http.StatusAccepted, jobAccepted("Payroll initiation queued", job))
 }
 
 // ValidatePayroll validates payroll components
@@ -170,6 +264,7 @@ func (h *PayrollHandler) FinalizePayroll(c *gin.Context) {
 
 	var req struct {
 		FinalizedBy string `json:"finalized_by" binding:"required"`
+		Comment     string `json:"comment"`
 	}
 
 	if err := c.BindJSON(&req); err != nil {
@@ -177,20 +272,22 @@ func (h *PayrollHandler) FinalizePayroll(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.FinalizePayroll(payrollRunID, req.FinalizedBy); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.FinalizePayroll(payrollRunID, req.FinalizedBy, req.Comment); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Payroll finalized successfully"})
 }
 
-// ApprovePayroll approves a payroll run
+// ApprovePayroll records an approval at the run's next pending level and
+// locks the run once its approval chain is complete
 func (h *PayrollHandler) ApprovePayroll(c *gin.Context) {
 	payrollRunID := c.Param("id")
 
 	var req struct {
 		ApprovedBy string `json:"approved_by" binding:"required"`
+		Comment    string `json:"comment"`
 	}
 
 	if err := c.BindJSON(&req); err != nil {
@@ -198,12 +295,48 @@ func (h *PayrollHandler) ApprovePayroll(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ApprovePayroll(payrollRunID, req.ApprovedBy); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	progress, err := h.service.ApprovePayroll(payrollRunID, req.ApprovedBy, req.Comment)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	message := "Payroll approved and locked successfully"
+	if progress.NextLevel != nil {
+		message = "Approval recorded; awaiting " + progress.NextLevel.Name
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  message,
+		"approval": progress,
+	})
+}
+
+// RejectPayroll rejects a finalized payroll run, sending it back to
+// in_progress
+func (h *PayrollHandler) RejectPayroll(c *gin.Context) {
+	payrollRunID := c.Param("id")
+
+	var req struct {
+		RejectedBy string `json:"rejected_by" binding:"required"`
+		Comment    string `json:"comment" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Payroll approved and locked successfully"})
+	progress, err := h.service.RejectPayroll(payrollRunID, req.RejectedBy, req.Comment)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":  "Payroll rejected and returned for rework",
+		"approval": progress,
+	})
 }
 
 // ReleasePayroll releases a payroll run
@@ -212,6 +345,7 @@ func (h *PayrollHandler) ReleasePayroll(c *gin.Context) {
 
 	var req struct {
 		ReleasedBy string `json:"released_by" binding:"required"`
+		Comment    string `json:"comment"`
 	}
 
 	if err := c.BindJSON(&req); err != nil {
@@ -219,24 +353,95 @@ func (h *PayrollHandler) ReleasePayroll(c *gin.Context) {
 		return
 	}
 
-	if err := h.service.ReleasePayroll(payrollRunID, req.ReleasedBy); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	if err := h.service.ReleasePayroll(payrollRunID, req.ReleasedBy, req.Comment); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Payroll released successfully"})
 }
 
-// DryRunPayroll performs a dry run
+// DryRunPayroll queues a dry run, which calculates the whole run without
+// storing it; the job's result holds the calculated components, validation
+// findings and the diff against the previous dry run. The body is optional;
+// without requested_by the dry run is recorded as a system action.
 func (h *PayrollHandler) DryRunPayroll(c *gin.Context) {
 	payrollRunID := c.Param("id")
 
-	if err := h.service.DryRunPayroll(payrollRunID); err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	var req struct {
+		StateCode   string `json:"state_code"` // Optional, defaults to MH
+		RequestedBy string `json:"requested_by"`
+		Comment     string `json:"comment"`
+	}
+
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.RequestedBy == "" {
+		req.RequestedBy = service.SystemActor
+	}
+
+	if req.StateCode == "" {
+		req.StateCode = "MH" // Default to Maharashtra
+	}
+
+	job, err := h.service.EnqueueDryRun(payrollRunID, req.StateCode, req.RequestedBy, req.Comment)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "Payroll dry run completed successfully"})
+	c.JSON(http.StatusAccepted, jobAccepted("Payroll dry run queued", job))
+}
+
+// GetPayrollDryRuns lists a payroll run's dry runs, latest first
+func (h *PayrollHandler) GetPayrollDryRuns(c *gin.Context) {
+	dryRuns, err := h.service.GetPayrollDryRuns(c.Param("id"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(dryRuns),
+		"data":  dryRuns,
+	})
+}
+
+// GetPayrollDryRun fetches a dry run with its components, validation
+// findings and the diff against the dry run before it
+func (h *PayrollHandler) GetPayrollDryRun(c *gin.Context) {
+	dryRunNumber, err := strconv.Atoi(c.Param("number"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "dry run number must be a number"})
+		return
+	}
+
+	result, err := h.service.GetPayrollDryRun(c.Param("id"), dryRunNumber)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetPayrollRunTransitions lists a payroll run's status history
+func (h *PayrollHandler) GetPayrollRunTransitions(c *gin.Context) {
+	payrollRunID := c.Param("id")
+
+	transitions, err := h.service.GetPayrollRunTransitions(payrollRunID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(transitions),
+		"data":  transitions,
+	})
 }
 
 // GetPayrollSummary gets financial summary
@@ -251,3 +456,26 @@ func (h *PayrollHandler) GetPayrollSummary(c *gin.Context) {
 
 	c.JSON(http.StatusOK, summary)
 }
+
+// ExplainPayrollComponent returns the step-by-step working of an employee's payroll
+// @Summary Explain payroll component calculation
+// @Param format query string false "Response format (json, text)"
+func (h *PayrollHandler) ExplainPayrollComponent(c *gin.Context) {
+	payrollRunID := c.Param("id")
+	employeeID := c.Param("employeeId")
+
+	trail, err := h.service.ExplainPayrollComponent(payrollRunID, employeeID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch c.DefaultQuery("format", "json") {
+	case "json":
+		c.JSON(http.StatusOK, trail)
+	case "text":
+		c.String(http.StatusOK, calculator.FormatAuditTrailText(trail))
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid format (use json or text)"})
+	}
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+)
+
+// RecalculateEmployees queues the recalculation of selected employees of an
+// in-progress or reopened run; the job's result holds the updated totals
+func (h *PayrollHandler) RecalculateEmployees(c *gin.Context) {
+	payrollRunID := c.Param("id")
+
+	var req struct {
+		RecalculatedBy string   `json:"recalculated_by" binding:"required"`
+		StateCode      string   `json:"state_code"`   // Optional, defaults to MH
+		EmployeeIDs    []string `json:"employee_ids"` // Required unless the run is reopened
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.service.EnqueueRecalculation(payrollRunID, req.StateCode, req.RecalculatedBy, req.EmployeeIDs)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusAccepted, jobAccepted("Recalculation queued", job))
+}
+
+// GetComponentOverrides lists the manual overrides of a run
+func (h *PayrollHandler) GetComponentOverrides(c *gin.Context) {
+	overrides, err := h.service.GetComponentOverrides(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(overrides),
+		"data":  overrides,
+	})
+}
+
+// SaveComponentOverride sets manual inputs for an employee in a run. They
+// apply from the employee's next calculation.
+func (h *PayrollHandler) SaveComponentOverride(c *gin.Context) {
+	var req struct {
+		UnpaidLeaveDays     *int     `json:"unpaid_leave_days"`
+		AdvanceRecovery     *float64 `json:"advance_recovery"`
+		LoanRecovery        *float64 `json:"loan_recovery"`
+		OtherDeductions     *float64 `json:"other_deductions"`
+		CourtOrderDeduction *float64 `json:"court_order_deduction"`
+		Reason              string   `json:"reason" binding:"required"`
+		UpdatedBy           string   `json:"updated_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override := &models.PayrollComponentOverride{
+		PayrollRunID:        c.Param("id"),
+		EmployeeID:          c.Param("employeeId"),
+		UnpaidLeaveDays:     req.UnpaidLeaveDays,
+		AdvanceRecovery:     req.AdvanceRecovery,
+		LoanRecovery:        req.LoanRecovery,
+		OtherDeductions:     req.OtherDeductions,
+		CourtOrderDeduction: req.CourtOrderDeduction,
+		Reason:              req.Reason,
+		UpdatedBy:           &req.UpdatedBy,
+	}
+
+	if err := h.service.SaveComponentOverride(override); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, override)
+}
+
+// DeleteComponentOverride removes an employee's manual overrides from a run
+func (h *PayrollHandler) DeleteComponentOverride(c *gin.Context) {
+	if err := h.service.DeleteComponentOverride(c.Param("id"), c.Param("employeeId")); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Override removed"})
+}
@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// RequestReopen asks to reopen a locked payroll run for corrections
+func (h *PayrollHandler) RequestReopen(c *gin.Context) {
+	payrollRunID := c.Param("id")
+
+	var req struct {
+		RequestedBy string   `json:"requested_by" binding:"required"`
+		Reason      string   `json:"reason" binding:"required"`
+		EmployeeIDs []string `json:"employee_ids" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reopen, err := h.service.RequestReopen(payrollRunID, req.RequestedBy, req.Reason, req.EmployeeIDs)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, reopen)
+}
+
+// GetReopenRequests lists a payroll run's reopen requests
+func (h *PayrollHandler) GetReopenRequests(c *gin.Context) {
+	requests, err := h.service.GetReopenRequests(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(requests),
+		"data":  requests,
+	})
+}
+
+// ApproveReopenRequest approves a reopen request, unlocking the run
+func (h *PayrollHandler) ApproveReopenRequest(c *gin.Context) {
+	var req struct {
+		ApprovedBy string `json:"approved_by" binding:"required"`
+		Comment    string `json:"comment"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reopen, err := h.service.ApproveReopenRequest(c.Param("requestId"), req.ApprovedBy, req.Comment)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Payroll run reopened",
+		"request": reopen,
+	})
+}
+
+// RejectReopenRequest rejects a reopen request
+func (h *PayrollHandler) RejectReopenRequest(c *gin.Context) {
+	var req struct {
+		RejectedBy string `json:"rejected_by" binding:"required"`
+		Comment    string `json:"comment" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	reopen, err := h.service.RejectReopenRequest(c.Param("requestId"), req.RejectedBy, req.Comment)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Reopen request rejected",
+		"request": reopen,
+	})
+}
+
+// GetReopenDiff compares a reopened run with its locked snapshot
+func (h *PayrollHandler) GetReopenDiff(c *gin.Context) {
+	diff, err := h.service.GetReopenDiff(c.Param("requestId"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, diff)
+}
+
+// GetPayrollLockEvents lists a payroll run's lock and unlock history
+func (h *PayrollHandler) GetPayrollLockEvents(c *gin.Context) {
+	events, err := h.service.GetPayrollLockEvents(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(events),
+		"data":  events,
+	})
+}
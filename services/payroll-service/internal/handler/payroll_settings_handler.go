@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type PayrollSettingsHandler struct {
+	service *service.PayrollSettingsService
+}
+
+func NewPayrollSettingsHandler(service *service.PayrollSettingsService) *PayrollSettingsHandler {
+	return &PayrollSettingsHandler{service: service}
+}
+
+// RegisterPayrollSettingsRoutes registers organization payroll settings routes
+func RegisterPayrollSettingsRoutes(router *gin.RouterGroup, service *service.PayrollSettingsService) {
+	handler := NewPayrollSettingsHandler(service)
+
+	settings := router.Group("/payroll/settings")
+	{
+		settings.GET("", handler.GetPayrollSettings)
+		settings.PUT("", handler.UpdatePayrollSettings)
+	}
+}
+
+// GetPayrollSettings gets an organization's payroll settings
+// @Summary Get payroll settings
+// @Param org_id query string true "Organization ID"
+func (h *PayrollSettingsHandler) GetPayrollSettings(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	settings, err := h.service.GetPayrollSettings(orgID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdatePayrollSettings replaces an organization's payroll settings
+// @Summary Update payroll settings
+func (h *PayrollSettingsHandler) UpdatePayrollSettings(c *gin.Context) {
+	var req struct {
+		OrgID          string  `json:"org_id" binding:"required"`
+		RoundingMode   string  `json:"rounding_mode" binding:"required"` // none, nearest, up, down
+		RoundingUnit   float64 `json:"rounding_unit"`                    // 1, 5, 10
+		ProrationBasis string  `json:"proration_basis"`                  // calendar_days (default), working_days, fixed_30
+		UpdatedBy      string  `json:"updated_by" binding:"required"`
+
+		// Month-over-month variance thresholds; default 10% and 5000, 0 turns one off
+		VariancePercentThreshold *float64 `json:"variance_percent_threshold"`
+		VarianceAmountThreshold  *float64 `json:"variance_amount_threshold"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.RoundingUnit == 0 {
+		req.RoundingUnit = 1
+	}
+
+	if req.ProrationBasis == "" {
+		req.ProrationBasis = "calendar_days"
+	}
+
+	settings := &models.PayrollSettings{
+		OrgID:                    req.OrgID,
+		RoundingMode:             req.RoundingMode,
+		RoundingUnit:             req.RoundingUnit,
+		ProrationBasis:           req.ProrationBasis,
+		VariancePercentThreshold: 10,
+		VarianceAmountThreshold:  5000,
+		UpdatedBy:                &req.UpdatedBy,
+	}
+	if req.VariancePercentThreshold != nil {
+		settings.VariancePercentThreshold = *req.VariancePercentThreshold
+	}
+	if req.VarianceAmountThreshold != nil {
+		settings.VarianceAmountThreshold = *req.VarianceAmountThreshold
+	}
+
+	if err := h.service.UpdatePayrollSettings(settings); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
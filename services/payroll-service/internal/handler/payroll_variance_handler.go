@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// GetVarianceReport compares a payroll run with the previous released run
+// per employee and component, flagging changes above the organization's
+// thresholds with their likely causes
+// @Summary Get variance report
+// @Param flagged query bool false "Only list flagged employees"
+func (h *PayrollHandler) GetVarianceReport(c *gin.Context) {
+	report, err := h.service.GetVarianceReport(c.Param("id"), c.Query("flagged") == "true")
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// AcknowledgeVariances accepts employees' unexplained variances so the run
+// can be finalized
+func (h *PayrollHandler) AcknowledgeVariances(c *gin.Context) {
+	payrollRunID := c.Param("id")
+
+	var req struct {
+		AcknowledgedBy string   `json:"acknowledged_by" binding:"required"`
+		Reason         string   `json:"reason" binding:"required"`
+		EmployeeIDs    []string `json:"employee_ids" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	acks, err := h.service.AcknowledgeVariances(payrollRunID, req.AcknowledgedBy, req.Reason, req.EmployeeIDs)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), errorBody(err))
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"count": len(acks),
+		"data":  acks,
+	})
+}
+
+// GetVarianceAcknowledgements lists a payroll run's variance acknowledgements
+func (h *PayrollHandler) GetVarianceAcknowledgements(c *gin.Context) {
+	acks, err := h.service.GetVarianceAcknowledgements(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(acks),
+		"data":  acks,
+	})
+}
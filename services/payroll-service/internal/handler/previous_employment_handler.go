@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type PreviousEmploymentHandler struct {
+	service *service.PreviousEmploymentService
+}
+
+func NewPreviousEmploymentHandler(service *service.PreviousEmploymentService) *PreviousEmploymentHandler {
+	return &PreviousEmploymentHandler{service: service}
+}
+
+// RegisterPreviousEmploymentRoutes registers Form 12B previous employment routes
+func RegisterPreviousEmploymentRoutes(router *gin.RouterGroup, service *service.PreviousEmploymentService) {
+	handler := NewPreviousEmploymentHandler(service)
+
+	router.GET("/employees/:id/previous-employment", handler.GetPreviousEmployments)
+	router.PUT("/employees/:id/previous-employment", handler.SavePreviousEmployment)
+	router.DELETE("/previous-employment/:id", handler.DeletePreviousEmployment)
+}
+
+// GetPreviousEmployments lists an employee's earlier employers in a financial year
+// @Summary Get previous employment
+// @Param financial_year query int true "Year the financial year starts in"
+func (h *PreviousEmploymentHandler) GetPreviousEmployments(c *gin.Context) {
+	financialYear, err := strconv.Atoi(c.Query("financial_year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "financial_year is required"})
+		return
+	}
+
+	employments, err := h.service.GetPreviousEmployments(c.Param("id"), financialYear)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(employments),
+		"data":  employments,
+	})
+}
+
+// SavePreviousEmployment records an earlier employer's salary and TDS from
+// Form 12B, replacing the one with the same TAN in the financial year
+// @Summary Save previous employment
+func (h *PreviousEmploymentHandler) SavePreviousEmployment(c *gin.Context) {
+	var req struct {
+		OrgID           string  `json:"org_id" binding:"required"`
+		FinancialYear   int     `json:"financial_year" binding:"required"`
+		EmployerName    string  `json:"employer_name" binding:"required"`
+		EmployerTAN     string  `json:"employer_tan" binding:"required"`
+		PeriodFrom      string  `json:"period_from" binding:"required"` // YYYY-MM-DD
+		PeriodTo        string  `json:"period_to" binding:"required"`   // YYYY-MM-DD
+		GrossSalary     float64 `json:"gross_salary"`
+		Exemptions      float64 `json:"exemptions"`
+		PFEmployee      float64 `json:"pf_employee"`
+		ProfessionalTax float64 `json:"professional_tax"`
+		TDS             float64 `json:"tds"`
+		CreatedBy       string  `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	periodFrom, err := time.Parse("2006-01-02", req.PeriodFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period_from format (use YYYY-MM-DD)"})
+		return
+	}
+
+	periodTo, err := time.Parse("2006-01-02", req.PeriodTo)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid period_to format (use YYYY-MM-DD)"})
+		return
+	}
+
+	employment := &models.PreviousEmployment{
+		OrgID:           req.OrgID,
+		EmployeeID:      c.Param("id"),
+		FinancialYear:   req.FinancialYear,
+		EmployerName:    req.EmployerName,
+		EmployerTAN:     req.EmployerTAN,
+		PeriodFrom:      periodFrom,
+		PeriodTo:        periodTo,
+		GrossSalary:     req.GrossSalary,
+		Exemptions:      req.Exemptions,
+		PFEmployee:      req.PFEmployee,
+		ProfessionalTax: req.ProfessionalTax,
+		TDS:             req.TDS,
+		CreatedBy:       &req.CreatedBy,
+	}
+
+	if err := h.service.SavePreviousEmployment(employment); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, employment)
+}
+
+// DeletePreviousEmployment removes an earlier employer recorded in error
+// @Summary Delete previous employment
+func (h *PreviousEmploymentHandler) DeletePreviousEmployment(c *gin.Context) {
+	if err := h.service.DeletePreviousEmployment(c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Previous employment deleted"})
+}
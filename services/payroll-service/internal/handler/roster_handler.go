@@ -0,0 +1,218 @@
+package handler
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type RosterHandler struct {
+	service *service.RosterService
+}
+
+func NewRosterHandler(service *service.RosterService) *RosterHandler {
+	return &RosterHandler{service: service}
+}
+
+// RegisterRosterRoutes registers shift roster and shift allowance routes
+func RegisterRosterRoutes(router *gin.RouterGroup, service *service.RosterService) {
+	handler := NewRosterHandler(service)
+
+	roster := router.Group("/roster")
+	{
+		roster.GET("/shifts", handler.GetShifts)
+		roster.POST("/shifts", handler.IngestShifts)
+		roster.GET("/allowance-policies", handler.GetShiftAllowancePolicies)
+		roster.PUT("/allowance-policies", handler.SaveShiftAllowancePolicy)
+	}
+}
+
+// GetShifts lists roster shifts
+// @Summary Get roster shifts
+// @Param org_id query string true "Organization ID"
+// @Param employee_id query string false "Employee ID"
+// @Param status query string false "Shift status (active, cancelled)"
+// @Param from query string false "From date (YYYY-MM-DD)"
+// @Param to query string false "To date (YYYY-MM-DD)"
+func (h *RosterHandler) GetShifts(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if employeeID := c.Query("employee_id"); employeeID != "" {
+		filters["employee_id"] = employeeID
+	}
+	if status := c.Query("status"); status != "" {
+		filters["status"] = status
+	}
+	if from := c.Query("from"); from != "" {
+		fromDate, err := time.Parse("2006-01-02", from)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from format (use YYYY-MM-DD)"})
+			return
+		}
+		filters["from"] = fromDate
+	}
+	if to := c.Query("to"); to != "" {
+		toDate, err := time.Parse("2006-01-02", to)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to format (use YYYY-MM-DD)"})
+			return
+		}
+		filters["to"] = toDate
+	}
+
+	shifts, err := h.service.GetShifts(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(shifts),
+		"data":  shifts,
+	})
+}
+
+// IngestShifts stores shift assignments exported from the roster
+// @Summary Ingest roster shifts
+func (h *RosterHandler) IngestShifts(c *gin.Context) {
+	var req struct {
+		OrgID  string `json:"org_id" binding:"required"`
+		Shifts []struct {
+			EmployeeID    string `json:"employee_id"`
+			ShiftDate     string `json:"shift_date"` // YYYY-MM-DD
+			ShiftType     string `json:"shift_type"`
+			StartTime     string `json:"start_time"` // HH:MM
+			EndTime       string `json:"end_time"`   // HH:MM
+			ShiftLocation string `json:"shift_location"`
+			Status        string `json:"status"` // active, cancelled (roster "Inactive")
+			Reference     string `json:"reference"`
+		} `json:"shifts" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shifts := make([]models.RosterShift, len(req.Shifts))
+	for i, s := range req.Shifts {
+		shiftDate, err := time.Parse("2006-01-02", s.ShiftDate)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid shift_date format (use YYYY-MM-DD)"})
+			return
+		}
+
+		shifts[i] = models.RosterShift{
+			EmployeeID:      s.EmployeeID,
+			ShiftDate:       shiftDate,
+			ShiftType:       s.ShiftType,
+			StartTime:       s.StartTime,
+			EndTime:         s.EndTime,
+			ShiftLocation:   sql.NullString{String: s.ShiftLocation, Valid: s.ShiftLocation != ""},
+			Status:          s.Status,
+			SourceReference: sql.NullString{String: s.Reference, Valid: s.Reference != ""},
+		}
+	}
+
+	if err := h.service.IngestShifts(req.OrgID, shifts); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Shifts ingested",
+		"count":   len(shifts),
+	})
+}
+
+// GetShiftAllowancePolicies lists the shift allowance policies in force
+// @Summary Get shift allowance policies
+// @Param org_id query string true "Organization ID"
+// @Param as_of query string false "Date (YYYY-MM-DD), defaults to today"
+func (h *RosterHandler) GetShiftAllowancePolicies(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	asOf := time.Now()
+	if value := c.Query("as_of"); value != "" {
+		parsed, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid as_of format (use YYYY-MM-DD)"})
+			return
+		}
+		asOf = parsed
+	}
+
+	policies, err := h.service.GetShiftAllowancePolicies(orgID, asOf)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(policies),
+		"data":  policies,
+	})
+}
+
+// SaveShiftAllowancePolicy creates or replaces a shift type's allowance policy
+// @Summary Save shift allowance policy
+func (h *RosterHandler) SaveShiftAllowancePolicy(c *gin.Context) {
+	var req struct {
+		OrgID          string  `json:"org_id" binding:"required"`
+		ShiftType      string  `json:"shift_type" binding:"required"`
+		AmountPerShift float64 `json:"amount_per_shift"`
+		NightPremium   float64 `json:"night_premium"`
+		MinShifts      int     `json:"min_shifts"`
+		EffectiveFrom  string  `json:"effective_from" binding:"required"` // YYYY-MM-DD
+		EffectiveTill  *string `json:"effective_till"`                    // YYYY-MM-DD
+		UpdatedBy      string  `json:"updated_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid effective_from format (use YYYY-MM-DD)"})
+		return
+	}
+
+	effectiveTill, err := parseOptionalDate(req.EffectiveTill)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid effective_till format (use YYYY-MM-DD)"})
+		return
+	}
+
+	policy := &models.ShiftAllowancePolicy{
+		OrgID:          req.OrgID,
+		ShiftType:      req.ShiftType,
+		AmountPerShift: req.AmountPerShift,
+		NightPremium:   req.NightPremium,
+		MinShifts:      req.MinShifts,
+		EffectiveFrom:  effectiveFrom,
+		EffectiveTill:  effectiveTill,
+		UpdatedBy:      &req.UpdatedBy,
+	}
+
+	if err := h.service.SaveShiftAllowancePolicy(policy); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
@@ -0,0 +1,310 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type StatutoryRuleHandler struct {
+	service *service.StatutoryRuleService
+}
+
+func NewStatutoryRuleHandler(service *service.StatutoryRuleService) *StatutoryRuleHandler {
+	return &StatutoryRuleHandler{service: service}
+}
+
+// RegisterStatutoryRuleRoutes registers statutory rule administration routes
+func RegisterStatutoryRuleRoutes(router *gin.RouterGroup, service *service.StatutoryRuleService) {
+	handler := NewStatutoryRuleHandler(service)
+
+	rules := router.Group("/statutory-rules")
+	{
+		rules.GET("", handler.GetStatutoryRules)
+		rules.POST("", handler.CreateRuleVersion)
+		rules.GET("/:id", handler.GetStatutoryRule)
+		rules.PUT("/:id", handler.UpdateStatutoryRule)
+		rules.DELETE("/:id", handler.DeleteStatutoryRule)
+		rules.POST("/:id/supersede", handler.SupersedeRuleVersion)
+	}
+}
+
+// ruleVersionRequest describes one rule version. PF, ESI and GRATUITY use the
+// rate fields; PT and TDS list their slabs.
+type ruleVersionRequest struct {
+	OrgID         *string `json:"org_id"`
+	RuleType      string  `json:"rule_type"`
+	StateCode     *string `json:"state_code"`
+	EffectiveFrom string  `json:"effective_from" binding:"required"` // YYYY-MM-DD
+	EffectiveTill *string `json:"effective_till"`                    // YYYY-MM-DD
+
+	PFEmployeeRate           *float64 `json:"pf_employee_rate"`
+	PFEmployerRate           *float64 `json:"pf_employer_rate"`
+	PFCeiling                *float64 `json:"pf_ceiling"`
+	ESIEmployeeRate          *float64 `json:"esi_employee_rate"`
+	ESIEmployerRate          *float64 `json:"esi_employer_rate"`
+	ESIWageCeiling           *float64 `json:"esi_wage_ceiling"`
+	ESIThresholdSalary       *float64 `json:"esi_threshold_salary"`
+	GratuityRatePerYear      *float64 `json:"gratuity_rate_per_year"`
+	GratuityCompletionMonths *int     `json:"gratuity_completion_months"`
+
+	Slabs []ruleSlabRequest `json:"slabs"`
+
+	CreatedBy string `json:"created_by" binding:"required"`
+}
+
+type ruleSlabRequest struct {
+	Min    float64  `json:"min"`
+	Max    *float64 `json:"max"`
+	Amount *float64 `json:"amount"` // PT
+	Rate   *float64 `json:"rate"`   // TDS
+}
+
+// toRows expands the request into statutory_rules rows
+func (req *ruleVersionRequest) toRows() ([]models.StatutoryRule, error) {
+	effectiveFrom, err := time.Parse("2006-01-02", req.EffectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid effective_from format (use YYYY-MM-DD)")
+	}
+
+	effectiveTill, err := parseOptionalDate(req.EffectiveTill)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid effective_till format (use YYYY-MM-DD)")
+	}
+
+	base := models.StatutoryRule{
+		OrgID:                    req.OrgID,
+		RuleType:                 req.RuleType,
+		StateCode:                req.StateCode,
+		EffectiveFrom:            effectiveFrom,
+		EffectiveTill:            effectiveTill,
+		PFEmployeeRate:           req.PFEmployeeRate,
+		PFEmployerRate:           req.PFEmployerRate,
+		PFCeiling:                req.PFCeiling,
+		ESIEmployeeRate:          req.ESIEmployeeRate,
+		ESIEmployerRate:          req.ESIEmployerRate,
+		ESIWageCeiling:           req.ESIWageCeiling,
+		ESIThresholdSalary:       req.ESIThresholdSalary,
+		GratuityRatePerYear:      req.GratuityRatePerYear,
+		GratuityCompletionMonths: req.GratuityCompletionMonths,
+		CreatedBy:                &req.CreatedBy,
+	}
+
+	if len(req.Slabs) == 0 {
+		return []models.StatutoryRule{base}, nil
+	}
+
+	rows := make([]models.StatutoryRule, 0, len(req.Slabs))
+	for _, slab := range req.Slabs {
+		row := base
+		min := slab.Min
+		switch req.RuleType {
+		case "TDS":
+			row.TDSSlabMin, row.TDSSlabMax, row.TDSRate = &min, slab.Max, slab.Rate
+		default:
+			row.PTSlabMin, row.PTSlabMax, row.PTAmount = &min, slab.Max, slab.Amount
+		}
+		rows = append(rows, row)
+	}
+
+	return rows, nil
+}
+
+// GetStatutoryRules lists statutory rules
+// @Summary Get statutory rules
+// @Param org_id query string false "Organization ID (includes global rules)"
+// @Param rule_type query string false "Rule type (PF, ESI, PT, TDS, GRATUITY)"
+// @Param state_code query string false "State code"
+// @Param active query bool false "Only active rules"
+func (h *StatutoryRuleHandler) GetStatutoryRules(c *gin.Context) {
+	filters := map[string]interface{}{}
+	if orgID := c.Query("org_id"); orgID != "" {
+		filters["org_id"] = orgID
+	}
+	if ruleType := c.Query("rule_type"); ruleType != "" {
+		filters["rule_type"] = ruleType
+	}
+	if stateCode := c.Query("state_code"); stateCode != "" {
+		filters["state_code"] = stateCode
+	}
+	if c.Query("active") == "true" {
+		filters["active"] = true
+	}
+
+	rules, err := h.service.GetStatutoryRules(filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(rules),
+		"data":  rules,
+	})
+}
+
+// GetStatutoryRule gets a single rule row
+func (h *StatutoryRuleHandler) GetStatutoryRule(c *gin.Context) {
+	rule, err := h.service.GetStatutoryRule(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// CreateRuleVersion creates a new effective-dated rule version
+// @Summary Create statutory rule version
+// @Param request body ruleVersionRequest true "Rule version"
+func (h *StatutoryRuleHandler) CreateRuleVersion(c *gin.Context) {
+	var req ruleVersionRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.RuleType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rule_type is required"})
+		return
+	}
+
+	rows, err := req.toRows()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.service.CreateRuleVersion(rows)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"count": len(created),
+		"data":  created,
+	})
+}
+
+// UpdateStatutoryRule updates a rule row's values and its version's effective_till
+func (h *StatutoryRuleHandler) UpdateStatutoryRule(c *gin.Context) {
+	var req struct {
+		EffectiveTill            *string  `json:"effective_till"` // YYYY-MM-DD, applies to the whole version
+		PFEmployeeRate           *float64 `json:"pf_employee_rate"`
+		PFEmployerRate           *float64 `json:"pf_employer_rate"`
+		PFCeiling                *float64 `json:"pf_ceiling"`
+		ESIEmployeeRate          *float64 `json:"esi_employee_rate"`
+		ESIEmployerRate          *float64 `json:"esi_employer_rate"`
+		ESIWageCeiling           *float64 `json:"esi_wage_ceiling"`
+		ESIThresholdSalary       *float64 `json:"esi_threshold_salary"`
+		PTSlabMin                *float64 `json:"pt_slab_min"`
+		PTSlabMax                *float64 `json:"pt_slab_max"`
+		PTAmount                 *float64 `json:"pt_amount"`
+		TDSSlabMin               *float64 `json:"tds_slab_min"`
+		TDSSlabMax               *float64 `json:"tds_slab_max"`
+		TDSRate                  *float64 `json:"tds_rate"`
+		GratuityRatePerYear      *float64 `json:"gratuity_rate_per_year"`
+		GratuityCompletionMonths *int     `json:"gratuity_completion_months"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	effectiveTill, err := parseOptionalDate(req.EffectiveTill)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid effective_till format (use YYYY-MM-DD)"})
+		return
+	}
+
+	update := models.StatutoryRule{
+		EffectiveTill:            effectiveTill,
+		PFEmployeeRate:           req.PFEmployeeRate,
+		PFEmployerRate:           req.PFEmployerRate,
+		PFCeiling:                req.PFCeiling,
+		ESIEmployeeRate:          req.ESIEmployeeRate,
+		ESIEmployerRate:          req.ESIEmployerRate,
+		ESIWageCeiling:           req.ESIWageCeiling,
+		ESIThresholdSalary:       req.ESIThresholdSalary,
+		PTSlabMin:                req.PTSlabMin,
+		PTSlabMax:                req.PTSlabMax,
+		PTAmount:                 req.PTAmount,
+		TDSSlabMin:               req.TDSSlabMin,
+		TDSSlabMax:               req.TDSSlabMax,
+		TDSRate:                  req.TDSRate,
+		GratuityRatePerYear:      req.GratuityRatePerYear,
+		GratuityCompletionMonths: req.GratuityCompletionMonths,
+	}
+
+	rule, err := h.service.UpdateStatutoryRule(c.Param("id"), update)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteStatutoryRule deactivates a rule row
+func (h *StatutoryRuleHandler) DeleteStatutoryRule(c *gin.Context) {
+	if err := h.service.DeleteStatutoryRule(c.Param("id")); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Statutory rule deactivated successfully"})
+}
+
+// SupersedeRuleVersion replaces a rule version from a new effective date
+// @Summary Supersede statutory rule version
+// @Param request body ruleVersionRequest true "Replacement version (rule_type, org_id and state_code are inherited)"
+func (h *StatutoryRuleHandler) SupersedeRuleVersion(c *gin.Context) {
+	var req ruleVersionRequest
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	existing, err := h.service.GetStatutoryRule(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	req.RuleType = existing.RuleType
+
+	rows, err := req.toRows()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	created, err := h.service.SupersedeRuleVersion(existing.ID, rows)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"count": len(created),
+		"data":  created,
+	})
+}
+
+func parseOptionalDate(value *string) (*time.Time, error) {
+	if value == nil || *value == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse("2006-01-02", *value)
+	if err != nil {
+		return nil, err
+	}
+
+	return &t, nil
+}
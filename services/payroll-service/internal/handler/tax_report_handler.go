@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/service"
+)
+
+type TaxReportHandler struct {
+	service *service.TaxReportService
+}
+
+func NewTaxReportHandler(service *service.TaxReportService) *TaxReportHandler {
+	return &TaxReportHandler{service: service}
+}
+
+// RegisterTaxReportRoutes registers employee income tax certificate routes
+func RegisterTaxReportRoutes(router *gin.RouterGroup, service *service.TaxReportService) {
+	handler := NewTaxReportHandler(service)
+
+	router.GET("/employees/:id/form12ba", handler.GenerateForm12BA)
+	router.GET("/employees/:id/form16", handler.GenerateForm16)
+	router.GET("/statutory-reports/pf-ecr", handler.GeneratePFECR)
+	router.GET("/statutory-reports/esi-challan", handler.GenerateESIChallan)
+	router.POST("/statutory-reports/pf-ecr", handler.QueuePFECR)
+	router.POST("/statutory-reports/esi-challan", handler.QueueESIChallan)
+}
+
+// GenerateForm12BA generates an employee's statement of perquisites
+// @Summary Generate Form 12BA
+// @Param financial_year query int true "Year the financial year starts in"
+func (h *TaxReportHandler) GenerateForm12BA(c *gin.Context) {
+	financialYear, err := strconv.Atoi(c.Query("financial_year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "financial_year is required"})
+		return
+	}
+
+	form, err := h.service.GenerateForm12BA(c.Param("id"), financialYear)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+// GenerateForm16 generates an employee's annual TDS certificate
+// @Summary Generate Form 16
+// @Param financial_year query int true "Year the financial year starts in"
+func (h *TaxReportHandler) GenerateForm16(c *gin.Context) {
+	financialYear, err := strconv.Atoi(c.Query("financial_year"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "financial_year is required"})
+		return
+	}
+
+	form, err := h.service.GenerateForm16(c.Param("id"), financialYear)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, form)
+}
+
+// GeneratePFECR generates an organization's monthly PF return
+// @Summary Generate PF ECR
+// @Param org_id query string true "Organization ID"
+// @Param payroll_month query string true "Payroll month (YYYY-MM)"
+func (h *TaxReportHandler) GeneratePFECR(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	ecr, err := h.service.GeneratePFECR(orgID, c.Query("payroll_month"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, ecr)
+}
+
+// GenerateESIChallan generates an organization's monthly ESI challan
+// @Summary Generate ESI challan
+// @Param org_id query string true "Organization ID"
+// @Param payroll_month query string true "Payroll month (YYYY-MM)"
+func (h *TaxReportHandler) GenerateESIChallan(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	challan, err := h.service.GenerateESIChallan(orgID, c.Query("payroll_month"))
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, challan)
+}
+
+// QueuePFECR queues the generation of a monthly PF ECR and returns the job
+// at once; the job's result is the return
+// @Summary Queue PF ECR
+func (h *TaxReportHandler) QueuePFECR(c *gin.Context) {
+	h.queueStatutoryReport(c, service.JobTypePFECR)
+}
+
+// QueueESIChallan queues the generation of a monthly ESI challan and returns
+// the job at once; the job's result is the challan
+// @Summary Queue ESI challan
+func (h *TaxReportHandler) QueueESIChallan(c *gin.Context) {
+	h.queueStatutoryReport(c, service.JobTypeESIChallan)
+}
+
+func (h *TaxReportHandler) queueStatutoryReport(c *gin.Context, jobType string) {
+	var req struct {
+		OrgID        string `json:"org_id" binding:"required"`
+		PayrollMonth string `json:"payroll_month" binding:"required"` // YYYY-MM
+		RequestedBy  string `json:"requested_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	job, err := h.service.EnqueueStatutoryReport(req.OrgID, jobType, req.PayrollMonth, req.RequestedBy)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusNotFound), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, jobAccepted("Report generation queued", job))
+}
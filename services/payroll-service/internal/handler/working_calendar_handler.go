@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"payroll-service/internal/models"
+	"payroll-service/internal/service"
+)
+
+type WorkingCalendarHandler struct {
+	service *service.WorkingCalendarService
+}
+
+func NewWorkingCalendarHandler(service *service.WorkingCalendarService) *WorkingCalendarHandler {
+	return &WorkingCalendarHandler{service: service}
+}
+
+// RegisterWorkingCalendarRoutes registers holiday calendar, weekly off and working day routes
+func RegisterWorkingCalendarRoutes(router *gin.RouterGroup, service *service.WorkingCalendarService) {
+	handler := NewWorkingCalendarHandler(service)
+
+	calendars := router.Group("/holiday-calendars")
+	{
+		calendars.GET("", handler.GetHolidayCalendars)
+		calendars.POST("", handler.CreateHolidayCalendar)
+		calendars.GET("/:id", handler.GetHolidayCalendar)
+		calendars.PUT("/:id/holidays", handler.ReplaceHolidays)
+	}
+
+	router.GET("/weekly-off-policies", handler.GetWeeklyOffPolicy)
+	router.PUT("/weekly-off-policies", handler.SaveWeeklyOffPolicy)
+	router.GET("/working-days", handler.GetWorkingDays)
+}
+
+// holidayRequest is one holiday in a calendar request
+type holidayRequest struct {
+	Date string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name string `json:"name" binding:"required"`
+	Type string `json:"type"` // mandatory (default), restricted
+}
+
+// parseHolidays converts requested holidays, returning the first invalid date
+func parseHolidays(requests []holidayRequest) ([]models.Holiday, string) {
+	holidays := make([]models.Holiday, len(requests))
+	for i, req := range requests {
+		date, err := time.Parse("2006-01-02", req.Date)
+		if err != nil {
+			return nil, req.Date
+		}
+		holidays[i] = models.Holiday{
+			HolidayDate: date,
+			Name:        req.Name,
+			HolidayType: req.Type,
+		}
+	}
+	return holidays, ""
+}
+
+// GetHolidayCalendars lists an organization's holiday calendars
+// @Summary Get holiday calendars
+// @Param org_id query string true "Organization ID"
+// @Param location query string false "Location (empty for the organization-wide calendar)"
+// @Param year query int false "Calendar year"
+func (h *WorkingCalendarHandler) GetHolidayCalendars(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	filters := map[string]interface{}{}
+	if location, ok := c.GetQuery("location"); ok {
+		filters["location"] = location
+	}
+	if year := c.Query("year"); year != "" {
+		y, err := strconv.Atoi(year)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid year"})
+			return
+		}
+		filters["year"] = y
+	}
+
+	calendars, err := h.service.GetHolidayCalendars(orgID, filters)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"count": len(calendars),
+		"data":  calendars,
+	})
+}
+
+// CreateHolidayCalendar creates a location's holiday calendar for a year
+// @Summary Create holiday calendar
+func (h *WorkingCalendarHandler) CreateHolidayCalendar(c *gin.Context) {
+	var req struct {
+		OrgID        string           `json:"org_id" binding:"required"`
+		Location     string           `json:"location"` // Empty for the organization-wide calendar
+		CalendarYear int              `json:"calendar_year" binding:"required"`
+		Name         string           `json:"name" binding:"required"`
+		Holidays     []holidayRequest `json:"holidays"`
+		CreatedBy    string           `json:"created_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	holidays, invalidDate := parseHolidays(req.Holidays)
+	if invalidDate != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holiday date " + invalidDate + " (use YYYY-MM-DD)"})
+		return
+	}
+
+	cal := &models.HolidayCalendar{
+		OrgID:        req.OrgID,
+		Location:     req.Location,
+		CalendarYear: req.CalendarYear,
+		Name:         req.Name,
+		Holidays:     holidays,
+		CreatedBy:    &req.CreatedBy,
+	}
+
+	if err := h.service.CreateHolidayCalendar(cal); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, cal)
+}
+
+// GetHolidayCalendar gets a holiday calendar with its holidays
+func (h *WorkingCalendarHandler) GetHolidayCalendar(c *gin.Context) {
+	cal, err := h.service.GetHolidayCalendar(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cal)
+}
+
+// ReplaceHolidays replaces all holidays of a calendar
+// @Summary Replace holidays
+func (h *WorkingCalendarHandler) ReplaceHolidays(c *gin.Context) {
+	var req struct {
+		Holidays []holidayRequest `json:"holidays"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	holidays, invalidDate := parseHolidays(req.Holidays)
+	if invalidDate != "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid holiday date " + invalidDate + " (use YYYY-MM-DD)"})
+		return
+	}
+
+	cal, err := h.service.ReplaceHolidays(c.Param("id"), holidays)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, cal)
+}
+
+// GetWeeklyOffPolicy gets the weekly off policy in force at a location
+// @Summary Get weekly off policy
+// @Param org_id query string true "Organization ID"
+// @Param location query string false "Location"
+func (h *WorkingCalendarHandler) GetWeeklyOffPolicy(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	policy, err := h.service.GetWeeklyOffPolicy(orgID, c.Query("location"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// SaveWeeklyOffPolicy creates or replaces a location's weekly off policy
+// @Summary Save weekly off policy
+func (h *WorkingCalendarHandler) SaveWeeklyOffPolicy(c *gin.Context) {
+	var req struct {
+		OrgID           string  `json:"org_id" binding:"required"`
+		Location        string  `json:"location"`                   // Empty for the organization-wide policy
+		Pattern         string  `json:"pattern" binding:"required"` // sunday_only, alternate_saturdays, rotating
+		SaturdayWeeks   []int64 `json:"saturday_weeks"`
+		RotationAnchor  *string `json:"rotation_anchor"` // YYYY-MM-DD
+		RotationCycle   *int    `json:"rotation_cycle"`
+		RotationOffDays []int64 `json:"rotation_off_days"`
+		UpdatedBy       string  `json:"updated_by" binding:"required"`
+	}
+
+	if err := c.BindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rotationAnchor, err := parseOptionalDate(req.RotationAnchor)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid rotation_anchor format (use YYYY-MM-DD)"})
+		return
+	}
+
+	policy := &models.WeeklyOffPolicy{
+		OrgID:           req.OrgID,
+		Location:        req.Location,
+		Pattern:         req.Pattern,
+		SaturdayWeeks:   req.SaturdayWeeks,
+		RotationAnchor:  rotationAnchor,
+		RotationCycle:   req.RotationCycle,
+		RotationOffDays: req.RotationOffDays,
+		UpdatedBy:       &req.UpdatedBy,
+	}
+
+	if err := h.service.SaveWeeklyOffPolicy(policy); err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// GetWorkingDays classifies every day of a period as working, weekly off or holiday
+// @Summary Get working days
+// @Param org_id query string true "Organization ID"
+// @Param location query string false "Location"
+// @Param employee_id query string false "Employee ID (uses the employee's location)"
+// @Param from query string true "From date (YYYY-MM-DD)"
+// @Param to query string true "To date (YYYY-MM-DD)"
+func (h *WorkingCalendarHandler) GetWorkingDays(c *gin.Context) {
+	orgID := c.Query("org_id")
+	if orgID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "org_id is required"})
+		return
+	}
+
+	from, err := time.Parse("2006-01-02", c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid from format (use YYYY-MM-DD)"})
+		return
+	}
+
+	to, err := time.Parse("2006-01-02", c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid to format (use YYYY-MM-DD)"})
+		return
+	}
+
+	summary, err := h.service.GetWorkingDays(orgID, c.Query("location"), c.Query("employee_id"), from, to)
+	if err != nil {
+		c.JSON(errorStatus(err, http.StatusInternalServerError), gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
@@ -2,6 +2,7 @@ package models
 
 import (
 	"database/sql"
+	"encoding/json"
 	"time"
 )
 
@@ -15,6 +16,11 @@ type Organization struct {
 	RegistrationNumber string    `json:"registration_number"`
 	PAN                string    `json:"pan"`
 	GSTNumber          string    `json:"gst_number"`
+	BankName           sql.NullString `json:"bank_name"`
+	BankAccountNumber  sql.NullString `json:"bank_account_number"`
+	BankIFSCCode       sql.NullString `json:"bank_ifsc_code"`
+	NPSRegistrationNumber sql.NullString `json:"nps_registration_number"` // Corporate NPS (CHO/CBO) registration
+	EligibleStartup    bool      `json:"eligible_startup"` // Section 80-IAC start-up; tax on equity perquisites is deferred
 	IsActive           bool      `json:"is_active"`
 	CreatedAt          time.Time `json:"created_at"`
 	UpdatedAt          time.Time `json:"updated_at"`
@@ -38,6 +44,8 @@ type Employee struct {
 	ManagerID           *string        `json:"manager_id"`
 	Location            sql.NullString `json:"location"`
 	PersonalPAN         sql.NullString `json:"personal_pan"`
+	PANStatus           string         `json:"pan_status"` // pending, verified, name_mismatch, inoperative
+	PANVerifiedAt       *time.Time     `json:"pan_verified_at"`
 	AadhaarNumber       sql.NullString `json:"aadhaar_number"` // Encrypted
 	PassportNumber      sql.NullString `json:"passport_number"`
 	BankName            sql.NullString `json:"bank_name"`
@@ -46,6 +54,8 @@ type Employee struct {
 	BankAccountHolder   sql.NullString `json:"bank_account_holder_name"`
 	PhoneNumber         sql.NullString `json:"phone_number"`
 	PersonalEmail       sql.NullString `json:"personal_email"`
+	PayGroupID          *string        `json:"pay_group_id"` // nil means the org's default pay group
+	TaxRegime           string         `json:"tax_regime"`   // old, new
 	CreatedAt           time.Time      `json:"created_at"`
 	UpdatedAt           time.Time      `json:"updated_at"`
 	CreatedBy           *string        `json:"created_by"`
@@ -65,6 +75,7 @@ type SalaryStructure struct {
 	MonthlyDA             float64         `json:"monthly_da"`
 	MonthlyHRA            float64         `json:"monthly_hra"`
 	MonthlyAllowance      float64         `json:"monthly_allowance"`
+	MonthlySuperannuation float64         `json:"monthly_superannuation"` // Employer contribution to a superannuation fund
 	IsTemplate            bool            `json:"is_template"`
 	IsActive              bool            `json:"is_active"`
 	CreatedAt             time.Time       `json:"created_at"`
@@ -76,10 +87,14 @@ type SalaryStructure struct {
 type PayrollRun struct {
 	ID                 string     `json:"id"`
 	OrgID              string     `json:"org_id"`
+	PayGroupID         string     `json:"pay_group_id"`
+	PeriodKey          string     `json:"period_key"` // e.g. 2024-01, 2024-01-2, 2024-W05, 2024-F03
+	RunType            string     `json:"run_type"`   // regular, supplementary, bonus, full_and_final, reversal
+	ReversesRunID      *string    `json:"reverses_payroll_run_id,omitempty"`
 	PayrollPeriodStart time.Time  `json:"payroll_period_start"`
 	PayrollPeriodEnd   time.Time  `json:"payroll_period_end"`
-	PayrollMonth       string     `json:"payroll_month"` // YYYY-MM
-	Status             string     `json:"status"`        // draft, in_progress, dry_run, finalized, locked, released
+	PayrollMonth       string     `json:"payroll_month"` // YYYY-MM the period is attributed to (month of period end)
+	Status             string     `json:"status"`        // draft, in_progress, dry_run, finalized, locked, reopened, released
 	DryRunCount        int        `json:"dry_run_count"`
 	TotalEmployees     int        `json:"total_employees"`
 	TotalGrossAmount   *float64   `json:"total_gross_amount"`
@@ -97,6 +112,7 @@ type PayrollRun struct {
 	ApprovedBy         *string    `json:"approved_by"`
 	ReleasedAt         *time.Time `json:"released_at"`
 	ReleasedBy         *string    `json:"released_by"`
+	BankFileGeneratedAt *time.Time `json:"bank_file_generated_at"` // Salaries sent to the bank; the run can no longer change
 	CreatedAt          time.Time  `json:"created_at"`
 	UpdatedAt          time.Time  `json:"updated_at"`
 	CreatedBy          *string    `json:"created_by"`
@@ -118,16 +134,42 @@ type PayrollComponent struct {
 	DAAmount           float64    `json:"dearness_allowance"`
 	HRAAmount          float64    `json:"house_rent_allowance"`
 	OtherAllowances    float64    `json:"other_allowances"`
+	ShiftAllowance     float64    `json:"shift_allowance"`
+	LOPDays            int        `json:"lop_days"`
+	LOPAmount          float64    `json:"lop_amount"`   // Already left out of the earnings above
+	LOPReversal        float64    `json:"lop_reversal"` // Paid back for earlier months
+	FBPFixed           float64    `json:"fbp_fixed"`            // Fixed-payout flexible benefits
+	FBPClaims          float64    `json:"fbp_claims"`           // Flexible benefit claims paid
+	FBPUnclaimedPayout float64    `json:"fbp_unclaimed_payout"` // Year-end payout of unclaimed flexible benefits, taxable
+	FBPAccrued         float64    `json:"fbp_accrued"`          // Claim-based flexible benefits earned, not paid
+	FBPExempt          float64    `json:"fbp_exempt"`           // Flexible benefits exempt from tax
+	OneTimePayment     float64    `json:"one_time_payment"`     // Bonus or settlement amount of an off-cycle run
 	GrossAmount        float64    `json:"gross_amount"`
 	PFEmployee         float64    `json:"pf_employee"`
 	PFEmployer         float64    `json:"pf_employer"`
 	ESIEmployee        float64    `json:"esi_employee"`
 	ESIEmployer        float64    `json:"esi_employer"`
 	ProfessionalTax    float64    `json:"professional_tax"`
+	NPSEmployee            float64 `json:"nps_employee"`
+	NPSEmployer            float64 `json:"nps_employer"`
+	NPS80CCD2              float64 `json:"nps_80ccd2"`              // Employer NPS deducted from taxable income
+	SuperannuationEmployer float64 `json:"superannuation_employer"`
+	RetirementPerquisite   float64 `json:"retirement_perquisite"`   // Employer PF, NPS and superannuation above the yearly cap
+	EquityPerquisite         float64 `json:"equity_perquisite"`          // ESOP exercises and RSU vestings, not paid in cash
+	EquityPerquisiteDeferred float64 `json:"equity_perquisite_deferred"` // Part of equity_perquisite whose tax is deferred
+	EquityDeferredTaxed      float64 `json:"equity_deferred_taxed"`      // Earlier deferred perquisites taxed this period
 	TDS                float64    `json:"tds"`
 	AdvanceRecovery    float64    `json:"advance_recovery"`
 	LoanRecovery       float64    `json:"loan_recovery"`
 	OtherDeductions    float64    `json:"other_deductions"`
+	CourtOrderDeduction   float64 `json:"court_order_deduction"`
+	CarryForwardRecovered float64 `json:"carry_forward_recovered"` // Recovered from earlier runs' shortfall
+	DeductionShortfall    float64 `json:"deduction_shortfall"`     // Deferred to the next run
+	RoundingAdjustment    float64 `json:"rounding_adjustment"`     // Positive is an earning, negative a deduction
+	PFWage                float64 `json:"pf_wage"`                 // Wage PF was calculated on, after the monthly ceiling
+	ESIWage               float64 `json:"esi_wage"`                // Wage ESI was calculated on, after the monthly ceiling
+	TaxableIncome         float64 `json:"taxable_income"`          // Income the TDS slab was applied to
+	HoldStatus            string  `json:"hold_status,omitempty"`   // From payroll_holds: active, lapsed, released, paid
 	TotalDeductions    float64    `json:"total_deductions"`
 	NetPay             float64    `json:"net_pay"`
 	IsValidated        bool       `json:"is_validated"`
@@ -139,6 +181,581 @@ type PayrollComponent struct {
 	CreatedBy          *string    `json:"created_by"`
 }
 
+// PayrollRunEmployee selects an employee for an off-cycle payroll run
+type PayrollRunEmployee struct {
+	ID           string    `json:"id"`
+	PayrollRunID string    `json:"payroll_run_id"`
+	EmployeeID   string    `json:"employee_id"`
+	Amount       *float64  `json:"amount"`      // Paid as a one-time payment; required for bonus runs
+	Description  *string   `json:"description"` // e.g. Diwali bonus, leave encashment
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PayrollRunOutcome records the result of calculating an employee in a run
+type PayrollRunOutcome struct {
+	ID                 string    `json:"id"`
+	OrgID              string    `json:"org_id"`
+	PayrollRunID       string    `json:"payroll_run_id"`
+	EmployeeID         string    `json:"employee_id"`
+	Status             string    `json:"status"`     // success, skipped, failed
+	ErrorCode          *string   `json:"error_code"` // e.g. no_salary_structure, validation_failed
+	ErrorMessage       *string   `json:"error_message"`
+	PayrollComponentID *string   `json:"payroll_component_id"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+}
+
+// PayrollDryRun is a sandboxed calculation of a payroll run, kept to compare
+// the next dry run with
+type PayrollDryRun struct {
+	ID                 string    `json:"id"`
+	OrgID              string    `json:"org_id"`
+	PayrollRunID       string    `json:"payroll_run_id"`
+	DryRunNumber       int       `json:"dry_run_number"`
+	EmployeesSucceeded int       `json:"employees_succeeded"`
+	EmployeesSkipped   int       `json:"employees_skipped"`
+	EmployeesFailed    int       `json:"employees_failed"`
+	TotalGrossAmount   float64   `json:"total_gross_amount"`
+	TotalDeductions    float64   `json:"total_deductions"`
+	TotalNetPay        float64   `json:"total_net_pay"`
+	Snapshot           string    `json:"-"` // JSON array of the components calculated
+	Findings           string    `json:"-"` // JSON array of the employees not calculated cleanly
+	RequestedBy        string    `json:"requested_by"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// PayrollVarianceAcknowledgement accepts an employee's unexplained variance
+// from the previous released run so the run can be finalized
+type PayrollVarianceAcknowledgement struct {
+	ID             string    `json:"id"`
+	OrgID          string    `json:"org_id"`
+	PayrollRunID   string    `json:"payroll_run_id"`
+	EmployeeID     string    `json:"employee_id"`
+	NetPay         float64   `json:"net_pay"` // Net pay acknowledged
+	Reason         string    `json:"reason"`
+	AcknowledgedBy string    `json:"acknowledged_by"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+// Job is a unit of background work, such as initiating a payroll run or
+// generating a file, picked up by a job worker
+type Job struct {
+	ID              string          `json:"id"`
+	OrgID           string          `json:"org_id"`
+	JobType         string          `json:"job_type"`
+	Payload         json.RawMessage `json:"payload"`
+	LockKey         *string         `json:"lock_key,omitempty"`
+	Status          string          `json:"status"` // queued, running, completed, failed, cancelled
+	ProgressDone    int             `json:"progress_done"`
+	ProgressTotal   int             `json:"progress_total"`
+	Result          json.RawMessage `json:"result,omitempty"`
+	Error           *string         `json:"error"`
+	Attempts        int             `json:"attempts"`
+	MaxAttempts     int             `json:"max_attempts"`
+	RunAfter        time.Time       `json:"run_after"`
+	LeaseOwner      *string         `json:"lease_owner,omitempty"`
+	LeaseExpiresAt  *time.Time      `json:"lease_expires_at,omitempty"`
+	CancelRequested bool            `json:"cancel_requested"`
+	CreatedBy       *string         `json:"created_by"`
+	CreatedAt       time.Time       `json:"created_at"`
+	StartedAt       *time.Time      `json:"started_at"`
+	FinishedAt      *time.Time      `json:"finished_at"`
+	UpdatedAt       time.Time       `json:"updated_at"`
+}
+
+// CalculationAudit stores the working behind a payroll component
+type CalculationAudit struct {
+	ID                 string         `json:"id"`
+	OrgID              string         `json:"org_id"`
+	PayrollRunID       string         `json:"payroll_run_id"`
+	PayrollComponentID string         `json:"payroll_component_id"`
+	EmployeeID         string         `json:"employee_id"`
+	Inputs             string         `json:"inputs"`        // JSON object
+	RuleVersions       string         `json:"rule_versions"` // JSON array
+	Steps              string         `json:"steps"`         // JSON array
+	Validations        sql.NullString `json:"validations"`   // JSON array
+	Status             string         `json:"status"`
+	CalculatedBy       *string        `json:"calculated_by"`
+	CalculatedAt       time.Time      `json:"calculated_at"`
+}
+
+// DeductionLedgerEntry moves an employee's unrecovered deduction balance.
+// "deferred" entries add to the balance, "recovered" entries clear it.
+type DeductionLedgerEntry struct {
+	ID                 string    `json:"id"`
+	OrgID              string    `json:"org_id"`
+	EmployeeID         string    `json:"employee_id"`
+	PayrollRunID       string    `json:"payroll_run_id"`
+	PayrollComponentID string    `json:"payroll_component_id"`
+	Category           string    `json:"category"`   // court_order, loan, advance, other
+	EntryType          string    `json:"entry_type"` // deferred, recovered
+	Amount             float64   `json:"amount"`
+	CreatedAt          time.Time `json:"created_at"`
+}
+
+// PayrollSettings holds organization-level payroll preferences
+type PayrollSettings struct {
+	ID           string    `json:"id"`
+	OrgID        string    `json:"org_id"`
+	RoundingMode string    `json:"rounding_mode"` // none, nearest, up, down
+	RoundingUnit float64   `json:"rounding_unit"` // 1, 5, 10
+	ProrationBasis string  `json:"proration_basis"` // calendar_days, working_days, fixed_30
+	VariancePercentThreshold float64 `json:"variance_percent_threshold"` // 0 turns it off
+	VarianceAmountThreshold  float64 `json:"variance_amount_threshold"`  // 0 turns it off
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	UpdatedBy    *string   `json:"updated_by"`
+}
+
+// PayrollHold withholds one employee's salary from a payroll run's bank file
+type PayrollHold struct {
+	ID                         string     `json:"id"`
+	OrgID                      string     `json:"org_id"`
+	PayrollRunID               string     `json:"payroll_run_id"`
+	PayrollComponentID         string     `json:"payroll_component_id"`
+	EmployeeID                 string     `json:"employee_id"`
+	ReasonCode                 string     `json:"reason_code"` // absconding, exit_clearance, bank_mismatch, other
+	Reason                     sql.NullString `json:"reason"`
+	Status                     string     `json:"status"` // active, released, paid, withdrawn
+	HeldBy                     string     `json:"held_by"`
+	HeldAt                     time.Time  `json:"held_at"`
+	ExpiresAt                  *time.Time `json:"expires_at"` // Hold lapses after this date
+	ReleasedBy                 *string    `json:"released_by"`
+	ReleasedAt                 *time.Time `json:"released_at"`
+	ReleaseNotes               sql.NullString `json:"release_notes"`
+	SupplementaryFileReference sql.NullString `json:"supplementary_file_reference"`
+	CreatedAt                  time.Time  `json:"created_at"`
+	UpdatedAt                  time.Time  `json:"updated_at"`
+}
+
+// PayGroup is a set of employees paid on the same frequency and calendar
+type PayGroup struct {
+	ID         string     `json:"id"`
+	OrgID      string     `json:"org_id"`
+	Name       string     `json:"name"`
+	Frequency  string     `json:"frequency"`   // monthly, semi_monthly, fortnightly, weekly
+	AnchorDate *time.Time `json:"anchor_date"` // First day of any period (weekly and fortnightly)
+	IsDefault  bool       `json:"is_default"`  // Employees without a pay group belong here
+	IsActive   bool       `json:"is_active"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+	CreatedBy  *string    `json:"created_by"`
+}
+
+// PayGroupPeriod is one period of a pay group's calendar
+type PayGroupPeriod struct {
+	ID           string     `json:"id"`
+	PayGroupID   string     `json:"pay_group_id"`
+	PeriodKey    string     `json:"period_key"`
+	PeriodStart  time.Time  `json:"period_start"`
+	PeriodEnd    time.Time  `json:"period_end"`
+	PayrollMonth string     `json:"payroll_month"` // YYYY-MM the period counts against
+	PaymentDate  *time.Time `json:"payment_date"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
+
+// MonthToDateTotals sums an employee's components from other runs attributed
+// to the same payroll month
+type MonthToDateTotals struct {
+	Components      int     `json:"components"`
+	DaysWorked      int     `json:"days_worked"`
+	GrossAmount     float64 `json:"gross_amount"`
+	OneTimePayment  float64 `json:"one_time_payment"`
+	PFWage          float64 `json:"pf_wage"`
+	ESIWage         float64 `json:"esi_wage"`
+	ESIEmployee     float64 `json:"esi_employee"`
+	ProfessionalTax float64 `json:"professional_tax"`
+	TaxableIncome   float64 `json:"taxable_income"`
+	TDS             float64 `json:"tds"`
+}
+
+// RosterShift is one shift assigned to an employee on the roster
+type RosterShift struct {
+	ID              string         `json:"id"`
+	OrgID           string         `json:"org_id"`
+	EmployeeID      string         `json:"employee_id"`
+	ShiftDate       time.Time      `json:"shift_date"`
+	ShiftType       string         `json:"shift_type"`
+	StartTime       string         `json:"start_time"` // HH:MM
+	EndTime         string         `json:"end_time"`   // HH:MM, before start_time when the shift crosses midnight
+	ShiftLocation   sql.NullString `json:"shift_location"`
+	Status          string         `json:"status"` // active, cancelled
+	SourceReference sql.NullString `json:"source_reference"` // Roster shift assignment name
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+}
+
+// ShiftAllowancePolicy sets the allowance paid for each shift of a shift type
+type ShiftAllowancePolicy struct {
+	ID             string     `json:"id"`
+	OrgID          string     `json:"org_id"`
+	ShiftType      string     `json:"shift_type"`
+	AmountPerShift float64    `json:"amount_per_shift"`
+	NightPremium   float64    `json:"night_premium"` // Added for each night shift
+	MinShifts      int        `json:"min_shifts"`    // Nothing is paid below this many shifts in a period
+	EffectiveFrom  time.Time  `json:"effective_from"`
+	EffectiveTill  *time.Time `json:"effective_till"`
+	IsActive       bool       `json:"is_active"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+	UpdatedBy      *string    `json:"updated_by"`
+}
+
+// LOPReversal pays back loss of pay wrongly deducted in an earlier month
+type LOPReversal struct {
+	ID                 string         `json:"id"`
+	OrgID              string         `json:"org_id"`
+	EmployeeID         string         `json:"employee_id"`
+	LOPMonth           string         `json:"lop_month"` // YYYY-MM the days were deducted in
+	Days               int            `json:"days"`
+	Reason             string         `json:"reason"`
+	Status             string         `json:"status"` // pending, applied, cancelled
+	PayrollRunID       sql.NullString `json:"payroll_run_id"`
+	PayrollComponentID sql.NullString `json:"payroll_component_id"`
+	Amount             *float64       `json:"amount"` // Set when applied
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	CreatedBy          *string        `json:"created_by"`
+}
+
+// EmployeeDeduction is a loan, advance, court order or other deduction
+// recovered from an employee in instalments
+type EmployeeDeduction struct {
+	ID          string    `json:"id"`
+	OrgID       string    `json:"org_id"`
+	EmployeeID  string    `json:"employee_id"`
+	Category    string    `json:"category"` // court_order, loan, advance, other
+	Description string    `json:"description"`
+	Instalment  float64   `json:"instalment"`   // Raised per payroll month
+	TotalAmount *float64  `json:"total_amount"` // Nil runs until cancelled
+	StartMonth  string    `json:"start_month"`  // YYYY-MM of the first instalment
+	Status      string    `json:"status"`       // active, cancelled
+	Raised      float64   `json:"raised"`       // Instalments of locked and released runs
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	CreatedBy   *string   `json:"created_by"`
+}
+
+// NPSEnrolment is an employee's corporate NPS enrolment
+type NPSEnrolment struct {
+	ID            string     `json:"id"`
+	OrgID         string     `json:"org_id"`
+	EmployeeID    string     `json:"employee_id"`
+	PRAN          string     `json:"pran"`          // Permanent Retirement Account Number
+	EmployerRate  float64    `json:"employer_rate"` // % of Basic + DA
+	EmployeeRate  float64    `json:"employee_rate"` // % of Basic + DA, 0 when the employee does not contribute
+	EffectiveFrom time.Time  `json:"effective_from"`
+	EffectiveTill *time.Time `json:"effective_till"`
+	IsActive      bool       `json:"is_active"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
+	CreatedBy     *string    `json:"created_by"`
+}
+
+// FBPPlanComponent is one component of a salary structure's flexible benefit plan
+type FBPPlanComponent struct {
+	ID                string    `json:"id"`
+	OrgID             string    `json:"org_id"`
+	SalaryStructureID string    `json:"salary_structure_id"`
+	Component         string    `json:"component"`    // meal_card, telephone, lta, books_periodicals, fuel
+	AnnualLimit       float64   `json:"annual_limit"` // Most an employee may allocate in a year
+	PayoutType        string    `json:"payout_type"`  // fixed, claim
+	IsActive          bool      `json:"is_active"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+	UpdatedBy         *string   `json:"updated_by"`
+}
+
+// FBPDeclaration is the amount an employee allocates to an FBP component for a year
+type FBPDeclaration struct {
+	ID            string    `json:"id"`
+	OrgID         string    `json:"org_id"`
+	EmployeeID    string    `json:"employee_id"`
+	FinancialYear int       `json:"financial_year"` // Year the financial year starts in
+	Component     string    `json:"component"`
+	AnnualAmount  float64   `json:"annual_amount"`
+	PayoutType    string    `json:"payout_type"` // Copied from the plan when declared
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	CreatedBy     *string   `json:"created_by"`
+}
+
+// FBPClaim is a bill claimed against a claim-based FBP component
+type FBPClaim struct {
+	ID                 string         `json:"id"`
+	OrgID              string         `json:"org_id"`
+	EmployeeID         string         `json:"employee_id"`
+	FinancialYear      int            `json:"financial_year"`
+	Component          string         `json:"component"`
+	ClaimDate          time.Time      `json:"claim_date"`
+	Amount             float64        `json:"amount"`
+	BillReference      sql.NullString `json:"bill_reference"`
+	Description        sql.NullString `json:"description"`
+	Status             string         `json:"status"` // submitted, approved, rejected, paid
+	ReviewedBy         *string        `json:"reviewed_by"`
+	ReviewedAt         *time.Time     `json:"reviewed_at"`
+	PayrollRunID       sql.NullString `json:"payroll_run_id"`
+	PayrollComponentID sql.NullString `json:"payroll_component_id"`
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	CreatedBy          *string        `json:"created_by"`
+}
+
+// SalaryMonthTotals sums an employee's components of locked and released
+// runs attributed to one payroll month
+type SalaryMonthTotals struct {
+	PayrollMonth             string  `json:"payroll_month"`
+	DaysWorked               int     `json:"days_worked"`
+	GrossAmount              float64 `json:"gross_amount"`
+	BasicPay                 float64 `json:"basic_pay"`
+	DAAmount                 float64 `json:"da_amount"`
+	PFEmployee               float64 `json:"pf_employee"`
+	ESIEmployee              float64 `json:"esi_employee"`
+	ProfessionalTax          float64 `json:"professional_tax"`
+	TDS                      float64 `json:"tds"`
+	TotalDeductions          float64 `json:"total_deductions"`
+	NetPay                   float64 `json:"net_pay"`
+	RetirementPerquisite     float64 `json:"retirement_perquisite"`
+	EquityPerquisite         float64 `json:"equity_perquisite"`
+	EquityPerquisiteDeferred float64 `json:"equity_perquisite_deferred"`
+	EquityDeferredTaxed      float64 `json:"equity_deferred_taxed"`
+}
+
+// StatutoryMonthTotals sums an employee's contributions across every locked
+// and released run, regular or off-cycle, attributed to one payroll month
+type StatutoryMonthTotals struct {
+	EmployeeID      string  `json:"employee_id"`
+	Runs            int     `json:"runs"`
+	PFWage          float64 `json:"pf_wage"`
+	PFEmployee      float64 `json:"pf_employee"`
+	PFEmployer      float64 `json:"pf_employer"`
+	ESIWage         float64 `json:"esi_wage"`
+	ESIEmployee     float64 `json:"esi_employee"`
+	ESIEmployer     float64 `json:"esi_employer"`
+	ProfessionalTax float64 `json:"professional_tax"`
+	TDS             float64 `json:"tds"`
+}
+
+// EquityGrant is an award of stock options or restricted stock units
+type EquityGrant struct {
+	ID            string         `json:"id"`
+	OrgID         string         `json:"org_id"`
+	EmployeeID    string         `json:"employee_id"`
+	GrantNumber   string         `json:"grant_number"`
+	GrantType     string         `json:"grant_type"` // esop, rsu
+	PlanName      sql.NullString `json:"plan_name"`
+	GrantDate     time.Time      `json:"grant_date"`
+	Units         float64        `json:"units"`
+	ExercisePrice float64        `json:"exercise_price"` // Per unit; usually 0 for RSUs
+	Status        string         `json:"status"`         // active, cancelled
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	CreatedBy     *string        `json:"created_by"`
+}
+
+// EquityEvent is a vesting, exercise or sale under an equity grant. An ESOP
+// exercise or RSU vesting carries a perquisite taxed through payroll.
+type EquityEvent struct {
+	ID                    string         `json:"id"`
+	OrgID                 string         `json:"org_id"`
+	EmployeeID            string         `json:"employee_id"`
+	GrantID               string         `json:"grant_id"`
+	EventType             string         `json:"event_type"` // vest, exercise, sale
+	EventDate             time.Time      `json:"event_date"`
+	Units                 float64        `json:"units"`
+	FMV                   float64        `json:"fmv"`            // Fair market value per unit on the event date
+	ExercisePrice         float64        `json:"exercise_price"` // Per unit paid by the employee
+	PerquisiteValue       float64        `json:"perquisite_value"`
+	TaxDeferred           bool           `json:"tax_deferred"` // Eligible start-up, Section 192(1C)
+	TaxDueDate            *time.Time     `json:"tax_due_date"` // Latest date tax may be deferred to
+	Status                string         `json:"status"`       // recorded, pending, deferred, taxed, cancelled
+	PerquisiteRunID       sql.NullString `json:"perquisite_run_id"`
+	PerquisiteComponentID sql.NullString `json:"perquisite_component_id"`
+	TaxRunID              sql.NullString `json:"tax_run_id"`
+	TaxComponentID        sql.NullString `json:"tax_component_id"`
+	CreatedAt             time.Time      `json:"created_at"`
+	UpdatedAt             time.Time      `json:"updated_at"`
+	CreatedBy             *string        `json:"created_by"`
+}
+
+// PreviousEmployment is salary and tax from an earlier employer in the
+// financial year, declared by a mid-year joiner on Form 12B
+type PreviousEmployment struct {
+	ID              string    `json:"id"`
+	OrgID           string    `json:"org_id"`
+	EmployeeID      string    `json:"employee_id"`
+	FinancialYear   int       `json:"financial_year"` // Year the financial year starts in
+	EmployerName    string    `json:"employer_name"`
+	EmployerTAN     string    `json:"employer_tan"`
+	PeriodFrom      time.Time `json:"period_from"`
+	PeriodTo        time.Time `json:"period_to"`
+	GrossSalary     float64   `json:"gross_salary"` // Including perquisites, Section 17
+	Exemptions      float64   `json:"exemptions"`   // Section 10, e.g. HRA, LTA
+	PFEmployee      float64   `json:"pf_employee"`
+	ProfessionalTax float64   `json:"professional_tax"`
+	TDS             float64   `json:"tds"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+	CreatedBy       *string   `json:"created_by"`
+}
+
+// PayrollRunTransition records a payroll run moving from one status to another
+type PayrollRunTransition struct {
+	ID           string    `json:"id"`
+	PayrollRunID string    `json:"payroll_run_id"`
+	FromStatus   *string   `json:"from_status"` // nil when the run was created
+	ToStatus     string    `json:"to_status"`
+	Action       string    `json:"action"` // create, initiate, dry_run, finalize, approve, reject, release, reopen, recalculate
+	Actor        string    `json:"actor"`
+	Comment      *string   `json:"comment"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// PayrollApprovalLevel is one step of an organization's payroll approval chain
+type PayrollApprovalLevel struct {
+	ID           string    `json:"id"`
+	OrgID        string    `json:"org_id"`
+	Level        int       `json:"level"` // Approved in ascending order
+	Name         string    `json:"name"`
+	ApproverIDs  []string  `json:"approver_ids"`
+	MinNetAmount float64   `json:"min_net_amount"` // Required only when the run's net pay exceeds this
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	CreatedBy    *string   `json:"created_by"`
+}
+
+// PayrollReopenRequest asks to reopen a locked payroll run to correct the
+// components of some employees
+type PayrollReopenRequest struct {
+	ID              string     `json:"id"`
+	OrgID           string     `json:"org_id"`
+	PayrollRunID    string     `json:"payroll_run_id"`
+	Reason          string     `json:"reason"`
+	EmployeeIDs     []string   `json:"employee_ids"`
+	Status          string     `json:"status"` // pending, approved, rejected, completed
+	RequestedBy     string     `json:"requested_by"`
+	DecidedBy       *string    `json:"decided_by"`
+	DecidedAt       *time.Time `json:"decided_at"`
+	DecisionComment *string    `json:"decision_comment"`
+	Snapshot        *string    `json:"-"` // JSON array of the components as locked
+	CompletedAt     *time.Time `json:"completed_at"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// PayrollLockEvent records a payroll run being locked or unlocked
+type PayrollLockEvent struct {
+	ID              string    `json:"id"`
+	PayrollLockID   string    `json:"payroll_lock_id"`
+	PayrollRunID    string    `json:"payroll_run_id"`
+	Action          string    `json:"action"` // lock, unlock
+	Actor           string    `json:"actor"`
+	Reason          *string   `json:"reason"`
+	ReopenRequestID *string   `json:"reopen_request_id"`
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+// PayrollComponentOverride holds manual inputs for an employee's component in
+// a run. They replace the calculated inputs every time the employee is
+// calculated; nil fields keep the calculated value.
+type PayrollComponentOverride struct {
+	ID                  string    `json:"id"`
+	OrgID               string    `json:"org_id"`
+	PayrollRunID        string    `json:"payroll_run_id"`
+	EmployeeID          string    `json:"employee_id"`
+	UnpaidLeaveDays     *int      `json:"unpaid_leave_days"`
+	AdvanceRecovery     *float64  `json:"advance_recovery"`
+	LoanRecovery        *float64  `json:"loan_recovery"`
+	OtherDeductions     *float64  `json:"other_deductions"`
+	CourtOrderDeduction *float64  `json:"court_order_deduction"`
+	Reason              string    `json:"reason"`
+	CreatedAt           time.Time `json:"created_at"`
+	UpdatedAt           time.Time `json:"updated_at"`
+	UpdatedBy           *string   `json:"updated_by"`
+}
+
+// PayrollRunApproval is an approver's decision on a finalized payroll run
+type PayrollRunApproval struct {
+	ID                   string    `json:"id"`
+	PayrollRunID         string    `json:"payroll_run_id"`
+	FinalizeTransitionID string    `json:"finalize_transition_id"` // Finalization that opened the approval round
+	Level                int       `json:"level"`
+	LevelName            string    `json:"level_name"`
+	Approver             string    `json:"approver"`
+	Decision             string    `json:"decision"` // approved, rejected
+	Comment              *string   `json:"comment"`
+	CreatedAt            time.Time `json:"created_at"`
+}
+
+// IncomeTaxSlab is one band of the annual income tax slabs for a financial
+// year, regime and age group
+type IncomeTaxSlab struct {
+	ID            string    `json:"id"`
+	FinancialYear int       `json:"financial_year"` // Year the financial year starts in
+	TaxRegime     string    `json:"tax_regime"`     // old, new
+	MinAge        int       `json:"min_age"`        // 0, 60 (senior citizen) or 80 (super senior citizen)
+	IncomeFrom    float64   `json:"income_from"`
+	IncomeTo      *float64  `json:"income_to"` // nil means no upper limit
+	Rate          float64   `json:"rate"`      // Percentage
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	CreatedBy     *string   `json:"created_by"`
+}
+
+// IncomeTaxSurcharge is the surcharge on income tax once income exceeds a
+// threshold
+type IncomeTaxSurcharge struct {
+	ID            string    `json:"id"`
+	FinancialYear int       `json:"financial_year"`
+	TaxRegime     string    `json:"tax_regime"`
+	IncomeAbove   float64   `json:"income_above"`
+	Rate          float64   `json:"rate"` // Percentage of income tax
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+	CreatedBy     *string   `json:"created_by"`
+}
+
+// HolidayCalendar lists a location's holidays for a year. Location "" is the
+// organization-wide calendar.
+type HolidayCalendar struct {
+	ID           string    `json:"id"`
+	OrgID        string    `json:"org_id"`
+	Location     string    `json:"location"`
+	CalendarYear int       `json:"calendar_year"`
+	Name         string    `json:"name"`
+	Holidays     []Holiday `json:"holidays,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	CreatedBy    *string   `json:"created_by"`
+}
+
+// Holiday is one day of a holiday calendar
+type Holiday struct {
+	ID          string    `json:"id"`
+	CalendarID  string    `json:"calendar_id"`
+	HolidayDate time.Time `json:"holiday_date"`
+	Name        string    `json:"name"`
+	HolidayType string    `json:"holiday_type"` // mandatory, restricted
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// WeeklyOffPolicy is a location's weekly off pattern. Location "" is the
+// organization-wide policy.
+type WeeklyOffPolicy struct {
+	ID              string     `json:"id"`
+	OrgID           string     `json:"org_id"`
+	Location        string     `json:"location"`
+	Pattern         string     `json:"pattern"` // sunday_only, alternate_saturdays, rotating
+	SaturdayWeeks   []int64    `json:"saturday_weeks"`
+	RotationAnchor  *time.Time `json:"rotation_anchor"`
+	RotationCycle   *int       `json:"rotation_cycle"`
+	RotationOffDays []int64    `json:"rotation_off_days"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+	UpdatedBy       *string    `json:"updated_by"`
+}
+
 // StatutoryRule represents India compliance rules
 type StatutoryRule struct {
 	ID                      string     `json:"id"`
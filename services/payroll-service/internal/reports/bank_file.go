@@ -23,7 +23,7 @@ func NewBankFileGenerator(orgDetails OrganizationDetails) *BankFileGenerator {
 // BankPaymentFile represents a payment file for bank submission
 type BankPaymentFile struct {
 	FileFormat       string // "NEFT", "RTGS", "IMPS", "ACH"
-	FileType         string // "DIRECT", "INDIRECT"
+	FileType         string // "DIRECT", "INDIRECT", "SUPPLEMENTARY"
 	FileName         string
 	GeneratedDate    string
 	FileReference    string
@@ -90,22 +90,57 @@ type NEFTFormat struct {
 	Trailer string // Trailer line
 }
 
-// GenerateBankFile generates payment file for salary disbursement
+// GenerateBankFile generates payment file for salary disbursement.
+// Components that have been held are left out; they are paid through
+// GenerateSupplementaryBankFile once the hold ends.
 func (bg *BankFileGenerator) GenerateBankFile(
 	payrollComponents []models.PayrollComponent,
 	employees map[string]*models.Employee,
 	payrollRun *models.PayrollRun,
 	fileFormat string, // "NEFT", "RTGS", "IMPS"
+) *BankPaymentFile {
+	var payable []models.PayrollComponent
+	for _, component := range payrollComponents {
+		if component.HoldStatus != "" {
+			continue // Held salaries are paid separately
+		}
+		payable = append(payable, component)
+	}
+
+	return bg.generatePaymentFile(payable, employees, payrollRun, fileFormat, "DIRECT")
+}
+
+// GenerateSupplementaryBankFile generates a payment file for salaries paid
+// outside the run's main bank file, such as released holds
+func (bg *BankFileGenerator) GenerateSupplementaryBankFile(
+	payrollComponents []models.PayrollComponent,
+	employees map[string]*models.Employee,
+	payrollRun *models.PayrollRun,
+	fileFormat string, // "NEFT", "RTGS", "IMPS"
+) *BankPaymentFile {
+	return bg.generatePaymentFile(payrollComponents, employees, payrollRun, fileFormat, "SUPPLEMENTARY")
+}
+
+func (bg *BankFileGenerator) generatePaymentFile(
+	payrollComponents []models.PayrollComponent,
+	employees map[string]*models.Employee,
+	payrollRun *models.PayrollRun,
+	fileFormat string,
+	fileType string,
 ) *BankPaymentFile {
 	file := &BankPaymentFile{
 		FileFormat:    fileFormat,
-		FileType:      "DIRECT",
+		FileType:      fileType,
 		FileName:      bg.generateFileName(fileFormat, payrollRun.PayrollMonth),
 		GeneratedDate: time.Now().Format("02-Jan-2006"),
 		FileReference: bg.generateFileReference(),
 		Currency:      "INR",
 	}
 
+	if fileType == "SUPPLEMENTARY" {
+		file.FileName = "SUPPL_" + file.FileName
+	}
+
 	var totalAmount float64
 	sequenceNumber := 1
 
@@ -198,7 +233,7 @@ func (bg *BankFileGenerator) formatNEFT(file *BankPaymentFile) string {
 	var content strings.Builder
 
 	// NEFT Header
-	headerLine := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%s|%s|%d|%s|%s",
+	headerLine := fmt.Sprintf("%s|%s|%s|%s|%s|%s|%s|%s|%d|%s|%d|%s|%s",
 		file.Header.RecordType,
 		file.Header.FileReference,
 		file.Header.FileCreatedDate,
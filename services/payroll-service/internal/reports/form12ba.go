@@ -0,0 +1,108 @@
+package reports
+
+import (
+	"fmt"
+	"time"
+
+	"payroll-service/internal/models"
+)
+
+// ============================================================================
+// FORM 12BA (Perquisites, Section 17(2))
+// ============================================================================
+
+// Form 12BA items reported from payroll
+const (
+	Form12BAStartupStockOptions = "16. Stock options allotted or transferred by employer being an eligible start-up referred to in section 80-IAC"
+	Form12BAStockOptions        = "17. Stock options (non-qualified options) other than ESOP in col 16 above"
+	Form12BAEmployerFund        = "18. Contribution by employer to fund and scheme taxable under section 17(2)(vii)"
+)
+
+// Form12BAData is the statement of perquisites issued with Form 16
+type Form12BAData struct {
+	FiscalYear       string
+	AssessmentYear   string
+	DeductorName     string
+	DeductorPAN      string
+	DeductorAddress  string
+	DeducteeID       string
+	DeducteeName     string
+	DeducteePAN      string
+	Designation      string
+	Perquisites      []PerquisiteItem
+	StockOptions     []StockOptionDetail // Exercises and vestings behind items 16 and 17
+	TotalPerquisites float64
+	TaxDeferred      float64 // Start-up stock option perquisites whose tax is deferred
+	GeneratedAt      string
+}
+
+// PerquisiteItem is one line of Form 12BA
+type PerquisiteItem struct {
+	Nature                string
+	Value                 float64 // Value of perquisite as per rules
+	RecoveredFromEmployee float64
+	Taxable               float64 // Chargeable under section 17(2)
+}
+
+// StockOptionDetail is one ESOP exercise or RSU vesting
+type StockOptionDetail struct {
+	GrantNumber   string
+	GrantType     string // esop, rsu
+	EventDate     string
+	Units         float64
+	FMV           float64
+	ExercisePrice float64
+	Value         float64
+	Startup       bool // Allotted by an eligible start-up
+	TaxDeferred   bool // Tax still deferred
+	TaxDueDate    string
+}
+
+// GenerateForm12BA generates Form 12BA for an employee from the year's equity
+// perquisites and employer retirement contributions above the yearly cap
+func (g *StatutoryReportGenerator) GenerateForm12BA(
+	employee *models.Employee,
+	stockOptions []StockOptionDetail,
+	retirementPerquisite float64,
+	organizationDetails OrganizationDetails,
+) *Form12BAData {
+	form := &Form12BAData{
+		FiscalYear:      g.fiscalYear,
+		AssessmentYear:  g.assessmentYear(),
+		DeductorName:    organizationDetails.Name,
+		DeductorPAN:     organizationDetails.PAN,
+		DeductorAddress: organizationDetails.Address,
+		DeducteeID:      employee.ID,
+		DeducteeName:    fmt.Sprintf("%s %s", employee.FirstName, employee.LastName),
+		DeducteePAN:     employee.PersonalPAN.String,
+		Designation:     employee.Designation.String,
+		StockOptions:    stockOptions,
+		GeneratedAt:     time.Now().Format("02-Jan-2006 15:04:05"),
+	}
+
+	var startup, other float64
+	for _, so := range stockOptions {
+		if so.Startup {
+			startup += so.Value
+		} else {
+			other += so.Value
+		}
+		if so.TaxDeferred {
+			form.TaxDeferred += so.Value
+		}
+	}
+
+	for _, item := range []PerquisiteItem{
+		{Nature: Form12BAStartupStockOptions, Value: startup, Taxable: startup},
+		{Nature: Form12BAStockOptions, Value: other, Taxable: other},
+		{Nature: Form12BAEmployerFund, Value: retirementPerquisite, Taxable: retirementPerquisite},
+	} {
+		if item.Value <= 0 {
+			continue
+		}
+		form.Perquisites = append(form.Perquisites, item)
+		form.TotalPerquisites += item.Taxable
+	}
+
+	return form
+}
@@ -0,0 +1,138 @@
+package reports
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"payroll-service/internal/models"
+)
+
+// NPSFileGenerator generates the monthly corporate NPS contribution file
+// uploaded to the Point of Presence (POP)
+type NPSFileGenerator struct {
+	organizationDetails OrganizationDetails
+}
+
+// NewNPSFileGenerator creates a new NPS contribution file generator
+func NewNPSFileGenerator(orgDetails OrganizationDetails) *NPSFileGenerator {
+	return &NPSFileGenerator{
+		organizationDetails: orgDetails,
+	}
+}
+
+// NPSContributionFile is one month's contributions for all enrolled employees
+type NPSContributionFile struct {
+	FileName           string
+	GeneratedDate      string
+	ContributionMonth  string // YYYY-MM
+	RegistrationNumber string // Corporate CHO/CBO registration
+	TotalRecords       int
+	TotalEmployee      float64
+	TotalEmployer      float64
+	TotalAmount        float64
+	Details            []NPSContributionDetail
+	RawContent         string
+}
+
+// NPSContributionDetail is one subscriber's contribution
+type NPSContributionDetail struct {
+	SequenceNumber       int
+	PRAN                 string
+	SubscriberName       string
+	EmployeeCode         string
+	EmployeeContribution float64
+	EmployerContribution float64
+	TotalContribution    float64
+	Reference            string // Payroll component ID, for reconciliation
+}
+
+// GenerateContributionFile lists the NPS contributions of a payroll run.
+// pran maps employee IDs to their PRAN; components without contributions or
+// a PRAN are left out.
+func (ng *NPSFileGenerator) GenerateContributionFile(
+	payrollComponents []models.PayrollComponent,
+	employees map[string]*models.Employee,
+	pran map[string]string,
+	payrollRun *models.PayrollRun,
+) *NPSContributionFile {
+	file := &NPSContributionFile{
+		FileName:           fmt.Sprintf("NPS_CONTRIBUTION_%s_%s.txt", payrollRun.PayrollMonth, time.Now().Format("20060102150405")),
+		GeneratedDate:      time.Now().Format("02-Jan-2006"),
+		ContributionMonth:  payrollRun.PayrollMonth,
+		RegistrationNumber: ng.organizationDetails.NPSRegistrationNumber,
+	}
+
+	for _, component := range payrollComponents {
+		total := component.NPSEmployee + component.NPSEmployer
+		if total <= 0 || pran[component.EmployeeID] == "" {
+			continue
+		}
+
+		detail := NPSContributionDetail{
+			SequenceNumber:       len(file.Details) + 1,
+			PRAN:                 pran[component.EmployeeID],
+			EmployeeContribution: component.NPSEmployee,
+			EmployerContribution: component.NPSEmployer,
+			TotalContribution:    total,
+			Reference:            component.ID,
+		}
+		if emp := employees[component.EmployeeID]; emp != nil {
+			detail.SubscriberName = fmt.Sprintf("%s %s", emp.FirstName, emp.LastName)
+			detail.EmployeeCode = emp.EmployeeID
+		}
+
+		file.Details = append(file.Details, detail)
+		file.TotalEmployee += component.NPSEmployee
+		file.TotalEmployer += component.NPSEmployer
+	}
+
+	file.TotalRecords = len(file.Details)
+	file.TotalAmount = file.TotalEmployee + file.TotalEmployer
+	file.RawContent = ng.formatContributionFile(file)
+
+	return file
+}
+
+// formatContributionFile renders the file as pipe-separated file header (FH),
+// batch header (BH) and subscriber detail (SD) records
+func (ng *NPSFileGenerator) formatContributionFile(file *NPSContributionFile) string {
+	var content strings.Builder
+
+	month := file.ContributionMonth
+	if t, err := time.Parse("2006-01", file.ContributionMonth); err == nil {
+		month = t.Format("012006")
+	}
+
+	fmt.Fprintf(&content, "FH|%s|%s|%s|1|%d|%.2f\n",
+		file.RegistrationNumber,
+		ng.organizationDetails.Name,
+		time.Now().Format("02012006"),
+		file.TotalRecords,
+		file.TotalAmount,
+	)
+
+	fmt.Fprintf(&content, "BH|1|%s|%s|%d|%.2f|%.2f|%.2f\n",
+		file.RegistrationNumber,
+		month,
+		file.TotalRecords,
+		file.TotalEmployee,
+		file.TotalEmployer,
+		file.TotalAmount,
+	)
+
+	for _, detail := range file.Details {
+		fmt.Fprintf(&content, "SD|%d|%s|%s|%s|%.2f|%.2f|%.2f|%s\n",
+			detail.SequenceNumber,
+			detail.PRAN,
+			detail.SubscriberName,
+			detail.EmployeeCode,
+			detail.EmployeeContribution,
+			detail.EmployerContribution,
+			detail.TotalContribution,
+			month,
+		)
+	}
+
+	return content.String()
+}
@@ -49,29 +49,48 @@ type Payslip struct {
 	DaysAbsent       int
 	DaysLeave        int
 	WorkingDays      int
+	LOPDays          int
+	LOPAmount        float64 // Already left out of the earnings below
 
 	// Earnings
 	BasicPay           float64
 	DeartnessAllowance float64
 	HouseRentAllowance float64
 	OtherAllowances    float64
+	ShiftAllowance     float64
+	LOPReversal        float64
+	FlexibleBenefits   float64 // FBP paid monthly and against claims
+	FBPUnclaimed       float64 // Unclaimed FBP paid out at year end, taxable
+	OneTimePayment     float64 // Bonus or settlement paid by an off-cycle run
 	Gross              float64
 	EarningsDetails    []EarningItem
+	EquityPerquisite   float64 // ESOP/RSU perquisite taxed this month, not paid
 
 	// Deductions
 	PFEmployee         float64
 	ESIEmployee        float64
 	ProfessionalTax    float64
+	NPSEmployee        float64
 	TDS                float64
 	AdvanceRecovery    float64
 	LoanRecovery       float64
 	OtherDeductions    float64
+	CourtOrderDeduction float64
 	TotalDeductions    float64
 	DeductionDetails   []DeductionItem
 
+	// Deduction Carry-forward
+	CarryForwardRecovered float64 // Included above, recovered from earlier months
+	DeductionShortfall    float64 // Deferred to next month
+
+	// Net pay rounding (positive is an earning, negative a deduction)
+	RoundingAdjustment float64
+
 	// Employer Contribution (for info only)
 	PFEmployer         float64
 	ESIEmployer        float64
+	NPSEmployer        float64
+	SuperannuationEmployer float64
 	TotalEmployerCont  float64
 
 	// Summary
@@ -159,32 +178,49 @@ func (pg *PayslipGenerator) GeneratePayslip(
 		DaysAbsent:   component.DaysAbsent,
 		DaysLeave:    component.DaysLeave,
 		WorkingDays:  component.DaysWorked,
+		LOPDays:      component.LOPDays,
+		LOPAmount:    component.LOPAmount,
 
 		// Earnings
 		BasicPay:           component.BasicPay,
 		DeartnessAllowance: component.DAAmount,
 		HouseRentAllowance: component.HRAAmount,
 		OtherAllowances:    component.OtherAllowances,
+		ShiftAllowance:     component.ShiftAllowance,
+		LOPReversal:        component.LOPReversal,
+		FlexibleBenefits:   component.FBPFixed + component.FBPClaims,
+		FBPUnclaimed:       component.FBPUnclaimedPayout,
+		OneTimePayment:     component.OneTimePayment,
 		Gross:              component.GrossAmount,
+		EquityPerquisite:   component.EquityPerquisite - component.EquityPerquisiteDeferred + component.EquityDeferredTaxed,
 
 		// Deductions
 		PFEmployee:      component.PFEmployee,
 		ESIEmployee:     component.ESIEmployee,
 		ProfessionalTax: component.ProfessionalTax,
+		NPSEmployee:     component.NPSEmployee,
 		TDS:             component.TDS,
 		AdvanceRecovery: component.AdvanceRecovery,
 		LoanRecovery:    component.LoanRecovery,
 		OtherDeductions: component.OtherDeductions,
+		CourtOrderDeduction: component.CourtOrderDeduction,
 		TotalDeductions: component.TotalDeductions,
 
+		// Deduction Carry-forward
+		CarryForwardRecovered: component.CarryForwardRecovered,
+		DeductionShortfall:    component.DeductionShortfall,
+		RoundingAdjustment:    component.RoundingAdjustment,
+
 		// Employer Contribution
 		PFEmployer:        component.PFEmployer,
 		ESIEmployer:       component.ESIEmployer,
-		TotalEmployerCont: component.PFEmployer + component.ESIEmployer,
+		NPSEmployer:       component.NPSEmployer,
+		SuperannuationEmployer: component.SuperannuationEmployer,
+		TotalEmployerCont: component.PFEmployer + component.ESIEmployer + component.NPSEmployer + component.SuperannuationEmployer,
 
 		// Summary
 		NetPay:      component.NetPay,
-		CtcMonthly:  (component.GrossAmount + component.PFEmployer + component.ESIEmployer) / 12,
+		CtcMonthly:  (component.GrossAmount + component.PFEmployer + component.ESIEmployer + component.NPSEmployer + component.SuperannuationEmployer) / 12,
 		PrintedDate: time.Now().Format("02-Jan-2006"),
 
 		// Year to Date
@@ -205,18 +241,36 @@ func (pg *PayslipGenerator) GeneratePayslip(
 		{Name: "Dearness Allowance", Amount: component.DAAmount},
 		{Name: "House Rent Allowance", Amount: component.HRAAmount},
 		{Name: "Other Allowances", Amount: component.OtherAllowances},
+		{Name: "Shift Allowance", Amount: component.ShiftAllowance, Notes: "From roster"},
+		{Name: "LOP Reversal", Amount: component.LOPReversal, Notes: "Loss of pay paid back for earlier months"},
+		{Name: "Flexible Benefits", Amount: component.FBPFixed + component.FBPClaims, Notes: "Fixed components and approved claims"},
+		{Name: "FBP Unclaimed Balance", Amount: component.FBPUnclaimedPayout, Notes: "Paid out at year end, taxable"},
+		{Name: "One-time Payment", Amount: component.OneTimePayment, Notes: "Bonus or settlement, off-cycle run"},
 	}
 
 	payslip.DeductionDetails = []DeductionItem{
 		{Name: "Provident Fund", Amount: component.PFEmployee, Notes: "Employee Contribution"},
 		{Name: "ESI", Amount: component.ESIEmployee, Notes: "Employee Contribution"},
 		{Name: "Professional Tax", Amount: component.ProfessionalTax},
+		{Name: "NPS", Amount: component.NPSEmployee, Notes: "Employee Contribution"},
 		{Name: "TDS", Amount: component.TDS, Notes: "Income Tax"},
+		{Name: "Court Order Deduction", Amount: component.CourtOrderDeduction},
 		{Name: "Advance Recovery", Amount: component.AdvanceRecovery},
 		{Name: "Loan Recovery", Amount: component.LoanRecovery},
 		{Name: "Other Deductions", Amount: component.OtherDeductions},
 	}
 
+	// Rounding shows as an earning or a deduction depending on its sign
+	if component.RoundingAdjustment > 0 {
+		payslip.EarningsDetails = append(payslip.EarningsDetails, EarningItem{
+			Name: "Rounding Adjustment", Amount: component.RoundingAdjustment, Notes: "Recovered from a later month",
+		})
+	} else if component.RoundingAdjustment < 0 {
+		payslip.DeductionDetails = append(payslip.DeductionDetails, DeductionItem{
+			Name: "Rounding Adjustment", Amount: -component.RoundingAdjustment, Notes: "Paid in a later month",
+		})
+	}
+
 	return payslip
 }
 
@@ -248,32 +302,46 @@ EMPLOYEE INFORMATION:
 
 ATTENDANCE:
   Days in Month: %d | Days Worked: %d | Days Absent: %d | Days Leave: %d
+  Loss of Pay: %d days (₹%.2f, not included in earnings)
 
 EARNINGS:
   Basic Pay              ₹%10.2f
   Dearness Allowance     ₹%10.2f
   House Rent Allowance   ₹%10.2f
   Other Allowances       ₹%10.2f
+  Shift Allowance        ₹%10.2f
+  LOP Reversal           ₹%10.2f
+  Flexible Benefits      ₹%10.2f
+  FBP Unclaimed Balance  ₹%10.2f
+  One-time Payment       ₹%10.2f
                          ───────────────
   GROSS AMOUNT           ₹%10.2f
+  ESOP/RSU Perquisite    ₹%10.2f (taxed, not paid)
 
 DEDUCTIONS:
   Provident Fund         ₹%10.2f
   ESI                    ₹%10.2f
   Professional Tax       ₹%10.2f
+  NPS                    ₹%10.2f
   TDS (Income Tax)       ₹%10.2f
-  Advance Recovery       ₹%10.2f
+  Court Order Deduction  ₹%10.2f
   Loan Recovery          ₹%10.2f
+  Advance Recovery       ₹%10.2f
   Other Deductions       ₹%10.2f
                          ───────────────
   TOTAL DEDUCTIONS       ₹%10.2f
+  Recovered from earlier months  ₹%10.2f
+  Deferred to next month         ₹%10.2f
 
 EMPLOYER'S CONTRIBUTION:
   Provident Fund         ₹%10.2f
   ESI                    ₹%10.2f
+  NPS                    ₹%10.2f
+  Superannuation         ₹%10.2f
                          ───────────────
   TOTAL                  ₹%10.2f
 
+ROUNDING ADJUSTMENT      ₹%10.2f
 NET PAY                  ₹%10.2f
 
 MONTHLY CTC              ₹%10.2f
@@ -316,26 +384,41 @@ Printed on: %s
 		payslip.DaysWorked,
 		payslip.DaysAbsent,
 		payslip.DaysLeave,
+		payslip.LOPDays,
+		payslip.LOPAmount,
 
 		payslip.BasicPay,
 		payslip.DeartnessAllowance,
 		payslip.HouseRentAllowance,
 		payslip.OtherAllowances,
+		payslip.ShiftAllowance,
+		payslip.LOPReversal,
+		payslip.FlexibleBenefits,
+		payslip.FBPUnclaimed,
+		payslip.OneTimePayment,
 		payslip.Gross,
+		payslip.EquityPerquisite,
 
 		payslip.PFEmployee,
 		payslip.ESIEmployee,
 		payslip.ProfessionalTax,
+		payslip.NPSEmployee,
 		payslip.TDS,
-		payslip.AdvanceRecovery,
+		payslip.CourtOrderDeduction,
 		payslip.LoanRecovery,
+		payslip.AdvanceRecovery,
 		payslip.OtherDeductions,
 		payslip.TotalDeductions,
+		payslip.CarryForwardRecovered,
+		payslip.DeductionShortfall,
 
 		payslip.PFEmployer,
 		payslip.ESIEmployer,
+		payslip.NPSEmployer,
+		payslip.SuperannuationEmployer,
 		payslip.TotalEmployerCont,
 
+		payslip.RoundingAdjustment,
 		payslip.NetPay,
 		payslip.CtcMonthly,
 
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"time"
 
+	"payroll-service/internal/calculator"
 	"payroll-service/internal/models"
 )
 
@@ -40,11 +41,20 @@ type Form16Data struct {
 	AssessmentYear         string // YYYY-YY
 	EmployerContribution   float64 // EPF/EPS
 	TotalIncome            float64 // Gross income for the year
+	Perquisites            float64 // Section 17(2), see Form 12BA
+	PerquisiteTaxDeferred  float64 // Start-up ESOP perquisites whose tax is deferred (Section 192(1C))
 	TotalTDSDeducted       float64 // Total TDS deducted
+	PreviousEmployers      []PreviousEmployerDetail // Part B: salary from earlier employers (Form 12B)
+	PreviousEmployerIncome float64 // Taxable salary from earlier employers
+	PreviousEmployerTDS    float64 // TDS deducted by earlier employers
 	SectionIVDeductions    []Section80Deduction // Section 80C, 80D, etc.
 	OtherIncome            float64 // Other income
 	GrossTotalIncome       float64
 	TaxablIncome           float64
+	TaxRegime              string  // old, new
+	IncomeTax              float64 // On the slabs of the regime and age group
+	Surcharge              float64 // After marginal relief
+	MarginalRelief         float64
 	TaxPayable             float64
 	TDSPaid                float64
 	TaxRefund              float64 // If TDS > Tax Payable
@@ -88,19 +98,32 @@ func (g *StatutoryReportGenerator) GenerateForm16(
 ) *Form16Data {
 	// Calculate TDS for the year
 	totalTDS := g.calculateAnnualTDS(annualSalaryData)
-	totalIncome := annualSalaryData.TotalGross
+	totalIncome := annualSalaryData.TotalGross + annualSalaryData.TotalPerquisites
+
+	// Salary and tax from earlier employers in the year count toward the
+	// year's tax
+	var previousIncome, previousTDS float64
+	for _, p := range annualSalaryData.PreviousEmployers {
+		previousIncome += p.TaxableIncome
+		previousTDS += p.TDS
+	}
 
 	// Calculate taxable income (simplified)
 	// In real scenario, this includes section 80 deductions, relief, etc.
-	taxableIncome := g.calculateTaxableIncome(totalIncome)
-
-	// Calculate tax payable (simplified using standard rates)
-	taxPayable := g.calculateIncomeTax(taxableIncome)
+	// Tax on deferred start-up ESOP perquisites is not due this year
+	taxableIncome := g.calculateTaxableIncome(totalIncome - annualSalaryData.DeferredPerquisites + previousIncome)
+
+	// Tax on the year's slabs for the employee's regime and age group
+	var tax calculator.AnnualTax
+	var taxRegime string
+	if annualSalaryData.IncomeTax != nil {
+		tax = annualSalaryData.IncomeTax.Tax(taxableIncome, annualSalaryData.Age)
+		taxRegime = annualSalaryData.IncomeTax.TaxRegime
+	}
+	taxPayable := tax.Total
 
 	// Assessment year (1 year after fiscal year ends)
-	assessmentYear := fmt.Sprintf("%s-%s", 
-		string(g.fiscalYear[5:9]),    // Last 4 digits of first year
-		g.fiscalYear[12:14])           // Last 2 digits of second year
+	assessmentYear := g.assessmentYear()
 
 	form16 := &Form16Data{
 		CertificateNumber:    g.generateCertificateNumber(employee.ID),
@@ -115,22 +138,31 @@ func (g *StatutoryReportGenerator) GenerateForm16(
 		FiscalYear:           g.fiscalYear,
 		AssessmentYear:       assessmentYear,
 		TotalIncome:          totalIncome,
+		Perquisites:          annualSalaryData.TotalPerquisites,
+		PerquisiteTaxDeferred: annualSalaryData.DeferredPerquisites,
 		TotalTDSDeducted:     totalTDS,
-		GrossTotalIncome:     totalIncome,
+		PreviousEmployers:      annualSalaryData.PreviousEmployers,
+		PreviousEmployerIncome: previousIncome,
+		PreviousEmployerTDS:    previousTDS,
+		GrossTotalIncome:     totalIncome + previousIncome,
 		TaxablIncome:         taxableIncome,
+		TaxRegime:            taxRegime,
+		IncomeTax:            tax.SlabTax,
+		Surcharge:            tax.Surcharge,
+		MarginalRelief:       tax.MarginalRelief,
 		TaxPayable:           taxPayable,
-		TDSPaid:              totalTDS,
+		TDSPaid:              totalTDS + previousTDS,
 		GeneratedBy:          "System", // In production, get from context
 		GeneratedAt:          time.Now().Format("02-Jan-2006 15:04:05"),
 		MonthlyTDSBreakdown:  g.generateMonthlyTDSBreakdown(annualSalaryData),
 	}
 
 	// Calculate refund or additional tax due
-	if totalTDS > taxPayable {
-		form16.TaxRefund = totalTDS - taxPayable
+	if form16.TDSPaid > taxPayable {
+		form16.TaxRefund = form16.TDSPaid - taxPayable
 		form16.TaxPayableNow = 0
 	} else {
-		form16.TaxPayableNow = taxPayable - totalTDS
+		form16.TaxPayableNow = taxPayable - form16.TDSPaid
 		form16.TaxRefund = 0
 	}
 
@@ -318,7 +350,27 @@ type AnnualSalaryData struct {
 	TotalBasic     float64
 	TotalDA        float64
 	TotalDeductions float64
+	TotalPerquisites    float64 // Not paid in cash, e.g. ESOP/RSU
+	PreviousEmployers   []PreviousEmployerDetail
+	DeferredPerquisites float64 // Part of TotalPerquisites whose tax is deferred
 	MonthlyData    []MonthlySalaryData
+	IncomeTax           *calculator.IncomeTaxTable // Slabs and surcharges of the year for the employee's regime
+	Age                 int                        // On 31 March of the year
+}
+
+// PreviousEmployerDetail is salary and tax from an earlier employer in the
+// year, as declared on Form 12B
+type PreviousEmployerDetail struct {
+	EmployerName  string
+	EmployerTAN   string
+	PeriodFrom    string
+	PeriodTo      string
+	GrossSalary   float64
+	Exemptions    float64 // Section 10
+	PF            float64
+	PT            float64
+	TaxableIncome float64
+	TDS           float64
 }
 
 // MonthlySalaryData represents monthly salary breakdown
@@ -352,6 +404,7 @@ type OrganizationDetails struct {
 	PFEstablishmentCode     string
 	PFAccountNumber         string
 	ESIRegistrationNumber   string
+	NPSRegistrationNumber   string
 	BankName                string
 	BankAccountNumber       string
 	IFSC                    string
@@ -401,6 +454,13 @@ func (g *StatutoryReportGenerator) generateCertificateNumber(employeeID string)
 		time.Now().Unix()%10000)
 }
 
+// assessmentYear returns the assessment year of the fiscal year, e.g.
+// "2024-25" for "2023-2024"
+func (g *StatutoryReportGenerator) assessmentYear() string {
+	endYear := parseInt(g.fiscalYear[5:9])
+	return fmt.Sprintf("%d-%02d", endYear, (endYear+1)%100)
+}
+
 func (g *StatutoryReportGenerator) generateChallanNumber(challanType, monthYear string) string {
 	// Format: TYPE-YYYY-MM-XXXX
 	return fmt.Sprintf("%s-%s-%d", challanType, monthYear, time.Now().Unix()%10000)
@@ -428,21 +488,6 @@ func (g *StatutoryReportGenerator) calculateTaxableIncome(totalIncome float64) f
 	return 0
 }
 
-func (g *StatutoryReportGenerator) calculateIncomeTax(taxableIncome float64) float64 {
-	// Simplified slab rates for FY 2023-24
-	// Actual rates depend on age, residence status, etc.
-	switch {
-	case taxableIncome <= 250000:
-		return 0
-	case taxableIncome <= 500000:
-		return (taxableIncome - 250000) * 0.05
-	case taxableIncome <= 1000000:
-		return 12500 + (taxableIncome-500000)*0.20
-	default:
-		return 112500 + (taxableIncome-1000000)*0.30
-	}
-}
-
 func (g *StatutoryReportGenerator) generateMonthlyTDSBreakdown(
 	data AnnualSalaryData,
 ) []MonthlyTDSDetail {
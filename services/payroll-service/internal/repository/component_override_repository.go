@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"payroll-service/internal/models"
+)
+
+type ComponentOverrideRepository struct {
+	db *sql.DB
+}
+
+func NewComponentOverrideRepository(db *sql.DB) *ComponentOverrideRepository {
+	return &ComponentOverrideRepository{db: db}
+}
+
+// GetComponentOverrides fetches the manual overrides of a run
+func (r *ComponentOverrideRepository) GetComponentOverrides(payrollRunID string) ([]models.PayrollComponentOverride, error) {
+	query := `
+		SELECT id, org_id, payroll_run_id, employee_id,
+		       unpaid_leave_days, advance_recovery, loan_recovery, other_deductions, court_order_deduction,
+		       reason, created_at, updated_at, updated_by
+		FROM payroll_component_overrides
+		WHERE payroll_run_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, payrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query component overrides: %w", err)
+	}
+	defer rows.Close()
+
+	var overrides []models.PayrollComponentOverride
+	for rows.Next() {
+		var o models.PayrollComponentOverride
+		err := rows.Scan(
+			&o.ID, &o.OrgID, &o.PayrollRunID, &o.EmployeeID,
+			&o.UnpaidLeaveDays, &o.AdvanceRecovery, &o.LoanRecovery, &o.OtherDeductions, &o.CourtOrderDeduction,
+			&o.Reason, &o.CreatedAt, &o.UpdatedAt, &o.UpdatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan component override: %w", err)
+		}
+		overrides = append(overrides, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating component overrides: %w", err)
+	}
+
+	return overrides, nil
+}
+
+// UpsertComponentOverride creates or replaces an employee's overrides in a run
+func (r *ComponentOverrideRepository) UpsertComponentOverride(o *models.PayrollComponentOverride) error {
+	query := `
+		INSERT INTO payroll_component_overrides (
+			org_id, payroll_run_id, employee_id,
+			unpaid_leave_days, advance_recovery, loan_recovery, other_deductions, court_order_deduction,
+			reason, created_at, updated_at, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW(), $10)
+		ON CONFLICT (payroll_run_id, employee_id) DO UPDATE SET
+			unpaid_leave_days = EXCLUDED.unpaid_leave_days,
+			advance_recovery = EXCLUDED.advance_recovery,
+			loan_recovery = EXCLUDED.loan_recovery,
+			other_deductions = EXCLUDED.other_deductions,
+			court_order_deduction = EXCLUDED.court_order_deduction,
+			reason = EXCLUDED.reason,
+			updated_at = NOW(),
+			updated_by = EXCLUDED.updated_by
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		o.OrgID, o.PayrollRunID, o.EmployeeID,
+		o.UnpaidLeaveDays, o.AdvanceRecovery, o.LoanRecovery, o.OtherDeductions, o.CourtOrderDeduction,
+		o.Reason, o.UpdatedBy,
+	).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save component override: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteComponentOverride removes an employee's overrides from a run
+func (r *ComponentOverrideRepository) DeleteComponentOverride(payrollRunID, employeeID string) error {
+	query := `DELETE FROM payroll_component_overrides WHERE payroll_run_id = $1 AND employee_id = $2`
+
+	result, err := r.db.Exec(query, payrollRunID, employeeID)
+	if err != nil {
+		return fmt.Errorf("failed to delete component override: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("component override not found")
+	}
+
+	return nil
+}
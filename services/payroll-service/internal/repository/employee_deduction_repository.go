@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type EmployeeDeductionRepository struct {
+	db *sql.DB
+}
+
+func NewEmployeeDeductionRepository(db *sql.DB) *EmployeeDeductionRepository {
+	return &EmployeeDeductionRepository{db: db}
+}
+
+// employeeDeductionColumns lists the employee_deductions columns in scan
+// order, with the instalments raised by locked and released runs
+const employeeDeductionColumns = `
+	d.id, d.org_id, d.employee_id, d.category, d.description, d.instalment, d.total_amount,
+	d.start_month, d.status, d.created_at, d.updated_at, d.created_by,
+	(SELECT COALESCE(SUM(i.amount), 0)
+	 FROM employee_deduction_instalments i
+	 JOIN payroll_runs pr ON pr.id = i.payroll_run_id
+	 WHERE i.deduction_id = d.id AND pr.status IN ('locked', 'released')) AS raised
+`
+
+// CreateEmployeeDeduction records an active deduction schedule
+func (r *EmployeeDeductionRepository) CreateEmployeeDeduction(d *models.EmployeeDeduction) error {
+	query := `
+		INSERT INTO employee_deductions (
+			org_id, employee_id, category, description, instalment, total_amount,
+			start_month, status, created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, 'active', NOW(), NOW(), $8)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		d.OrgID, d.EmployeeID, d.Category, d.Description, d.Instalment, d.TotalAmount,
+		d.StartMonth, d.CreatedBy,
+	).Scan(&d.ID, &d.Status, &d.CreatedAt, &d.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create employee deduction: %w", err)
+	}
+
+	return nil
+}
+
+// GetEmployeeDeductions fetches an organization's deduction schedules with
+// optional filters
+func (r *EmployeeDeductionRepository) GetEmployeeDeductions(orgID string, filters map[string]interface{}) ([]models.EmployeeDeduction, error) {
+	query := "SELECT " + employeeDeductionColumns + " FROM employee_deductions d WHERE d.org_id = $1"
+	args := []interface{}{orgID}
+	argCount := 2
+
+	// Apply filters
+	if employeeID, ok := filters["employee_id"].(string); ok {
+		query += fmt.Sprintf(" AND d.employee_id = $%d", argCount)
+		args = append(args, employeeID)
+		argCount++
+	}
+
+	if category, ok := filters["category"].(string); ok {
+		query += fmt.Sprintf(" AND d.category = $%d", argCount)
+		args = append(args, category)
+		argCount++
+	}
+
+	if status, ok := filters["status"].(string); ok {
+		query += fmt.Sprintf(" AND d.status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+
+	query += " ORDER BY d.created_at DESC"
+
+	return r.queryEmployeeDeductions(query, args...)
+}
+
+// GetDueEmployeeDeductions fetches the active schedules a payroll run of the
+// given month should raise an instalment of for the given employees, keyed by
+// employee ID. Schedules that already raised the month's instalment in
+// another locked or released run, and those whose total has been raised, are
+// left out.
+func (r *EmployeeDeductionRepository) GetDueEmployeeDeductions(employeeIDs []string, payrollRunID, payrollMonth string) (map[string][]models.EmployeeDeduction, error) {
+	query := "SELECT " + employeeDeductionColumns + ` FROM employee_deductions d
+		WHERE d.employee_id = ANY($1) AND d.status = 'active' AND d.start_month <= $3
+		  AND NOT EXISTS (
+			SELECT 1
+			FROM employee_deduction_instalments i
+			JOIN payroll_runs pr ON pr.id = i.payroll_run_id
+			WHERE i.deduction_id = d.id AND i.payroll_run_id <> $2
+			  AND pr.payroll_month = $3 AND pr.status IN ('locked', 'released')
+		  )
+		ORDER BY d.start_month, d.created_at`
+
+	deductions, err := r.queryEmployeeDeductions(query, pq.Array(employeeIDs), payrollRunID, payrollMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmployee := make(map[string][]models.EmployeeDeduction)
+	for _, d := range deductions {
+		if d.TotalAmount != nil && d.Raised >= *d.TotalAmount {
+			continue
+		}
+		byEmployee[d.EmployeeID] = append(byEmployee[d.EmployeeID], d)
+	}
+	return byEmployee, nil
+}
+
+// RecordDeductionInstalment records an instalment raised by a payroll
+// component, in the component's transaction
+func (r *EmployeeDeductionRepository) RecordDeductionInstalment(tx *sql.Tx, deductionID, payrollRunID, payrollComponentID string, amount float64) error {
+	query := `
+		INSERT INTO employee_deduction_instalments (
+			deduction_id, payroll_run_id, payroll_component_id, amount, created_at
+		) VALUES ($1, $2, $3, $4, NOW())
+	`
+
+	if _, err := tx.Exec(query, deductionID, payrollRunID, payrollComponentID, amount); err != nil {
+		return fmt.Errorf("failed to record deduction instalment: %w", err)
+	}
+
+	return nil
+}
+
+// CancelEmployeeDeduction stops an active schedule; instalments already
+// raised stand
+func (r *EmployeeDeductionRepository) CancelEmployeeDeduction(id string) error {
+	query := `
+		UPDATE employee_deductions
+		SET status = 'cancelled', updated_at = NOW()
+		WHERE id = $1 AND status = 'active'
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel employee deduction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("active employee deduction not found")
+	}
+
+	return nil
+}
+
+func (r *EmployeeDeductionRepository) queryEmployeeDeductions(query string, args ...interface{}) ([]models.EmployeeDeduction, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query employee deductions: %w", err)
+	}
+	defer rows.Close()
+
+	var deductions []models.EmployeeDeduction
+	for rows.Next() {
+		var d models.EmployeeDeduction
+		err := rows.Scan(
+			&d.ID, &d.OrgID, &d.EmployeeID, &d.Category, &d.Description, &d.Instalment, &d.TotalAmount,
+			&d.StartMonth, &d.Status, &d.CreatedAt, &d.UpdatedAt, &d.CreatedBy, &d.Raised,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan employee deduction: %w", err)
+		}
+		deductions = append(deductions, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating employee deductions: %w", err)
+	}
+
+	return deductions, nil
+}
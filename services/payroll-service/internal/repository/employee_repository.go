@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 
+	"github.com/lib/pq"
 	"payroll-service/internal/models"
 )
 
@@ -20,10 +21,10 @@ func (r *EmployeeRepository) GetEmployees(orgID string, filters map[string]inter
 	query := `
 		SELECT id, org_id, employee_id, first_name, last_name, email, date_of_birth,
 		       gender, date_of_joining, date_of_exit, employment_status, department,
-		       designation, manager_id, location, personal_pan, aadhaar_number,
+		       designation, manager_id, location, personal_pan, pan_status, pan_verified_at, aadhaar_number,
 		       passport_number, bank_name, bank_account_number, bank_ifsc_code,
-		       bank_account_holder_name, phone_number, personal_email,
-		       created_at, updated_at, created_by, updated_by
+		       bank_account_holder_name, phone_number, personal_email, pay_group_id,
+		       tax_regime, created_at, updated_at, created_by, updated_by
 		FROM employees
 		WHERE org_id = $1
 	`
@@ -43,6 +44,17 @@ func (r *EmployeeRepository) GetEmployees(orgID string, filters map[string]inter
 		argCount++
 	}
 
+	// Employees without a pay group belong to the default one
+	if payGroupID, ok := filters["pay_group_id"].(string); ok {
+		if includeUnassigned, _ := filters["include_unassigned"].(bool); includeUnassigned {
+			query += fmt.Sprintf(" AND (pay_group_id = $%d OR pay_group_id IS NULL)", argCount)
+		} else {
+			query += fmt.Sprintf(" AND pay_group_id = $%d", argCount)
+		}
+		args = append(args, payGroupID)
+		argCount++
+	}
+
 	query += " ORDER BY first_name, last_name"
 
 	rows, err := r.db.Query(query, args...)
@@ -57,10 +69,10 @@ func (r *EmployeeRepository) GetEmployees(orgID string, filters map[string]inter
 		err := rows.Scan(
 			&emp.ID, &emp.OrgID, &emp.EmployeeID, &emp.FirstName, &emp.LastName, &emp.Email, &emp.DateOfBirth,
 			&emp.Gender, &emp.DateOfJoining, &emp.DateOfExit, &emp.EmploymentStatus, &emp.Department,
-			&emp.Designation, &emp.ManagerID, &emp.Location, &emp.PersonalPAN, &emp.AadhaarNumber,
+			&emp.Designation, &emp.ManagerID, &emp.Location, &emp.PersonalPAN, &emp.PANStatus, &emp.PANVerifiedAt, &emp.AadhaarNumber,
 			&emp.PassportNumber, &emp.BankName, &emp.BankAccountNumber, &emp.BankIFSCCode,
-			&emp.BankAccountHolder, &emp.PhoneNumber, &emp.PersonalEmail,
-			&emp.CreatedAt, &emp.UpdatedAt, &emp.CreatedBy, &emp.UpdatedBy,
+			&emp.BankAccountHolder, &emp.PhoneNumber, &emp.PersonalEmail, &emp.PayGroupID,
+			&emp.TaxRegime, &emp.CreatedAt, &emp.UpdatedAt, &emp.CreatedBy, &emp.UpdatedBy,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan employee: %w", err)
@@ -80,10 +92,10 @@ func (r *EmployeeRepository) GetEmployeeByID(employeeID string) (*models.Employe
 	query := `
 		SELECT id, org_id, employee_id, first_name, last_name, email, date_of_birth,
 		       gender, date_of_joining, date_of_exit, employment_status, department,
-		       designation, manager_id, location, personal_pan, aadhaar_number,
+		       designation, manager_id, location, personal_pan, pan_status, pan_verified_at, aadhaar_number,
 		       passport_number, bank_name, bank_account_number, bank_ifsc_code,
-		       bank_account_holder_name, phone_number, personal_email,
-		       created_at, updated_at, created_by, updated_by
+		       bank_account_holder_name, phone_number, personal_email, pay_group_id,
+		       tax_regime, created_at, updated_at, created_by, updated_by
 		FROM employees
 		WHERE id = $1
 	`
@@ -92,10 +104,10 @@ func (r *EmployeeRepository) GetEmployeeByID(employeeID string) (*models.Employe
 	err := r.db.QueryRow(query, employeeID).Scan(
 		&emp.ID, &emp.OrgID, &emp.EmployeeID, &emp.FirstName, &emp.LastName, &emp.Email, &emp.DateOfBirth,
 		&emp.Gender, &emp.DateOfJoining, &emp.DateOfExit, &emp.EmploymentStatus, &emp.Department,
-		&emp.Designation, &emp.ManagerID, &emp.Location, &emp.PersonalPAN, &emp.AadhaarNumber,
+		&emp.Designation, &emp.ManagerID, &emp.Location, &emp.PersonalPAN, &emp.PANStatus, &emp.PANVerifiedAt, &emp.AadhaarNumber,
 		&emp.PassportNumber, &emp.BankName, &emp.BankAccountNumber, &emp.BankIFSCCode,
-		&emp.BankAccountHolder, &emp.PhoneNumber, &emp.PersonalEmail,
-		&emp.CreatedAt, &emp.UpdatedAt, &emp.CreatedBy, &emp.UpdatedBy,
+		&emp.BankAccountHolder, &emp.PhoneNumber, &emp.PersonalEmail, &emp.PayGroupID,
+		&emp.TaxRegime, &emp.CreatedAt, &emp.UpdatedAt, &emp.CreatedBy, &emp.UpdatedBy,
 	)
 
 	if err != nil {
@@ -108,12 +120,92 @@ func (r *EmployeeRepository) GetEmployeeByID(employeeID string) (*models.Employe
 	return &emp, nil
 }
 
+// UpdatePayGroup assigns an employee to a pay group
+func (r *EmployeeRepository) UpdatePayGroup(employeeID, payGroupID, updatedBy string) error {
+	query := `
+		UPDATE employees
+		SET pay_group_id = $1, updated_by = $2, updated_at = NOW()
+		WHERE id = $3
+	`
+
+	result, err := r.db.Exec(query, payGroupID, nullString(updatedBy), employeeID)
+	if err != nil {
+		return fmt.Errorf("failed to update employee pay group: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("employee not found")
+	}
+
+	return nil
+}
+
+// UpdateTaxRegime sets the income tax regime an employee opted for
+func (r *EmployeeRepository) UpdateTaxRegime(employeeID, taxRegime, updatedBy string) error {
+	query := `
+		UPDATE employees
+		SET tax_regime = $1, updated_by = $2, updated_at = NOW()
+		WHERE id = $3
+	`
+
+	result, err := r.db.Exec(query, taxRegime, nullString(updatedBy), employeeID)
+	if err != nil {
+		return fmt.Errorf("failed to update employee tax regime: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("employee not found")
+	}
+
+	return nil
+}
+
+// UpdatePAN records an employee's PAN and its verification status; the
+// verification time is kept while the PAN stays verified
+func (r *EmployeeRepository) UpdatePAN(employeeID, pan, status, updatedBy string) error {
+	query := `
+		UPDATE employees
+		SET personal_pan = $1, pan_status = $2,
+		    pan_verified_at = CASE WHEN $2 = 'pending' THEN NULL
+		                           WHEN personal_pan = $1 AND pan_status = $2 THEN pan_verified_at
+		                           ELSE NOW() END,
+		    updated_by = $3, updated_at = NOW()
+		WHERE id = $4
+	`
+
+	result, err := r.db.Exec(query, nullString(pan), status, nullString(updatedBy), employeeID)
+	if err != nil {
+		return fmt.Errorf("failed to update employee PAN: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("employee not found")
+	}
+
+	return nil
+}
+
 // GetSalaryStructure fetches salary structure for an employee
 func (r *EmployeeRepository) GetSalaryStructure(employeeID string) (*models.SalaryStructure, error) {
 	query := `
 		SELECT ss.id, ss.org_id, ss.name, ss.description, ss.effective_from,
 		       ss.effective_till, ss.annual_ctc, ss.monthly_basic, ss.monthly_da,
-		       ss.monthly_hra, ss.monthly_allowance, ss.is_template, ss.is_active,
+		       ss.monthly_hra, ss.monthly_allowance, ss.monthly_superannuation, ss.is_template, ss.is_active,
 		       ss.created_at, ss.updated_at, ss.created_by
 		FROM salary_structures ss
 		INNER JOIN employee_salary_assignments esa ON ss.id = esa.salary_structure_id
@@ -126,7 +218,36 @@ func (r *EmployeeRepository) GetSalaryStructure(employeeID string) (*models.Sala
 	err := r.db.QueryRow(query, employeeID).Scan(
 		&ss.ID, &ss.OrgID, &ss.Name, &ss.Description, &ss.EffectiveFrom,
 		&ss.EffectiveTill, &ss.AnnualCTC, &ss.MonthlyBasic, &ss.MonthlyDA,
-		&ss.MonthlyHRA, &ss.MonthlyAllowance, &ss.IsTemplate, &ss.IsActive,
+		&ss.MonthlyHRA, &ss.MonthlyAllowance, &ss.MonthlySuperannuation, &ss.IsTemplate, &ss.IsActive,
+		&ss.CreatedAt, &ss.UpdatedAt, &ss.CreatedBy,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("salary structure not found")
+		}
+		return nil, fmt.Errorf("failed to query salary structure: %w", err)
+	}
+
+	return &ss, nil
+}
+
+// GetSalaryStructureByID fetches a salary structure by ID
+func (r *EmployeeRepository) GetSalaryStructureByID(id string) (*models.SalaryStructure, error) {
+	query := `
+		SELECT id, org_id, name, description, effective_from,
+		       effective_till, annual_ctc, monthly_basic, monthly_da,
+		       monthly_hra, monthly_allowance, monthly_superannuation, is_template, is_active,
+		       created_at, updated_at, created_by
+		FROM salary_structures
+		WHERE id = $1
+	`
+
+	var ss models.SalaryStructure
+	err := r.db.QueryRow(query, id).Scan(
+		&ss.ID, &ss.OrgID, &ss.Name, &ss.Description, &ss.EffectiveFrom,
+		&ss.EffectiveTill, &ss.AnnualCTC, &ss.MonthlyBasic, &ss.MonthlyDA,
+		&ss.MonthlyHRA, &ss.MonthlyAllowance, &ss.MonthlySuperannuation, &ss.IsTemplate, &ss.IsActive,
 		&ss.CreatedAt, &ss.UpdatedAt, &ss.CreatedBy,
 	)
 
@@ -140,6 +261,45 @@ func (r *EmployeeRepository) GetSalaryStructure(employeeID string) (*models.Sala
 	return &ss, nil
 }
 
+// GetSalaryStructuresByID fetches salary structures by ID, keyed by ID
+func (r *EmployeeRepository) GetSalaryStructuresByID(ids []string) (map[string]*models.SalaryStructure, error) {
+	query := `
+		SELECT id, org_id, name, description, effective_from,
+		       effective_till, annual_ctc, monthly_basic, monthly_da,
+		       monthly_hra, monthly_allowance, monthly_superannuation, is_template, is_active,
+		       created_at, updated_at, created_by
+		FROM salary_structures
+		WHERE id = ANY($1)
+	`
+
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query salary structures: %w", err)
+	}
+	defer rows.Close()
+
+	structures := map[string]*models.SalaryStructure{}
+	for rows.Next() {
+		var ss models.SalaryStructure
+		err := rows.Scan(
+			&ss.ID, &ss.OrgID, &ss.Name, &ss.Description, &ss.EffectiveFrom,
+			&ss.EffectiveTill, &ss.AnnualCTC, &ss.MonthlyBasic, &ss.MonthlyDA,
+			&ss.MonthlyHRA, &ss.MonthlyAllowance, &ss.MonthlySuperannuation, &ss.IsTemplate, &ss.IsActive,
+			&ss.CreatedAt, &ss.UpdatedAt, &ss.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan salary structure: %w", err)
+		}
+		structures[ss.ID] = &ss
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating salary structures: %w", err)
+	}
+
+	return structures, nil
+}
+
 // GetAttendanceSummary fetches attendance summary for a month
 func (r *EmployeeRepository) GetAttendanceSummary(employeeID string, month string) (*models.AttendanceSummary, error) {
 	query := `
@@ -193,3 +353,124 @@ func (r *EmployeeRepository) GetLeaveSummary(employeeID string, month string) (*
 
 	return &ls, nil
 }
+
+// GetSalaryStructures fetches the current salary structure of each of the
+// given employees, keyed by employee ID. Employees without one are left out.
+func (r *EmployeeRepository) GetSalaryStructures(employeeIDs []string) (map[string]*models.SalaryStructure, error) {
+	query := `
+		SELECT DISTINCT ON (esa.employee_id) esa.employee_id,
+		       ss.id, ss.org_id, ss.name, ss.description, ss.effective_from,
+		       ss.effective_till, ss.annual_ctc, ss.monthly_basic, ss.monthly_da,
+		       ss.monthly_hra, ss.monthly_allowance, ss.monthly_superannuation, ss.is_template, ss.is_active,
+		       ss.created_at, ss.updated_at, ss.created_by
+		FROM salary_structures ss
+		INNER JOIN employee_salary_assignments esa ON ss.id = esa.salary_structure_id
+		WHERE esa.employee_id = ANY($1) AND esa.effective_till IS NULL
+		ORDER BY esa.employee_id, ss.effective_from DESC
+	`
+
+	rows, err := r.db.Query(query, pq.Array(employeeIDs))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query salary structures: %w", err)
+	}
+	defer rows.Close()
+
+	structures := map[string]*models.SalaryStructure{}
+	for rows.Next() {
+		var employeeID string
+		var ss models.SalaryStructure
+		err := rows.Scan(
+			&employeeID,
+			&ss.ID, &ss.OrgID, &ss.Name, &ss.Description, &ss.EffectiveFrom,
+			&ss.EffectiveTill, &ss.AnnualCTC, &ss.MonthlyBasic, &ss.MonthlyDA,
+			&ss.MonthlyHRA, &ss.MonthlyAllowance, &ss.MonthlySuperannuation, &ss.IsTemplate, &ss.IsActive,
+			&ss.CreatedAt, &ss.UpdatedAt, &ss.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan salary structure: %w", err)
+		}
+		structures[employeeID] = &ss
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating salary structures: %w", err)
+	}
+
+	return structures, nil
+}
+
+// GetAttendanceSummaries fetches the attendance summaries of the given
+// employees for a month, keyed by employee ID
+func (r *EmployeeRepository) GetAttendanceSummaries(employeeIDs []string, month string) (map[string]*models.AttendanceSummary, error) {
+	query := `
+		SELECT id, org_id, employee_id, attendance_month, total_days_in_month,
+		       present_days, absent_days, leave_days, holiday_days,
+		       working_days_expected, working_days_actual, created_at, updated_at
+		FROM attendance_summary
+		WHERE employee_id = ANY($1) AND attendance_month = $2
+	`
+
+	rows, err := r.db.Query(query, pq.Array(employeeIDs), month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attendance summaries: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := map[string]*models.AttendanceSummary{}
+	for rows.Next() {
+		var as models.AttendanceSummary
+		err := rows.Scan(
+			&as.ID, &as.OrgID, &as.EmployeeID, &as.AttendanceMonth, &as.TotalDaysInMonth,
+			&as.PresentDays, &as.AbsentDays, &as.LeaveDays, &as.HolidayDays,
+			&as.WorkingDaysExpected, &as.WorkingDaysActual, &as.CreatedAt, &as.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan attendance summary: %w", err)
+		}
+		summaries[as.EmployeeID] = &as
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating attendance summaries: %w", err)
+	}
+
+	return summaries, nil
+}
+
+// GetLeaveSummaries fetches the leave summaries of the given employees for a
+// month, keyed by employee ID
+func (r *EmployeeRepository) GetLeaveSummaries(employeeIDs []string, month string) (map[string]*models.LeaveSummary, error) {
+	query := `
+		SELECT id, org_id, employee_id, leave_month, casual_leave_taken,
+		       sick_leave_taken, earned_leave_taken, unpaid_leave_taken,
+		       total_leave_days_deducted, loss_of_pay, created_at, updated_at
+		FROM leave_summary
+		WHERE employee_id = ANY($1) AND leave_month = $2
+	`
+
+	rows, err := r.db.Query(query, pq.Array(employeeIDs), month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query leave summaries: %w", err)
+	}
+	defer rows.Close()
+
+	summaries := map[string]*models.LeaveSummary{}
+	for rows.Next() {
+		var ls models.LeaveSummary
+		err := rows.Scan(
+			&ls.ID, &ls.OrgID, &ls.EmployeeID, &ls.LeaveMonth, &ls.CasualLeaveTaken,
+			&ls.SickLeaveTaken, &ls.EarnedLeaveTaken, &ls.UnpaidLeaveTaken,
+			&ls.TotalLeaveDaysDeducted, &ls.LossOfPay, &ls.CreatedAt, &ls.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan leave summary: %w", err)
+		}
+		summaries[ls.EmployeeID] = &ls
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating leave summaries: %w", err)
+	}
+
+	return summaries, nil
+}
@@ -0,0 +1,323 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type EquityRepository struct {
+	db *sql.DB
+}
+
+func NewEquityRepository(db *sql.DB) *EquityRepository {
+	return &EquityRepository{db: db}
+}
+
+// equityGrantColumns lists the equity_grants columns in scan order
+const equityGrantColumns = `
+	id, org_id, employee_id, grant_number, grant_type, plan_name, grant_date,
+	units, exercise_price, status, created_at, updated_at, created_by
+`
+
+// equityEventColumns lists the equity_events columns in scan order
+const equityEventColumns = `
+	id, org_id, employee_id, grant_id, event_type, event_date, units,
+	fmv, exercise_price, perquisite_value, tax_deferred, tax_due_date, status,
+	perquisite_run_id, perquisite_component_id, tax_run_id, tax_component_id,
+	created_at, updated_at, created_by
+`
+
+// CreateEquityGrant records an ESOP or RSU grant
+func (r *EquityRepository) CreateEquityGrant(g *models.EquityGrant) error {
+	query := `
+		INSERT INTO equity_grants (
+			org_id, employee_id, grant_number, grant_type, plan_name, grant_date,
+			units, exercise_price, status, created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'active', NOW(), NOW(), $9)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		g.OrgID, g.EmployeeID, g.GrantNumber, g.GrantType, g.PlanName, g.GrantDate,
+		g.Units, g.ExercisePrice, g.CreatedBy,
+	).Scan(&g.ID, &g.Status, &g.CreatedAt, &g.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create equity grant: %w", err)
+	}
+
+	return nil
+}
+
+// GetEquityGrants fetches an organization's equity grants with optional filters
+func (r *EquityRepository) GetEquityGrants(orgID string, filters map[string]interface{}) ([]models.EquityGrant, error) {
+	query := "SELECT " + equityGrantColumns + " FROM equity_grants WHERE org_id = $1"
+	args := []interface{}{orgID}
+	argCount := 2
+
+	// Apply filters
+	if employeeID, ok := filters["employee_id"].(string); ok {
+		query += fmt.Sprintf(" AND employee_id = $%d", argCount)
+		args = append(args, employeeID)
+		argCount++
+	}
+
+	if status, ok := filters["status"].(string); ok {
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+
+	query += " ORDER BY grant_date DESC, grant_number"
+
+	return r.queryEquityGrants(query, args...)
+}
+
+// GetEquityGrantByID fetches a single equity grant
+func (r *EquityRepository) GetEquityGrantByID(id string) (*models.EquityGrant, error) {
+	grants, err := r.queryEquityGrants("SELECT "+equityGrantColumns+" FROM equity_grants WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(grants) == 0 {
+		return nil, fmt.Errorf("equity grant not found")
+	}
+
+	return &grants[0], nil
+}
+
+// GetGrantUnits sums the units of a grant vested, exercised and sold so far,
+// leaving out cancelled events
+func (r *EquityRepository) GetGrantUnits(grantID string) (vested, exercised, sold float64, err error) {
+	query := `
+		SELECT COALESCE(SUM(units) FILTER (WHERE event_type = 'vest'), 0),
+		       COALESCE(SUM(units) FILTER (WHERE event_type = 'exercise'), 0),
+		       COALESCE(SUM(units) FILTER (WHERE event_type = 'sale'), 0)
+		FROM equity_events
+		WHERE grant_id = $1 AND status <> 'cancelled'
+	`
+
+	if err = r.db.QueryRow(query, grantID).Scan(&vested, &exercised, &sold); err != nil {
+		return 0, 0, 0, fmt.Errorf("failed to query grant units: %w", err)
+	}
+
+	return vested, exercised, sold, nil
+}
+
+// CreateEquityEvent records a vesting, exercise or sale
+func (r *EquityRepository) CreateEquityEvent(e *models.EquityEvent) error {
+	query := `
+		INSERT INTO equity_events (
+			org_id, employee_id, grant_id, event_type, event_date, units,
+			fmv, exercise_price, perquisite_value, tax_deferred, tax_due_date, status,
+			created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW(), $13)
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		e.OrgID, e.EmployeeID, e.GrantID, e.EventType, e.EventDate, e.Units,
+		e.FMV, e.ExercisePrice, e.PerquisiteValue, e.TaxDeferred, e.TaxDueDate, e.Status,
+		e.CreatedBy,
+	).Scan(&e.ID, &e.CreatedAt, &e.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create equity event: %w", err)
+	}
+
+	return nil
+}
+
+// GetEquityEvents fetches an organization's equity events with optional filters
+func (r *EquityRepository) GetEquityEvents(orgID string, filters map[string]interface{}) ([]models.EquityEvent, error) {
+	query := "SELECT " + equityEventColumns + " FROM equity_events WHERE org_id = $1"
+	args := []interface{}{orgID}
+	argCount := 2
+
+	// Apply filters
+	if employeeID, ok := filters["employee_id"].(string); ok {
+		query += fmt.Sprintf(" AND employee_id = $%d", argCount)
+		args = append(args, employeeID)
+		argCount++
+	}
+
+	if grantID, ok := filters["grant_id"].(string); ok {
+		query += fmt.Sprintf(" AND grant_id = $%d", argCount)
+		args = append(args, grantID)
+		argCount++
+	}
+
+	if status, ok := filters["status"].(string); ok {
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+
+	if from, ok := filters["from"].(time.Time); ok {
+		query += fmt.Sprintf(" AND event_date >= $%d", argCount)
+		args = append(args, from)
+		argCount++
+	}
+
+	if to, ok := filters["to"].(time.Time); ok {
+		query += fmt.Sprintf(" AND event_date <= $%d", argCount)
+		args = append(args, to)
+		argCount++
+	}
+
+	query += " ORDER BY event_date, created_at"
+
+	return r.queryEquityEvents(query, args...)
+}
+
+// GetPayablePerquisites fetches the perquisites a payroll run should report
+// for the given employees, keyed by employee ID: those pending on or before
+// periodEnd, and those it already reported if the run is being initiated
+// again
+func (r *EquityRepository) GetPayablePerquisites(employeeIDs []string, payrollRunID string, periodEnd time.Time) (map[string][]models.EquityEvent, error) {
+	query := "SELECT " + equityEventColumns + ` FROM equity_events
+		WHERE employee_id = ANY($1)
+		  AND ((status = 'pending' AND event_date <= $3)
+		       OR (status IN ('deferred', 'taxed') AND perquisite_run_id = $2))
+		ORDER BY event_date, created_at`
+
+	events, err := r.queryEquityEvents(query, pq.Array(employeeIDs), payrollRunID, periodEnd)
+	if err != nil {
+		return nil, err
+	}
+	return equityEventsByEmployee(events), nil
+}
+
+// GetDueDeferredPerquisites fetches perquisites reported by earlier runs whose
+// tax deferral ends by periodEnd, keyed by employee ID: the due date has
+// passed, shares of the grant were sold, or the employee is among those
+// leaving. Those the run already taxed are included if it is being initiated
+// again.
+func (r *EquityRepository) GetDueDeferredPerquisites(employeeIDs []string, payrollRunID string, periodEnd time.Time, exitingIDs []string) (map[string][]models.EquityEvent, error) {
+	query := "SELECT " + equityEventColumns + ` FROM equity_events e
+		WHERE e.employee_id = ANY($1)
+		  AND e.perquisite_run_id IS DISTINCT FROM $2
+		  AND ((e.status = 'deferred' AND (
+		          e.tax_due_date <= $3 OR e.employee_id = ANY($4)
+		          OR EXISTS (
+		              SELECT 1 FROM equity_events s
+		              WHERE s.grant_id = e.grant_id AND s.event_type = 'sale'
+		                AND s.status <> 'cancelled' AND s.event_date <= $3
+		          )))
+		       OR (e.status = 'taxed' AND e.tax_run_id = $2))
+		ORDER BY e.event_date, e.created_at`
+
+	events, err := r.queryEquityEvents(query, pq.Array(employeeIDs), payrollRunID, periodEnd, pq.Array(exitingIDs))
+	if err != nil {
+		return nil, err
+	}
+	return equityEventsByEmployee(events), nil
+}
+
+func equityEventsByEmployee(events []models.EquityEvent) map[string][]models.EquityEvent {
+	byEmployee := make(map[string][]models.EquityEvent)
+	for _, e := range events {
+		byEmployee[e.EmployeeID] = append(byEmployee[e.EmployeeID], e)
+	}
+	return byEmployee
+}
+
+// MarkPerquisiteReported records, in the component's transaction, the payroll
+// component that reported a perquisite, and that taxed it unless tax is
+// deferred
+func (r *EquityRepository) MarkPerquisiteReported(tx *sql.Tx, id, payrollRunID, payrollComponentID string, deferred bool) error {
+	query := `
+		UPDATE equity_events
+		SET status = CASE WHEN $4 THEN 'deferred' ELSE 'taxed' END,
+		    perquisite_run_id = $2, perquisite_component_id = $3,
+		    tax_run_id = CASE WHEN $4 THEN NULL ELSE $2::uuid END,
+		    tax_component_id = CASE WHEN $4 THEN NULL ELSE $3::uuid END,
+		    updated_at = NOW()
+		WHERE id = $1
+	`
+
+	if _, err := tx.Exec(query, id, payrollRunID, payrollComponentID, deferred); err != nil {
+		return fmt.Errorf("failed to mark equity perquisite reported: %w", err)
+	}
+
+	return nil
+}
+
+// MarkDeferredPerquisiteTaxed records, in the component's transaction, the
+// payroll component that taxed a deferred perquisite
+func (r *EquityRepository) MarkDeferredPerquisiteTaxed(tx *sql.Tx, id, payrollRunID, payrollComponentID string) error {
+	query := `
+		UPDATE equity_events
+		SET status = 'taxed', tax_run_id = $2, tax_component_id = $3, updated_at = NOW()
+		WHERE id = $1 AND status IN ('deferred', 'taxed')
+	`
+
+	if _, err := tx.Exec(query, id, payrollRunID, payrollComponentID); err != nil {
+		return fmt.Errorf("failed to mark deferred perquisite taxed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *EquityRepository) queryEquityGrants(query string, args ...interface{}) ([]models.EquityGrant, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query equity grants: %w", err)
+	}
+	defer rows.Close()
+
+	var grants []models.EquityGrant
+	for rows.Next() {
+		var g models.EquityGrant
+		err := rows.Scan(
+			&g.ID, &g.OrgID, &g.EmployeeID, &g.GrantNumber, &g.GrantType, &g.PlanName, &g.GrantDate,
+			&g.Units, &g.ExercisePrice, &g.Status, &g.CreatedAt, &g.UpdatedAt, &g.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan equity grant: %w", err)
+		}
+		grants = append(grants, g)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating equity grants: %w", err)
+	}
+
+	return grants, nil
+}
+
+func (r *EquityRepository) queryEquityEvents(query string, args ...interface{}) ([]models.EquityEvent, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query equity events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.EquityEvent
+	for rows.Next() {
+		var e models.EquityEvent
+		err := rows.Scan(
+			&e.ID, &e.OrgID, &e.EmployeeID, &e.GrantID, &e.EventType, &e.EventDate, &e.Units,
+			&e.FMV, &e.ExercisePrice, &e.PerquisiteValue, &e.TaxDeferred, &e.TaxDueDate, &e.Status,
+			&e.PerquisiteRunID, &e.PerquisiteComponentID, &e.TaxRunID, &e.TaxComponentID,
+			&e.CreatedAt, &e.UpdatedAt, &e.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan equity event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating equity events: %w", err)
+	}
+
+	return events, nil
+}
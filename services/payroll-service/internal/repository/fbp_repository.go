@@ -0,0 +1,411 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type FBPRepository struct {
+	db *sql.DB
+}
+
+func NewFBPRepository(db *sql.DB) *FBPRepository {
+	return &FBPRepository{db: db}
+}
+
+// fbpDeclarationColumns lists the fbp_declarations columns in scan order
+const fbpDeclarationColumns = `
+	id, org_id, employee_id, financial_year, component, annual_amount,
+	payout_type, created_at, updated_at, created_by
+`
+
+// fbpClaimColumns lists the fbp_claims columns in scan order
+const fbpClaimColumns = `
+	id, org_id, employee_id, financial_year, component, claim_date, amount,
+	bill_reference, description, status, reviewed_by, reviewed_at,
+	payroll_run_id, payroll_component_id, created_at, updated_at, created_by
+`
+
+// GetFBPPlan fetches the flexible benefit plan of a salary structure
+func (r *FBPRepository) GetFBPPlan(salaryStructureID string) ([]models.FBPPlanComponent, error) {
+	query := `
+		SELECT id, org_id, salary_structure_id, component, annual_limit, payout_type,
+		       is_active, created_at, updated_at, updated_by
+		FROM fbp_plan_components
+		WHERE salary_structure_id = $1
+		ORDER BY component
+	`
+
+	rows, err := r.db.Query(query, salaryStructureID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query FBP plan: %w", err)
+	}
+	defer rows.Close()
+
+	var plan []models.FBPPlanComponent
+	for rows.Next() {
+		var c models.FBPPlanComponent
+		err := rows.Scan(
+			&c.ID, &c.OrgID, &c.SalaryStructureID, &c.Component, &c.AnnualLimit, &c.PayoutType,
+			&c.IsActive, &c.CreatedAt, &c.UpdatedAt, &c.UpdatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan FBP plan component: %w", err)
+		}
+		plan = append(plan, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating FBP plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// UpsertFBPPlan creates or replaces the given components of a salary
+// structure's flexible benefit plan
+func (r *FBPRepository) UpsertFBPPlan(components []models.FBPPlanComponent) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO fbp_plan_components (
+			org_id, salary_structure_id, component, annual_limit, payout_type,
+			is_active, created_at, updated_at, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW(), $7)
+		ON CONFLICT (salary_structure_id, component) DO UPDATE SET
+			annual_limit = EXCLUDED.annual_limit,
+			payout_type = EXCLUDED.payout_type,
+			is_active = EXCLUDED.is_active,
+			updated_at = NOW(),
+			updated_by = EXCLUDED.updated_by
+		RETURNING id, created_at, updated_at
+	`
+
+	for i := range components {
+		c := &components[i]
+		err := tx.QueryRow(
+			query,
+			c.OrgID, c.SalaryStructureID, c.Component, c.AnnualLimit, c.PayoutType,
+			c.IsActive, c.UpdatedBy,
+		).Scan(&c.ID, &c.CreatedAt, &c.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save FBP plan component: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetFBPDeclarations fetches an employee's FBP declarations for a financial year
+func (r *FBPRepository) GetFBPDeclarations(employeeID string, financialYear int) ([]models.FBPDeclaration, error) {
+	query := "SELECT " + fbpDeclarationColumns + ` FROM fbp_declarations
+		WHERE employee_id = $1 AND financial_year = $2
+		ORDER BY component`
+
+	return r.queryFBPDeclarations(query, employeeID, financialYear)
+}
+
+// GetEmployeesFBPDeclarations fetches the given employees' FBP declarations
+// for a financial year, keyed by employee ID
+func (r *FBPRepository) GetEmployeesFBPDeclarations(employeeIDs []string, financialYear int) (map[string][]models.FBPDeclaration, error) {
+	query := "SELECT " + fbpDeclarationColumns + ` FROM fbp_declarations
+		WHERE employee_id = ANY($1) AND financial_year = $2
+		ORDER BY employee_id, component`
+
+	declarations, err := r.queryFBPDeclarations(query, pq.Array(employeeIDs), financialYear)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmployee := make(map[string][]models.FBPDeclaration)
+	for _, d := range declarations {
+		byEmployee[d.EmployeeID] = append(byEmployee[d.EmployeeID], d)
+	}
+	return byEmployee, nil
+}
+
+func (r *FBPRepository) queryFBPDeclarations(query string, args ...interface{}) ([]models.FBPDeclaration, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query FBP declarations: %w", err)
+	}
+	defer rows.Close()
+
+	var declarations []models.FBPDeclaration
+	for rows.Next() {
+		var d models.FBPDeclaration
+		err := rows.Scan(
+			&d.ID, &d.OrgID, &d.EmployeeID, &d.FinancialYear, &d.Component, &d.AnnualAmount,
+			&d.PayoutType, &d.CreatedAt, &d.UpdatedAt, &d.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan FBP declaration: %w", err)
+		}
+		declarations = append(declarations, d)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating FBP declarations: %w", err)
+	}
+
+	return declarations, nil
+}
+
+// ReplaceFBPDeclarations replaces all of an employee's FBP declarations for a
+// financial year
+func (r *FBPRepository) ReplaceFBPDeclarations(employeeID string, financialYear int, declarations []models.FBPDeclaration) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM fbp_declarations WHERE employee_id = $1 AND financial_year = $2`, employeeID, financialYear); err != nil {
+		return fmt.Errorf("failed to clear FBP declarations: %w", err)
+	}
+
+	query := `
+		INSERT INTO fbp_declarations (
+			org_id, employee_id, financial_year, component, annual_amount, payout_type,
+			created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW(), $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	for i := range declarations {
+		d := &declarations[i]
+		err := tx.QueryRow(
+			query,
+			d.OrgID, employeeID, financialYear, d.Component, d.AnnualAmount, d.PayoutType, d.CreatedBy,
+		).Scan(&d.ID, &d.CreatedAt, &d.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create FBP declaration: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreateFBPClaim records a submitted claim
+func (r *FBPRepository) CreateFBPClaim(claim *models.FBPClaim) error {
+	query := `
+		INSERT INTO fbp_claims (
+			org_id, employee_id, financial_year, component, claim_date, amount,
+			bill_reference, description, status, created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, 'submitted', NOW(), NOW(), $9)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		claim.OrgID, claim.EmployeeID, claim.FinancialYear, claim.Component, claim.ClaimDate, claim.Amount,
+		claim.BillReference, claim.Description, claim.CreatedBy,
+	).Scan(&claim.ID, &claim.Status, &claim.CreatedAt, &claim.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create FBP claim: %w", err)
+	}
+
+	return nil
+}
+
+// GetFBPClaims fetches an organization's FBP claims with optional filters
+func (r *FBPRepository) GetFBPClaims(orgID string, filters map[string]interface{}) ([]models.FBPClaim, error) {
+	query := "SELECT " + fbpClaimColumns + " FROM fbp_claims WHERE org_id = $1"
+	args := []interface{}{orgID}
+	argCount := 2
+
+	// Apply filters
+	if employeeID, ok := filters["employee_id"].(string); ok {
+		query += fmt.Sprintf(" AND employee_id = $%d", argCount)
+		args = append(args, employeeID)
+		argCount++
+	}
+
+	if status, ok := filters["status"].(string); ok {
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+
+	if year, ok := filters["financial_year"].(int); ok {
+		query += fmt.Sprintf(" AND financial_year = $%d", argCount)
+		args = append(args, year)
+		argCount++
+	}
+
+	query += " ORDER BY claim_date DESC, created_at DESC"
+
+	return r.queryFBPClaims(query, args...)
+}
+
+// GetFBPClaimByID fetches a single FBP claim
+func (r *FBPRepository) GetFBPClaimByID(id string) (*models.FBPClaim, error) {
+	claims, err := r.queryFBPClaims("SELECT "+fbpClaimColumns+" FROM fbp_claims WHERE id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(claims) == 0 {
+		return nil, fmt.Errorf("FBP claim not found")
+	}
+
+	return &claims[0], nil
+}
+
+// GetClaimedAmount returns what an employee has claimed against a component
+// in a financial year, leaving out rejected claims
+func (r *FBPRepository) GetClaimedAmount(employeeID string, financialYear int, component string) (float64, error) {
+	query := `
+		SELECT COALESCE(SUM(amount), 0)
+		FROM fbp_claims
+		WHERE employee_id = $1 AND financial_year = $2 AND component = $3 AND status <> 'rejected'
+	`
+
+	var claimed float64
+	if err := r.db.QueryRow(query, employeeID, financialYear, component).Scan(&claimed); err != nil {
+		return 0, fmt.Errorf("failed to query claimed FBP amount: %w", err)
+	}
+
+	return claimed, nil
+}
+
+// ReviewFBPClaim approves or rejects a submitted claim
+func (r *FBPRepository) ReviewFBPClaim(id, status, reviewedBy string) error {
+	query := `
+		UPDATE fbp_claims
+		SET status = $2, reviewed_by = $3, reviewed_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status = 'submitted'
+	`
+
+	result, err := r.db.Exec(query, id, status, nullString(reviewedBy))
+	if err != nil {
+		return fmt.Errorf("failed to review FBP claim: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("submitted FBP claim not found")
+	}
+
+	return nil
+}
+
+// GetPayableFBPClaims fetches the claims a payroll run should pay the given
+// employees, keyed by employee ID: those approved, and those it already paid
+// if the run is being initiated again
+func (r *FBPRepository) GetPayableFBPClaims(employeeIDs []string, payrollRunID string) (map[string][]models.FBPClaim, error) {
+	query := "SELECT " + fbpClaimColumns + ` FROM fbp_claims
+		WHERE employee_id = ANY($1)
+		  AND (status = 'approved' OR (status = 'paid' AND payroll_run_id = $2))
+		ORDER BY claim_date, created_at`
+
+	claims, err := r.queryFBPClaims(query, pq.Array(employeeIDs), payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmployee := make(map[string][]models.FBPClaim)
+	for _, c := range claims {
+		byEmployee[c.EmployeeID] = append(byEmployee[c.EmployeeID], c)
+	}
+	return byEmployee, nil
+}
+
+// MarkFBPClaimPaid marks a claim paid by a payroll component, in the
+// component's transaction
+func (r *FBPRepository) MarkFBPClaimPaid(tx *sql.Tx, id, payrollRunID, payrollComponentID string) error {
+	query := `
+		UPDATE fbp_claims
+		SET status = 'paid', payroll_run_id = $2, payroll_component_id = $3, updated_at = NOW()
+		WHERE id = $1 AND status IN ('approved', 'paid')
+	`
+
+	if _, err := tx.Exec(query, id, payrollRunID, payrollComponentID); err != nil {
+		return fmt.Errorf("failed to mark FBP claim paid: %w", err)
+	}
+
+	return nil
+}
+
+// GetFBPBalances returns the given employees' claim-based FBP accrued and not
+// yet claimed or paid out, from other payroll runs attributed to fromMonth
+// through toMonth (YYYY-MM), keyed by employee ID
+func (r *FBPRepository) GetFBPBalances(employeeIDs []string, fromMonth, toMonth, excludePayrollRunID string) (map[string]float64, error) {
+	query := `
+		SELECT pc.employee_id, SUM(pc.fbp_accrued - pc.fbp_claims - pc.fbp_unclaimed_payout)
+		FROM payroll_components pc
+		JOIN payroll_runs pr ON pr.id = pc.payroll_run_id
+		WHERE pc.employee_id = ANY($1) AND pr.payroll_month BETWEEN $2 AND $3 AND pr.id <> $4
+		GROUP BY pc.employee_id
+	`
+
+	rows, err := r.db.Query(query, pq.Array(employeeIDs), fromMonth, toMonth, excludePayrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query FBP balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[string]float64)
+	for rows.Next() {
+		var employeeID string
+		var balance float64
+		if err := rows.Scan(&employeeID, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan FBP balance: %w", err)
+		}
+		balances[employeeID] = balance
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating FBP balances: %w", err)
+	}
+
+	return balances, nil
+}
+
+func (r *FBPRepository) queryFBPClaims(query string, args ...interface{}) ([]models.FBPClaim, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query FBP claims: %w", err)
+	}
+	defer rows.Close()
+
+	var claims []models.FBPClaim
+	for rows.Next() {
+		var c models.FBPClaim
+		err := rows.Scan(
+			&c.ID, &c.OrgID, &c.EmployeeID, &c.FinancialYear, &c.Component, &c.ClaimDate, &c.Amount,
+			&c.BillReference, &c.Description, &c.Status, &c.ReviewedBy, &c.ReviewedAt,
+			&c.PayrollRunID, &c.PayrollComponentID, &c.CreatedAt, &c.UpdatedAt, &c.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan FBP claim: %w", err)
+		}
+		claims = append(claims, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating FBP claims: %w", err)
+	}
+
+	return claims, nil
+}
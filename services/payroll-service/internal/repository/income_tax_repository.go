@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"payroll-service/internal/models"
+)
+
+type IncomeTaxRepository struct {
+	db *sql.DB
+}
+
+func NewIncomeTaxRepository(db *sql.DB) *IncomeTaxRepository {
+	return &IncomeTaxRepository{db: db}
+}
+
+// GetIncomeTaxSlabs fetches a financial year's slabs, of one regime or of
+// both when taxRegime is empty
+func (r *IncomeTaxRepository) GetIncomeTaxSlabs(financialYear int, taxRegime string) ([]models.IncomeTaxSlab, error) {
+	query := `
+		SELECT id, financial_year, tax_regime, min_age, income_from, income_to, rate,
+		       created_at, updated_at, created_by
+		FROM income_tax_slabs
+		WHERE financial_year = $1 AND ($2 = '' OR tax_regime = $2)
+		ORDER BY tax_regime, min_age, income_from
+	`
+
+	rows, err := r.db.Query(query, financialYear, taxRegime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query income tax slabs: %w", err)
+	}
+	defer rows.Close()
+
+	var slabs []models.IncomeTaxSlab
+	for rows.Next() {
+		var s models.IncomeTaxSlab
+		err := rows.Scan(
+			&s.ID, &s.FinancialYear, &s.TaxRegime, &s.MinAge, &s.IncomeFrom, &s.IncomeTo, &s.Rate,
+			&s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan income tax slab: %w", err)
+		}
+		slabs = append(slabs, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating income tax slabs: %w", err)
+	}
+
+	return slabs, nil
+}
+
+// GetIncomeTaxSurcharges fetches a financial year's surcharge thresholds, of
+// one regime or of both when taxRegime is empty
+func (r *IncomeTaxRepository) GetIncomeTaxSurcharges(financialYear int, taxRegime string) ([]models.IncomeTaxSurcharge, error) {
+	query := `
+		SELECT id, financial_year, tax_regime, income_above, rate,
+		       created_at, updated_at, created_by
+		FROM income_tax_surcharges
+		WHERE financial_year = $1 AND ($2 = '' OR tax_regime = $2)
+		ORDER BY tax_regime, income_above
+	`
+
+	rows, err := r.db.Query(query, financialYear, taxRegime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query income tax surcharges: %w", err)
+	}
+	defer rows.Close()
+
+	var surcharges []models.IncomeTaxSurcharge
+	for rows.Next() {
+		var s models.IncomeTaxSurcharge
+		err := rows.Scan(
+			&s.ID, &s.FinancialYear, &s.TaxRegime, &s.IncomeAbove, &s.Rate,
+			&s.CreatedAt, &s.UpdatedAt, &s.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan income tax surcharge: %w", err)
+		}
+		surcharges = append(surcharges, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating income tax surcharges: %w", err)
+	}
+
+	return surcharges, nil
+}
+
+// ReplaceIncomeTaxTable replaces the slabs and surcharges of a financial year
+// and regime in one transaction
+func (r *IncomeTaxRepository) ReplaceIncomeTaxTable(financialYear int, taxRegime string, slabs []models.IncomeTaxSlab, surcharges []models.IncomeTaxSurcharge) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM income_tax_slabs WHERE financial_year = $1 AND tax_regime = $2", financialYear, taxRegime); err != nil {
+		return fmt.Errorf("failed to delete income tax slabs: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM income_tax_surcharges WHERE financial_year = $1 AND tax_regime = $2", financialYear, taxRegime); err != nil {
+		return fmt.Errorf("failed to delete income tax surcharges: %w", err)
+	}
+
+	slabQuery := `
+		INSERT INTO income_tax_slabs (
+			financial_year, tax_regime, min_age, income_from, income_to, rate,
+			created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW(), $7)
+		RETURNING id, created_at, updated_at
+	`
+
+	for i := range slabs {
+		s := &slabs[i]
+		err := tx.QueryRow(
+			slabQuery,
+			s.FinancialYear, s.TaxRegime, s.MinAge, s.IncomeFrom, s.IncomeTo, s.Rate, s.CreatedBy,
+		).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create income tax slab: %w", err)
+		}
+	}
+
+	surchargeQuery := `
+		INSERT INTO income_tax_surcharges (
+			financial_year, tax_regime, income_above, rate,
+			created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	for i := range surcharges {
+		s := &surcharges[i]
+		err := tx.QueryRow(
+			surchargeQuery,
+			s.FinancialYear, s.TaxRegime, s.IncomeAbove, s.Rate, s.CreatedBy,
+		).Scan(&s.ID, &s.CreatedAt, &s.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create income tax surcharge: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
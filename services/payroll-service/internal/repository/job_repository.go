@@ -0,0 +1,317 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type JobRepository struct {
+	db *sql.DB
+}
+
+func NewJobRepository(db *sql.DB) *JobRepository {
+	return &JobRepository{db: db}
+}
+
+const jobColumns = `
+		id, org_id, job_type, payload, lock_key, status, progress_done, progress_total,
+		result, error, attempts, max_attempts, run_after, lease_owner, lease_expires_at,
+		cancel_requested, created_by, created_at, started_at, finished_at, updated_at
+`
+
+// GetJobs lists an organization's jobs, newest first, with optional filters
+func (r *JobRepository) GetJobs(orgID string, filters map[string]interface{}) ([]models.Job, error) {
+	query := `SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE org_id = $1
+	`
+	args := []interface{}{orgID}
+	argCount := 2
+
+	if status, ok := filters["status"].(string); ok {
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+
+	if jobType, ok := filters["job_type"].(string); ok {
+		query += fmt.Sprintf(" AND job_type = $%d", argCount)
+		args = append(args, jobType)
+		argCount++
+	}
+
+	query += " ORDER BY created_at DESC LIMIT 100"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanJobs(rows)
+}
+
+// GetJobByID fetches a job by ID
+func (r *JobRepository) GetJobByID(id string) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE id = $1
+	`
+
+	rows, err := r.db.Query(query, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query job: %w", err)
+	}
+	defer rows.Close()
+
+	jobs, err := scanJobs(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(jobs) == 0 {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	return &jobs[0], nil
+}
+
+// GetActiveJob fetches the queued or running job holding a lock key.
+// Returns nil when there is none.
+func (r *JobRepository) GetActiveJob(lockKey string) (*models.Job, error) {
+	query := `SELECT ` + jobColumns + `
+		FROM jobs
+		WHERE lock_key = $1 AND status IN ('queued', 'running')
+	`
+
+	rows, err := r.db.Query(query, lockKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query active job: %w", err)
+	}
+	defer rows.Close()
+
+	jobs, err := scanJobs(rows)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return &jobs[0], nil
+}
+
+// CreateJob queues a job
+func (r *JobRepository) CreateJob(job *models.Job) error {
+	query := `
+		INSERT INTO jobs (
+			org_id, job_type, payload, lock_key, status, max_attempts,
+			run_after, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, 'queued', $5, NOW(), $6, NOW(), NOW())
+		RETURNING id, status, run_after, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		job.OrgID, job.JobType, string(job.Payload), job.LockKey, job.MaxAttempts,
+		job.CreatedBy,
+	).Scan(&job.ID, &job.Status, &job.RunAfter, &job.CreatedAt, &job.UpdatedAt)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("a job for %s is already queued or running", *job.LockKey)
+		}
+		return fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return nil
+}
+
+// ClaimJob leases the next due job to a worker: a queued job whose retry
+// time has come, or a running one whose worker let its lease expire. Locked
+// rows are skipped so workers never wait on each other. Returns nil when no
+// job is due.
+func (r *JobRepository) ClaimJob(owner string, lease time.Duration) (*models.Job, error) {
+	query := `
+		UPDATE jobs SET
+			status = 'running',
+			attempts = attempts + 1,
+			lease_owner = $1,
+			lease_expires_at = NOW() + ($2::FLOAT8 * INTERVAL '1 second'),
+			started_at = NOW(),
+			updated_at = NOW()
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE (status = 'queued' AND run_after <= NOW())
+			   OR (status = 'running' AND lease_expires_at < NOW())
+			ORDER BY run_after
+			FOR UPDATE SKIP LOCKED
+			LIMIT 1
+		)
+		RETURNING ` + jobColumns
+
+	rows, err := r.db.Query(query, owner, lease.Seconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	defer rows.Close()
+
+	jobs, err := scanJobs(rows)
+	if err != nil || len(jobs) == 0 {
+		return nil, err
+	}
+
+	return &jobs[0], nil
+}
+
+// RenewJobLease records a running job's progress and extends its worker's
+// lease. Reports whether cancellation has been requested; fails when the
+// worker no longer holds the job.
+func (r *JobRepository) RenewJobLease(id, owner string, done, total int, lease time.Duration) (bool, error) {
+	query := `
+		UPDATE jobs SET
+			progress_done = $3,
+			progress_total = $4,
+			lease_expires_at = NOW() + ($5::FLOAT8 * INTERVAL '1 second'),
+			updated_at = NOW()
+		WHERE id = $1 AND lease_owner = $2 AND status = 'running'
+		RETURNING cancel_requested
+	`
+
+	var cancelRequested bool
+	err := r.db.QueryRow(query, id, owner, done, total, lease.Seconds()).Scan(&cancelRequested)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("job %s is no longer held by %s", id, owner)
+		}
+		return false, fmt.Errorf("failed to renew job lease: %w", err)
+	}
+
+	return cancelRequested, nil
+}
+
+// CompleteJob stores a running job's result
+func (r *JobRepository) CompleteJob(id, owner string, result []byte) error {
+	query := `
+		UPDATE jobs SET
+			status = 'completed',
+			result = $3,
+			progress_done = GREATEST(progress_done, progress_total),
+			lease_owner = NULL,
+			lease_expires_at = NULL,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND lease_owner = $2 AND status = 'running'
+	`
+
+	return r.finishJob(query, id, owner, string(result))
+}
+
+// FailJob records a failed attempt of a running job. With retryIn set the
+// job is queued again after it; otherwise it fails for good.
+func (r *JobRepository) FailJob(id, owner, message string, retryIn time.Duration) error {
+	query := `
+		UPDATE jobs SET
+			status = CASE WHEN $4::FLOAT8 > 0 THEN 'queued' ELSE 'failed' END,
+			error = $3,
+			run_after = CASE WHEN $4::FLOAT8 > 0 THEN NOW() + ($4::FLOAT8 * INTERVAL '1 second') ELSE run_after END,
+			lease_owner = NULL,
+			lease_expires_at = NULL,
+			finished_at = CASE WHEN $4::FLOAT8 > 0 THEN NULL ELSE NOW() END,
+			updated_at = NOW()
+		WHERE id = $1 AND lease_owner = $2 AND status = 'running'
+	`
+
+	return r.finishJob(query, id, owner, message, retryIn.Seconds())
+}
+
+// MarkJobCancelled ends a running job whose cancellation was requested
+func (r *JobRepository) MarkJobCancelled(id, owner string) error {
+	query := `
+		UPDATE jobs SET
+			status = 'cancelled',
+			lease_owner = NULL,
+			lease_expires_at = NULL,
+			finished_at = NOW(),
+			updated_at = NOW()
+		WHERE id = $1 AND lease_owner = $2 AND status = 'running'
+	`
+
+	return r.finishJob(query, id, owner)
+}
+
+func (r *JobRepository) finishJob(query, id, owner string, args ...interface{}) error {
+	result, err := r.db.Exec(query, append([]interface{}{id, owner}, args...)...)
+	if err != nil {
+		return fmt.Errorf("failed to update job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("job %s is no longer held by %s", id, owner)
+	}
+
+	return nil
+}
+
+// CancelJob cancels a queued job at once and asks the worker of a running
+// one to stop. Finished jobs are left as they are.
+func (r *JobRepository) CancelJob(id string) error {
+	query := `
+		UPDATE jobs SET
+			status = CASE WHEN status = 'queued' THEN 'cancelled' ELSE status END,
+			cancel_requested = TRUE,
+			finished_at = CASE WHEN status = 'queued' THEN NOW() ELSE finished_at END,
+			updated_at = NOW()
+		WHERE id = $1 AND status IN ('queued', 'running')
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel job: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("job is not queued or running")
+	}
+
+	return nil
+}
+
+func scanJobs(rows *sql.Rows) ([]models.Job, error) {
+	var jobs []models.Job
+	for rows.Next() {
+		var j models.Job
+		var payload string
+		var result sql.NullString
+		err := rows.Scan(
+			&j.ID, &j.OrgID, &j.JobType, &payload, &j.LockKey, &j.Status, &j.ProgressDone, &j.ProgressTotal,
+			&result, &j.Error, &j.Attempts, &j.MaxAttempts, &j.RunAfter, &j.LeaseOwner, &j.LeaseExpiresAt,
+			&j.CancelRequested, &j.CreatedBy, &j.CreatedAt, &j.StartedAt, &j.FinishedAt, &j.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan job: %w", err)
+		}
+		j.Payload = []byte(payload)
+		if result.Valid {
+			j.Result = []byte(result.String)
+		}
+		jobs = append(jobs, j)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating jobs: %w", err)
+	}
+
+	return jobs, nil
+}
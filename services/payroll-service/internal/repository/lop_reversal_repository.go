@@ -0,0 +1,180 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type LOPReversalRepository struct {
+	db *sql.DB
+}
+
+func NewLOPReversalRepository(db *sql.DB) *LOPReversalRepository {
+	return &LOPReversalRepository{db: db}
+}
+
+// lopReversalColumns lists the lop_reversals columns in scan order
+const lopReversalColumns = `
+	id, org_id, employee_id, lop_month, days, reason, status,
+	payroll_run_id, payroll_component_id, amount, created_at, updated_at, created_by
+`
+
+// CreateLOPReversal records a pending LOP reversal
+func (r *LOPReversalRepository) CreateLOPReversal(rev *models.LOPReversal) error {
+	query := `
+		INSERT INTO lop_reversals (
+			org_id, employee_id, lop_month, days, reason, status, created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, 'pending', NOW(), NOW(), $6)
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		rev.OrgID, rev.EmployeeID, rev.LOPMonth, rev.Days, rev.Reason, rev.CreatedBy,
+	).Scan(&rev.ID, &rev.Status, &rev.CreatedAt, &rev.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to create LOP reversal: %w", err)
+	}
+
+	return nil
+}
+
+// GetLOPReversals fetches an organization's LOP reversals with optional filters
+func (r *LOPReversalRepository) GetLOPReversals(orgID string, filters map[string]interface{}) ([]models.LOPReversal, error) {
+	query := "SELECT " + lopReversalColumns + " FROM lop_reversals WHERE org_id = $1"
+	args := []interface{}{orgID}
+	argCount := 2
+
+	// Apply filters
+	if employeeID, ok := filters["employee_id"].(string); ok {
+		query += fmt.Sprintf(" AND employee_id = $%d", argCount)
+		args = append(args, employeeID)
+		argCount++
+	}
+
+	if status, ok := filters["status"].(string); ok {
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+
+	if month, ok := filters["lop_month"].(string); ok {
+		query += fmt.Sprintf(" AND lop_month = $%d", argCount)
+		args = append(args, month)
+		argCount++
+	}
+
+	query += " ORDER BY created_at DESC"
+
+	return r.queryLOPReversals(query, args...)
+}
+
+// GetPayableLOPReversals fetches the reversals a payroll run should pay the
+// given employees, keyed by employee ID: those still pending, and those it
+// already applied if the run is being initiated again
+func (r *LOPReversalRepository) GetPayableLOPReversals(employeeIDs []string, payrollRunID string) (map[string][]models.LOPReversal, error) {
+	query := "SELECT " + lopReversalColumns + ` FROM lop_reversals
+		WHERE employee_id = ANY($1)
+		  AND (status = 'pending' OR (status = 'applied' AND payroll_run_id = $2))
+		ORDER BY lop_month, created_at`
+
+	reversals, err := r.queryLOPReversals(query, pq.Array(employeeIDs), payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmployee := make(map[string][]models.LOPReversal)
+	for _, rev := range reversals {
+		byEmployee[rev.EmployeeID] = append(byEmployee[rev.EmployeeID], rev)
+	}
+	return byEmployee, nil
+}
+
+// GetReversedDays returns the LOP days of a month already reversed or
+// awaiting reversal for an employee
+func (r *LOPReversalRepository) GetReversedDays(employeeID, lopMonth string) (int, error) {
+	query := `
+		SELECT COALESCE(SUM(days), 0)
+		FROM lop_reversals
+		WHERE employee_id = $1 AND lop_month = $2 AND status <> 'cancelled'
+	`
+
+	var days int
+	if err := r.db.QueryRow(query, employeeID, lopMonth).Scan(&days); err != nil {
+		return 0, fmt.Errorf("failed to query reversed LOP days: %w", err)
+	}
+
+	return days, nil
+}
+
+// ApplyLOPReversal marks a reversal paid by a payroll component, in the
+// component's transaction
+func (r *LOPReversalRepository) ApplyLOPReversal(tx *sql.Tx, id, payrollRunID, payrollComponentID string, amount float64) error {
+	query := `
+		UPDATE lop_reversals
+		SET status = 'applied', payroll_run_id = $2, payroll_component_id = $3, amount = $4, updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending', 'applied')
+	`
+
+	if _, err := tx.Exec(query, id, payrollRunID, payrollComponentID, amount); err != nil {
+		return fmt.Errorf("failed to apply LOP reversal: %w", err)
+	}
+
+	return nil
+}
+
+// CancelLOPReversal cancels a reversal that has not been paid yet
+func (r *LOPReversalRepository) CancelLOPReversal(id string) error {
+	query := `
+		UPDATE lop_reversals
+		SET status = 'cancelled', updated_at = NOW()
+		WHERE id = $1 AND status = 'pending'
+	`
+
+	result, err := r.db.Exec(query, id)
+	if err != nil {
+		return fmt.Errorf("failed to cancel LOP reversal: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("pending LOP reversal not found")
+	}
+
+	return nil
+}
+
+func (r *LOPReversalRepository) queryLOPReversals(query string, args ...interface{}) ([]models.LOPReversal, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query LOP reversals: %w", err)
+	}
+	defer rows.Close()
+
+	var reversals []models.LOPReversal
+	for rows.Next() {
+		var rev models.LOPReversal
+		err := rows.Scan(
+			&rev.ID, &rev.OrgID, &rev.EmployeeID, &rev.LOPMonth, &rev.Days, &rev.Reason, &rev.Status,
+			&rev.PayrollRunID, &rev.PayrollComponentID, &rev.Amount, &rev.CreatedAt, &rev.UpdatedAt, &rev.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan LOP reversal: %w", err)
+		}
+		reversals = append(reversals, rev)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating LOP reversals: %w", err)
+	}
+
+	return reversals, nil
+}
@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type NPSRepository struct {
+	db *sql.DB
+}
+
+func NewNPSRepository(db *sql.DB) *NPSRepository {
+	return &NPSRepository{db: db}
+}
+
+// npsEnrolmentColumns lists the nps_enrolments columns in scan order
+const npsEnrolmentColumns = `
+		id, org_id, employee_id, pran, employer_rate, employee_rate,
+		effective_from, effective_till, is_active, created_at, updated_at, created_by
+`
+
+// GetNPSEnrolments fetches an organization's NPS enrolments with optional filters
+func (r *NPSRepository) GetNPSEnrolments(orgID string, filters map[string]interface{}) ([]models.NPSEnrolment, error) {
+	query := `SELECT ` + npsEnrolmentColumns + `
+		FROM nps_enrolments
+		WHERE org_id = $1
+	`
+	args := []interface{}{orgID}
+	argCount := 2
+
+	// Apply filters
+	if employeeID, ok := filters["employee_id"].(string); ok {
+		query += fmt.Sprintf(" AND employee_id = $%d", argCount)
+		args = append(args, employeeID)
+		argCount++
+	}
+
+	if asOf, ok := filters["as_of"].(time.Time); ok {
+		query += fmt.Sprintf(" AND is_active = true AND effective_from <= $%d AND (effective_till IS NULL OR effective_till >= $%d)", argCount, argCount)
+		args = append(args, asOf)
+		argCount++
+	}
+
+	query += " ORDER BY employee_id, effective_from DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query NPS enrolments: %w", err)
+	}
+	defer rows.Close()
+
+	var enrolments []models.NPSEnrolment
+	for rows.Next() {
+		var e models.NPSEnrolment
+		err := rows.Scan(
+			&e.ID, &e.OrgID, &e.EmployeeID, &e.PRAN, &e.EmployerRate, &e.EmployeeRate,
+			&e.EffectiveFrom, &e.EffectiveTill, &e.IsActive, &e.CreatedAt, &e.UpdatedAt, &e.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan NPS enrolment: %w", err)
+		}
+		enrolments = append(enrolments, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating NPS enrolments: %w", err)
+	}
+
+	return enrolments, nil
+}
+
+// GetNPSEnrolmentsInForce fetches the enrolments of the given employees in
+// force on asOf, keyed by employee ID. Employees not enrolled are left out.
+func (r *NPSRepository) GetNPSEnrolmentsInForce(employeeIDs []string, asOf time.Time) (map[string]*models.NPSEnrolment, error) {
+	query := `SELECT DISTINCT ON (employee_id) ` + npsEnrolmentColumns + `
+		FROM nps_enrolments
+		WHERE employee_id = ANY($1) AND is_active = true
+		  AND effective_from <= $2
+		  AND (effective_till IS NULL OR effective_till >= $2)
+		ORDER BY employee_id, effective_from DESC
+	`
+
+	rows, err := r.db.Query(query, pq.Array(employeeIDs), asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query NPS enrolments: %w", err)
+	}
+	defer rows.Close()
+
+	enrolments := map[string]*models.NPSEnrolment{}
+	for rows.Next() {
+		var e models.NPSEnrolment
+		err := rows.Scan(
+			&e.ID, &e.OrgID, &e.EmployeeID, &e.PRAN, &e.EmployerRate, &e.EmployeeRate,
+			&e.EffectiveFrom, &e.EffectiveTill, &e.IsActive, &e.CreatedAt, &e.UpdatedAt, &e.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan NPS enrolment: %w", err)
+		}
+		enrolments[e.EmployeeID] = &e
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating NPS enrolments: %w", err)
+	}
+
+	return enrolments, nil
+}
+
+// UpsertNPSEnrolment creates or replaces an employee's enrolment starting on
+// its effective_from date
+func (r *NPSRepository) UpsertNPSEnrolment(e *models.NPSEnrolment) error {
+	query := `
+		INSERT INTO nps_enrolments (
+			org_id, employee_id, pran, employer_rate, employee_rate,
+			effective_from, effective_till, is_active, created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, true, NOW(), NOW(), $8)
+		ON CONFLICT (employee_id, effective_from) DO UPDATE SET
+			pran = EXCLUDED.pran,
+			employer_rate = EXCLUDED.employer_rate,
+			employee_rate = EXCLUDED.employee_rate,
+			effective_till = EXCLUDED.effective_till,
+			is_active = true,
+			updated_at = NOW()
+		RETURNING id, is_active, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		e.OrgID, e.EmployeeID, e.PRAN, e.EmployerRate, e.EmployeeRate,
+		e.EffectiveFrom, e.EffectiveTill, e.CreatedBy,
+	).Scan(&e.ID, &e.IsActive, &e.CreatedAt, &e.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save NPS enrolment: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"payroll-service/internal/models"
+)
+
+type OrganizationRepository struct {
+	db *sql.DB
+}
+
+func NewOrganizationRepository(db *sql.DB) *OrganizationRepository {
+	return &OrganizationRepository{db: db}
+}
+
+// GetOrganizationByID fetches a single organization
+func (r *OrganizationRepository) GetOrganizationByID(orgID string) (*models.Organization, error) {
+	query := `
+		SELECT id, name, entity_code, state_code, country_code,
+		       COALESCE(registration_number, ''), COALESCE(pan, ''), COALESCE(gst_number, ''),
+		       bank_name, bank_account_number, bank_ifsc_code, nps_registration_number,
+		       COALESCE(eligible_startup, FALSE),
+		       is_active, created_at, updated_at
+		FROM organizations
+		WHERE id = $1
+	`
+
+	var org models.Organization
+	err := r.db.QueryRow(query, orgID).Scan(
+		&org.ID, &org.Name, &org.EntityCode, &org.StateCode, &org.CountryCode,
+		&org.RegistrationNumber, &org.PAN, &org.GSTNumber,
+		&org.BankName, &org.BankAccountNumber, &org.BankIFSCCode, &org.NPSRegistrationNumber,
+		&org.EligibleStartup,
+		&org.IsActive, &org.CreatedAt, &org.UpdatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("organization not found")
+		}
+		return nil, fmt.Errorf("failed to query organization: %w", err)
+	}
+
+	return &org, nil
+}
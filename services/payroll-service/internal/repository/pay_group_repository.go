@@ -0,0 +1,261 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type PayGroupRepository struct {
+	db *sql.DB
+}
+
+func NewPayGroupRepository(db *sql.DB) *PayGroupRepository {
+	return &PayGroupRepository{db: db}
+}
+
+// payGroupColumns lists the pay_groups columns in scan order
+const payGroupColumns = `
+		id, org_id, name, frequency, anchor_date, is_default, is_active,
+		created_at, updated_at, created_by
+`
+
+// GetPayGroups fetches an organization's pay groups
+func (r *PayGroupRepository) GetPayGroups(orgID string) ([]models.PayGroup, error) {
+	query := `SELECT ` + payGroupColumns + `
+		FROM pay_groups
+		WHERE org_id = $1
+		ORDER BY is_default DESC, name
+	`
+
+	rows, err := r.db.Query(query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pay groups: %w", err)
+	}
+	defer rows.Close()
+
+	var groups []models.PayGroup
+	for rows.Next() {
+		var pg models.PayGroup
+		err := rows.Scan(
+			&pg.ID, &pg.OrgID, &pg.Name, &pg.Frequency, &pg.AnchorDate, &pg.IsDefault, &pg.IsActive,
+			&pg.CreatedAt, &pg.UpdatedAt, &pg.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pay group: %w", err)
+		}
+		groups = append(groups, pg)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pay groups: %w", err)
+	}
+
+	return groups, nil
+}
+
+// GetPayGroupByID fetches a single pay group
+func (r *PayGroupRepository) GetPayGroupByID(payGroupID string) (*models.PayGroup, error) {
+	query := `SELECT ` + payGroupColumns + `
+		FROM pay_groups
+		WHERE id = $1
+	`
+
+	pg, err := r.queryPayGroup(query, payGroupID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("pay group not found")
+		}
+		return nil, fmt.Errorf("failed to query pay group: %w", err)
+	}
+
+	return pg, nil
+}
+
+// GetDefaultPayGroup fetches the pay group of employees without one.
+// Returns nil when the organization has no default pay group yet.
+func (r *PayGroupRepository) GetDefaultPayGroup(orgID string) (*models.PayGroup, error) {
+	query := `SELECT ` + payGroupColumns + `
+		FROM pay_groups
+		WHERE org_id = $1 AND is_default = true
+	`
+
+	pg, err := r.queryPayGroup(query, orgID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query default pay group: %w", err)
+	}
+
+	return pg, nil
+}
+
+func (r *PayGroupRepository) queryPayGroup(query string, arg string) (*models.PayGroup, error) {
+	var pg models.PayGroup
+	err := r.db.QueryRow(query, arg).Scan(
+		&pg.ID, &pg.OrgID, &pg.Name, &pg.Frequency, &pg.AnchorDate, &pg.IsDefault, &pg.IsActive,
+		&pg.CreatedAt, &pg.UpdatedAt, &pg.CreatedBy,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &pg, nil
+}
+
+// CreatePayGroup creates a pay group. A new default group takes over from
+// the previous one.
+func (r *PayGroupRepository) CreatePayGroup(pg *models.PayGroup) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if pg.IsDefault {
+		if _, err := tx.Exec(`UPDATE pay_groups SET is_default = false, updated_at = NOW() WHERE org_id = $1 AND is_default = true`, pg.OrgID); err != nil {
+			return fmt.Errorf("failed to clear default pay group: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO pay_groups (
+			org_id, name, frequency, anchor_date, is_default, is_active,
+			created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, true, $6, NOW(), NOW())
+		RETURNING id, is_active, created_at, updated_at
+	`
+
+	err = tx.QueryRow(
+		query,
+		pg.OrgID, pg.Name, pg.Frequency, pg.AnchorDate, pg.IsDefault, pg.CreatedBy,
+	).Scan(&pg.ID, &pg.IsActive, &pg.CreatedAt, &pg.UpdatedAt)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("pay group %s already exists", pg.Name)
+		}
+		return fmt.Errorf("failed to create pay group: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePayGroupPeriods adds periods to a pay group's calendar. Periods that
+// already exist are left as they are.
+func (r *PayGroupRepository) CreatePayGroupPeriods(periods []models.PayGroupPeriod) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO pay_group_periods (
+			pay_group_id, period_key, period_start, period_end, payroll_month, payment_date, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (pay_group_id, period_key) DO NOTHING
+	`
+
+	created := 0
+	for _, p := range periods {
+		result, err := tx.Exec(query, p.PayGroupID, p.PeriodKey, p.PeriodStart, p.PeriodEnd, p.PayrollMonth, p.PaymentDate)
+		if err != nil {
+			return 0, fmt.Errorf("failed to create pay group period: %w", err)
+		}
+		if n, _ := result.RowsAffected(); n > 0 {
+			created++
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return created, nil
+}
+
+// payGroupPeriodColumns lists the pay_group_periods columns in scan order
+const payGroupPeriodColumns = `
+		id, pay_group_id, period_key, period_start, period_end, payroll_month, payment_date, created_at
+`
+
+// GetPayGroupPeriods fetches a pay group's calendar, optionally limited to
+// periods overlapping [from, to]
+func (r *PayGroupRepository) GetPayGroupPeriods(payGroupID string, from, to *time.Time) ([]models.PayGroupPeriod, error) {
+	query := `SELECT ` + payGroupPeriodColumns + `
+		FROM pay_group_periods
+		WHERE pay_group_id = $1
+	`
+	args := []interface{}{payGroupID}
+	argCount := 2
+
+	if from != nil {
+		query += fmt.Sprintf(" AND period_end >= $%d", argCount)
+		args = append(args, *from)
+		argCount++
+	}
+
+	if to != nil {
+		query += fmt.Sprintf(" AND period_start <= $%d", argCount)
+		args = append(args, *to)
+		argCount++
+	}
+
+	query += " ORDER BY period_start"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query pay group periods: %w", err)
+	}
+	defer rows.Close()
+
+	var periods []models.PayGroupPeriod
+	for rows.Next() {
+		var p models.PayGroupPeriod
+		err := rows.Scan(
+			&p.ID, &p.PayGroupID, &p.PeriodKey, &p.PeriodStart, &p.PeriodEnd, &p.PayrollMonth, &p.PaymentDate, &p.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan pay group period: %w", err)
+		}
+		periods = append(periods, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating pay group periods: %w", err)
+	}
+
+	return periods, nil
+}
+
+// GetPayGroupPeriod fetches one period of a pay group by key
+func (r *PayGroupRepository) GetPayGroupPeriod(payGroupID, periodKey string) (*models.PayGroupPeriod, error) {
+	query := `SELECT ` + payGroupPeriodColumns + `
+		FROM pay_group_periods
+		WHERE pay_group_id = $1 AND period_key = $2
+	`
+
+	var p models.PayGroupPeriod
+	err := r.db.QueryRow(query, payGroupID, periodKey).Scan(
+		&p.ID, &p.PayGroupID, &p.PeriodKey, &p.PeriodStart, &p.PeriodEnd, &p.PayrollMonth, &p.PaymentDate, &p.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("pay period %s not found", periodKey)
+		}
+		return nil, fmt.Errorf("failed to query pay group period: %w", err)
+	}
+
+	return &p, nil
+}
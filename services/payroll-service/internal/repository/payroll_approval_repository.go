@@ -0,0 +1,156 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type PayrollApprovalRepository struct {
+	db *sql.DB
+}
+
+func NewPayrollApprovalRepository(db *sql.DB) *PayrollApprovalRepository {
+	return &PayrollApprovalRepository{db: db}
+}
+
+// GetApprovalLevels fetches an organization's approval chain in approval order
+func (r *PayrollApprovalRepository) GetApprovalLevels(orgID string) ([]models.PayrollApprovalLevel, error) {
+	query := `
+		SELECT id, org_id, level, name, approver_ids, min_net_amount,
+		       created_at, updated_at, created_by
+		FROM payroll_approval_levels
+		WHERE org_id = $1
+		ORDER BY level
+	`
+
+	rows, err := r.db.Query(query, orgID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query approval levels: %w", err)
+	}
+	defer rows.Close()
+
+	var levels []models.PayrollApprovalLevel
+	for rows.Next() {
+		var l models.PayrollApprovalLevel
+		err := rows.Scan(
+			&l.ID, &l.OrgID, &l.Level, &l.Name, pq.Array(&l.ApproverIDs), &l.MinNetAmount,
+			&l.CreatedAt, &l.UpdatedAt, &l.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan approval level: %w", err)
+		}
+		levels = append(levels, l)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating approval levels: %w", err)
+	}
+
+	return levels, nil
+}
+
+// ReplaceApprovalLevels replaces an organization's approval chain in one
+// transaction
+func (r *PayrollApprovalRepository) ReplaceApprovalLevels(orgID string, levels []models.PayrollApprovalLevel) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM payroll_approval_levels WHERE org_id = $1", orgID); err != nil {
+		return fmt.Errorf("failed to delete approval levels: %w", err)
+	}
+
+	query := `
+		INSERT INTO payroll_approval_levels (
+			org_id, level, name, approver_ids, min_net_amount,
+			created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, NOW(), NOW(), $6)
+		RETURNING id, created_at, updated_at
+	`
+
+	for i := range levels {
+		l := &levels[i]
+		err := tx.QueryRow(
+			query,
+			orgID, l.Level, l.Name, pq.Array(l.ApproverIDs), l.MinNetAmount, l.CreatedBy,
+		).Scan(&l.ID, &l.CreatedAt, &l.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create approval level: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetRunApprovals fetches the decisions of one approval round, identified by
+// the finalization that opened it
+func (r *PayrollApprovalRepository) GetRunApprovals(finalizeTransitionID string) ([]models.PayrollRunApproval, error) {
+	query := `
+		SELECT id, payroll_run_id, finalize_transition_id, level, level_name,
+		       approver, decision, comment, created_at
+		FROM payroll_run_approvals
+		WHERE finalize_transition_id = $1
+		ORDER BY level
+	`
+
+	rows, err := r.db.Query(query, finalizeTransitionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll run approvals: %w", err)
+	}
+	defer rows.Close()
+
+	var approvals []models.PayrollRunApproval
+	for rows.Next() {
+		var a models.PayrollRunApproval
+		err := rows.Scan(
+			&a.ID, &a.PayrollRunID, &a.FinalizeTransitionID, &a.Level, &a.LevelName,
+			&a.Approver, &a.Decision, &a.Comment, &a.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payroll run approval: %w", err)
+		}
+		approvals = append(approvals, a)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payroll run approvals: %w", err)
+	}
+
+	return approvals, nil
+}
+
+// CreateRunApproval records an approver's decision. A level decides once per
+// round, so a concurrent decision on the same level is a duplicate.
+func (r *PayrollApprovalRepository) CreateRunApproval(a *models.PayrollRunApproval) error {
+	query := `
+		INSERT INTO payroll_run_approvals (
+			payroll_run_id, finalize_transition_id, level, level_name,
+			approver, decision, comment, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		RETURNING id, created_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		a.PayrollRunID, a.FinalizeTransitionID, a.Level, a.LevelName,
+		a.Approver, a.Decision, a.Comment,
+	).Scan(&a.ID, &a.CreatedAt)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("level %d has already decided in this approval round", a.Level)
+		}
+		return fmt.Errorf("failed to create payroll run approval: %w", err)
+	}
+
+	return nil
+}
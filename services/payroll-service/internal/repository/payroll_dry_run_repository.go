@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"payroll-service/internal/models"
+)
+
+type PayrollDryRunRepository struct {
+	db *sql.DB
+}
+
+func NewPayrollDryRunRepository(db *sql.DB) *PayrollDryRunRepository {
+	return &PayrollDryRunRepository{db: db}
+}
+
+const payrollDryRunColumns = `
+		id, org_id, payroll_run_id, dry_run_number, employees_succeeded, employees_skipped,
+		employees_failed, total_gross_amount, total_deductions, total_net_pay, snapshot,
+		findings, requested_by, created_at
+`
+
+// CreatePayrollDryRun stores a dry run together with its run's move to
+// dry_run in one transaction. The dry run takes the run's incremented
+// dry_run_count as its number. It returns false, changing nothing, when the
+// run is no longer in the from status.
+func (r *PayrollDryRunRepository) CreatePayrollDryRun(dr *models.PayrollDryRun, fromStatus, comment string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statusQuery := `
+		UPDATE payroll_runs
+		SET status = 'dry_run',
+		    dry_run_count = dry_run_count + 1,
+		    updated_at = NOW()
+		WHERE id = $1 AND status = $2
+		RETURNING dry_run_count
+	`
+
+	err = tx.QueryRow(statusQuery, dr.PayrollRunID, fromStatus).Scan(&dr.DryRunNumber)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to update payroll run status: %w", err)
+	}
+
+	if err := insertRunTransition(tx, dr.PayrollRunID, nullString(fromStatus), "dry_run", "dry_run", dr.RequestedBy, comment); err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO payroll_dry_runs (
+			org_id, payroll_run_id, dry_run_number, employees_succeeded, employees_skipped,
+			employees_failed, total_gross_amount, total_deductions, total_net_pay, snapshot,
+			findings, requested_by, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW())
+		RETURNING id, created_at
+	`
+
+	err = tx.QueryRow(
+		query,
+		dr.OrgID, dr.PayrollRunID, dr.DryRunNumber, dr.EmployeesSucceeded, dr.EmployeesSkipped,
+		dr.EmployeesFailed, dr.TotalGrossAmount, dr.TotalDeductions, dr.TotalNetPay, dr.Snapshot,
+		dr.Findings, dr.RequestedBy,
+	).Scan(&dr.ID, &dr.CreatedAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to create payroll dry run: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetPayrollDryRuns lists a run's dry runs, latest first
+func (r *PayrollDryRunRepository) GetPayrollDryRuns(payrollRunID string) ([]models.PayrollDryRun, error) {
+	query := `SELECT ` + payrollDryRunColumns + `
+		FROM payroll_dry_runs
+		WHERE payroll_run_id = $1
+		ORDER BY dry_run_number DESC
+	`
+
+	rows, err := r.db.Query(query, payrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll dry runs: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPayrollDryRuns(rows)
+}
+
+// GetPayrollDryRun fetches a run's dry run by its number
+func (r *PayrollDryRunRepository) GetPayrollDryRun(payrollRunID string, dryRunNumber int) (*models.PayrollDryRun, error) {
+	query := `SELECT ` + payrollDryRunColumns + `
+		FROM payroll_dry_runs
+		WHERE payroll_run_id = $1 AND dry_run_number = $2
+	`
+
+	rows, err := r.db.Query(query, payrollRunID, dryRunNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll dry run: %w", err)
+	}
+	defer rows.Close()
+
+	dryRuns, err := scanPayrollDryRuns(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(dryRuns) == 0 {
+		return nil, fmt.Errorf("dry run %d of payroll run %s not found", dryRunNumber, payrollRunID)
+	}
+
+	return &dryRuns[0], nil
+}
+
+// GetPreviousPayrollDryRun fetches the latest dry run of a run numbered
+// below the one given. Returns nil when there is none.
+func (r *PayrollDryRunRepository) GetPreviousPayrollDryRun(payrollRunID string, dryRunNumber int) (*models.PayrollDryRun, error) {
+	query := `SELECT ` + payrollDryRunColumns + `
+		FROM payroll_dry_runs
+		WHERE payroll_run_id = $1 AND dry_run_number < $2
+		ORDER BY dry_run_number DESC
+		LIMIT 1
+	`
+
+	rows, err := r.db.Query(query, payrollRunID, dryRunNumber)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query previous payroll dry run: %w", err)
+	}
+	defer rows.Close()
+
+	dryRuns, err := scanPayrollDryRuns(rows)
+	if err != nil || len(dryRuns) == 0 {
+		return nil, err
+	}
+
+	return &dryRuns[0], nil
+}
+
+func scanPayrollDryRuns(rows *sql.Rows) ([]models.PayrollDryRun, error) {
+	var dryRuns []models.PayrollDryRun
+	for rows.Next() {
+		var dr models.PayrollDryRun
+		err := rows.Scan(
+			&dr.ID, &dr.OrgID, &dr.PayrollRunID, &dr.DryRunNumber, &dr.EmployeesSucceeded, &dr.EmployeesSkipped,
+			&dr.EmployeesFailed, &dr.TotalGrossAmount, &dr.TotalDeductions, &dr.TotalNetPay, &dr.Snapshot,
+			&dr.Findings, &dr.RequestedBy, &dr.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payroll dry run: %w", err)
+		}
+		dryRuns = append(dryRuns, dr)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payroll dry runs: %w", err)
+	}
+
+	return dryRuns, nil
+}
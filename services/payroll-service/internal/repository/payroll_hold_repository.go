@@ -0,0 +1,253 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type PayrollHoldRepository struct {
+	db *sql.DB
+}
+
+func NewPayrollHoldRepository(db *sql.DB) *PayrollHoldRepository {
+	return &PayrollHoldRepository{db: db}
+}
+
+const payrollHoldColumns = `
+		id, org_id, payroll_run_id, payroll_component_id, employee_id,
+		reason_code, reason, status, held_by, held_at, expires_at,
+		released_by, released_at, release_notes, supplementary_file_reference,
+		created_at, updated_at
+`
+
+// GetPayrollHolds lists holds for an organization with optional filters
+func (r *PayrollHoldRepository) GetPayrollHolds(orgID string, filters map[string]interface{}) ([]models.PayrollHold, error) {
+	query := `SELECT ` + payrollHoldColumns + `
+		FROM payroll_holds
+		WHERE org_id = $1
+	`
+	args := []interface{}{orgID}
+	argCount := 2
+
+	if status, ok := filters["status"].(string); ok {
+		switch status {
+		case "lapsed":
+			query += " AND status = 'active' AND expires_at < CURRENT_DATE"
+		case "active":
+			query += " AND status = 'active' AND (expires_at IS NULL OR expires_at >= CURRENT_DATE)"
+		default:
+			query += fmt.Sprintf(" AND status = $%d", argCount)
+			args = append(args, status)
+			argCount++
+		}
+	}
+
+	if payrollRunID, ok := filters["payroll_run_id"].(string); ok {
+		query += fmt.Sprintf(" AND payroll_run_id = $%d", argCount)
+		args = append(args, payrollRunID)
+		argCount++
+	}
+
+	query += " ORDER BY held_at DESC"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll holds: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPayrollHolds(rows)
+}
+
+// GetActiveHold fetches the active (possibly lapsed) hold on an employee's
+// component in a payroll run
+func (r *PayrollHoldRepository) GetActiveHold(payrollRunID, employeeID string) (*models.PayrollHold, error) {
+	query := `SELECT ` + payrollHoldColumns + `
+		FROM payroll_holds
+		WHERE payroll_run_id = $1 AND employee_id = $2 AND status = 'active'
+	`
+
+	rows, err := r.db.Query(query, payrollRunID, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll hold: %w", err)
+	}
+	defer rows.Close()
+
+	holds, err := scanPayrollHolds(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(holds) == 0 {
+		return nil, fmt.Errorf("payroll hold not found")
+	}
+
+	return &holds[0], nil
+}
+
+// GetPayableHolds fetches a run's holds that were released or have lapsed
+// and are not yet paid
+func (r *PayrollHoldRepository) GetPayableHolds(payrollRunID string) ([]models.PayrollHold, error) {
+	query := `SELECT ` + payrollHoldColumns + `
+		FROM payroll_holds
+		WHERE payroll_run_id = $1
+		  AND (status = 'released' OR (status = 'active' AND expires_at < CURRENT_DATE))
+		ORDER BY held_at
+	`
+
+	rows, err := r.db.Query(query, payrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payable holds: %w", err)
+	}
+	defer rows.Close()
+
+	return scanPayrollHolds(rows)
+}
+
+// CreatePayrollHold places a hold on a payroll component. It returns false,
+// creating nothing, once the run's main bank file has been generated; the
+// run row is locked so the hold and the file cannot pass each other.
+func (r *PayrollHoldRepository) CreatePayrollHold(hold *models.PayrollHold) (bool, error) {
+	query := `
+		INSERT INTO payroll_holds (
+			org_id, payroll_run_id, payroll_component_id, employee_id,
+			reason_code, reason, status, held_by, held_at, expires_at,
+			created_at, updated_at
+		)
+		SELECT $1::UUID, id, $3::UUID, $4::UUID, $5, $6, 'active', $7::UUID, NOW(), $8::DATE, NOW(), NOW()
+		FROM payroll_runs
+		WHERE id = $2 AND bank_file_generated_at IS NULL
+		FOR UPDATE
+		RETURNING id, status, held_at, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		hold.OrgID, hold.PayrollRunID, hold.PayrollComponentID, hold.EmployeeID,
+		hold.ReasonCode, hold.Reason, hold.HeldBy, hold.ExpiresAt,
+	).Scan(&hold.ID, &hold.Status, &hold.HeldAt, &hold.CreatedAt, &hold.UpdatedAt)
+
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return false, fmt.Errorf("component is already on hold")
+		}
+		return false, fmt.Errorf("failed to create payroll hold: %w", err)
+	}
+
+	return true, nil
+}
+
+// ReleasePayrollHold ends a hold. Status is "released" when the salary still
+// has to be paid separately, or "withdrawn" when it goes out with the run.
+func (r *PayrollHoldRepository) ReleasePayrollHold(holdID, status, releasedBy, notes string) error {
+	query := `
+		UPDATE payroll_holds
+		SET status = $1, released_by = $2, released_at = NOW(), release_notes = $3, updated_at = NOW()
+		WHERE id = $4 AND status = 'active'
+	`
+
+	result, err := r.db.Exec(query, status, releasedBy, nullString(notes), holdID)
+	if err != nil {
+		return fmt.Errorf("failed to release payroll hold: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("payroll hold not found")
+	}
+
+	return nil
+}
+
+// PayHolds pays held salaries in one transaction: it claims those of the
+// holds that are still released or lapsed, calls pay with the claimed IDs to
+// build the supplementary bank file, and records the file's reference. Holds
+// another payment claimed first are left out, so a salary is paid once; pay
+// is called with no IDs when none is left. If pay fails nothing is claimed.
+func (r *PayrollHoldRepository) PayHolds(holdIDs []string, pay func(claimed []string) (string, error)) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	claimQuery := `
+		UPDATE payroll_holds
+		SET status = 'paid', updated_at = NOW()
+		WHERE id = ANY($1)
+		  AND (status = 'released' OR (status = 'active' AND expires_at < CURRENT_DATE))
+		RETURNING id
+	`
+
+	rows, err := tx.Query(claimQuery, pq.Array(holdIDs))
+	if err != nil {
+		return fmt.Errorf("failed to claim holds: %w", err)
+	}
+	var claimed []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan claimed hold: %w", err)
+		}
+		claimed = append(claimed, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating claimed holds: %w", err)
+	}
+
+	fileReference, err := pay(claimed)
+	if err != nil {
+		return err
+	}
+
+	referenceQuery := `
+		UPDATE payroll_holds
+		SET supplementary_file_reference = $1
+		WHERE id = ANY($2)
+	`
+
+	if _, err := tx.Exec(referenceQuery, fileReference, pq.Array(claimed)); err != nil {
+		return fmt.Errorf("failed to mark holds paid: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func scanPayrollHolds(rows *sql.Rows) ([]models.PayrollHold, error) {
+	var holds []models.PayrollHold
+	for rows.Next() {
+		var h models.PayrollHold
+		err := rows.Scan(
+			&h.ID, &h.OrgID, &h.PayrollRunID, &h.PayrollComponentID, &h.EmployeeID,
+			&h.ReasonCode, &h.Reason, &h.Status, &h.HeldBy, &h.HeldAt, &h.ExpiresAt,
+			&h.ReleasedBy, &h.ReleasedAt, &h.ReleaseNotes, &h.SupplementaryFileReference,
+			&h.CreatedAt, &h.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payroll hold: %w", err)
+		}
+		holds = append(holds, h)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payroll holds: %w", err)
+	}
+
+	return holds, nil
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type PayrollReopenRepository struct {
+	db *sql.DB
+}
+
+func NewPayrollReopenRepository(db *sql.DB) *PayrollReopenRepository {
+	return &PayrollReopenRepository{db: db}
+}
+
+// reopenRequestColumns lists the payroll_reopen_requests columns in scan order
+const reopenRequestColumns = `
+	id, org_id, payroll_run_id, reason, employee_ids, status,
+	requested_by, decided_by, decided_at, decision_comment, snapshot,
+	completed_at, created_at, updated_at
+`
+
+// CreateReopenRequest creates a pending reopen request
+func (r *PayrollReopenRepository) CreateReopenRequest(req *models.PayrollReopenRequest) error {
+	query := `
+		INSERT INTO payroll_reopen_requests (
+			org_id, payroll_run_id, reason, employee_ids, status, requested_by,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, 'pending', $5, NOW(), NOW())
+		RETURNING id, status, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		req.OrgID, req.PayrollRunID, req.Reason, pq.Array(req.EmployeeIDs), req.RequestedBy,
+	).Scan(&req.ID, &req.Status, &req.CreatedAt, &req.UpdatedAt)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("payroll run already has an open reopen request")
+		}
+		return fmt.Errorf("failed to create reopen request: %w", err)
+	}
+
+	return nil
+}
+
+// GetReopenRequestByID fetches a reopen request
+func (r *PayrollReopenRepository) GetReopenRequestByID(requestID string) (*models.PayrollReopenRequest, error) {
+	requests, err := r.queryReopenRequests("SELECT "+reopenRequestColumns+" FROM payroll_reopen_requests WHERE id = $1", requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(requests) == 0 {
+		return nil, fmt.Errorf("reopen request not found")
+	}
+
+	return &requests[0], nil
+}
+
+// GetOpenReopenRequest fetches a run's pending or approved reopen request.
+// Returns nil when there is none.
+func (r *PayrollReopenRepository) GetOpenReopenRequest(payrollRunID string) (*models.PayrollReopenRequest, error) {
+	query := "SELECT " + reopenRequestColumns + ` FROM payroll_reopen_requests
+		WHERE payroll_run_id = $1 AND status IN ('pending', 'approved')`
+
+	requests, err := r.queryReopenRequests(query, payrollRunID)
+	if err != nil || len(requests) == 0 {
+		return nil, err
+	}
+
+	return &requests[0], nil
+}
+
+// GetReopenRequests fetches a run's reopen requests, newest first
+func (r *PayrollReopenRepository) GetReopenRequests(payrollRunID string) ([]models.PayrollReopenRequest, error) {
+	query := "SELECT " + reopenRequestColumns + ` FROM payroll_reopen_requests
+		WHERE payroll_run_id = $1
+		ORDER BY created_at DESC`
+
+	return r.queryReopenRequests(query, payrollRunID)
+}
+
+// DecideReopenRequest records the decision on a pending request. It returns
+// false when the request was no longer pending.
+func (r *PayrollReopenRepository) DecideReopenRequest(req *models.PayrollReopenRequest) (bool, error) {
+	query := `
+		UPDATE payroll_reopen_requests
+		SET status = $1, decided_by = $2, decided_at = NOW(), decision_comment = $3,
+		    snapshot = $4, updated_at = NOW()
+		WHERE id = $5 AND status = 'pending'
+		RETURNING decided_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		req.Status, req.DecidedBy, req.DecisionComment, req.Snapshot, req.ID,
+	).Scan(&req.DecidedAt, &req.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to decide reopen request: %w", err)
+	}
+
+	return true, nil
+}
+
+// ApproveReopenRequest approves a pending request and reopens its run in one
+// transaction: the decision, the run's transition from fromStatus to
+// toStatus and the deactivation of the run's lock either all apply or none
+// do, so a failed unlock never leaves an approved request behind. It returns
+// false when the request was no longer pending, the run had changed status
+// or its bank file had been generated.
+func (r *PayrollReopenRepository) ApproveReopenRequest(req *models.PayrollReopenRequest, fromStatus, toStatus, action, actor string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	decideQuery := `
+		UPDATE payroll_reopen_requests
+		SET status = $1, decided_by = $2, decided_at = NOW(), decision_comment = $3,
+		    snapshot = $4, updated_at = NOW()
+		WHERE id = $5 AND status = 'pending'
+		RETURNING decided_at, updated_at
+	`
+
+	err = tx.QueryRow(
+		decideQuery,
+		req.Status, req.DecidedBy, req.DecisionComment, req.Snapshot, req.ID,
+	).Scan(&req.DecidedAt, &req.UpdatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to decide reopen request: %w", err)
+	}
+
+	statusQuery := `
+		UPDATE payroll_runs
+		SET status = $1, updated_at = NOW()
+		WHERE id = $2 AND status = $3 AND bank_file_generated_at IS NULL
+	`
+
+	result, err := tx.Exec(statusQuery, toStatus, req.PayrollRunID, fromStatus)
+	if err != nil {
+		return false, fmt.Errorf("failed to update payroll run status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := insertRunTransition(tx, req.PayrollRunID, nullString(fromStatus), toStatus, action, actor, req.Reason); err != nil {
+		return false, err
+	}
+
+	lockQuery := `
+		UPDATE payroll_locks
+		SET is_active = false
+		WHERE payroll_run_id = $1 AND is_active = true
+		RETURNING id
+	`
+
+	var lockID string
+	if err := tx.QueryRow(lockQuery, req.PayrollRunID).Scan(&lockID); err != nil {
+		if err == sql.ErrNoRows {
+			return false, fmt.Errorf("payroll run is not locked")
+		}
+		return false, fmt.Errorf("failed to deactivate lock: %w", err)
+	}
+
+	if err := insertLockEvent(tx, lockID, req.PayrollRunID, "unlock", actor, req.Reason, nullString(req.ID)); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+func (r *PayrollReopenRepository) queryReopenRequests(query string, args ...interface{}) ([]models.PayrollReopenRequest, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reopen requests: %w", err)
+	}
+	defer rows.Close()
+
+	var requests []models.PayrollReopenRequest
+	for rows.Next() {
+		var req models.PayrollReopenRequest
+		err := rows.Scan(
+			&req.ID, &req.OrgID, &req.PayrollRunID, &req.Reason, pq.Array(&req.EmployeeIDs), &req.Status,
+			&req.RequestedBy, &req.DecidedBy, &req.DecidedAt, &req.DecisionComment, &req.Snapshot,
+			&req.CompletedAt, &req.CreatedAt, &req.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan reopen request: %w", err)
+		}
+		requests = append(requests, req)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reopen requests: %w", err)
+	}
+
+	return requests, nil
+}
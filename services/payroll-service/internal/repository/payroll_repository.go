@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/lib/pq"
 	"payroll-service/internal/models"
 )
 
@@ -19,11 +20,11 @@ func NewPayrollRepository(db *sql.DB) *PayrollRepository {
 // GetPayrollRuns fetches all payroll runs for an organization with optional filters
 func (r *PayrollRepository) GetPayrollRuns(orgID string, filters map[string]interface{}) ([]models.PayrollRun, error) {
 	query := `
-		SELECT id, org_id, payroll_period_start, payroll_period_end, payroll_month,
-		       status, dry_run_count, total_employees, total_gross_amount, total_deductions,
+		SELECT id, org_id, COALESCE(pay_group_id::text, ''), COALESCE(period_key, payroll_month),
+		       run_type, reverses_payroll_run_id, payroll_period_start, payroll_period_end, payroll_month, status, dry_run_count, total_employees, total_gross_amount, total_deductions,
 		       total_net_amount, total_pf_employee, total_pf_employer, total_esi_employee,
 		       total_esi_employer, total_pt, total_tds, locked_at, locked_by, approved_at,
-		       approved_by, released_at, released_by, created_at, updated_at, created_by, notes
+		       approved_by, released_at, released_by, bank_file_generated_at, created_at, updated_at, created_by, notes
 		FROM payroll_runs
 		WHERE org_id = $1
 	`
@@ -43,6 +44,30 @@ func (r *PayrollRepository) GetPayrollRuns(orgID string, filters map[string]inte
 		argCount++
 	}
 
+	if payGroupID, ok := filters["pay_group_id"].(string); ok {
+		query += fmt.Sprintf(" AND pay_group_id = $%d", argCount)
+		args = append(args, payGroupID)
+		argCount++
+	}
+
+	if periodKey, ok := filters["period_key"].(string); ok {
+		query += fmt.Sprintf(" AND period_key = $%d", argCount)
+		args = append(args, periodKey)
+		argCount++
+	}
+
+	if runType, ok := filters["run_type"].(string); ok {
+		query += fmt.Sprintf(" AND run_type = $%d", argCount)
+		args = append(args, runType)
+		argCount++
+	}
+
+	if reversesRunID, ok := filters["reverses_payroll_run_id"].(string); ok {
+		query += fmt.Sprintf(" AND reverses_payroll_run_id = $%d", argCount)
+		args = append(args, reversesRunID)
+		argCount++
+	}
+
 	query += " ORDER BY payroll_period_start DESC"
 
 	rows, err := r.db.Query(query, args...)
@@ -55,11 +80,11 @@ func (r *PayrollRepository) GetPayrollRuns(orgID string, filters map[string]inte
 	for rows.Next() {
 		var pr models.PayrollRun
 		err := rows.Scan(
-			&pr.ID, &pr.OrgID, &pr.PayrollPeriodStart, &pr.PayrollPeriodEnd, &pr.PayrollMonth,
-			&pr.Status, &pr.DryRunCount, &pr.TotalEmployees, &pr.TotalGrossAmount, &pr.TotalDeductions,
+			&pr.ID, &pr.OrgID, &pr.PayGroupID, &pr.PeriodKey,
+			&pr.RunType, &pr.ReversesRunID, &pr.PayrollPeriodStart, &pr.PayrollPeriodEnd, &pr.PayrollMonth, &pr.Status, &pr.DryRunCount, &pr.TotalEmployees, &pr.TotalGrossAmount, &pr.TotalDeductions,
 			&pr.TotalNetAmount, &pr.TotalPFEmployee, &pr.TotalPFEmployer, &pr.TotalESIEmployee,
 			&pr.TotalESIEmployer, &pr.TotalPT, &pr.TotalTDS, &pr.LockedAt, &pr.LockedBy, &pr.ApprovedAt,
-			&pr.ApprovedBy, &pr.ReleasedAt, &pr.ReleasedBy, &pr.CreatedAt, &pr.UpdatedAt, &pr.CreatedBy, &pr.Notes,
+			&pr.ApprovedBy, &pr.ReleasedAt, &pr.ReleasedBy, &pr.BankFileGeneratedAt, &pr.CreatedAt, &pr.UpdatedAt, &pr.CreatedBy, &pr.Notes,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan payroll run: %w", err)
@@ -77,22 +102,22 @@ func (r *PayrollRepository) GetPayrollRuns(orgID string, filters map[string]inte
 // GetPayrollRunByID fetches a single payroll run by ID
 func (r *PayrollRepository) GetPayrollRunByID(payrollRunID string) (*models.PayrollRun, error) {
 	query := `
-		SELECT id, org_id, payroll_period_start, payroll_period_end, payroll_month,
-		       status, dry_run_count, total_employees, total_gross_amount, total_deductions,
+		SELECT id, org_id, COALESCE(pay_group_id::text, ''), COALESCE(period_key, payroll_month),
+		       run_type, reverses_payroll_run_id, payroll_period_start, payroll_period_end, payroll_month, status, dry_run_count, total_employees, total_gross_amount, total_deductions,
 		       total_net_amount, total_pf_employee, total_pf_employer, total_esi_employee,
 		       total_esi_employer, total_pt, total_tds, locked_at, locked_by, approved_at,
-		       approved_by, released_at, released_by, created_at, updated_at, created_by, notes
+		       approved_by, released_at, released_by, bank_file_generated_at, created_at, updated_at, created_by, notes
 		FROM payroll_runs
 		WHERE id = $1
 	`
 
 	var pr models.PayrollRun
 	err := r.db.QueryRow(query, payrollRunID).Scan(
-		&pr.ID, &pr.OrgID, &pr.PayrollPeriodStart, &pr.PayrollPeriodEnd, &pr.PayrollMonth,
-		&pr.Status, &pr.DryRunCount, &pr.TotalEmployees, &pr.TotalGrossAmount, &pr.TotalDeductions,
+		&pr.ID, &pr.OrgID, &pr.PayGroupID, &pr.PeriodKey,
+		&pr.RunType, &pr.ReversesRunID, &pr.PayrollPeriodStart, &pr.PayrollPeriodEnd, &pr.PayrollMonth, &pr.Status, &pr.DryRunCount, &pr.TotalEmployees, &pr.TotalGrossAmount, &pr.TotalDeductions,
 		&pr.TotalNetAmount, &pr.TotalPFEmployee, &pr.TotalPFEmployer, &pr.TotalESIEmployee,
 		&pr.TotalESIEmployer, &pr.TotalPT, &pr.TotalTDS, &pr.LockedAt, &pr.LockedBy, &pr.ApprovedAt,
-		&pr.ApprovedBy, &pr.ReleasedAt, &pr.ReleasedBy, &pr.CreatedAt, &pr.UpdatedAt, &pr.CreatedBy, &pr.Notes,
+		&pr.ApprovedBy, &pr.ReleasedAt, &pr.ReleasedBy, &pr.BankFileGeneratedAt, &pr.CreatedAt, &pr.UpdatedAt, &pr.CreatedBy, &pr.Notes,
 	)
 
 	if err != nil {
@@ -109,84 +134,321 @@ func (r *PayrollRepository) GetPayrollRunByID(payrollRunID string) (*models.Payr
 func (r *PayrollRepository) CreatePayrollRun(pr *models.PayrollRun) error {
 	query := `
 		INSERT INTO payroll_runs (
-			org_id, payroll_period_start, payroll_period_end, payroll_month,
+			org_id, pay_group_id, period_key, run_type, reverses_payroll_run_id,
+			payroll_period_start, payroll_period_end, payroll_month,
 			status, dry_run_count, total_employees, total_gross_amount, total_deductions,
 			total_net_amount, total_pf_employee, total_pf_employer, total_esi_employee,
 			total_esi_employer, total_pt, total_tds, created_by, notes, created_at, updated_at
 		) VALUES (
-			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, NOW(), NOW()
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, NOW(), NOW()
 		)
 		RETURNING id, created_at, updated_at
 	`
 
 	err := r.db.QueryRow(
 		query,
-		pr.OrgID, pr.PayrollPeriodStart, pr.PayrollPeriodEnd, pr.PayrollMonth,
+		pr.OrgID, pr.PayGroupID, pr.PeriodKey, pr.RunType, pr.ReversesRunID,
+		pr.PayrollPeriodStart, pr.PayrollPeriodEnd, pr.PayrollMonth,
 		pr.Status, pr.DryRunCount, pr.TotalEmployees, pr.TotalGrossAmount, pr.TotalDeductions,
 		pr.TotalNetAmount, pr.TotalPFEmployee, pr.TotalPFEmployer, pr.TotalESIEmployee,
 		pr.TotalESIEmployer, pr.TotalPT, pr.TotalTDS, pr.CreatedBy, pr.Notes,
 	).Scan(&pr.ID, &pr.CreatedAt, &pr.UpdatedAt)
 
 	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("a regular payroll run already exists for period %s of this pay group", pr.PeriodKey)
+		}
 		return fmt.Errorf("failed to create payroll run: %w", err)
 	}
 
 	return nil
 }
 
-// UpdatePayrollRunStatus updates the status of a payroll run
-func (r *PayrollRepository) UpdatePayrollRunStatus(payrollRunID string, status string, updatedBy string) error {
-	query := `
+// TransitionPayrollRun moves a run from one status to another and records
+// the transition in one transaction. It returns false, changing nothing, when
+// the run is no longer in the from status.
+func (r *PayrollRepository) TransitionPayrollRun(payrollRunID, fromStatus, toStatus, action, actor, comment string) (bool, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statusQuery := `
 		UPDATE payroll_runs
-		SET status = $1, updated_at = NOW()
-		WHERE id = $2
+		SET status = $1,
+		    dry_run_count = dry_run_count + CASE WHEN $1 = 'dry_run' THEN 1 ELSE 0 END,
+		    updated_at = NOW()
+		WHERE id = $2 AND status = $3
 	`
 
-	result, err := r.db.Exec(query, status, payrollRunID)
+	result, err := tx.Exec(statusQuery, toStatus, payrollRunID, fromStatus)
 	if err != nil {
-		return fmt.Errorf("failed to update payroll run status: %w", err)
+		return false, fmt.Errorf("failed to update payroll run status: %w", err)
 	}
 
 	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		return fmt.Errorf("failed to get rows affected: %w", err)
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("payroll run not found")
+		return false, nil
 	}
 
-	return nil
+	if err := insertRunTransition(tx, payrollRunID, nullString(fromStatus), toStatus, action, actor, comment); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
 }
 
-// LockPayrollRun locks a payroll run to prevent modifications
-func (r *PayrollRepository) LockPayrollRun(payrollRunID string, lockedBy string, reason string) error {
-	// Start transaction
+// RecordPayrollRunCreated records a new run's first transition
+func (r *PayrollRepository) RecordPayrollRunCreated(payrollRunID, status, actor string) error {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to start transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	// Insert lock record
-	lockQuery := `
-		INSERT INTO payroll_locks (payroll_run_id, locked_by, lock_reason, is_active, created_at)
-		VALUES ($1, $2, $3, true, NOW())
+	if err := insertRunTransition(tx, payrollRunID, sql.NullString{}, status, "create", actor, ""); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPayrollRunTransitions fetches a run's status history, oldest first
+func (r *PayrollRepository) GetPayrollRunTransitions(payrollRunID string) ([]models.PayrollRunTransition, error) {
+	query := `
+		SELECT id, payroll_run_id, from_status, to_status, action, actor, comment, created_at
+		FROM payroll_run_transitions
+		WHERE payroll_run_id = $1
+		ORDER BY created_at, id
 	`
 
-	if _, err := tx.Exec(lockQuery, payrollRunID, lockedBy, reason); err != nil {
-		return fmt.Errorf("failed to create lock: %w", err)
+	rows, err := r.db.Query(query, payrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll run transitions: %w", err)
 	}
+	defer rows.Close()
+
+	var transitions []models.PayrollRunTransition
+	for rows.Next() {
+		var t models.PayrollRunTransition
+		err := rows.Scan(&t.ID, &t.PayrollRunID, &t.FromStatus, &t.ToStatus, &t.Action, &t.Actor, &t.Comment, &t.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payroll run transition: %w", err)
+		}
+		transitions = append(transitions, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payroll run transitions: %w", err)
+	}
+
+	return transitions, nil
+}
+
+// GetLatestPayrollRunTransition fetches the most recent transition of a run
+// made by action. Returns nil when there is none.
+func (r *PayrollRepository) GetLatestPayrollRunTransition(payrollRunID, action string) (*models.PayrollRunTransition, error) {
+	query := `
+		SELECT id, payroll_run_id, from_status, to_status, action, actor, comment, created_at
+		FROM payroll_run_transitions
+		WHERE payroll_run_id = $1 AND action = $2
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	var t models.PayrollRunTransition
+	err := r.db.QueryRow(query, payrollRunID, action).Scan(
+		&t.ID, &t.PayrollRunID, &t.FromStatus, &t.ToStatus, &t.Action, &t.Actor, &t.Comment, &t.CreatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query payroll run transition: %w", err)
+	}
+
+	return &t, nil
+}
+
+func insertRunTransition(tx *sql.Tx, payrollRunID string, fromStatus sql.NullString, toStatus, action, actor, comment string) error {
+	query := `
+		INSERT INTO payroll_run_transitions (payroll_run_id, from_status, to_status, action, actor, comment, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`
+
+	if _, err := tx.Exec(query, payrollRunID, fromStatus, toStatus, action, actor, nullString(comment)); err != nil {
+		return fmt.Errorf("failed to record payroll run transition: %w", err)
+	}
+
+	return nil
+}
+
+// LockPayrollRun moves a run from fromStatus to locked, records the
+// transition and locks the run in one transaction. A run unlocked for a
+// correction gets its lock reactivated, and the reopen request that unlocked
+// it is completed. It returns false, changing nothing, when the run is no
+// longer in the from status.
+func (r *PayrollRepository) LockPayrollRun(payrollRunID, fromStatus, action, lockedBy, comment, reason string) (bool, error) {
+	// Start transaction
+	tx, err := r.db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
 
 	// Update payroll run status
 	statusQuery := `
 		UPDATE payroll_runs
 		SET status = 'locked', locked_at = NOW(), locked_by = $1, updated_at = NOW()
-		WHERE id = $2
+		WHERE id = $2 AND status = $3
 	`
 
-	if _, err := tx.Exec(statusQuery, lockedBy, payrollRunID); err != nil {
-		return fmt.Errorf("failed to update payroll run status: %w", err)
+	result, err := tx.Exec(statusQuery, lockedBy, payrollRunID, fromStatus)
+	if err != nil {
+		return false, fmt.Errorf("failed to update payroll run status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return false, nil
+	}
+
+	if err := insertRunTransition(tx, payrollRunID, nullString(fromStatus), "locked", action, lockedBy, comment); err != nil {
+		return false, err
+	}
+
+	// Insert or reactivate the lock record
+	lockQuery := `
+		INSERT INTO payroll_locks (org_id, payroll_run_id, locked_at, locked_by, lock_reason, is_active)
+		SELECT org_id, id, NOW(), $2, $3, true FROM payroll_runs WHERE id = $1
+		ON CONFLICT (payroll_run_id) DO UPDATE SET
+			locked_at = NOW(),
+			locked_by = EXCLUDED.locked_by,
+			lock_reason = EXCLUDED.lock_reason,
+			is_active = true
+		RETURNING id
+	`
+
+	var lockID string
+	if err := tx.QueryRow(lockQuery, payrollRunID, lockedBy, reason).Scan(&lockID); err != nil {
+		return false, fmt.Errorf("failed to create lock: %w", err)
+	}
+
+	completeQuery := `
+		UPDATE payroll_reopen_requests
+		SET status = 'completed', completed_at = NOW(), updated_at = NOW()
+		WHERE payroll_run_id = $1 AND status = 'approved'
+		RETURNING id
+	`
+
+	var reopenRequestID sql.NullString
+	if err := tx.QueryRow(completeQuery, payrollRunID).Scan(&reopenRequestID); err != nil && err != sql.ErrNoRows {
+		return false, fmt.Errorf("failed to complete reopen request: %w", err)
+	}
+
+	if err := insertLockEvent(tx, lockID, payrollRunID, "lock", lockedBy, reason, reopenRequestID); err != nil {
+		return false, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return false, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return true, nil
+}
+
+// GetPayrollLockEvents fetches a run's lock history, oldest first
+func (r *PayrollRepository) GetPayrollLockEvents(payrollRunID string) ([]models.PayrollLockEvent, error) {
+	query := `
+		SELECT id, payroll_lock_id, payroll_run_id, action, actor, reason, reopen_request_id, created_at
+		FROM payroll_lock_events
+		WHERE payroll_run_id = $1
+		ORDER BY created_at, id
+	`
+
+	rows, err := r.db.Query(query, payrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll lock events: %w", err)
+	}
+	defer rows.Close()
+
+	var events []models.PayrollLockEvent
+	for rows.Next() {
+		var e models.PayrollLockEvent
+		err := rows.Scan(&e.ID, &e.PayrollLockID, &e.PayrollRunID, &e.Action, &e.Actor, &e.Reason, &e.ReopenRequestID, &e.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payroll lock event: %w", err)
+		}
+		events = append(events, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payroll lock events: %w", err)
+	}
+
+	return events, nil
+}
+
+func insertLockEvent(tx *sql.Tx, lockID, payrollRunID, action, actor, reason string, reopenRequestID sql.NullString) error {
+	query := `
+		INSERT INTO payroll_lock_events (payroll_lock_id, payroll_run_id, action, actor, reason, reopen_request_id, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`
+
+	if _, err := tx.Exec(query, lockID, payrollRunID, action, actor, nullString(reason), reopenRequestID); err != nil {
+		return fmt.Errorf("failed to record payroll lock event: %w", err)
+	}
+
+	return nil
+}
+
+// CreatePayrollRunEmployees stores the employee selection of an off-cycle
+// run in one transaction
+func (r *PayrollRepository) CreatePayrollRunEmployees(entries []models.PayrollRunEmployee) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO payroll_run_employees (
+			payroll_run_id, employee_id, amount, description, created_at
+		) VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`
+
+	for i := range entries {
+		e := &entries[i]
+		err := tx.QueryRow(query, e.PayrollRunID, e.EmployeeID, e.Amount, e.Description).Scan(&e.ID, &e.CreatedAt)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return fmt.Errorf("employee %s is selected more than once", e.EmployeeID)
+			}
+			return fmt.Errorf("failed to create payroll run employee: %w", err)
+		}
 	}
 
 	if err := tx.Commit(); err != nil {
@@ -196,6 +458,69 @@ func (r *PayrollRepository) LockPayrollRun(payrollRunID string, lockedBy string,
 	return nil
 }
 
+// GetPayrollRunEmployees fetches the employee selection of an off-cycle run
+func (r *PayrollRepository) GetPayrollRunEmployees(payrollRunID string) ([]models.PayrollRunEmployee, error) {
+	query := `
+		SELECT id, payroll_run_id, employee_id, amount, description, created_at
+		FROM payroll_run_employees
+		WHERE payroll_run_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.Query(query, payrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll run employees: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []models.PayrollRunEmployee
+	for rows.Next() {
+		var e models.PayrollRunEmployee
+		if err := rows.Scan(&e.ID, &e.PayrollRunID, &e.EmployeeID, &e.Amount, &e.Description, &e.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan payroll run employee: %w", err)
+		}
+		entries = append(entries, e)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payroll run employees: %w", err)
+	}
+
+	return entries, nil
+}
+
+// GetReversedEmployees returns the employees of a run already selected by
+// one of its reversal runs, with the reversal run's ID
+func (r *PayrollRepository) GetReversedEmployees(payrollRunID string) (map[string]string, error) {
+	query := `
+		SELECT pre.employee_id, pr.id
+		FROM payroll_run_employees pre
+		JOIN payroll_runs pr ON pr.id = pre.payroll_run_id
+		WHERE pr.reverses_payroll_run_id = $1
+	`
+
+	rows, err := r.db.Query(query, payrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reversed employees: %w", err)
+	}
+	defer rows.Close()
+
+	reversed := make(map[string]string)
+	for rows.Next() {
+		var employeeID, reversalRunID string
+		if err := rows.Scan(&employeeID, &reversalRunID); err != nil {
+			return nil, fmt.Errorf("failed to scan reversed employee: %w", err)
+		}
+		reversed[employeeID] = reversalRunID
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating reversed employees: %w", err)
+	}
+
+	return reversed, nil
+}
+
 // GetPayrollComponents fetches all payroll components for a payroll run
 func (r *PayrollRepository) GetPayrollComponents(payrollRunID string) ([]models.PayrollComponent, error) {
 	query := `
@@ -204,8 +529,19 @@ func (r *PayrollRepository) GetPayrollComponents(payrollRunID string) ([]models.
 		       basic_pay, dearness_allowance, house_rent_allowance, other_allowances,
 		       gross_amount, pf_employee, pf_employer, esi_employee, esi_employer,
 		       professional_tax, tds, advance_recovery, loan_recovery, other_deductions,
+		       court_order_deduction, carry_forward_recovered, deduction_shortfall, rounding_adjustment,
+		       pf_wage, esi_wage, taxable_income, shift_allowance,
+		       lop_days, lop_amount, lop_reversal,
+		       nps_employee, nps_employer, nps_80ccd2, superannuation_employer, retirement_perquisite,
+		       fbp_fixed, fbp_claims, fbp_unclaimed_payout, fbp_accrued, fbp_exempt, one_time_payment,
+		       equity_perquisite, equity_perquisite_deferred, equity_deferred_taxed,
 		       total_deductions, net_pay, is_validated, validation_errors, is_locked,
-		       locked_at, created_at, updated_at, created_by
+		       locked_at, created_at, updated_at, created_by,
+		       COALESCE((
+		           SELECT CASE WHEN h.status = 'active' AND h.expires_at < CURRENT_DATE THEN 'lapsed' ELSE h.status END
+		           FROM payroll_holds h
+		           WHERE h.payroll_component_id = payroll_components.id AND h.status <> 'withdrawn'
+		       ), '') AS hold_status
 		FROM payroll_components
 		WHERE payroll_run_id = $1
 		ORDER BY created_at
@@ -226,8 +562,15 @@ func (r *PayrollRepository) GetPayrollComponents(payrollRunID string) ([]models.
 			&pc.BasicPay, &pc.DAAmount, &pc.HRAAmount, &pc.OtherAllowances,
 			&pc.GrossAmount, &pc.PFEmployee, &pc.PFEmployer, &pc.ESIEmployee, &pc.ESIEmployer,
 			&pc.ProfessionalTax, &pc.TDS, &pc.AdvanceRecovery, &pc.LoanRecovery, &pc.OtherDeductions,
+			&pc.CourtOrderDeduction, &pc.CarryForwardRecovered, &pc.DeductionShortfall, &pc.RoundingAdjustment,
+			&pc.PFWage, &pc.ESIWage, &pc.TaxableIncome, &pc.ShiftAllowance,
+			&pc.LOPDays, &pc.LOPAmount, &pc.LOPReversal,
+			&pc.NPSEmployee, &pc.NPSEmployer, &pc.NPS80CCD2, &pc.SuperannuationEmployer, &pc.RetirementPerquisite,
+			&pc.FBPFixed, &pc.FBPClaims, &pc.FBPUnclaimedPayout, &pc.FBPAccrued, &pc.FBPExempt, &pc.OneTimePayment,
+			&pc.EquityPerquisite, &pc.EquityPerquisiteDeferred, &pc.EquityDeferredTaxed,
 			&pc.TotalDeductions, &pc.NetPay, &pc.IsValidated, &pc.ValidationErrors, &pc.IsLocked,
 			&pc.LockedAt, &pc.CreatedAt, &pc.UpdatedAt, &pc.CreatedBy,
+			&pc.HoldStatus,
 		)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan payroll component: %w", err)
@@ -242,8 +585,22 @@ func (r *PayrollRepository) GetPayrollComponents(payrollRunID string) ([]models.
 	return components, nil
 }
 
-// CreatePayrollComponent creates a new payroll component
-func (r *PayrollRepository) CreatePayrollComponent(pc *models.PayrollComponent) error {
+// Begin starts a transaction for writes that span repositories, such as a
+// component and what it recovered and paid against other modules
+func (r *PayrollRepository) Begin() (*sql.Tx, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to start transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// UpsertPayrollComponent creates an employee's component in a run, or
+// replaces the amounts of the existing one so it keeps its ID, holds and
+// audit. The deduction ledger, deduction instalment and rounding rows of a
+// replaced component are cleared for the caller to record again in the same
+// transaction.
+func (r *PayrollRepository) UpsertPayrollComponent(tx *sql.Tx, pc *models.PayrollComponent) error {
 	query := `
 		INSERT INTO payroll_components (
 			org_id, payroll_run_id, employee_id, salary_structure_id,
@@ -251,41 +608,593 @@ func (r *PayrollRepository) CreatePayrollComponent(pc *models.PayrollComponent)
 			basic_pay, dearness_allowance, house_rent_allowance, other_allowances,
 			gross_amount, pf_employee, pf_employer, esi_employee, esi_employer,
 			professional_tax, tds, advance_recovery, loan_recovery, other_deductions,
+			court_order_deduction, carry_forward_recovered, deduction_shortfall, rounding_adjustment,
+			pf_wage, esi_wage, taxable_income, shift_allowance,
+			lop_days, lop_amount, lop_reversal,
+			nps_employee, nps_employer, nps_80ccd2, superannuation_employer, retirement_perquisite,
+			fbp_fixed, fbp_claims, fbp_unclaimed_payout, fbp_accrued, fbp_exempt, one_time_payment,
+			equity_perquisite, equity_perquisite_deferred, equity_deferred_taxed,
 			total_deductions, net_pay, is_validated, created_by, created_at, updated_at
 		) VALUES (
 			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
-			$18, $19, $20, $21, $22, $23, $24, $25, $26, NOW(), NOW()
+			$18, $19, $20, $21, $22, $23, $24, $25, $26, $27, $28, $29, $30, $31, $32, $33, $34,
+			$35, $36, $37, $38, $39, $40, $41, $42, $43, $44, $45, $46, $47, $48, $49, $50, $51, NOW(), NOW()
 		)
+		ON CONFLICT (payroll_run_id, employee_id) DO UPDATE SET
+			salary_structure_id = EXCLUDED.salary_structure_id,
+			days_worked = EXCLUDED.days_worked, days_absent = EXCLUDED.days_absent,
+			days_leave = EXCLUDED.days_leave, days_in_month = EXCLUDED.days_in_month,
+			basic_pay = EXCLUDED.basic_pay, dearness_allowance = EXCLUDED.dearness_allowance,
+			house_rent_allowance = EXCLUDED.house_rent_allowance, other_allowances = EXCLUDED.other_allowances,
+			gross_amount = EXCLUDED.gross_amount, pf_employee = EXCLUDED.pf_employee,
+			pf_employer = EXCLUDED.pf_employer, esi_employee = EXCLUDED.esi_employee,
+			esi_employer = EXCLUDED.esi_employer, professional_tax = EXCLUDED.professional_tax,
+			tds = EXCLUDED.tds, advance_recovery = EXCLUDED.advance_recovery,
+			loan_recovery = EXCLUDED.loan_recovery, other_deductions = EXCLUDED.other_deductions,
+			court_order_deduction = EXCLUDED.court_order_deduction,
+			carry_forward_recovered = EXCLUDED.carry_forward_recovered,
+			deduction_shortfall = EXCLUDED.deduction_shortfall,
+			rounding_adjustment = EXCLUDED.rounding_adjustment,
+			pf_wage = EXCLUDED.pf_wage, esi_wage = EXCLUDED.esi_wage,
+			taxable_income = EXCLUDED.taxable_income, shift_allowance = EXCLUDED.shift_allowance,
+			lop_days = EXCLUDED.lop_days, lop_amount = EXCLUDED.lop_amount, lop_reversal = EXCLUDED.lop_reversal,
+			nps_employee = EXCLUDED.nps_employee, nps_employer = EXCLUDED.nps_employer,
+			nps_80ccd2 = EXCLUDED.nps_80ccd2, superannuation_employer = EXCLUDED.superannuation_employer,
+			retirement_perquisite = EXCLUDED.retirement_perquisite,
+			fbp_fixed = EXCLUDED.fbp_fixed, fbp_claims = EXCLUDED.fbp_claims,
+			fbp_unclaimed_payout = EXCLUDED.fbp_unclaimed_payout, fbp_accrued = EXCLUDED.fbp_accrued,
+			fbp_exempt = EXCLUDED.fbp_exempt, one_time_payment = EXCLUDED.one_time_payment,
+			equity_perquisite = EXCLUDED.equity_perquisite,
+			equity_perquisite_deferred = EXCLUDED.equity_perquisite_deferred,
+			equity_deferred_taxed = EXCLUDED.equity_deferred_taxed,
+			total_deductions = EXCLUDED.total_deductions, net_pay = EXCLUDED.net_pay,
+			is_validated = EXCLUDED.is_validated, created_by = EXCLUDED.created_by,
+			updated_at = NOW()
 		RETURNING id, created_at, updated_at
 	`
 
-	err := r.db.QueryRow(
+	err := tx.QueryRow(
 		query,
 		pc.OrgID, pc.PayrollRunID, pc.EmployeeID, pc.SalaryStructureID,
 		pc.DaysWorked, pc.DaysAbsent, pc.DaysLeave, pc.DaysInMonth,
 		pc.BasicPay, pc.DAAmount, pc.HRAAmount, pc.OtherAllowances,
 		pc.GrossAmount, pc.PFEmployee, pc.PFEmployer, pc.ESIEmployee, pc.ESIEmployer,
 		pc.ProfessionalTax, pc.TDS, pc.AdvanceRecovery, pc.LoanRecovery, pc.OtherDeductions,
+		pc.CourtOrderDeduction, pc.CarryForwardRecovered, pc.DeductionShortfall, pc.RoundingAdjustment,
+		pc.PFWage, pc.ESIWage, pc.TaxableIncome, pc.ShiftAllowance,
+		pc.LOPDays, pc.LOPAmount, pc.LOPReversal,
+		pc.NPSEmployee, pc.NPSEmployer, pc.NPS80CCD2, pc.SuperannuationEmployer, pc.RetirementPerquisite,
+		pc.FBPFixed, pc.FBPClaims, pc.FBPUnclaimedPayout, pc.FBPAccrued, pc.FBPExempt, pc.OneTimePayment,
+		pc.EquityPerquisite, pc.EquityPerquisiteDeferred, pc.EquityDeferredTaxed,
 		pc.TotalDeductions, pc.NetPay, pc.IsValidated, pc.CreatedBy,
 	).Scan(&pc.ID, &pc.CreatedAt, &pc.UpdatedAt)
 
 	if err != nil {
-		return fmt.Errorf("failed to create payroll component: %w", err)
+		return fmt.Errorf("failed to save payroll component: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM deduction_ledger WHERE payroll_component_id = $1", pc.ID); err != nil {
+		return fmt.Errorf("failed to clear deduction ledger entries: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM employee_deduction_instalments WHERE payroll_component_id = $1", pc.ID); err != nil {
+		return fmt.Errorf("failed to clear deduction instalments: %w", err)
+	}
+
+	if _, err := tx.Exec("DELETE FROM rounding_adjustments WHERE payroll_component_id = $1", pc.ID); err != nil {
+		return fmt.Errorf("failed to clear rounding adjustment: %w", err)
+	}
+
+	return nil
+}
+
+// MarkBankFileGenerated records that a run's main bank file was generated,
+// keeping the time it first was. It waits for any hold being placed on the
+// run, and no hold can be placed afterwards.
+func (r *PayrollRepository) MarkBankFileGenerated(payrollRunID string) error {
+	query := `
+		UPDATE payroll_runs
+		SET bank_file_generated_at = COALESCE(bank_file_generated_at, NOW())
+		WHERE id = $1
+	`
+
+	if _, err := r.db.Exec(query, payrollRunID); err != nil {
+		return fmt.Errorf("failed to mark bank file generated: %w", err)
+	}
+
+	return nil
+}
+
+// UpdatePayrollRunTotals recomputes a run's financial summary from its
+// components
+func (r *PayrollRepository) UpdatePayrollRunTotals(payrollRunID string) error {
+	query := `
+		UPDATE payroll_runs pr
+		SET total_employees = t.employees,
+		    total_gross_amount = t.gross,
+		    total_deductions = t.deductions,
+		    total_net_amount = t.net,
+		    total_pf_employee = t.pf_employee,
+		    total_pf_employer = t.pf_employer,
+		    total_esi_employee = t.esi_employee,
+		    total_esi_employer = t.esi_employer,
+		    total_pt = t.pt,
+		    total_tds = t.tds,
+		    updated_at = NOW()
+		FROM (
+			SELECT COUNT(*) AS employees,
+			       COALESCE(SUM(gross_amount), 0) AS gross,
+			       COALESCE(SUM(total_deductions), 0) AS deductions,
+			       COALESCE(SUM(net_pay), 0) AS net,
+			       COALESCE(SUM(pf_employee), 0) AS pf_employee,
+			       COALESCE(SUM(pf_employer), 0) AS pf_employer,
+			       COALESCE(SUM(esi_employee), 0) AS esi_employee,
+			       COALESCE(SUM(esi_employer), 0) AS esi_employer,
+			       COALESCE(SUM(professional_tax), 0) AS pt,
+			       COALESCE(SUM(tds), 0) AS tds
+			FROM payroll_components
+			WHERE payroll_run_id = $1
+		) t
+		WHERE pr.id = $1
+	`
+
+	if _, err := r.db.Exec(query, payrollRunID); err != nil {
+		return fmt.Errorf("failed to update payroll run totals: %w", err)
+	}
+
+	return nil
+}
+
+// CreateCalculationAudit stores the calculation working for a payroll
+// component, in the component's transaction
+func (r *PayrollRepository) CreateCalculationAudit(tx *sql.Tx, audit *models.CalculationAudit) error {
+	query := `
+		INSERT INTO payroll_calculation_audits (
+			org_id, payroll_run_id, payroll_component_id, employee_id,
+			inputs, rule_versions, steps, validations,
+			status, calculated_by, calculated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
+		)
+		ON CONFLICT (payroll_component_id) DO UPDATE SET
+			inputs = EXCLUDED.inputs,
+			rule_versions = EXCLUDED.rule_versions,
+			steps = EXCLUDED.steps,
+			validations = EXCLUDED.validations,
+			status = EXCLUDED.status,
+			calculated_by = EXCLUDED.calculated_by,
+			calculated_at = EXCLUDED.calculated_at
+		RETURNING id
+	`
+
+	err := tx.QueryRow(
+		query,
+		audit.OrgID, audit.PayrollRunID, audit.PayrollComponentID, audit.EmployeeID,
+		audit.Inputs, audit.RuleVersions, audit.Steps, audit.Validations,
+		audit.Status, audit.CalculatedBy, audit.CalculatedAt,
+	).Scan(&audit.ID)
+
+	if err != nil {
+		return fmt.Errorf("failed to create calculation audit: %w", err)
 	}
 
 	return nil
 }
 
+// GetCalculationAudit fetches the calculation working for an employee in a payroll run
+func (r *PayrollRepository) GetCalculationAudit(payrollRunID, employeeID string) (*models.CalculationAudit, error) {
+	query := `
+		SELECT id, org_id, payroll_run_id, payroll_component_id, employee_id,
+		       inputs, rule_versions, steps, validations,
+		       status, calculated_by, calculated_at
+		FROM payroll_calculation_audits
+		WHERE payroll_run_id = $1 AND employee_id = $2
+		ORDER BY calculated_at DESC
+		LIMIT 1
+	`
+
+	audit := &models.CalculationAudit{}
+	err := r.db.QueryRow(query, payrollRunID, employeeID).Scan(
+		&audit.ID, &audit.OrgID, &audit.PayrollRunID, &audit.PayrollComponentID, &audit.EmployeeID,
+		&audit.Inputs, &audit.RuleVersions, &audit.Steps, &audit.Validations,
+		&audit.Status, &audit.CalculatedBy, &audit.CalculatedAt,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("calculation audit not found")
+		}
+		return nil, fmt.Errorf("failed to query calculation audit: %w", err)
+	}
+
+	return audit, nil
+}
+
+// GetDeductionBalances returns the given employees' unrecovered deduction
+// balances by category from the entries of locked and released runs,
+// ignoring entries written by the given payroll run, keyed by employee ID
+func (r *PayrollRepository) GetDeductionBalances(employeeIDs []string, excludePayrollRunID string) (map[string]map[string]float64, error) {
+	query := `
+		SELECT dl.employee_id, dl.category,
+		       SUM(CASE WHEN dl.entry_type = 'deferred' THEN dl.amount ELSE -dl.amount END)
+		FROM deduction_ledger dl
+		JOIN payroll_runs pr ON pr.id = dl.payroll_run_id
+		WHERE dl.employee_id = ANY($1) AND dl.payroll_run_id <> $2
+		  AND pr.status IN ('locked', 'released')
+		GROUP BY dl.employee_id, dl.category
+	`
+
+	rows, err := r.db.Query(query, pq.Array(employeeIDs), excludePayrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query deduction balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := make(map[string]map[string]float64)
+	for rows.Next() {
+		var employeeID, category string
+		var balance float64
+		if err := rows.Scan(&employeeID, &category, &balance); err != nil {
+			return nil, fmt.Errorf("failed to scan deduction balance: %w", err)
+		}
+		if balance <= 0 {
+			continue
+		}
+		if balances[employeeID] == nil {
+			balances[employeeID] = make(map[string]float64)
+		}
+		balances[employeeID][category] = balance
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating deduction balances: %w", err)
+	}
+
+	return balances, nil
+}
+
+// CreateDeductionLedgerEntries writes deduction ledger entries in the
+// component's transaction
+func (r *PayrollRepository) CreateDeductionLedgerEntries(tx *sql.Tx, entries []models.DeductionLedgerEntry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO deduction_ledger (
+			org_id, employee_id, payroll_run_id, payroll_component_id,
+			category, entry_type, amount, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		RETURNING id, created_at
+	`
+
+	for i := range entries {
+		e := &entries[i]
+		err := tx.QueryRow(
+			query,
+			e.OrgID, e.EmployeeID, e.PayrollRunID, e.PayrollComponentID,
+			e.Category, e.EntryType, e.Amount,
+		).Scan(&e.ID, &e.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create deduction ledger entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ReverseDeductionLedgerEntries writes the opposite of a component's
+// deduction ledger entries for the component reversing it: what it recovered
+// is owed again and what it deferred is cleared
+func (r *PayrollRepository) ReverseDeductionLedgerEntries(tx *sql.Tx, originalComponentID string, reversal *models.PayrollComponent) error {
+	query := `
+		INSERT INTO deduction_ledger (
+			org_id, employee_id, payroll_run_id, payroll_component_id,
+			category, entry_type, amount, created_at
+		)
+		SELECT org_id, employee_id, $2, $3, category,
+		       CASE WHEN entry_type = 'deferred' THEN 'recovered' ELSE 'deferred' END,
+		       amount, NOW()
+		FROM deduction_ledger
+		WHERE payroll_component_id = $1
+	`
+
+	if _, err := tx.Exec(query, originalComponentID, reversal.PayrollRunID, reversal.ID); err != nil {
+		return fmt.Errorf("failed to reverse deduction ledger entries: %w", err)
+	}
+
+	return nil
+}
+
+// GetRoundingBalances returns the sum of the given employees' rounding
+// adjustments from locked and released runs, ignoring those written by the
+// given payroll run, keyed by employee ID
+func (r *PayrollRepository) GetRoundingBalances(employeeIDs []string, excludePayrollRunID string) (map[string]float64, error) {
+	query := `
+		SELECT ra.employee_id, SUM(ra.amount)
+		FROM rounding_adjustments ra
+		JOIN payroll_runs pr ON pr.id = ra.payroll_run_id
+		WHERE ra.employee_id = ANY($1) AND ra.payroll_run_id <> $2
+		  AND pr.status IN ('locked', 'released')
+		GROUP BY ra.employee_id
+	`
+
+	balances, err := r.queryEmployeeAmounts(query, pq.Array(employeeIDs), excludePayrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rounding balances: %w", err)
+	}
+
+	return balances, nil
+}
+
+// RecordRoundingAdjustment writes a component's rounding adjustment to the ledger
+func (r *PayrollRepository) RecordRoundingAdjustment(tx *sql.Tx, pc *models.PayrollComponent) error {
+	if pc.RoundingAdjustment == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO rounding_adjustments (
+			org_id, employee_id, payroll_run_id, payroll_component_id, amount, created_at
+		) VALUES ($1, $2, $3, $4, $5, NOW())
+		ON CONFLICT (payroll_component_id) DO UPDATE SET amount = EXCLUDED.amount
+	`
+
+	if _, err := tx.Exec(query, pc.OrgID, pc.EmployeeID, pc.PayrollRunID, pc.ID, pc.RoundingAdjustment); err != nil {
+		return fmt.Errorf("failed to record rounding adjustment: %w", err)
+	}
+
+	return nil
+}
+
+// GetMonthToDate sums the given employees' components from other locked and
+// released payroll runs attributed to the same payroll month, so monthly
+// limits can be applied across the periods of sub-monthly pay groups and
+// across off-cycle runs. Employees no other run paid are left out.
+func (r *PayrollRepository) GetMonthToDate(employeeIDs []string, payrollMonth, excludePayrollRunID string) (map[string]*models.MonthToDateTotals, error) {
+	query := `
+		SELECT pc.employee_id, COUNT(*), COALESCE(SUM(pc.days_worked + pc.lop_days), 0), COALESCE(SUM(pc.gross_amount), 0),
+		       COALESCE(SUM(pc.one_time_payment), 0), COALESCE(SUM(pc.pf_wage), 0), COALESCE(SUM(pc.esi_wage), 0), COALESCE(SUM(pc.esi_employee), 0),
+		       COALESCE(SUM(pc.professional_tax), 0), COALESCE(SUM(pc.taxable_income), 0), COALESCE(SUM(pc.tds), 0)
+		FROM payroll_components pc
+		JOIN payroll_runs pr ON pr.id = pc.payroll_run_id
+		WHERE pc.employee_id = ANY($1) AND pr.payroll_month = $2 AND pr.id <> $3
+		  AND pr.status IN ('locked', 'released')
+		GROUP BY pc.employee_id
+	`
+
+	rows, err := r.db.Query(query, pq.Array(employeeIDs), payrollMonth, excludePayrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query month-to-date totals: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]*models.MonthToDateTotals)
+	for rows.Next() {
+		var employeeID string
+		var t models.MonthToDateTotals
+		err := rows.Scan(
+			&employeeID, &t.Components, &t.DaysWorked, &t.GrossAmount,
+			&t.OneTimePayment, &t.PFWage, &t.ESIWage, &t.ESIEmployee,
+			&t.ProfessionalTax, &t.TaxableIncome, &t.TDS,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan month-to-date totals: %w", err)
+		}
+		totals[employeeID] = &t
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating month-to-date totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// GetEmployerRetirementYTD sums the employer's PF, NPS and superannuation
+// contributions for the given employees from other locked and released
+// payroll runs attributed to fromMonth through toMonth (YYYY-MM), keyed by
+// employee ID
+func (r *PayrollRepository) GetEmployerRetirementYTD(employeeIDs []string, fromMonth, toMonth, excludePayrollRunID string) (map[string]float64, error) {
+	query := `
+		SELECT pc.employee_id, SUM(pc.pf_employer + pc.nps_employer + pc.superannuation_employer)
+		FROM payroll_components pc
+		JOIN payroll_runs pr ON pr.id = pc.payroll_run_id
+		WHERE pc.employee_id = ANY($1) AND pr.payroll_month BETWEEN $2 AND $3 AND pr.id <> $4
+		  AND pr.status IN ('locked', 'released')
+		GROUP BY pc.employee_id
+	`
+
+	totals, err := r.queryEmployeeAmounts(query, pq.Array(employeeIDs), fromMonth, toMonth, excludePayrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query employer retirement contributions: %w", err)
+	}
+
+	return totals, nil
+}
+
+// GetTaxYTD sums the taxable income and TDS of the given employees'
+// components from other locked and released payroll runs attributed to
+// fromMonth up to, not including, beforeMonth (YYYY-MM), keyed by employee ID
+func (r *PayrollRepository) GetTaxYTD(employeeIDs []string, fromMonth, beforeMonth, excludePayrollRunID string) (taxableIncome, tds map[string]float64, err error) {
+	query := `
+		SELECT pc.employee_id, SUM(pc.taxable_income), SUM(pc.tds)
+		FROM payroll_components pc
+		JOIN payroll_runs pr ON pr.id = pc.payroll_run_id
+		WHERE pc.employee_id = ANY($1) AND pr.payroll_month >= $2 AND pr.payroll_month < $3 AND pr.id <> $4
+		  AND pr.status IN ('locked', 'released')
+		GROUP BY pc.employee_id
+	`
+
+	rows, err := r.db.Query(query, pq.Array(employeeIDs), fromMonth, beforeMonth, excludePayrollRunID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query tax year to date: %w", err)
+	}
+	defer rows.Close()
+
+	taxableIncome = make(map[string]float64)
+	tds = make(map[string]float64)
+	for rows.Next() {
+		var employeeID string
+		var taxable, tax float64
+		if err := rows.Scan(&employeeID, &taxable, &tax); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan tax year to date: %w", err)
+		}
+		taxableIncome[employeeID] = taxable
+		tds[employeeID] = tax
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating tax year to date: %w", err)
+	}
+
+	return taxableIncome, tds, nil
+}
+
+// queryEmployeeAmounts runs a query returning an employee ID and an amount
+// per row, keyed by employee ID
+func (r *PayrollRepository) queryEmployeeAmounts(query string, args ...interface{}) (map[string]float64, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	amounts := make(map[string]float64)
+	for rows.Next() {
+		var employeeID string
+		var amount float64
+		if err := rows.Scan(&employeeID, &amount); err != nil {
+			return nil, err
+		}
+		amounts[employeeID] = amount
+	}
+
+	return amounts, rows.Err()
+}
+
+// GetSalaryMonths sums an employee's components of locked and released runs
+// by payroll month, for fromMonth through toMonth (YYYY-MM)
+func (r *PayrollRepository) GetSalaryMonths(employeeID, fromMonth, toMonth string) ([]models.SalaryMonthTotals, error) {
+	query := `
+		SELECT pr.payroll_month, COALESCE(SUM(pc.days_worked), 0),
+		       COALESCE(SUM(pc.gross_amount), 0), COALESCE(SUM(pc.basic_pay), 0), COALESCE(SUM(pc.dearness_allowance), 0),
+		       COALESCE(SUM(pc.pf_employee), 0), COALESCE(SUM(pc.esi_employee), 0), COALESCE(SUM(pc.professional_tax), 0),
+		       COALESCE(SUM(pc.tds), 0), COALESCE(SUM(pc.total_deductions), 0), COALESCE(SUM(pc.net_pay), 0),
+		       COALESCE(SUM(pc.retirement_perquisite), 0), COALESCE(SUM(pc.equity_perquisite), 0),
+		       COALESCE(SUM(pc.equity_perquisite_deferred), 0), COALESCE(SUM(pc.equity_deferred_taxed), 0)
+		FROM payroll_components pc
+		JOIN payroll_runs pr ON pr.id = pc.payroll_run_id
+		WHERE pc.employee_id = $1 AND pr.payroll_month BETWEEN $2 AND $3
+		  AND pr.status IN ('locked', 'released')
+		GROUP BY pr.payroll_month
+		ORDER BY pr.payroll_month
+	`
+
+	rows, err := r.db.Query(query, employeeID, fromMonth, toMonth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query salary months: %w", err)
+	}
+	defer rows.Close()
+
+	var months []models.SalaryMonthTotals
+	for rows.Next() {
+		var m models.SalaryMonthTotals
+		err := rows.Scan(
+			&m.PayrollMonth, &m.DaysWorked,
+			&m.GrossAmount, &m.BasicPay, &m.DAAmount,
+			&m.PFEmployee, &m.ESIEmployee, &m.ProfessionalTax,
+			&m.TDS, &m.TotalDeductions, &m.NetPay,
+			&m.RetirementPerquisite, &m.EquityPerquisite,
+			&m.EquityPerquisiteDeferred, &m.EquityDeferredTaxed,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan salary month: %w", err)
+		}
+		months = append(months, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating salary months: %w", err)
+	}
+
+	return months, nil
+}
+
+// GetStatutoryMonthTotals sums each employee's contributions across every
+// locked and released run of an organization attributed to a payroll month
+func (r *PayrollRepository) GetStatutoryMonthTotals(orgID, payrollMonth string) ([]models.StatutoryMonthTotals, error) {
+	query := `
+		SELECT pc.employee_id, COUNT(DISTINCT pr.id),
+		       COALESCE(SUM(pc.pf_wage), 0), COALESCE(SUM(pc.pf_employee), 0), COALESCE(SUM(pc.pf_employer), 0),
+		       COALESCE(SUM(pc.esi_wage), 0), COALESCE(SUM(pc.esi_employee), 0), COALESCE(SUM(pc.esi_employer), 0),
+		       COALESCE(SUM(pc.professional_tax), 0), COALESCE(SUM(pc.tds), 0)
+		FROM payroll_components pc
+		JOIN payroll_runs pr ON pr.id = pc.payroll_run_id
+		WHERE pr.org_id = $1 AND pr.payroll_month = $2
+		  AND pr.status IN ('locked', 'released')
+		GROUP BY pc.employee_id
+		ORDER BY pc.employee_id
+	`
+
+	rows, err := r.db.Query(query, orgID, payrollMonth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statutory month totals: %w", err)
+	}
+	defer rows.Close()
+
+	var totals []models.StatutoryMonthTotals
+	for rows.Next() {
+		var t models.StatutoryMonthTotals
+		err := rows.Scan(
+			&t.EmployeeID, &t.Runs,
+			&t.PFWage, &t.PFEmployee, &t.PFEmployer,
+			&t.ESIWage, &t.ESIEmployee, &t.ESIEmployer,
+			&t.ProfessionalTax, &t.TDS,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan statutory month totals: %w", err)
+		}
+		totals = append(totals, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating statutory month totals: %w", err)
+	}
+
+	return totals, nil
+}
+
+// RecordRunStatutoryRules records which statutory rule rows a payroll run
+// was calculated with, so rules used by locked runs can be kept immutable
+func (r *PayrollRepository) RecordRunStatutoryRules(payrollRunID string, ruleIDs []string) error {
+	query := `
+		INSERT INTO payroll_run_statutory_rules (payroll_run_id, statutory_rule_id, created_at)
+		SELECT $1, unnest($2::uuid[]), NOW()
+		ON CONFLICT (payroll_run_id, statutory_rule_id) DO NOTHING
+	`
+
+	if _, err := r.db.Exec(query, payrollRunID, pq.Array(ruleIDs)); err != nil {
+		return fmt.Errorf("failed to record payroll run statutory rules: %w", err)
+	}
+
+	return nil
+}
+
+// statutoryRuleColumns lists the statutory_rules columns in scan order
+const statutoryRuleColumns = `
+		id, org_id, rule_type, state_code, effective_from, effective_till,
+		pf_employee_rate, pf_employer_rate, pf_ceiling,
+		esi_employee_rate, esi_employer_rate, esi_wage_ceiling, esi_threshold_salary,
+		pt_slab_min, pt_slab_max, pt_amount,
+		tds_slab_min, tds_slab_max, tds_rate,
+		gratuity_rate_per_year, gratuity_completion_months,
+		is_active, created_at, updated_at, created_by
+`
+
 // GetStatutoryRules fetches applicable statutory rules
 func (r *PayrollRepository) GetStatutoryRules(ruleType string, stateCode *string) ([]models.StatutoryRule, error) {
-	query := `
-		SELECT id, org_id, rule_type, state_code, effective_from, effective_till,
-		       pf_employee_rate, pf_employer_rate, pf_ceiling,
-		       esi_employee_rate, esi_employer_rate, esi_wage_ceiling, esi_threshold_salary,
-		       pt_slab_min, pt_slab_max, pt_amount,
-		       tds_slab_min, tds_slab_max, tds_rate,
-		       gratuity_rate_per_year, gratuity_completion_months,
-		       is_active, created_at, updated_at, created_by
+	query := `SELECT ` + statutoryRuleColumns + `
 		FROM statutory_rules
 		WHERE rule_type = $1 AND is_active = true
 	`
@@ -304,6 +1213,33 @@ func (r *PayrollRepository) GetStatutoryRules(ruleType string, stateCode *string
 	}
 	defer rows.Close()
 
+	return scanStatutoryRules(rows)
+}
+
+// GetEffectiveStatutoryRules fetches every active rule in force on a date for an
+// organization and state, including global (org-less) and state-less rows.
+// Picking the most specific version per rule type is left to the caller.
+func (r *PayrollRepository) GetEffectiveStatutoryRules(orgID, stateCode string, asOf time.Time) ([]models.StatutoryRule, error) {
+	query := `SELECT ` + statutoryRuleColumns + `
+		FROM statutory_rules
+		WHERE is_active = true
+		  AND (org_id = $1 OR org_id IS NULL)
+		  AND (state_code = $2 OR state_code IS NULL)
+		  AND effective_from <= $3
+		  AND (effective_till IS NULL OR effective_till >= $3)
+		ORDER BY rule_type, effective_from DESC, pt_slab_min, tds_slab_min
+	`
+
+	rows, err := r.db.Query(query, nullString(orgID), nullString(stateCode), asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query effective statutory rules: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStatutoryRules(rows)
+}
+
+func scanStatutoryRules(rows *sql.Rows) ([]models.StatutoryRule, error) {
 	var rules []models.StatutoryRule
 	for rows.Next() {
 		var sr models.StatutoryRule
@@ -322,9 +1258,14 @@ func (r *PayrollRepository) GetStatutoryRules(ruleType string, stateCode *string
 		rules = append(rules, sr)
 	}
 
-	if err = rows.Err(); err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("error iterating statutory rules: %w", err)
 	}
 
 	return rules, nil
 }
+
+// nullString maps an empty string to SQL NULL
+func nullString(s string) sql.NullString {
+	return sql.NullString{String: s, Valid: s != ""}
+}
@@ -0,0 +1,110 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"payroll-service/internal/models"
+)
+
+type PayrollRunOutcomeRepository struct {
+	db *sql.DB
+}
+
+func NewPayrollRunOutcomeRepository(db *sql.DB) *PayrollRunOutcomeRepository {
+	return &PayrollRunOutcomeRepository{db: db}
+}
+
+// SavePayrollRunOutcomes stores the outcomes of calculating a run, replacing
+// those of the same employees. With replaceAll set, outcomes of employees no
+// longer calculated are removed as well.
+func (r *PayrollRunOutcomeRepository) SavePayrollRunOutcomes(payrollRunID string, outcomes []models.PayrollRunOutcome, replaceAll bool) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if replaceAll {
+		if _, err := tx.Exec(`DELETE FROM payroll_run_outcomes WHERE payroll_run_id = $1`, payrollRunID); err != nil {
+			return fmt.Errorf("failed to clear payroll run outcomes: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO payroll_run_outcomes (
+			org_id, payroll_run_id, employee_id, status, error_code, error_message,
+			payroll_component_id, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+		ON CONFLICT (payroll_run_id, employee_id) DO UPDATE SET
+			status = EXCLUDED.status,
+			error_code = EXCLUDED.error_code,
+			error_message = EXCLUDED.error_message,
+			payroll_component_id = EXCLUDED.payroll_component_id,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	for i := range outcomes {
+		o := &outcomes[i]
+		err := tx.QueryRow(
+			query,
+			o.OrgID, payrollRunID, o.EmployeeID, o.Status, o.ErrorCode, o.ErrorMessage,
+			o.PayrollComponentID,
+		).Scan(&o.ID, &o.CreatedAt, &o.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save payroll run outcome: %w", err)
+		}
+		o.PayrollRunID = payrollRunID
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetPayrollRunOutcomes fetches the per-employee outcomes of a run,
+// optionally of one status
+func (r *PayrollRunOutcomeRepository) GetPayrollRunOutcomes(payrollRunID, status string) ([]models.PayrollRunOutcome, error) {
+	query := `
+		SELECT id, org_id, payroll_run_id, employee_id, status, error_code, error_message,
+		       payroll_component_id, created_at, updated_at
+		FROM payroll_run_outcomes
+		WHERE payroll_run_id = $1
+	`
+	args := []interface{}{payrollRunID}
+
+	if status != "" {
+		query += " AND status = $2"
+		args = append(args, status)
+	}
+
+	query += " ORDER BY status, employee_id"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query payroll run outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	var outcomes []models.PayrollRunOutcome
+	for rows.Next() {
+		var o models.PayrollRunOutcome
+		err := rows.Scan(
+			&o.ID, &o.OrgID, &o.PayrollRunID, &o.EmployeeID, &o.Status, &o.ErrorCode, &o.ErrorMessage,
+			&o.PayrollComponentID, &o.CreatedAt, &o.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan payroll run outcome: %w", err)
+		}
+		outcomes = append(outcomes, o)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating payroll run outcomes: %w", err)
+	}
+
+	return outcomes, nil
+}
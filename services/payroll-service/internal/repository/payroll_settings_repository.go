@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"payroll-service/internal/models"
+)
+
+type PayrollSettingsRepository struct {
+	db *sql.DB
+}
+
+func NewPayrollSettingsRepository(db *sql.DB) *PayrollSettingsRepository {
+	return &PayrollSettingsRepository{db: db}
+}
+
+// GetPayrollSettings fetches an organization's payroll settings. Organizations
+// without a settings row get the defaults.
+func (r *PayrollSettingsRepository) GetPayrollSettings(orgID string) (*models.PayrollSettings, error) {
+	query := `
+		SELECT id, org_id, rounding_mode, rounding_unit, proration_basis, variance_percent_threshold,
+		       variance_amount_threshold, created_at, updated_at, updated_by
+		FROM payroll_settings
+		WHERE org_id = $1
+	`
+
+	var ps models.PayrollSettings
+	err := r.db.QueryRow(query, orgID).Scan(
+		&ps.ID, &ps.OrgID, &ps.RoundingMode, &ps.RoundingUnit, &ps.ProrationBasis, &ps.VariancePercentThreshold,
+		&ps.VarianceAmountThreshold, &ps.CreatedAt, &ps.UpdatedAt, &ps.UpdatedBy,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return &models.PayrollSettings{
+				OrgID:                    orgID,
+				RoundingMode:             "none",
+				RoundingUnit:             1,
+				ProrationBasis:           "calendar_days",
+				VariancePercentThreshold: 10,
+				VarianceAmountThreshold:  5000,
+			}, nil
+		}
+		return nil, fmt.Errorf("failed to query payroll settings: %w", err)
+	}
+
+	return &ps, nil
+}
+
+// UpsertPayrollSettings creates or replaces an organization's payroll settings
+func (r *PayrollSettingsRepository) UpsertPayrollSettings(ps *models.PayrollSettings) error {
+	query := `
+		INSERT INTO payroll_settings (
+			org_id, rounding_mode, rounding_unit, proration_basis, variance_percent_threshold,
+			variance_amount_threshold, created_at, updated_at, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW(), $7)
+		ON CONFLICT (org_id) DO UPDATE SET
+			rounding_mode = EXCLUDED.rounding_mode,
+			rounding_unit = EXCLUDED.rounding_unit,
+			proration_basis = EXCLUDED.proration_basis,
+			variance_percent_threshold = EXCLUDED.variance_percent_threshold,
+			variance_amount_threshold = EXCLUDED.variance_amount_threshold,
+			updated_at = NOW(),
+			updated_by = EXCLUDED.updated_by
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		ps.OrgID, ps.RoundingMode, ps.RoundingUnit, ps.ProrationBasis, ps.VariancePercentThreshold,
+		ps.VarianceAmountThreshold, ps.UpdatedBy,
+	).Scan(&ps.ID, &ps.CreatedAt, &ps.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save payroll settings: %w", err)
+	}
+
+	return nil
+}
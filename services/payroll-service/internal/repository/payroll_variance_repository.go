@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"payroll-service/internal/models"
+)
+
+type PayrollVarianceRepository struct {
+	db *sql.DB
+}
+
+func NewPayrollVarianceRepository(db *sql.DB) *PayrollVarianceRepository {
+	return &PayrollVarianceRepository{db: db}
+}
+
+// SaveVarianceAcknowledgements stores acknowledgements of a run's variances,
+// replacing earlier ones of the same employees
+func (r *PayrollVarianceRepository) SaveVarianceAcknowledgements(acks []models.PayrollVarianceAcknowledgement) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO payroll_variance_acknowledgements (
+			org_id, payroll_run_id, employee_id, net_pay, reason, acknowledged_by,
+			created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW(), NOW())
+		ON CONFLICT (payroll_run_id, employee_id) DO UPDATE SET
+			net_pay = EXCLUDED.net_pay,
+			reason = EXCLUDED.reason,
+			acknowledged_by = EXCLUDED.acknowledged_by,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	for i := range acks {
+		a := &acks[i]
+		err := tx.QueryRow(
+			query,
+			a.OrgID, a.PayrollRunID, a.EmployeeID, a.NetPay, a.Reason, a.AcknowledgedBy,
+		).Scan(&a.ID, &a.CreatedAt, &a.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to save variance acknowledgement: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetVarianceAcknowledgements fetches the variance acknowledgements of a run
+func (r *PayrollVarianceRepository) GetVarianceAcknowledgements(payrollRunID string) ([]models.PayrollVarianceAcknowledgement, error) {
+	query := `
+		SELECT id, org_id, payroll_run_id, employee_id, net_pay, reason, acknowledged_by,
+		       created_at, updated_at
+		FROM payroll_variance_acknowledgements
+		WHERE payroll_run_id = $1
+		ORDER BY employee_id
+	`
+
+	rows, err := r.db.Query(query, payrollRunID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variance acknowledgements: %w", err)
+	}
+	defer rows.Close()
+
+	var acks []models.PayrollVarianceAcknowledgement
+	for rows.Next() {
+		var a models.PayrollVarianceAcknowledgement
+		err := rows.Scan(
+			&a.ID, &a.OrgID, &a.PayrollRunID, &a.EmployeeID, &a.NetPay, &a.Reason, &a.AcknowledgedBy,
+			&a.CreatedAt, &a.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan variance acknowledgement: %w", err)
+		}
+		acks = append(acks, a)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating variance acknowledgements: %w", err)
+	}
+
+	return acks, nil
+}
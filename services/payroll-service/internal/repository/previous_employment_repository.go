@@ -0,0 +1,136 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type PreviousEmploymentRepository struct {
+	db *sql.DB
+}
+
+func NewPreviousEmploymentRepository(db *sql.DB) *PreviousEmploymentRepository {
+	return &PreviousEmploymentRepository{db: db}
+}
+
+// previousEmploymentColumns lists the previous_employments columns in scan order
+const previousEmploymentColumns = `
+	id, org_id, employee_id, financial_year, employer_name, employer_tan,
+	period_from, period_to, gross_salary, exemptions, pf_employee, professional_tax, tds,
+	created_at, updated_at, created_by
+`
+
+// GetPreviousEmployments fetches an employee's earlier employers in a
+// financial year
+func (r *PreviousEmploymentRepository) GetPreviousEmployments(employeeID string, financialYear int) ([]models.PreviousEmployment, error) {
+	query := "SELECT " + previousEmploymentColumns + ` FROM previous_employments
+		WHERE employee_id = $1 AND financial_year = $2
+		ORDER BY period_from`
+
+	return r.queryPreviousEmployments(query, employeeID, financialYear)
+}
+
+// GetEmployeesPreviousEmployments fetches the given employees' earlier
+// employers in a financial year, keyed by employee ID
+func (r *PreviousEmploymentRepository) GetEmployeesPreviousEmployments(employeeIDs []string, financialYear int) (map[string][]models.PreviousEmployment, error) {
+	query := "SELECT " + previousEmploymentColumns + ` FROM previous_employments
+		WHERE employee_id = ANY($1) AND financial_year = $2
+		ORDER BY employee_id, period_from`
+
+	employments, err := r.queryPreviousEmployments(query, pq.Array(employeeIDs), financialYear)
+	if err != nil {
+		return nil, err
+	}
+
+	byEmployee := make(map[string][]models.PreviousEmployment)
+	for _, p := range employments {
+		byEmployee[p.EmployeeID] = append(byEmployee[p.EmployeeID], p)
+	}
+	return byEmployee, nil
+}
+
+func (r *PreviousEmploymentRepository) queryPreviousEmployments(query string, args ...interface{}) ([]models.PreviousEmployment, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query previous employments: %w", err)
+	}
+	defer rows.Close()
+
+	var employments []models.PreviousEmployment
+	for rows.Next() {
+		var p models.PreviousEmployment
+		err := rows.Scan(
+			&p.ID, &p.OrgID, &p.EmployeeID, &p.FinancialYear, &p.EmployerName, &p.EmployerTAN,
+			&p.PeriodFrom, &p.PeriodTo, &p.GrossSalary, &p.Exemptions, &p.PFEmployee, &p.ProfessionalTax, &p.TDS,
+			&p.CreatedAt, &p.UpdatedAt, &p.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan previous employment: %w", err)
+		}
+		employments = append(employments, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating previous employments: %w", err)
+	}
+
+	return employments, nil
+}
+
+// UpsertPreviousEmployment records an earlier employer, or replaces the one
+// with the same TAN in the financial year
+func (r *PreviousEmploymentRepository) UpsertPreviousEmployment(p *models.PreviousEmployment) error {
+	query := `
+		INSERT INTO previous_employments (
+			org_id, employee_id, financial_year, employer_name, employer_tan,
+			period_from, period_to, gross_salary, exemptions, pf_employee, professional_tax, tds,
+			created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, NOW(), NOW(), $13)
+		ON CONFLICT (employee_id, financial_year, employer_tan) DO UPDATE SET
+			employer_name = EXCLUDED.employer_name,
+			period_from = EXCLUDED.period_from,
+			period_to = EXCLUDED.period_to,
+			gross_salary = EXCLUDED.gross_salary,
+			exemptions = EXCLUDED.exemptions,
+			pf_employee = EXCLUDED.pf_employee,
+			professional_tax = EXCLUDED.professional_tax,
+			tds = EXCLUDED.tds,
+			updated_at = NOW()
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		p.OrgID, p.EmployeeID, p.FinancialYear, p.EmployerName, p.EmployerTAN,
+		p.PeriodFrom, p.PeriodTo, p.GrossSalary, p.Exemptions, p.PFEmployee, p.ProfessionalTax, p.TDS,
+		p.CreatedBy,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save previous employment: %w", err)
+	}
+
+	return nil
+}
+
+// DeletePreviousEmployment removes an earlier employer recorded in error
+func (r *PreviousEmploymentRepository) DeletePreviousEmployment(id string) error {
+	result, err := r.db.Exec("DELETE FROM previous_employments WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to delete previous employment: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("previous employment not found")
+	}
+
+	return nil
+}
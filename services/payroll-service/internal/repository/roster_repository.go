@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type RosterRepository struct {
+	db *sql.DB
+}
+
+func NewRosterRepository(db *sql.DB) *RosterRepository {
+	return &RosterRepository{db: db}
+}
+
+// UpsertShifts stores roster shifts, replacing any already recorded for the
+// same employee, date and shift type. Every employee must belong to orgID.
+func (r *RosterRepository) UpsertShifts(orgID string, shifts []models.RosterShift) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO shift_roster (
+			org_id, employee_id, shift_date, shift_type, start_time, end_time,
+			shift_location, status, source_reference, created_at, updated_at
+		)
+		SELECT $1, e.id, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW()
+		FROM employees e
+		WHERE e.id = $2 AND e.org_id = $1
+		ON CONFLICT (employee_id, shift_date, shift_type) DO UPDATE SET
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			shift_location = EXCLUDED.shift_location,
+			status = EXCLUDED.status,
+			source_reference = EXCLUDED.source_reference,
+			updated_at = NOW()
+	`
+
+	for _, s := range shifts {
+		result, err := tx.Exec(
+			query,
+			orgID, s.EmployeeID, s.ShiftDate, s.ShiftType, s.StartTime, s.EndTime,
+			s.ShiftLocation, s.Status, s.SourceReference,
+		)
+		if err != nil {
+			return fmt.Errorf("failed to save roster shift: %w", err)
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("employee %s not found in organization", s.EmployeeID)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetShifts fetches roster shifts for an organization with optional filters
+func (r *RosterRepository) GetShifts(orgID string, filters map[string]interface{}) ([]models.RosterShift, error) {
+	query := `
+		SELECT id, org_id, employee_id, shift_date, shift_type,
+		       to_char(start_time, 'HH24:MI'), to_char(end_time, 'HH24:MI'),
+		       shift_location, status, source_reference, created_at, updated_at
+		FROM shift_roster
+		WHERE org_id = $1
+	`
+	args := []interface{}{orgID}
+	argCount := 2
+
+	// Apply filters
+	if employeeID, ok := filters["employee_id"].(string); ok {
+		query += fmt.Sprintf(" AND employee_id = $%d", argCount)
+		args = append(args, employeeID)
+		argCount++
+	}
+
+	if employeeIDs, ok := filters["employee_ids"].([]string); ok {
+		query += fmt.Sprintf(" AND employee_id = ANY($%d)", argCount)
+		args = append(args, pq.Array(employeeIDs))
+		argCount++
+	}
+
+	if status, ok := filters["status"].(string); ok {
+		query += fmt.Sprintf(" AND status = $%d", argCount)
+		args = append(args, status)
+		argCount++
+	}
+
+	if from, ok := filters["from"].(time.Time); ok {
+		query += fmt.Sprintf(" AND shift_date >= $%d", argCount)
+		args = append(args, from)
+		argCount++
+	}
+
+	if to, ok := filters["to"].(time.Time); ok {
+		query += fmt.Sprintf(" AND shift_date <= $%d", argCount)
+		args = append(args, to)
+		argCount++
+	}
+
+	query += " ORDER BY shift_date, start_time"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query roster shifts: %w", err)
+	}
+	defer rows.Close()
+
+	var shifts []models.RosterShift
+	for rows.Next() {
+		var s models.RosterShift
+		err := rows.Scan(
+			&s.ID, &s.OrgID, &s.EmployeeID, &s.ShiftDate, &s.ShiftType,
+			&s.StartTime, &s.EndTime,
+			&s.ShiftLocation, &s.Status, &s.SourceReference, &s.CreatedAt, &s.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan roster shift: %w", err)
+		}
+		shifts = append(shifts, s)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating roster shifts: %w", err)
+	}
+
+	return shifts, nil
+}
+
+// GetShiftAllowancePolicies fetches the active policy of each shift type in
+// force on asOf
+func (r *RosterRepository) GetShiftAllowancePolicies(orgID string, asOf time.Time) ([]models.ShiftAllowancePolicy, error) {
+	query := `
+		SELECT DISTINCT ON (shift_type)
+		       id, org_id, shift_type, amount_per_shift, night_premium, min_shifts,
+		       effective_from, effective_till, is_active, created_at, updated_at, updated_by
+		FROM shift_allowance_policies
+		WHERE org_id = $1 AND is_active = true
+		  AND effective_from <= $2
+		  AND (effective_till IS NULL OR effective_till >= $2)
+		ORDER BY shift_type, effective_from DESC
+	`
+
+	rows, err := r.db.Query(query, orgID, asOf)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query shift allowance policies: %w", err)
+	}
+	defer rows.Close()
+
+	var policies []models.ShiftAllowancePolicy
+	for rows.Next() {
+		var p models.ShiftAllowancePolicy
+		err := rows.Scan(
+			&p.ID, &p.OrgID, &p.ShiftType, &p.AmountPerShift, &p.NightPremium, &p.MinShifts,
+			&p.EffectiveFrom, &p.EffectiveTill, &p.IsActive, &p.CreatedAt, &p.UpdatedAt, &p.UpdatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan shift allowance policy: %w", err)
+		}
+		policies = append(policies, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating shift allowance policies: %w", err)
+	}
+
+	return policies, nil
+}
+
+// UpsertShiftAllowancePolicy creates or replaces a shift type's policy
+// version starting on its effective_from date
+func (r *RosterRepository) UpsertShiftAllowancePolicy(p *models.ShiftAllowancePolicy) error {
+	query := `
+		INSERT INTO shift_allowance_policies (
+			org_id, shift_type, amount_per_shift, night_premium, min_shifts,
+			effective_from, effective_till, is_active, created_at, updated_at, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, true, NOW(), NOW(), $8)
+		ON CONFLICT (org_id, shift_type, effective_from) DO UPDATE SET
+			amount_per_shift = EXCLUDED.amount_per_shift,
+			night_premium = EXCLUDED.night_premium,
+			min_shifts = EXCLUDED.min_shifts,
+			effective_till = EXCLUDED.effective_till,
+			is_active = true,
+			updated_at = NOW(),
+			updated_by = EXCLUDED.updated_by
+		RETURNING id, is_active, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		p.OrgID, p.ShiftType, p.AmountPerShift, p.NightPremium, p.MinShifts,
+		p.EffectiveFrom, p.EffectiveTill, p.UpdatedBy,
+	).Scan(&p.ID, &p.IsActive, &p.CreatedAt, &p.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save shift allowance policy: %w", err)
+	}
+
+	return nil
+}
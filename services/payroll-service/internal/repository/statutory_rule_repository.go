@@ -0,0 +1,322 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type StatutoryRuleRepository struct {
+	db *sql.DB
+}
+
+func NewStatutoryRuleRepository(db *sql.DB) *StatutoryRuleRepository {
+	return &StatutoryRuleRepository{db: db}
+}
+
+// GetStatutoryRules lists statutory rules with optional filters
+func (r *StatutoryRuleRepository) GetStatutoryRules(filters map[string]interface{}) ([]models.StatutoryRule, error) {
+	query := `SELECT ` + statutoryRuleColumns + `
+		FROM statutory_rules
+		WHERE 1 = 1
+	`
+	args := []interface{}{}
+	argCount := 1
+
+	if orgID, ok := filters["org_id"].(string); ok {
+		query += fmt.Sprintf(" AND (org_id = $%d OR org_id IS NULL)", argCount)
+		args = append(args, orgID)
+		argCount++
+	}
+
+	if ruleType, ok := filters["rule_type"].(string); ok {
+		query += fmt.Sprintf(" AND rule_type = $%d", argCount)
+		args = append(args, ruleType)
+		argCount++
+	}
+
+	if stateCode, ok := filters["state_code"].(string); ok {
+		query += fmt.Sprintf(" AND state_code = $%d", argCount)
+		args = append(args, stateCode)
+		argCount++
+	}
+
+	if activeOnly, ok := filters["active"].(bool); ok && activeOnly {
+		query += " AND is_active = true"
+	}
+
+	query += " ORDER BY rule_type, state_code NULLS FIRST, effective_from DESC, pt_slab_min, tds_slab_min"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statutory rules: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStatutoryRules(rows)
+}
+
+// GetStatutoryRuleByID fetches a single statutory rule row
+func (r *StatutoryRuleRepository) GetStatutoryRuleByID(ruleID string) (*models.StatutoryRule, error) {
+	query := `SELECT ` + statutoryRuleColumns + `
+		FROM statutory_rules
+		WHERE id = $1
+	`
+
+	rows, err := r.db.Query(query, ruleID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query statutory rule: %w", err)
+	}
+	defer rows.Close()
+
+	rules, err := scanStatutoryRules(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rules) == 0 {
+		return nil, fmt.Errorf("statutory rule not found")
+	}
+
+	return &rules[0], nil
+}
+
+// GetRuleVersion fetches the active rows that make up the same version as a
+// rule: same rule family, org scope, state and effective_from
+func (r *StatutoryRuleRepository) GetRuleVersion(ruleTypes []string, orgID, stateCode *string, effectiveFrom time.Time) ([]models.StatutoryRule, error) {
+	query := `SELECT ` + statutoryRuleColumns + `
+		FROM statutory_rules
+		WHERE is_active = true
+		  AND rule_type = ANY($1)
+		  AND org_id IS NOT DISTINCT FROM $2
+		  AND state_code IS NOT DISTINCT FROM $3
+		  AND effective_from = $4
+		ORDER BY pt_slab_min, tds_slab_min
+	`
+
+	rows, err := r.db.Query(query, pq.Array(ruleTypes), orgID, stateCode, effectiveFrom)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule version: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStatutoryRules(rows)
+}
+
+// GetOverlappingRules fetches active rows of the same rule family, org scope
+// and state whose effective window overlaps [from, till]. A nil till is open-ended.
+func (r *StatutoryRuleRepository) GetOverlappingRules(ruleTypes []string, orgID, stateCode *string, from time.Time, till *time.Time) ([]models.StatutoryRule, error) {
+	query := `SELECT ` + statutoryRuleColumns + `
+		FROM statutory_rules
+		WHERE is_active = true
+		  AND rule_type = ANY($1)
+		  AND org_id IS NOT DISTINCT FROM $2
+		  AND state_code IS NOT DISTINCT FROM $3
+		  AND COALESCE(effective_till, 'infinity'::date) >= $4
+		  AND ($5::date IS NULL OR effective_from <= $5)
+		ORDER BY effective_from
+	`
+
+	rows, err := r.db.Query(query, pq.Array(ruleTypes), orgID, stateCode, from, till)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query overlapping rules: %w", err)
+	}
+	defer rows.Close()
+
+	return scanStatutoryRules(rows)
+}
+
+// GetLatestLockedUsage returns the latest period end of a locked or released
+// payroll run that used any of the given rules, or nil if none did
+func (r *StatutoryRuleRepository) GetLatestLockedUsage(ruleIDs []string) (*time.Time, error) {
+	query := `
+		SELECT MAX(pr.payroll_period_end)
+		FROM payroll_run_statutory_rules prs
+		INNER JOIN payroll_runs pr ON pr.id = prs.payroll_run_id
+		WHERE prs.statutory_rule_id = ANY($1)
+		  AND pr.status IN ('locked', 'released')
+	`
+
+	var latest sql.NullTime
+	if err := r.db.QueryRow(query, pq.Array(ruleIDs)).Scan(&latest); err != nil {
+		return nil, fmt.Errorf("failed to query rule usage: %w", err)
+	}
+
+	if !latest.Valid {
+		return nil, nil
+	}
+
+	return &latest.Time, nil
+}
+
+// CreateRuleVersion inserts every row of a rule version in one transaction
+func (r *StatutoryRuleRepository) CreateRuleVersion(rules []models.StatutoryRule) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := insertStatutoryRules(tx, rules); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// SupersedeRuleVersion end-dates an existing version and inserts its
+// replacement in one transaction
+func (r *StatutoryRuleRepository) SupersedeRuleVersion(oldRuleIDs []string, effectiveTill time.Time, rules []models.StatutoryRule) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE statutory_rules
+		SET effective_till = $1, updated_at = NOW()
+		WHERE id = ANY($2)
+	`
+
+	if _, err := tx.Exec(query, effectiveTill, pq.Array(oldRuleIDs)); err != nil {
+		return fmt.Errorf("failed to end-date rule version: %w", err)
+	}
+
+	if err := insertStatutoryRules(tx, rules); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// UpdateStatutoryRule updates the value columns of a rule row and the
+// effective_till of every row in its version
+func (r *StatutoryRuleRepository) UpdateStatutoryRule(rule *models.StatutoryRule, versionRuleIDs []string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE statutory_rules
+		SET pf_employee_rate = $1, pf_employer_rate = $2, pf_ceiling = $3,
+		    esi_employee_rate = $4, esi_employer_rate = $5, esi_wage_ceiling = $6, esi_threshold_salary = $7,
+		    pt_slab_min = $8, pt_slab_max = $9, pt_amount = $10,
+		    tds_slab_min = $11, tds_slab_max = $12, tds_rate = $13,
+		    gratuity_rate_per_year = $14, gratuity_completion_months = $15,
+		    updated_at = NOW()
+		WHERE id = $16
+	`
+
+	result, err := tx.Exec(query,
+		rule.PFEmployeeRate, rule.PFEmployerRate, rule.PFCeiling,
+		rule.ESIEmployeeRate, rule.ESIEmployerRate, rule.ESIWageCeiling, rule.ESIThresholdSalary,
+		rule.PTSlabMin, rule.PTSlabMax, rule.PTAmount,
+		rule.TDSSlabMin, rule.TDSSlabMax, rule.TDSRate,
+		rule.GratuityRatePerYear, rule.GratuityCompletionMonths,
+		rule.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update statutory rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("statutory rule not found")
+	}
+
+	tillQuery := `
+		UPDATE statutory_rules
+		SET effective_till = $1, updated_at = NOW()
+		WHERE id = ANY($2)
+	`
+
+	if _, err := tx.Exec(tillQuery, rule.EffectiveTill, pq.Array(versionRuleIDs)); err != nil {
+		return fmt.Errorf("failed to update rule version window: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeactivateStatutoryRule soft-deletes a rule row
+func (r *StatutoryRuleRepository) DeactivateStatutoryRule(ruleID string) error {
+	query := `
+		UPDATE statutory_rules
+		SET is_active = false, updated_at = NOW()
+		WHERE id = $1
+	`
+
+	result, err := r.db.Exec(query, ruleID)
+	if err != nil {
+		return fmt.Errorf("failed to deactivate statutory rule: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("statutory rule not found")
+	}
+
+	return nil
+}
+
+func insertStatutoryRules(tx *sql.Tx, rules []models.StatutoryRule) error {
+	query := `
+		INSERT INTO statutory_rules (
+			org_id, rule_type, state_code, effective_from, effective_till,
+			pf_employee_rate, pf_employer_rate, pf_ceiling,
+			esi_employee_rate, esi_employer_rate, esi_wage_ceiling, esi_threshold_salary,
+			pt_slab_min, pt_slab_max, pt_amount,
+			tds_slab_min, tds_slab_max, tds_rate,
+			gratuity_rate_per_year, gratuity_completion_months,
+			is_active, created_by, created_at, updated_at
+		) VALUES (
+			$1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18,
+			$19, $20, true, $21, NOW(), NOW()
+		)
+		RETURNING id, is_active, created_at, updated_at
+	`
+
+	for i := range rules {
+		sr := &rules[i]
+		err := tx.QueryRow(
+			query,
+			sr.OrgID, sr.RuleType, sr.StateCode, sr.EffectiveFrom, sr.EffectiveTill,
+			sr.PFEmployeeRate, sr.PFEmployerRate, sr.PFCeiling,
+			sr.ESIEmployeeRate, sr.ESIEmployerRate, sr.ESIWageCeiling, sr.ESIThresholdSalary,
+			sr.PTSlabMin, sr.PTSlabMax, sr.PTAmount,
+			sr.TDSSlabMin, sr.TDSSlabMax, sr.TDSRate,
+			sr.GratuityRatePerYear, sr.GratuityCompletionMonths,
+			sr.CreatedBy,
+		).Scan(&sr.ID, &sr.IsActive, &sr.CreatedAt, &sr.UpdatedAt)
+		if err != nil {
+			return fmt.Errorf("failed to create statutory rule: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,376 @@
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	"payroll-service/internal/models"
+)
+
+type WorkingCalendarRepository struct {
+	db *sql.DB
+}
+
+func NewWorkingCalendarRepository(db *sql.DB) *WorkingCalendarRepository {
+	return &WorkingCalendarRepository{db: db}
+}
+
+// CreateHolidayCalendar creates a holiday calendar with its holidays
+func (r *WorkingCalendarRepository) CreateHolidayCalendar(cal *models.HolidayCalendar) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		INSERT INTO holiday_calendars (
+			org_id, location, calendar_year, name, created_at, updated_at, created_by
+		) VALUES ($1, $2, $3, $4, NOW(), NOW(), $5)
+		RETURNING id, created_at, updated_at
+	`
+
+	err = tx.QueryRow(
+		query,
+		cal.OrgID, cal.Location, cal.CalendarYear, cal.Name, cal.CreatedBy,
+	).Scan(&cal.ID, &cal.CreatedAt, &cal.UpdatedAt)
+
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return fmt.Errorf("a holiday calendar for %d already exists at this location", cal.CalendarYear)
+		}
+		return fmt.Errorf("failed to create holiday calendar: %w", err)
+	}
+
+	if err := insertHolidays(tx, cal.ID, cal.Holidays); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// ReplaceHolidays replaces all holidays of a calendar
+func (r *WorkingCalendarRepository) ReplaceHolidays(calendarID string, holidays []models.Holiday) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to start transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM holidays WHERE calendar_id = $1`, calendarID); err != nil {
+		return fmt.Errorf("failed to clear holidays: %w", err)
+	}
+
+	if err := insertHolidays(tx, calendarID, holidays); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(`UPDATE holiday_calendars SET updated_at = NOW() WHERE id = $1`, calendarID); err != nil {
+		return fmt.Errorf("failed to update holiday calendar: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func insertHolidays(tx *sql.Tx, calendarID string, holidays []models.Holiday) error {
+	query := `
+		INSERT INTO holidays (calendar_id, holiday_date, name, holiday_type, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		RETURNING id, created_at
+	`
+
+	for i := range holidays {
+		h := &holidays[i]
+		h.CalendarID = calendarID
+		err := tx.QueryRow(query, calendarID, h.HolidayDate, h.Name, h.HolidayType).Scan(&h.ID, &h.CreatedAt)
+		if err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				return fmt.Errorf("%s is listed more than once", h.HolidayDate.Format("2006-01-02"))
+			}
+			return fmt.Errorf("failed to create holiday: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// GetHolidayCalendars fetches an organization's holiday calendars with optional filters
+func (r *WorkingCalendarRepository) GetHolidayCalendars(orgID string, filters map[string]interface{}) ([]models.HolidayCalendar, error) {
+	query := `
+		SELECT id, org_id, location, calendar_year, name, created_at, updated_at, created_by
+		FROM holiday_calendars
+		WHERE org_id = $1
+	`
+	args := []interface{}{orgID}
+	argCount := 2
+
+	// Apply filters
+	if location, ok := filters["location"].(string); ok {
+		query += fmt.Sprintf(" AND location = $%d", argCount)
+		args = append(args, location)
+		argCount++
+	}
+
+	if year, ok := filters["year"].(int); ok {
+		query += fmt.Sprintf(" AND calendar_year = $%d", argCount)
+		args = append(args, year)
+		argCount++
+	}
+
+	query += " ORDER BY calendar_year DESC, location"
+
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holiday calendars: %w", err)
+	}
+	defer rows.Close()
+
+	var calendars []models.HolidayCalendar
+	for rows.Next() {
+		var cal models.HolidayCalendar
+		err := rows.Scan(
+			&cal.ID, &cal.OrgID, &cal.Location, &cal.CalendarYear, &cal.Name,
+			&cal.CreatedAt, &cal.UpdatedAt, &cal.CreatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan holiday calendar: %w", err)
+		}
+		calendars = append(calendars, cal)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating holiday calendars: %w", err)
+	}
+
+	return calendars, nil
+}
+
+// GetHolidayCalendarByID fetches a holiday calendar with its holidays
+func (r *WorkingCalendarRepository) GetHolidayCalendarByID(id string) (*models.HolidayCalendar, error) {
+	query := `
+		SELECT id, org_id, location, calendar_year, name, created_at, updated_at, created_by
+		FROM holiday_calendars
+		WHERE id = $1
+	`
+
+	var cal models.HolidayCalendar
+	err := r.db.QueryRow(query, id).Scan(
+		&cal.ID, &cal.OrgID, &cal.Location, &cal.CalendarYear, &cal.Name,
+		&cal.CreatedAt, &cal.UpdatedAt, &cal.CreatedBy,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("holiday calendar not found")
+		}
+		return nil, fmt.Errorf("failed to query holiday calendar: %w", err)
+	}
+
+	cal.Holidays, err = r.queryHolidays(`
+		SELECT id, calendar_id, holiday_date, name, holiday_type, created_at
+		FROM holidays
+		WHERE calendar_id = $1
+		ORDER BY holiday_date
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cal, nil
+}
+
+// GetHolidays fetches the holidays at a location between two dates. Years
+// without a calendar for the location use the organization-wide calendar.
+func (r *WorkingCalendarRepository) GetHolidays(orgID, location string, from, to time.Time) ([]models.Holiday, error) {
+	return r.queryHolidays(`
+		SELECT h.id, h.calendar_id, h.holiday_date, h.name, h.holiday_type, h.created_at
+		FROM holidays h
+		JOIN holiday_calendars c ON c.id = h.calendar_id
+		WHERE c.org_id = $1
+		  AND h.holiday_date BETWEEN $3 AND $4
+		  AND (c.location = $2 OR (c.location = '' AND NOT EXISTS (
+		      SELECT 1 FROM holiday_calendars lc
+		      WHERE lc.org_id = c.org_id AND lc.location = $2 AND lc.calendar_year = c.calendar_year
+		  )))
+		ORDER BY h.holiday_date
+	`, orgID, location, from, to)
+}
+
+// GetHolidaysByLocation fetches the holidays between two dates at each of
+// the given locations, keyed by location, with the same fallback to the
+// organization-wide calendar as GetHolidays
+func (r *WorkingCalendarRepository) GetHolidaysByLocation(orgID string, locations []string, from, to time.Time) (map[string][]models.Holiday, error) {
+	query := `
+		SELECT loc.location, h.id, h.calendar_id, h.holiday_date, h.name, h.holiday_type, h.created_at
+		FROM unnest($2::text[]) AS loc(location)
+		JOIN holiday_calendars c ON c.org_id = $1
+		  AND (c.location = loc.location OR (c.location = '' AND NOT EXISTS (
+		      SELECT 1 FROM holiday_calendars lc
+		      WHERE lc.org_id = c.org_id AND lc.location = loc.location AND lc.calendar_year = c.calendar_year
+		  )))
+		JOIN holidays h ON h.calendar_id = c.id
+		WHERE h.holiday_date BETWEEN $3 AND $4
+		ORDER BY loc.location, h.holiday_date
+	`
+
+	rows, err := r.db.Query(query, orgID, pq.Array(locations), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holidays: %w", err)
+	}
+	defer rows.Close()
+
+	byLocation := make(map[string][]models.Holiday)
+	for rows.Next() {
+		var location string
+		var h models.Holiday
+		err := rows.Scan(&location, &h.ID, &h.CalendarID, &h.HolidayDate, &h.Name, &h.HolidayType, &h.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan holiday: %w", err)
+		}
+		byLocation[location] = append(byLocation[location], h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating holidays: %w", err)
+	}
+
+	return byLocation, nil
+}
+
+func (r *WorkingCalendarRepository) queryHolidays(query string, args ...interface{}) ([]models.Holiday, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query holidays: %w", err)
+	}
+	defer rows.Close()
+
+	var holidays []models.Holiday
+	for rows.Next() {
+		var h models.Holiday
+		err := rows.Scan(&h.ID, &h.CalendarID, &h.HolidayDate, &h.Name, &h.HolidayType, &h.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan holiday: %w", err)
+		}
+		holidays = append(holidays, h)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating holidays: %w", err)
+	}
+
+	return holidays, nil
+}
+
+// GetWeeklyOffPolicy fetches the weekly off policy of a location, falling
+// back to the organization-wide policy. Returns nil when neither exists.
+func (r *WorkingCalendarRepository) GetWeeklyOffPolicy(orgID, location string) (*models.WeeklyOffPolicy, error) {
+	query := `
+		SELECT id, org_id, location, pattern, saturday_weeks, rotation_anchor,
+		       rotation_cycle, rotation_off_days, created_at, updated_at, updated_by
+		FROM weekly_off_policies
+		WHERE org_id = $1 AND location IN ($2, '')
+		ORDER BY location = ''
+		LIMIT 1
+	`
+
+	var p models.WeeklyOffPolicy
+	err := r.db.QueryRow(query, orgID, location).Scan(
+		&p.ID, &p.OrgID, &p.Location, &p.Pattern, pq.Array(&p.SaturdayWeeks), &p.RotationAnchor,
+		&p.RotationCycle, pq.Array(&p.RotationOffDays), &p.CreatedAt, &p.UpdatedAt, &p.UpdatedBy,
+	)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query weekly off policy: %w", err)
+	}
+
+	return &p, nil
+}
+
+// GetWeeklyOffPolicies fetches the weekly off policy of each of the given
+// locations, keyed by location, falling back to the organization-wide
+// policy. Locations with neither are missing from the map.
+func (r *WorkingCalendarRepository) GetWeeklyOffPolicies(orgID string, locations []string) (map[string]*models.WeeklyOffPolicy, error) {
+	query := `
+		SELECT id, org_id, location, pattern, saturday_weeks, rotation_anchor,
+		       rotation_cycle, rotation_off_days, created_at, updated_at, updated_by
+		FROM weekly_off_policies
+		WHERE org_id = $1 AND (location = ANY($2) OR location = '')
+	`
+
+	rows, err := r.db.Query(query, orgID, pq.Array(locations))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query weekly off policies: %w", err)
+	}
+	defer rows.Close()
+
+	stored := make(map[string]*models.WeeklyOffPolicy)
+	for rows.Next() {
+		var p models.WeeklyOffPolicy
+		err := rows.Scan(
+			&p.ID, &p.OrgID, &p.Location, &p.Pattern, pq.Array(&p.SaturdayWeeks), &p.RotationAnchor,
+			&p.RotationCycle, pq.Array(&p.RotationOffDays), &p.CreatedAt, &p.UpdatedAt, &p.UpdatedBy,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan weekly off policy: %w", err)
+		}
+		stored[p.Location] = &p
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating weekly off policies: %w", err)
+	}
+
+	policies := make(map[string]*models.WeeklyOffPolicy)
+	for _, location := range locations {
+		if p := stored[location]; p != nil {
+			policies[location] = p
+		} else if p := stored[""]; p != nil {
+			policies[location] = p
+		}
+	}
+	return policies, nil
+}
+
+// UpsertWeeklyOffPolicy creates or replaces a location's weekly off policy
+func (r *WorkingCalendarRepository) UpsertWeeklyOffPolicy(p *models.WeeklyOffPolicy) error {
+	query := `
+		INSERT INTO weekly_off_policies (
+			org_id, location, pattern, saturday_weeks, rotation_anchor,
+			rotation_cycle, rotation_off_days, created_at, updated_at, updated_by
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW(), $8)
+		ON CONFLICT (org_id, location) DO UPDATE SET
+			pattern = EXCLUDED.pattern,
+			saturday_weeks = EXCLUDED.saturday_weeks,
+			rotation_anchor = EXCLUDED.rotation_anchor,
+			rotation_cycle = EXCLUDED.rotation_cycle,
+			rotation_off_days = EXCLUDED.rotation_off_days,
+			updated_at = NOW(),
+			updated_by = EXCLUDED.updated_by
+		RETURNING id, created_at, updated_at
+	`
+
+	err := r.db.QueryRow(
+		query,
+		p.OrgID, p.Location, p.Pattern, pq.Array(p.SaturdayWeeks), p.RotationAnchor,
+		p.RotationCycle, pq.Array(p.RotationOffDays), p.UpdatedBy,
+	).Scan(&p.ID, &p.CreatedAt, &p.UpdatedAt)
+
+	if err != nil {
+		return fmt.Errorf("failed to save weekly off policy: %w", err)
+	}
+
+	return nil
+}
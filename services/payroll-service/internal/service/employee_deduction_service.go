@@ -0,0 +1,121 @@
+package service
+
+import (
+	"database/sql"
+	"math"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+type EmployeeDeductionService struct {
+	repo    *repository.EmployeeDeductionRepository
+	empRepo *repository.EmployeeRepository
+}
+
+func NewEmployeeDeductionService(db *sql.DB) *EmployeeDeductionService {
+	return &EmployeeDeductionService{
+		repo:    repository.NewEmployeeDeductionRepository(db),
+		empRepo: repository.NewEmployeeRepository(db),
+	}
+}
+
+// CreateEmployeeDeduction records a loan, advance, court order or other
+// deduction schedule. Regular payroll runs raise its instalment each month
+// from the start month until the total has been raised.
+func (s *EmployeeDeductionService) CreateEmployeeDeduction(d *models.EmployeeDeduction) error {
+	if !isDeductionCategory(d.Category) {
+		return invalidInput("category must be one of %v", calculator.DeductionPriority)
+	}
+
+	if d.Description == "" {
+		return invalidInput("description is required")
+	}
+
+	if d.Instalment <= 0 {
+		return invalidInput("instalment must be greater than 0")
+	}
+
+	if d.TotalAmount != nil && *d.TotalAmount < d.Instalment {
+		return invalidInput("total_amount must be at least the instalment")
+	}
+
+	if _, err := time.Parse("2006-01", d.StartMonth); err != nil {
+		return invalidInput("start_month must be in YYYY-MM format")
+	}
+
+	emp, err := s.empRepo.GetEmployeeByID(d.EmployeeID)
+	if err != nil || emp.OrgID != d.OrgID {
+		return invalidInput("employee %s not found in organization", d.EmployeeID)
+	}
+
+	return s.repo.CreateEmployeeDeduction(d)
+}
+
+// GetEmployeeDeductions fetches an organization's deduction schedules
+func (s *EmployeeDeductionService) GetEmployeeDeductions(orgID string, filters map[string]interface{}) ([]models.EmployeeDeduction, error) {
+	return s.repo.GetEmployeeDeductions(orgID, filters)
+}
+
+// CancelEmployeeDeduction stops an active schedule
+func (s *EmployeeDeductionService) CancelEmployeeDeduction(id string) error {
+	return s.repo.CancelEmployeeDeduction(id)
+}
+
+func isDeductionCategory(category string) bool {
+	for _, c := range calculator.DeductionPriority {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// deductionInstalments converts an employee's due deduction schedules for the
+// calculator. The last instalment of a schedule is what is left of its total.
+// A category entered manually for the run replaces its schedules.
+func deductionInstalments(deductions []models.EmployeeDeduction, o *models.PayrollComponentOverride) []calculator.DeductionInstalment {
+	var instalments []calculator.DeductionInstalment
+	for _, d := range deductions {
+		if overridesDeduction(o, d.Category) {
+			continue
+		}
+
+		amount := d.Instalment
+		if d.TotalAmount != nil {
+			amount = math.Min(amount, math.Round((*d.TotalAmount-d.Raised)*100)/100)
+		}
+		if amount <= 0 {
+			continue
+		}
+
+		instalments = append(instalments, calculator.DeductionInstalment{
+			ID:       d.ID,
+			Category: d.Category,
+			Amount:   amount,
+		})
+	}
+	return instalments
+}
+
+// overridesDeduction reports whether a run's manual inputs set the amount of
+// a deduction category
+func overridesDeduction(o *models.PayrollComponentOverride, category string) bool {
+	if o == nil {
+		return false
+	}
+
+	switch category {
+	case calculator.DeductionCourtOrder:
+		return o.CourtOrderDeduction != nil
+	case calculator.DeductionLoan:
+		return o.LoanRecovery != nil
+	case calculator.DeductionAdvance:
+		return o.AdvanceRecovery != nil
+	case calculator.DeductionOther:
+		return o.OtherDeductions != nil
+	}
+	return false
+}
@@ -2,7 +2,9 @@ package service
 
 import (
 	"database/sql"
+	"strings"
 
+	"payroll-service/internal/calculator"
 	"payroll-service/internal/models"
 	"payroll-service/internal/repository"
 )
@@ -41,3 +43,32 @@ func (s *EmployeeService) GetAttendanceSummary(employeeID, month string) (*model
 func (s *EmployeeService) GetLeaveSummary(employeeID, month string) (*models.LeaveSummary, error) {
 	return s.repo.GetLeaveSummary(employeeID, month)
 }
+
+// UpdatePAN records an employee's PAN and the result of the PAN-name check.
+// A changed PAN goes back to pending unless a status is given. Payroll picks
+// the change up in the next run, and the TDS projection adjusts the rest of
+// the year for what was deducted under Section 206AA.
+func (s *EmployeeService) UpdatePAN(employeeID, pan, status, updatedBy string) error {
+	pan = strings.ToUpper(strings.TrimSpace(pan))
+	if !calculator.IsValidPANFormat(pan) {
+		return invalidInput("pan must be 5 letters, 4 digits and a letter")
+	}
+
+	emp, err := s.repo.GetEmployeeByID(employeeID)
+	if err != nil {
+		return err
+	}
+
+	if status == "" {
+		status = emp.PANStatus
+		if emp.PersonalPAN.String != pan {
+			status = calculator.PANStatusPending
+		}
+	}
+
+	if !calculator.IsValidPANStatus(status) {
+		return invalidInput("pan_status must be pending, verified, name_mismatch or inoperative")
+	}
+
+	return s.repo.UpdatePAN(employeeID, pan, status, updatedBy)
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+type EquityService struct {
+	repo    *repository.EquityRepository
+	empRepo *repository.EmployeeRepository
+	orgRepo *repository.OrganizationRepository
+}
+
+func NewEquityService(db *sql.DB) *EquityService {
+	return &EquityService{
+		repo:    repository.NewEquityRepository(db),
+		empRepo: repository.NewEmployeeRepository(db),
+		orgRepo: repository.NewOrganizationRepository(db),
+	}
+}
+
+// GetEquityGrants fetches an organization's equity grants
+func (s *EquityService) GetEquityGrants(orgID string, filters map[string]interface{}) ([]models.EquityGrant, error) {
+	return s.repo.GetEquityGrants(orgID, filters)
+}
+
+// CreateEquityGrant validates and records an ESOP or RSU grant
+func (s *EquityService) CreateEquityGrant(g *models.EquityGrant) error {
+	if g.GrantType != calculator.GrantTypeESOP && g.GrantType != calculator.GrantTypeRSU {
+		return invalidInput("grant_type must be esop or rsu")
+	}
+
+	if g.Units <= 0 {
+		return invalidInput("units must be positive")
+	}
+
+	if g.ExercisePrice < 0 {
+		return invalidInput("exercise_price cannot be negative")
+	}
+
+	emp, err := s.empRepo.GetEmployeeByID(g.EmployeeID)
+	if err != nil || emp.OrgID != g.OrgID {
+		return invalidInput("employee %s not found in organization", g.EmployeeID)
+	}
+
+	return s.repo.CreateEquityGrant(g)
+}
+
+// GetEquityEvents fetches an organization's equity events
+func (s *EquityService) GetEquityEvents(orgID string, filters map[string]interface{}) ([]models.EquityEvent, error) {
+	return s.repo.GetEquityEvents(orgID, filters)
+}
+
+// RecordEquityEvent validates and records a vesting, exercise or sale. An
+// ESOP exercise or RSU vesting is valued as a perquisite and left pending for
+// the next payroll run; an eligible start-up's perquisite has its tax
+// deferred.
+func (s *EquityService) RecordEquityEvent(e *models.EquityEvent) error {
+	if e.Units <= 0 {
+		return invalidInput("units must be positive")
+	}
+
+	grant, err := s.repo.GetEquityGrantByID(e.GrantID)
+	if err != nil || grant.OrgID != e.OrgID {
+		return invalidInput("equity grant %s not found in organization", e.GrantID)
+	}
+
+	if grant.Status != "active" {
+		return conflict("equity grant %s is %s", grant.GrantNumber, grant.Status)
+	}
+
+	if e.EventDate.Before(grant.GrantDate) {
+		return invalidInput("event_date cannot be before the grant date")
+	}
+
+	vested, exercised, sold, err := s.repo.GetGrantUnits(grant.ID)
+	if err != nil {
+		return err
+	}
+
+	switch e.EventType {
+	case calculator.EquityEventVest:
+		if vested+e.Units > grant.Units {
+			return invalidInput("only %.4f of %.4f units are left to vest", grant.Units-vested, grant.Units)
+		}
+	case calculator.EquityEventExercise:
+		if grant.GrantType != calculator.GrantTypeESOP {
+			return invalidInput("only ESOP grants are exercised")
+		}
+		if exercised+e.Units > vested {
+			return invalidInput("only %.4f vested units are left to exercise", vested-exercised)
+		}
+	case calculator.EquityEventSale:
+		held := vested
+		if grant.GrantType == calculator.GrantTypeESOP {
+			held = exercised
+		}
+		if sold+e.Units > held {
+			return invalidInput("only %.4f shares are held", held-sold)
+		}
+	default:
+		return invalidInput("event_type must be vest, exercise or sale")
+	}
+
+	e.EmployeeID = grant.EmployeeID
+	e.Status = "recorded"
+
+	if calculator.IsPerquisiteEvent(grant.GrantType, e.EventType) {
+		if e.FMV <= 0 {
+			return invalidInput("fmv is required for an %s of %s units", e.EventType, grant.GrantType)
+		}
+
+		org, err := s.orgRepo.GetOrganizationByID(grant.OrgID)
+		if err != nil {
+			return fmt.Errorf("failed to fetch organization: %w", err)
+		}
+
+		e.ExercisePrice = grant.ExercisePrice
+		e.PerquisiteValue = calculator.EquityPerquisiteValue(e.Units, e.FMV, e.ExercisePrice)
+		e.Status = "pending"
+
+		if org.EligibleStartup {
+			due := calculator.DeferredPerquisiteTaxDue(e.EventDate)
+			e.TaxDeferred = true
+			e.TaxDueDate = &due
+		}
+	}
+
+	return s.repo.CreateEquityEvent(e)
+}
+
+// equityPerquisites converts equity events for the calculator. Tax is not
+// deferred for an employee who is leaving.
+func equityPerquisites(events []models.EquityEvent, exiting bool) []calculator.EquityPerquisite {
+	var perquisites []calculator.EquityPerquisite
+	for _, e := range events {
+		perquisites = append(perquisites, calculator.EquityPerquisite{
+			ID:          e.ID,
+			Description: fmt.Sprintf("%s of %.4f units on %s", e.EventType, e.Units, e.EventDate.Format("2006-01-02")),
+			Amount:      e.PerquisiteValue,
+			TaxDeferred: e.TaxDeferred && !exiting,
+		})
+	}
+	return perquisites
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds returned by services so handlers can pick a status code.
+// Wrap them with context: fmt.Errorf("%w: details", ErrInvalidInput).
+var (
+	ErrInvalidInput = errors.New("invalid input")
+	ErrConflict     = errors.New("conflict")
+	ErrForbidden    = errors.New("forbidden")
+)
+
+func invalidInput(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidInput, fmt.Sprintf(format, args...))
+}
+
+func conflict(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrConflict, fmt.Sprintf(format, args...))
+}
+
+func forbidden(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrForbidden, fmt.Sprintf(format, args...))
+}
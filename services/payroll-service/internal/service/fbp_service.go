@@ -0,0 +1,259 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+type FBPService struct {
+	repo    *repository.FBPRepository
+	empRepo *repository.EmployeeRepository
+}
+
+func NewFBPService(db *sql.DB) *FBPService {
+	return &FBPService{
+		repo:    repository.NewFBPRepository(db),
+		empRepo: repository.NewEmployeeRepository(db),
+	}
+}
+
+// GetFBPPlan fetches the flexible benefit plan of a salary structure
+func (s *FBPService) GetFBPPlan(salaryStructureID string) ([]models.FBPPlanComponent, error) {
+	return s.repo.GetFBPPlan(salaryStructureID)
+}
+
+// SaveFBPPlan validates and saves components of a salary structure's
+// flexible benefit plan
+func (s *FBPService) SaveFBPPlan(salaryStructureID string, components []models.FBPPlanComponent, updatedBy string) ([]models.FBPPlanComponent, error) {
+	ss, err := s.empRepo.GetSalaryStructureByID(salaryStructureID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := map[string]bool{}
+	for i := range components {
+		c := &components[i]
+
+		if !calculator.IsFBPComponent(c.Component) {
+			return nil, invalidInput("unknown FBP component %q", c.Component)
+		}
+		if seen[c.Component] {
+			return nil, invalidInput("%s is listed more than once", c.Component)
+		}
+		seen[c.Component] = true
+
+		if c.AnnualLimit < 0 {
+			return nil, invalidInput("%s: annual_limit cannot be negative", c.Component)
+		}
+
+		switch c.PayoutType {
+		case "":
+			c.PayoutType = calculator.FBPPayoutClaim
+		case calculator.FBPPayoutFixed, calculator.FBPPayoutClaim:
+		default:
+			return nil, invalidInput("%s: payout_type must be fixed or claim", c.Component)
+		}
+
+		c.OrgID = ss.OrgID
+		c.SalaryStructureID = ss.ID
+		c.UpdatedBy = &updatedBy
+	}
+
+	if err := s.repo.UpsertFBPPlan(components); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetFBPPlan(salaryStructureID)
+}
+
+// GetFBPDeclarations fetches an employee's FBP declarations for a financial year
+func (s *FBPService) GetFBPDeclarations(employeeID string, financialYear int) ([]models.FBPDeclaration, error) {
+	return s.repo.GetFBPDeclarations(employeeID, financialYear)
+}
+
+// DeclareFBP replaces an employee's FBP allocation for a financial year.
+// Each component must be in the plan of the employee's salary structure and
+// within its limit, and the total must fit in the special allowance. A
+// claim-based component cannot be cut below what has been claimed.
+func (s *FBPService) DeclareFBP(orgID, employeeID string, financialYear int, declarations []models.FBPDeclaration, createdBy string) ([]models.FBPDeclaration, error) {
+	if financialYear < 2000 || financialYear > 2100 {
+		return nil, invalidInput("financial_year must be between 2000 and 2100")
+	}
+
+	emp, err := s.empRepo.GetEmployeeByID(employeeID)
+	if err != nil || emp.OrgID != orgID {
+		return nil, invalidInput("employee %s not found in organization", employeeID)
+	}
+
+	ss, err := s.empRepo.GetSalaryStructure(employeeID)
+	if err != nil {
+		return nil, invalidInput("employee has no salary structure")
+	}
+
+	plan, err := s.repo.GetFBPPlan(ss.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	planned := map[string]models.FBPPlanComponent{}
+	for _, c := range plan {
+		if c.IsActive {
+			planned[c.Component] = c
+		}
+	}
+
+	declared := map[string]float64{}
+	total := 0.0
+	for i := range declarations {
+		d := &declarations[i]
+
+		c, ok := planned[d.Component]
+		if !ok {
+			return nil, invalidInput("%s is not in the flexible benefit plan of %s", d.Component, ss.Name)
+		}
+		if _, dup := declared[d.Component]; dup {
+			return nil, invalidInput("%s is listed more than once", d.Component)
+		}
+		if d.AnnualAmount < 0 || d.AnnualAmount > c.AnnualLimit {
+			return nil, invalidInput("%s: annual_amount must be between 0 and %.2f", d.Component, c.AnnualLimit)
+		}
+
+		d.OrgID = orgID
+		d.EmployeeID = employeeID
+		d.FinancialYear = financialYear
+		d.PayoutType = c.PayoutType
+		d.CreatedBy = &createdBy
+
+		declared[d.Component] = d.AnnualAmount
+		total += d.AnnualAmount
+	}
+
+	if total/12 > ss.MonthlyAllowance {
+		return nil, invalidInput("FBP of %.2f a month exceeds the special allowance of %.2f", total/12, ss.MonthlyAllowance)
+	}
+
+	existing, err := s.repo.GetFBPDeclarations(employeeID, financialYear)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, d := range existing {
+		if d.PayoutType != calculator.FBPPayoutClaim {
+			continue
+		}
+		claimed, err := s.repo.GetClaimedAmount(employeeID, financialYear, d.Component)
+		if err != nil {
+			return nil, err
+		}
+		if declared[d.Component] < claimed {
+			return nil, conflict("%s: %.2f has already been claimed", d.Component, claimed)
+		}
+	}
+
+	if err := s.repo.ReplaceFBPDeclarations(employeeID, financialYear, declarations); err != nil {
+		return nil, err
+	}
+
+	return declarations, nil
+}
+
+// GetFBPClaims fetches an organization's FBP claims
+func (s *FBPService) GetFBPClaims(orgID string, filters map[string]interface{}) ([]models.FBPClaim, error) {
+	return s.repo.GetFBPClaims(orgID, filters)
+}
+
+// SubmitFBPClaim validates and records a claim against a claim-based
+// component the employee declared for the financial year of the bill
+func (s *FBPService) SubmitFBPClaim(claim *models.FBPClaim) error {
+	if claim.Amount <= 0 {
+		return invalidInput("amount must be positive")
+	}
+
+	emp, err := s.empRepo.GetEmployeeByID(claim.EmployeeID)
+	if err != nil || emp.OrgID != claim.OrgID {
+		return invalidInput("employee %s not found in organization", claim.EmployeeID)
+	}
+
+	fyStart := calculator.FinancialYearStart(claim.ClaimDate)
+	claim.FinancialYear = fyStart.Year()
+
+	// The year's unclaimed balance is paid out with the March payroll
+	if time.Now().After(fyStart.AddDate(1, 0, 0)) {
+		return invalidInput("claims for financial year %d are closed", claim.FinancialYear)
+	}
+
+	declarations, err := s.repo.GetFBPDeclarations(claim.EmployeeID, claim.FinancialYear)
+	if err != nil {
+		return err
+	}
+
+	var declaration *models.FBPDeclaration
+	for i := range declarations {
+		if declarations[i].Component == claim.Component && declarations[i].PayoutType == calculator.FBPPayoutClaim {
+			declaration = &declarations[i]
+		}
+	}
+	if declaration == nil {
+		return invalidInput("no claim-based %s declaration for financial year %d", claim.Component, claim.FinancialYear)
+	}
+
+	claimed, err := s.repo.GetClaimedAmount(claim.EmployeeID, claim.FinancialYear, claim.Component)
+	if err != nil {
+		return err
+	}
+
+	if remaining := declaration.AnnualAmount - claimed; claim.Amount > remaining {
+		return invalidInput("%s: claim of %.2f exceeds the %.2f left to claim", claim.Component, claim.Amount, remaining)
+	}
+
+	return s.repo.CreateFBPClaim(claim)
+}
+
+// ApproveFBPClaim approves a submitted claim for payment in the next payroll run
+func (s *FBPService) ApproveFBPClaim(id, reviewedBy string) (*models.FBPClaim, error) {
+	if err := s.repo.ReviewFBPClaim(id, "approved", reviewedBy); err != nil {
+		return nil, err
+	}
+	return s.repo.GetFBPClaimByID(id)
+}
+
+// RejectFBPClaim rejects a submitted claim
+func (s *FBPService) RejectFBPClaim(id, reviewedBy string) (*models.FBPClaim, error) {
+	if err := s.repo.ReviewFBPClaim(id, "rejected", reviewedBy); err != nil {
+		return nil, err
+	}
+	return s.repo.GetFBPClaimByID(id)
+}
+
+// fbpAllocations converts an employee's declarations for the calculator
+func fbpAllocations(declarations []models.FBPDeclaration) []calculator.FBPAllocation {
+	var allocations []calculator.FBPAllocation
+	for _, d := range declarations {
+		if d.AnnualAmount <= 0 {
+			continue
+		}
+		allocations = append(allocations, calculator.FBPAllocation{
+			Component:     d.Component,
+			PayoutType:    d.PayoutType,
+			MonthlyAmount: d.AnnualAmount / 12,
+		})
+	}
+	return allocations
+}
+
+// fbpClaims converts payable claims for the calculator
+func fbpClaims(claims []models.FBPClaim) []calculator.FBPClaim {
+	var converted []calculator.FBPClaim
+	for _, c := range claims {
+		converted = append(converted, calculator.FBPClaim{
+			ID:        c.ID,
+			Component: c.Component,
+			Amount:    c.Amount,
+		})
+	}
+	return converted
+}
@@ -0,0 +1,143 @@
+package service
+
+import (
+	"database/sql"
+	"sort"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+type IncomeTaxService struct {
+	repo *repository.IncomeTaxRepository
+}
+
+func NewIncomeTaxService(db *sql.DB) *IncomeTaxService {
+	return &IncomeTaxService{
+		repo: repository.NewIncomeTaxRepository(db),
+	}
+}
+
+// GetIncomeTaxTable fetches the slabs and surcharges of a financial year and regime
+func (s *IncomeTaxService) GetIncomeTaxTable(financialYear int, taxRegime string) ([]models.IncomeTaxSlab, []models.IncomeTaxSurcharge, error) {
+	slabs, err := s.repo.GetIncomeTaxSlabs(financialYear, taxRegime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	surcharges, err := s.repo.GetIncomeTaxSurcharges(financialYear, taxRegime)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return slabs, surcharges, nil
+}
+
+// SaveIncomeTaxTable validates and replaces the slabs and surcharges of a
+// financial year and regime. Each age group's slabs must run from 0 without
+// gaps and end without an upper limit.
+func (s *IncomeTaxService) SaveIncomeTaxTable(financialYear int, taxRegime string, slabs []models.IncomeTaxSlab, surcharges []models.IncomeTaxSurcharge) error {
+	if financialYear < 2000 || financialYear > 2100 {
+		return invalidInput("financial_year must be between 2000 and 2100")
+	}
+
+	if taxRegime != calculator.TaxRegimeOld && taxRegime != calculator.TaxRegimeNew {
+		return invalidInput("tax_regime must be old or new")
+	}
+
+	if len(slabs) == 0 {
+		return invalidInput("at least one slab is required")
+	}
+
+	groups := map[int][]models.IncomeTaxSlab{}
+	for _, slab := range slabs {
+		if slab.MinAge < 0 {
+			return invalidInput("min_age cannot be negative")
+		}
+		if slab.Rate < 0 || slab.Rate > 100 {
+			return invalidInput("slab rate must be between 0 and 100")
+		}
+		groups[slab.MinAge] = append(groups[slab.MinAge], slab)
+	}
+
+	if _, ok := groups[0]; !ok {
+		return invalidInput("slabs for min_age 0 are required")
+	}
+
+	for minAge, group := range groups {
+		sort.Slice(group, func(i, j int) bool { return group[i].IncomeFrom < group[j].IncomeFrom })
+		next := 0.0
+		for i, slab := range group {
+			if slab.IncomeFrom != next {
+				return invalidInput("min_age %d slabs must continue from %.2f, not %.2f", minAge, next, slab.IncomeFrom)
+			}
+			if slab.IncomeTo == nil {
+				if i != len(group)-1 {
+					return invalidInput("min_age %d has a slab without income_to before its last", minAge)
+				}
+				break
+			}
+			if *slab.IncomeTo <= slab.IncomeFrom {
+				return invalidInput("income_to must be above income_from")
+			}
+			next = *slab.IncomeTo
+		}
+		if group[len(group)-1].IncomeTo != nil {
+			return invalidInput("the last min_age %d slab must have no income_to", minAge)
+		}
+	}
+
+	seen := map[float64]bool{}
+	for _, sc := range surcharges {
+		if sc.IncomeAbove <= 0 || seen[sc.IncomeAbove] {
+			return invalidInput("surcharge thresholds must be positive and distinct")
+		}
+		if sc.Rate < 0 || sc.Rate > 100 {
+			return invalidInput("surcharge rate must be between 0 and 100")
+		}
+		seen[sc.IncomeAbove] = true
+	}
+
+	for i := range slabs {
+		slabs[i].FinancialYear, slabs[i].TaxRegime = financialYear, taxRegime
+	}
+	for i := range surcharges {
+		surcharges[i].FinancialYear, surcharges[i].TaxRegime = financialYear, taxRegime
+	}
+
+	return s.repo.ReplaceIncomeTaxTable(financialYear, taxRegime, slabs, surcharges)
+}
+
+// incomeTaxTables converts stored slabs and surcharges for the calculator,
+// one table per regime that has slabs
+func incomeTaxTables(slabs []models.IncomeTaxSlab, surcharges []models.IncomeTaxSurcharge) []calculator.IncomeTaxTable {
+	byRegime := map[string]*calculator.IncomeTaxTable{}
+	var regimes []string
+	for _, s := range slabs {
+		table, ok := byRegime[s.TaxRegime]
+		if !ok {
+			table = &calculator.IncomeTaxTable{FinancialYear: s.FinancialYear, TaxRegime: s.TaxRegime}
+			byRegime[s.TaxRegime] = table
+			regimes = append(regimes, s.TaxRegime)
+		}
+		table.Slabs = append(table.Slabs, calculator.IncomeTaxSlab{
+			MinAge: s.MinAge,
+			From:   s.IncomeFrom,
+			To:     s.IncomeTo,
+			Rate:   s.Rate,
+		})
+	}
+
+	for _, s := range surcharges {
+		if table, ok := byRegime[s.TaxRegime]; ok {
+			table.Surcharges = append(table.Surcharges, calculator.Surcharge{IncomeAbove: s.IncomeAbove, Rate: s.Rate})
+		}
+	}
+
+	tables := make([]calculator.IncomeTaxTable, 0, len(regimes))
+	for _, regime := range regimes {
+		tables = append(tables, *byRegime[regime])
+	}
+	return tables
+}
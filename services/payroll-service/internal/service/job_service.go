@@ -0,0 +1,336 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+// Job types
+const (
+	JobTypePayrollInitiate    = "payroll_initiate"
+	JobTypePayrollRecalculate = "payroll_recalculate"
+	JobTypePayrollDryRun      = "payroll_dry_run"
+	JobTypeBankFile           = "bank_file"
+	JobTypePFECR              = "pf_ecr"
+	JobTypeESIChallan         = "esi_challan"
+)
+
+// Job statuses
+const (
+	JobStatusQueued    = "queued"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+const (
+	jobMaxAttempts      = 3
+	jobLeaseDuration    = time.Minute     // Renewed every third of it while a job runs
+	jobPollInterval     = 2 * time.Second // Between claims while the queue is empty
+	jobProgressInterval = time.Second     // Least time between progress writes
+	jobRetryBackoff     = 30 * time.Second
+)
+
+// ErrJobCancelled is returned by JobProgress once a job's cancellation has
+// been requested. Job handlers return it to stop early.
+var ErrJobCancelled = errors.New("job cancelled")
+
+// JobProgress reports how much of its work a job has done. A nil JobProgress
+// reports nothing.
+type JobProgress func(done, total int) error
+
+func (p JobProgress) report(done, total int) error {
+	if p == nil {
+		return nil
+	}
+	return p(done, total)
+}
+
+// JobHandler runs a job of one type and returns its result, stored as JSON
+type JobHandler func(job *models.Job, progress JobProgress) (interface{}, error)
+
+// JobReport is a job with its progress as a percentage and, while it runs,
+// an estimate of the time left
+type JobReport struct {
+	models.Job
+	Percent    float64 `json:"percent"`
+	ETASeconds *int    `json:"eta_seconds"`
+}
+
+type JobService struct {
+	repo     *repository.JobRepository
+	handlers map[string]JobHandler
+}
+
+func NewJobService(db *sql.DB) *JobService {
+	return &JobService{
+		repo:     repository.NewJobRepository(db),
+		handlers: map[string]JobHandler{},
+	}
+}
+
+// RegisterJobHandlers adds the handlers of some job types. Register every
+// handler before starting workers.
+func (s *JobService) RegisterJobHandlers(handlers map[string]JobHandler) {
+	for jobType, handler := range handlers {
+		s.handlers[jobType] = handler
+	}
+}
+
+// Start runs job workers in the background. Workers of every instance share
+// the queue.
+func (s *JobService) Start(workers int) {
+	host, _ := os.Hostname()
+	for i := 0; i < workers; i++ {
+		go s.work(fmt.Sprintf("%s-%d-%d", host, os.Getpid(), i+1))
+	}
+}
+
+// GetJobs lists an organization's most recent jobs
+func (s *JobService) GetJobs(orgID string, filters map[string]interface{}) ([]JobReport, error) {
+	jobs, err := s.repo.GetJobs(orgID, filters)
+	if err != nil {
+		return nil, err
+	}
+
+	reports := make([]JobReport, len(jobs))
+	for i := range jobs {
+		reports[i] = jobReport(&jobs[i])
+	}
+	return reports, nil
+}
+
+// GetJob fetches a job with its progress
+func (s *JobService) GetJob(id string) (*JobReport, error) {
+	job, err := s.repo.GetJobByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report := jobReport(job)
+	return &report, nil
+}
+
+// CancelJob cancels a queued job, or asks a running one to stop. Running
+// jobs stop at their next progress report; work they already saved is kept.
+func (s *JobService) CancelJob(id string) (*JobReport, error) {
+	job, err := s.repo.GetJobByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if job.Status != JobStatusQueued && job.Status != JobStatusRunning {
+		return nil, conflict("job %s is already %s", job.ID, job.Status)
+	}
+
+	if err := s.repo.CancelJob(id); err != nil {
+		return nil, conflict("%s", err.Error())
+	}
+
+	return s.GetJob(id)
+}
+
+// jobReport works out a job's percentage done and, while it runs, the time
+// left at the rate of its work so far
+func jobReport(job *models.Job) JobReport {
+	report := JobReport{Job: *job}
+
+	switch {
+	case job.Status == JobStatusCompleted:
+		report.Percent = 100
+	case job.ProgressTotal > 0:
+		report.Percent = float64(job.ProgressDone) * 100 / float64(job.ProgressTotal)
+	}
+
+	if job.Status == JobStatusRunning && job.StartedAt != nil && job.ProgressDone > 0 && job.ProgressTotal > job.ProgressDone {
+		elapsed := job.UpdatedAt.Sub(*job.StartedAt)
+		eta := int((elapsed * time.Duration(job.ProgressTotal-job.ProgressDone) / time.Duration(job.ProgressDone)).Seconds())
+		report.ETASeconds = &eta
+	}
+
+	return report
+}
+
+// enqueueJob queues a job. With a lock key, the job is refused while another
+// job holding the key is queued or running. Jobs the system queues have no
+// creator.
+func enqueueJob(repo *repository.JobRepository, orgID, jobType, lockKey string, payload interface{}, createdBy string) (*models.Job, error) {
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	job := &models.Job{
+		OrgID:       orgID,
+		JobType:     jobType,
+		Payload:     payloadJSON,
+		MaxAttempts: jobMaxAttempts,
+	}
+	if createdBy != SystemActor {
+		job.CreatedBy = &createdBy
+	}
+
+	if lockKey != "" {
+		active, err := repo.GetActiveJob(lockKey)
+		if err != nil {
+			return nil, err
+		}
+		if active != nil {
+			return nil, conflict("%s job %s is already %s for %s", active.JobType, active.ID, active.Status, lockKey)
+		}
+		job.LockKey = &lockKey
+	}
+
+	if err := repo.CreateJob(job); err != nil {
+		return nil, err
+	}
+
+	return job, nil
+}
+
+// work claims and runs jobs until the process exits
+func (s *JobService) work(owner string) {
+	for {
+		job, err := s.repo.ClaimJob(owner, jobLeaseDuration)
+		if err != nil {
+			log.Printf("Job worker %s: %v", owner, err)
+		}
+		if job == nil {
+			time.Sleep(jobPollInterval)
+			continue
+		}
+		s.runJob(owner, job)
+	}
+}
+
+// runJob runs a claimed job and records how it ended. Failures are retried
+// with growing backoff, except invalid, conflicting or forbidden requests,
+// which would fail the same way again.
+func (s *JobService) runJob(owner string, job *models.Job) {
+	var err error
+	handler, ok := s.handlers[job.JobType]
+	switch {
+	case job.CancelRequested:
+		err = s.repo.MarkJobCancelled(job.ID, owner)
+	case !ok:
+		err = s.repo.FailJob(job.ID, owner, fmt.Sprintf("unknown job type %s", job.JobType), 0)
+	case job.Attempts > job.MaxAttempts:
+		// Its worker stopped renewing the lease on the last attempt
+		err = s.repo.FailJob(job.ID, owner, fmt.Sprintf("abandoned after %d attempts", job.MaxAttempts), 0)
+	default:
+		err = s.finishJob(owner, job, handler)
+	}
+
+	if err != nil {
+		log.Printf("Job %s (%s): %v", job.ID, job.JobType, err)
+	}
+}
+
+func (s *JobService) finishJob(owner string, job *models.Job, handler JobHandler) error {
+	lease := newJobLease(s.repo, owner, job)
+	stop := lease.keepAlive()
+	result, err := runJobHandler(handler, job, lease.report)
+	stop()
+
+	switch {
+	case err == nil:
+		resultJSON, err := json.Marshal(result)
+		if err != nil {
+			return s.repo.FailJob(job.ID, owner, fmt.Sprintf("failed to encode job result: %v", err), 0)
+		}
+		return s.repo.CompleteJob(job.ID, owner, resultJSON)
+	case errors.Is(err, ErrJobCancelled):
+		return s.repo.MarkJobCancelled(job.ID, owner)
+	case errors.Is(err, ErrInvalidInput), errors.Is(err, ErrConflict), errors.Is(err, ErrForbidden),
+		job.Attempts >= job.MaxAttempts:
+		return s.repo.FailJob(job.ID, owner, err.Error(), 0)
+	default:
+		return s.repo.FailJob(job.ID, owner, err.Error(), jobRetryBackoff<<(job.Attempts-1))
+	}
+}
+
+// runJobHandler runs a handler, turning a panic into the job's error
+func runJobHandler(handler JobHandler, job *models.Job, progress JobProgress) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return handler(job, progress)
+}
+
+// jobLease keeps a running job's lease alive and writes its progress. Job
+// handlers may report progress from several goroutines.
+type jobLease struct {
+	repo      *repository.JobRepository
+	owner     string
+	jobID     string
+	mu        sync.Mutex
+	done      int
+	total     int
+	written   time.Time
+	cancelled bool
+}
+
+func newJobLease(repo *repository.JobRepository, owner string, job *models.Job) *jobLease {
+	return &jobLease{repo: repo, owner: owner, jobID: job.ID, written: time.Now()}
+}
+
+// report records progress, writing it at most every jobProgressInterval and
+// when the work is done, and stops the job once cancellation is requested
+func (l *jobLease) report(done, total int) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.done, l.total = done, total
+	if !l.cancelled && (time.Since(l.written) >= jobProgressInterval || done == total) {
+		l.renew()
+	}
+
+	if l.cancelled {
+		return ErrJobCancelled
+	}
+	return nil
+}
+
+// keepAlive renews the lease in the background until the returned function
+// is called
+func (l *jobLease) keepAlive() func() {
+	stop := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(jobLeaseDuration / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				l.mu.Lock()
+				l.renew()
+				l.mu.Unlock()
+			}
+		}
+	}()
+	return func() { close(stop) }
+}
+
+// renew writes the progress and extends the lease; callers hold mu
+func (l *jobLease) renew() {
+	cancelRequested, err := l.repo.RenewJobLease(l.jobID, l.owner, l.done, l.total, jobLeaseDuration)
+	if err != nil {
+		log.Printf("Job %s: %v", l.jobID, err)
+		return
+	}
+	l.written = time.Now()
+	l.cancelled = cancelRequested
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+type LOPReversalService struct {
+	repo    *repository.LOPReversalRepository
+	empRepo *repository.EmployeeRepository
+}
+
+func NewLOPReversalService(db *sql.DB) *LOPReversalService {
+	return &LOPReversalService{
+		repo:    repository.NewLOPReversalRepository(db),
+		empRepo: repository.NewEmployeeRepository(db),
+	}
+}
+
+// CreateLOPReversal records loss of pay days wrongly deducted in an earlier
+// month. The employee's next payroll run pays them back.
+func (s *LOPReversalService) CreateLOPReversal(rev *models.LOPReversal) error {
+	month, err := time.Parse("2006-01", rev.LOPMonth)
+	if err != nil {
+		return invalidInput("lop_month must be in YYYY-MM format")
+	}
+
+	currentMonth := time.Now().Format("2006-01")
+	if month.Format("2006-01") >= currentMonth {
+		return invalidInput("LOP can only be reversed for a month before %s", currentMonth)
+	}
+
+	if rev.Days <= 0 {
+		return invalidInput("days must be greater than 0")
+	}
+
+	if rev.Reason == "" {
+		return invalidInput("reason is required")
+	}
+
+	emp, err := s.empRepo.GetEmployeeByID(rev.EmployeeID)
+	if err != nil || emp.OrgID != rev.OrgID {
+		return invalidInput("employee %s not found in organization", rev.EmployeeID)
+	}
+
+	// Only days the month actually lost to absence or unpaid leave can be reversed
+	attendance, err := s.empRepo.GetAttendanceSummary(rev.EmployeeID, rev.LOPMonth)
+	if err != nil {
+		return err
+	}
+
+	leave, err := s.empRepo.GetLeaveSummary(rev.EmployeeID, rev.LOPMonth)
+	if err != nil {
+		return err
+	}
+
+	lopDays := 0
+	if attendance != nil {
+		lopDays += attendance.AbsentDays
+	}
+	if leave != nil {
+		lopDays += leave.UnpaidLeaveTaken
+	}
+
+	reversed, err := s.repo.GetReversedDays(rev.EmployeeID, rev.LOPMonth)
+	if err != nil {
+		return err
+	}
+
+	remaining := lopDays - reversed
+	if remaining < 0 {
+		remaining = 0
+	}
+	if rev.Days > remaining {
+		return invalidInput("only %d LOP days of %s are left to reverse", remaining, rev.LOPMonth)
+	}
+
+	return s.repo.CreateLOPReversal(rev)
+}
+
+// GetLOPReversals fetches an organization's LOP reversals
+func (s *LOPReversalService) GetLOPReversals(orgID string, filters map[string]interface{}) ([]models.LOPReversal, error) {
+	return s.repo.GetLOPReversals(orgID, filters)
+}
+
+// CancelLOPReversal cancels a reversal that has not been paid yet
+func (s *LOPReversalService) CancelLOPReversal(id string) error {
+	return s.repo.CancelLOPReversal(id)
+}
+
+// lopReversalInputs converts employees' reversals for the calculator, keyed
+// by employee ID. Each is valued on the calendar and expected working days
+// of the month it reverses, loaded once per month reversed.
+func (s *PayrollService) lopReversalInputs(reversals map[string][]models.LOPReversal) (map[string][]calculator.LOPReversal, error) {
+	employeesByMonth := map[string][]string{}
+	for employeeID, revs := range reversals {
+		for _, rev := range revs {
+			employeesByMonth[rev.LOPMonth] = append(employeesByMonth[rev.LOPMonth], employeeID)
+		}
+	}
+
+	attendance := map[string]map[string]*models.AttendanceSummary{}
+	for month, employeeIDs := range employeesByMonth {
+		summaries, err := s.empRepo.GetAttendanceSummaries(employeeIDs, month)
+		if err != nil {
+			return nil, err
+		}
+		attendance[month] = summaries
+	}
+
+	converted := map[string][]calculator.LOPReversal{}
+	for employeeID, revs := range reversals {
+		for _, rev := range revs {
+			input := calculator.LOPReversal{
+				ID:          rev.ID,
+				Month:       rev.LOPMonth,
+				Days:        rev.Days,
+				DaysInMonth: daysInPayrollMonth(rev.LOPMonth),
+			}
+			if a := attendance[rev.LOPMonth][employeeID]; a != nil && a.WorkingDaysExpected > 0 {
+				input.WorkingDays = a.WorkingDaysExpected
+			}
+			converted[employeeID] = append(converted[employeeID], input)
+		}
+	}
+	return converted, nil
+}
@@ -0,0 +1,146 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/reports"
+	"payroll-service/internal/repository"
+)
+
+// pranPattern matches a 12-digit Permanent Retirement Account Number
+var pranPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+type NPSService struct {
+	repo        *repository.NPSRepository
+	empRepo     *repository.EmployeeRepository
+	payrollRepo *repository.PayrollRepository
+	orgRepo     *repository.OrganizationRepository
+}
+
+func NewNPSService(db *sql.DB) *NPSService {
+	return &NPSService{
+		repo:        repository.NewNPSRepository(db),
+		empRepo:     repository.NewEmployeeRepository(db),
+		payrollRepo: repository.NewPayrollRepository(db),
+		orgRepo:     repository.NewOrganizationRepository(db),
+	}
+}
+
+// GetNPSEnrolments fetches an organization's NPS enrolments
+func (s *NPSService) GetNPSEnrolments(orgID string, filters map[string]interface{}) ([]models.NPSEnrolment, error) {
+	return s.repo.GetNPSEnrolments(orgID, filters)
+}
+
+// SaveNPSEnrolment validates and saves an employee's NPS enrolment. The
+// employer rate may not exceed the 80CCD(2) limit of the employee's regime.
+func (s *NPSService) SaveNPSEnrolment(e *models.NPSEnrolment) error {
+	if !pranPattern.MatchString(e.PRAN) {
+		return invalidInput("pran must be 12 digits")
+	}
+
+	if e.EffectiveTill != nil && e.EffectiveTill.Before(e.EffectiveFrom) {
+		return invalidInput("effective_till cannot be before effective_from")
+	}
+
+	emp, err := s.empRepo.GetEmployeeByID(e.EmployeeID)
+	if err != nil || emp.OrgID != e.OrgID {
+		return invalidInput("employee %s not found in organization", e.EmployeeID)
+	}
+
+	limit := calculator.NPS80CCD2Limit(emp.TaxRegime)
+	if e.EmployerRate <= 0 || e.EmployerRate > limit {
+		return invalidInput("employer_rate must be above 0 and at most %.0f%% under the %s regime", limit, emp.TaxRegime)
+	}
+
+	if e.EmployeeRate < 0 || e.EmployeeRate > 100 {
+		return invalidInput("employee_rate must be between 0 and 100")
+	}
+
+	return s.repo.UpsertNPSEnrolment(e)
+}
+
+// SetTaxRegime records the income tax regime an employee opted for
+func (s *NPSService) SetTaxRegime(employeeID, taxRegime, updatedBy string) error {
+	if taxRegime != calculator.TaxRegimeOld && taxRegime != calculator.TaxRegimeNew {
+		return invalidInput("tax_regime must be old or new")
+	}
+
+	return s.empRepo.UpdateTaxRegime(employeeID, taxRegime, updatedBy)
+}
+
+// GenerateContributionFile generates the NPS contribution file of a locked
+// or released run for upload to the POP
+func (s *NPSService) GenerateContributionFile(payrollRunID string) (*reports.NPSContributionFile, error) {
+	pr, err := s.payrollRepo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isPayable(pr) {
+		return nil, conflict("payroll must be locked before generating the NPS contribution file")
+	}
+
+	org, err := s.orgRepo.GetOrganizationByID(pr.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !org.NPSRegistrationNumber.Valid || org.NPSRegistrationNumber.String == "" {
+		return nil, invalidInput("organization has no corporate NPS registration number")
+	}
+
+	components, err := s.payrollRepo.GetPayrollComponents(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrolments, err := s.repo.GetNPSEnrolments(pr.OrgID, map[string]interface{}{"as_of": pr.PayrollPeriodEnd})
+	if err != nil {
+		return nil, err
+	}
+
+	pran := make(map[string]string, len(enrolments))
+	for _, e := range enrolments {
+		if _, ok := pran[e.EmployeeID]; !ok {
+			pran[e.EmployeeID] = e.PRAN // Latest enrolment first
+		}
+	}
+
+	employees, err := s.empRepo.GetEmployees(pr.OrgID, map[string]interface{}{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employees: %w", err)
+	}
+
+	byID := make(map[string]*models.Employee, len(employees))
+	for i := range employees {
+		byID[employees[i].ID] = &employees[i]
+	}
+
+	generator := reports.NewNPSFileGenerator(reports.OrganizationDetails{
+		ID:                    org.ID,
+		Name:                  org.Name,
+		Code:                  org.EntityCode,
+		PAN:                   org.PAN,
+		NPSRegistrationNumber: org.NPSRegistrationNumber.String,
+	})
+
+	return generator.GenerateContributionFile(components, byID, pran, pr), nil
+}
+
+// npsContribution converts an employee's enrolment in force for the
+// calculator, nil when not enrolled
+func npsContribution(enrolment *models.NPSEnrolment) *calculator.NPSContribution {
+	if enrolment == nil {
+		return nil
+	}
+
+	return &calculator.NPSContribution{
+		PRAN:         enrolment.PRAN,
+		EmployerRate: enrolment.EmployerRate,
+		EmployeeRate: enrolment.EmployeeRate,
+	}
+}
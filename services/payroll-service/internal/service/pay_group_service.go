@@ -0,0 +1,148 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+// maxPeriodGenerationDays bounds how much of a calendar is generated at once
+const maxPeriodGenerationDays = 400
+
+type PayGroupService struct {
+	repo    *repository.PayGroupRepository
+	empRepo *repository.EmployeeRepository
+}
+
+func NewPayGroupService(db *sql.DB) *PayGroupService {
+	return &PayGroupService{
+		repo:    repository.NewPayGroupRepository(db),
+		empRepo: repository.NewEmployeeRepository(db),
+	}
+}
+
+// GetPayGroups fetches an organization's pay groups
+func (s *PayGroupService) GetPayGroups(orgID string) ([]models.PayGroup, error) {
+	return s.repo.GetPayGroups(orgID)
+}
+
+// CreatePayGroup validates and creates a pay group
+func (s *PayGroupService) CreatePayGroup(pg *models.PayGroup) error {
+	if pg.Name == "" {
+		return invalidInput("name is required")
+	}
+
+	if err := calculator.ValidateFrequency(pg.Frequency, pg.AnchorDate); err != nil {
+		return invalidInput("%v", err)
+	}
+
+	return s.repo.CreatePayGroup(pg)
+}
+
+// GeneratePayGroupPeriods adds the periods overlapping [from, to] to a pay
+// group's calendar and returns them with the number newly created
+func (s *PayGroupService) GeneratePayGroupPeriods(payGroupID string, from, to time.Time) ([]models.PayGroupPeriod, int, error) {
+	group, err := s.repo.GetPayGroupByID(payGroupID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if to.Sub(from).Hours()/24 > maxPeriodGenerationDays {
+		return nil, 0, invalidInput("periods can be generated for at most %d days at a time", maxPeriodGenerationDays)
+	}
+
+	periods, err := payGroupPeriods(group, from, to)
+	if err != nil {
+		return nil, 0, invalidInput("%v", err)
+	}
+
+	created, err := s.repo.CreatePayGroupPeriods(periods)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return periods, created, nil
+}
+
+// GetPayGroupPeriods fetches a pay group's calendar
+func (s *PayGroupService) GetPayGroupPeriods(payGroupID string, from, to *time.Time) ([]models.PayGroupPeriod, error) {
+	if _, err := s.repo.GetPayGroupByID(payGroupID); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPayGroupPeriods(payGroupID, from, to)
+}
+
+// AssignEmployee moves an employee to a pay group of their organization.
+// The change applies to runs initiated from then on.
+func (s *PayGroupService) AssignEmployee(employeeID, payGroupID, updatedBy string) (*models.Employee, error) {
+	emp, err := s.empRepo.GetEmployeeByID(employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := s.repo.GetPayGroupByID(payGroupID)
+	if err != nil {
+		return nil, err
+	}
+
+	if group.OrgID != emp.OrgID {
+		return nil, invalidInput("pay group belongs to a different organization")
+	}
+
+	if !group.IsActive {
+		return nil, conflict("pay group %s is inactive", group.Name)
+	}
+
+	if err := s.empRepo.UpdatePayGroup(employeeID, payGroupID, updatedBy); err != nil {
+		return nil, err
+	}
+
+	emp.PayGroupID = &group.ID
+	return emp, nil
+}
+
+// defaultPayGroup returns an organization's default pay group, creating a
+// monthly one for organizations that predate pay groups
+func defaultPayGroup(repo *repository.PayGroupRepository, orgID string) (*models.PayGroup, error) {
+	group, err := repo.GetDefaultPayGroup(orgID)
+	if err != nil || group != nil {
+		return group, err
+	}
+
+	group = &models.PayGroup{
+		OrgID:     orgID,
+		Name:      "Monthly",
+		Frequency: calculator.FrequencyMonthly,
+		IsDefault: true,
+	}
+	if err := repo.CreatePayGroup(group); err != nil {
+		return nil, err
+	}
+
+	return group, nil
+}
+
+// payGroupPeriods builds a pay group's calendar for [from, to]
+func payGroupPeriods(group *models.PayGroup, from, to time.Time) ([]models.PayGroupPeriod, error) {
+	generated, err := calculator.GeneratePayPeriods(group.Frequency, group.AnchorDate, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	periods := make([]models.PayGroupPeriod, len(generated))
+	for i, p := range generated {
+		periods[i] = models.PayGroupPeriod{
+			PayGroupID:   group.ID,
+			PeriodKey:    p.Key,
+			PeriodStart:  p.Start,
+			PeriodEnd:    p.End,
+			PayrollMonth: p.PayrollMonth,
+		}
+	}
+
+	return periods, nil
+}
@@ -0,0 +1,339 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"payroll-service/internal/models"
+	"payroll-service/internal/reports"
+	"payroll-service/internal/repository"
+)
+
+// Hold reason codes
+var holdReasonCodes = map[string]bool{
+	"absconding":     true,
+	"exit_clearance": true,
+	"bank_mismatch":  true,
+	"other":          true,
+}
+
+type PaymentService struct {
+	repo     *repository.PayrollRepository
+	holdRepo *repository.PayrollHoldRepository
+	empRepo  *repository.EmployeeRepository
+	orgRepo  *repository.OrganizationRepository
+	jobRepo  *repository.JobRepository
+}
+
+func NewPaymentService(db *sql.DB) *PaymentService {
+	return &PaymentService{
+		repo:     repository.NewPayrollRepository(db),
+		holdRepo: repository.NewPayrollHoldRepository(db),
+		empRepo:  repository.NewEmployeeRepository(db),
+		orgRepo:  repository.NewOrganizationRepository(db),
+		jobRepo:  repository.NewJobRepository(db),
+	}
+}
+
+// GetPayrollHolds lists salary holds for an organization
+func (s *PaymentService) GetPayrollHolds(orgID string, filters map[string]interface{}) ([]models.PayrollHold, error) {
+	return s.holdRepo.GetPayrollHolds(orgID, filters)
+}
+
+// PlaceHold withholds an employee's salary in a payroll run. The component
+// stays in the run and its statutory reports but is left out of the bank file.
+// Once the bank file has been generated the salary has been paid, so it can
+// no longer be held.
+func (s *PaymentService) PlaceHold(payrollRunID, employeeID, reasonCode, reason, heldBy string, expiresAt *time.Time) (*models.PayrollHold, error) {
+	if !holdReasonCodes[reasonCode] {
+		return nil, invalidInput("reason_code must be one of absconding, exit_clearance, bank_mismatch, other")
+	}
+
+	if expiresAt != nil && expiresAt.Before(time.Now().Truncate(24*time.Hour)) {
+		return nil, invalidInput("expires_at cannot be in the past")
+	}
+
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pr.Status == "released" {
+		return nil, conflict("payroll run has been released for payment")
+	}
+
+	component, err := s.findComponent(payrollRunID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if component.HoldStatus != "" {
+		return nil, conflict("salary is already held (%s)", component.HoldStatus)
+	}
+
+	hold := &models.PayrollHold{
+		OrgID:              pr.OrgID,
+		PayrollRunID:       payrollRunID,
+		PayrollComponentID: component.ID,
+		EmployeeID:         employeeID,
+		ReasonCode:         reasonCode,
+		Reason:             sql.NullString{String: reason, Valid: reason != ""},
+		HeldBy:             heldBy,
+		ExpiresAt:          expiresAt,
+	}
+
+	ok, err := s.holdRepo.CreatePayrollHold(hold)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, conflict("the run's bank file has been generated; salaries can no longer be held")
+	}
+
+	return hold, nil
+}
+
+// ReleaseHold ends a hold. Before the run is locked the salary simply goes
+// out with the run's bank file and nil is returned; afterwards a
+// supplementary bank file is generated for it.
+func (s *PaymentService) ReleaseHold(payrollRunID, employeeID, releasedBy, notes, fileFormat string) (*reports.BankPaymentFile, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	hold, err := s.holdRepo.GetActiveHold(payrollRunID, employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isPayable(pr) {
+		return nil, s.holdRepo.ReleasePayrollHold(hold.ID, "withdrawn", releasedBy, notes)
+	}
+
+	if err := s.holdRepo.ReleasePayrollHold(hold.ID, "released", releasedBy, notes); err != nil {
+		return nil, err
+	}
+
+	return s.paySupplementary(pr, []models.PayrollHold{*hold}, fileFormat)
+}
+
+// GenerateBankFile generates the salary bank file for a locked or released
+// run. Held salaries are left out.
+func (s *PaymentService) GenerateBankFile(payrollRunID, fileFormat string) (*reports.BankPaymentFile, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isPayable(pr) {
+		return nil, conflict("payroll must be locked before generating the bank file")
+	}
+
+	generator, employees, err := s.bankFileInputs(pr.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Refuse new holds before reading which salaries are held
+	if err := s.repo.MarkBankFileGenerated(payrollRunID); err != nil {
+		return nil, err
+	}
+
+	components, err := s.repo.GetPayrollComponents(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	return generator.GenerateBankFile(components, employees, pr, fileFormat), nil
+}
+
+// GenerateSupplementaryBankFile pays every held salary in a run that was
+// released or whose hold has lapsed, and is not yet paid
+func (s *PaymentService) GenerateSupplementaryBankFile(payrollRunID, fileFormat string) (*reports.BankPaymentFile, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isPayable(pr) {
+		return nil, conflict("payroll must be locked before generating a supplementary bank file")
+	}
+
+	holds, err := s.holdRepo.GetPayableHolds(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(holds) == 0 {
+		return nil, conflict("no released or lapsed holds awaiting payment")
+	}
+
+	return s.paySupplementary(pr, holds, fileFormat)
+}
+
+// paySupplementary claims the holds still awaiting payment and generates a
+// supplementary bank file for just their components. Holds paid by a
+// concurrent request in the meantime are left out.
+func (s *PaymentService) paySupplementary(pr *models.PayrollRun, holds []models.PayrollHold, fileFormat string) (*reports.BankPaymentFile, error) {
+	components, err := s.repo.GetPayrollComponents(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	generator, employees, err := s.bankFileInputs(pr.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	componentIDs := make(map[string]string, len(holds))
+	holdIDs := make([]string, len(holds))
+	for i, hold := range holds {
+		componentIDs[hold.ID] = hold.PayrollComponentID
+		holdIDs[i] = hold.ID
+	}
+
+	var file *reports.BankPaymentFile
+	err = s.holdRepo.PayHolds(holdIDs, func(claimed []string) (string, error) {
+		if len(claimed) == 0 {
+			return "", conflict("no released or lapsed holds awaiting payment")
+		}
+
+		held := make(map[string]bool, len(claimed))
+		for _, id := range claimed {
+			held[componentIDs[id]] = true
+		}
+
+		var payable []models.PayrollComponent
+		for _, component := range components {
+			if held[component.ID] {
+				payable = append(payable, component)
+			}
+		}
+
+		file = generator.GenerateSupplementaryBankFile(payable, employees, pr, fileFormat)
+		return file.FileReference, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return file, nil
+}
+
+// bankFileInputs loads the organization's disbursement account and its
+// employees (including those who have left) keyed by ID
+func (s *PaymentService) bankFileInputs(orgID string) (*reports.BankFileGenerator, map[string]*models.Employee, error) {
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !org.BankAccountNumber.Valid || !org.BankIFSCCode.Valid {
+		return nil, nil, invalidInput("organization has no disbursement bank account")
+	}
+
+	employees, err := s.empRepo.GetEmployees(orgID, map[string]interface{}{})
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch employees: %w", err)
+	}
+
+	byID := make(map[string]*models.Employee, len(employees))
+	for i := range employees {
+		byID[employees[i].ID] = &employees[i]
+	}
+
+	generator := reports.NewBankFileGenerator(reports.OrganizationDetails{
+		ID:                org.ID,
+		Name:              org.Name,
+		Code:              org.EntityCode,
+		PAN:               org.PAN,
+		BankName:          org.BankName.String,
+		BankAccountNumber: org.BankAccountNumber.String,
+		IFSC:              org.BankIFSCCode.String,
+	})
+
+	return generator, byID, nil
+}
+
+func (s *PaymentService) findComponent(payrollRunID, employeeID string) (*models.PayrollComponent, error) {
+	components, err := s.repo.GetPayrollComponents(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range components {
+		if components[i].EmployeeID == employeeID {
+			return &components[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("payroll component not found")
+}
+
+// isPayable reports whether a run's salaries can be disbursed
+func isPayable(pr *models.PayrollRun) bool {
+	return pr.Status == "locked" || pr.Status == "released"
+}
+
+// bankFilePayload is the payload of a bank_file job
+type bankFilePayload struct {
+	PayrollRunID string `json:"payroll_run_id"`
+	FileFormat   string `json:"file_format"`
+}
+
+// BankFileJobResult is the result of a bank_file job
+type BankFileJobResult struct {
+	FileName      string  `json:"file_name"`
+	FileReference string  `json:"file_reference"`
+	FileFormat    string  `json:"file_format"`
+	FileType      string  `json:"file_type"`
+	TotalRecords  int     `json:"total_records"`
+	TotalAmount   float64 `json:"total_amount"`
+	Content       string  `json:"content"`
+}
+
+// EnqueueBankFile queues the generation of a run's salary bank file after
+// checking the run is locked
+func (s *PaymentService) EnqueueBankFile(payrollRunID, fileFormat, requestedBy string) (*models.Job, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isPayable(pr) {
+		return nil, conflict("payroll must be locked before generating the bank file")
+	}
+
+	payload := bankFilePayload{PayrollRunID: pr.ID, FileFormat: fileFormat}
+	return enqueueJob(s.jobRepo, pr.OrgID, JobTypeBankFile, "", payload, requestedBy)
+}
+
+// JobHandlers returns the handlers of bank file jobs
+func (s *PaymentService) JobHandlers() map[string]JobHandler {
+	return map[string]JobHandler{
+		JobTypeBankFile: func(job *models.Job, progress JobProgress) (interface{}, error) {
+			var p bankFilePayload
+			if err := json.Unmarshal(job.Payload, &p); err != nil {
+				return nil, invalidInput("invalid %s payload: %v", job.JobType, err)
+			}
+
+			file, err := s.GenerateBankFile(p.PayrollRunID, p.FileFormat)
+			if err != nil {
+				return nil, err
+			}
+
+			return &BankFileJobResult{
+				FileName:      file.FileName,
+				FileReference: file.FileReference,
+				FileFormat:    file.FileFormat,
+				FileType:      file.FileType,
+				TotalRecords:  file.TotalRecords,
+				TotalAmount:   file.TotalAmount,
+				Content:       file.RawContent,
+			}, nil
+		},
+	}
+}
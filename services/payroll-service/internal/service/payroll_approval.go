@@ -0,0 +1,365 @@
+package service
+
+import (
+	"math"
+	"sort"
+
+	"payroll-service/internal/models"
+)
+
+// Approval decisions
+const (
+	ApprovalApproved = "approved"
+	ApprovalRejected = "rejected"
+)
+
+// defaultApprovalLevel applies to organizations without an approval chain:
+// one approval by anyone other than the maker
+var defaultApprovalLevel = models.PayrollApprovalLevel{Level: 0, Name: "Approver"}
+
+// ApprovalProgress is where a payroll run stands in its approval chain. A
+// round of approvals starts each time the run is finalized.
+type ApprovalProgress struct {
+	PayrollRunID   string                        `json:"payroll_run_id"`
+	PayrollMonth   string                        `json:"payroll_month"`
+	Status         string                        `json:"status"`
+	NetAmount      float64                       `json:"net_amount"`
+	Makers         []string                      `json:"makers"` // Initiator, recalculator and finalizer, who cannot approve
+	RequiredLevels []models.PayrollApprovalLevel `json:"required_levels"`
+	Approvals      []models.PayrollRunApproval   `json:"approvals"`
+	NextLevel      *models.PayrollApprovalLevel  `json:"next_level"` // nil unless the run awaits a decision
+
+	finalizeTransitionID string
+}
+
+// GetApprovalChain fetches an organization's approval chain
+func (s *PayrollService) GetApprovalChain(orgID string) ([]models.PayrollApprovalLevel, error) {
+	return s.approvalRepo.GetApprovalLevels(orgID)
+}
+
+// SaveApprovalChain validates and replaces an organization's approval chain.
+// Levels escalate: the first is always required and each later level applies
+// from a net pay at least as high as the one before. An empty chain falls
+// back to a single approval by anyone other than the maker.
+func (s *PayrollService) SaveApprovalChain(orgID string, levels []models.PayrollApprovalLevel) error {
+	sort.Slice(levels, func(i, j int) bool { return levels[i].Level < levels[j].Level })
+
+	for i, l := range levels {
+		if l.Level <= 0 {
+			return invalidInput("level must be positive")
+		}
+		if i > 0 && l.Level == levels[i-1].Level {
+			return invalidInput("level %d is defined more than once", l.Level)
+		}
+		if l.Name == "" {
+			return invalidInput("level %d needs a name", l.Level)
+		}
+		if len(l.ApproverIDs) == 0 {
+			return invalidInput("level %d needs at least one approver", l.Level)
+		}
+		if l.MinNetAmount < 0 {
+			return invalidInput("min_net_amount cannot be negative")
+		}
+		if i == 0 && l.MinNetAmount != 0 {
+			return invalidInput("the first level must apply to every run (min_net_amount 0)")
+		}
+		if i > 0 && l.MinNetAmount < levels[i-1].MinNetAmount {
+			return invalidInput("level %d cannot apply from a lower net amount than level %d", l.Level, levels[i-1].Level)
+		}
+	}
+
+	return s.approvalRepo.ReplaceApprovalLevels(orgID, levels)
+}
+
+// GetApprovalProgress reports a run's approval round
+func (s *PayrollService) GetApprovalProgress(payrollRunID string) (*ApprovalProgress, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+	return s.approvalProgress(pr)
+}
+
+// ApprovePayroll records an approval at the run's next pending level. The
+// run is locked once every level its net pay requires has approved.
+func (s *PayrollService) ApprovePayroll(payrollRunID string, approvedBy string, comment string) (*ApprovalProgress, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.checkRunTransition(pr, RunActionApprove, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireNoActiveCalculation(s, pr); err != nil {
+		return nil, err
+	}
+
+	progress, err := s.approvalProgress(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	// A previous final approval may have been recorded without locking the run
+	if progress.NextLevel != nil {
+		if _, err := s.recordDecision(progress, approvedBy, ApprovalApproved, comment); err != nil {
+			return nil, err
+		}
+		if progress.NextLevel != nil {
+			return progress, nil
+		}
+	} else if isMaker(progress, approvedBy) {
+		return nil, forbidden("%s prepared payroll run %s and cannot approve it", approvedBy, pr.ID)
+	}
+
+	if err := t.guard(s, pr); err != nil {
+		return nil, err
+	}
+
+	// The status change, its transition and the lock are applied together
+	ok, err := s.repo.LockPayrollRun(pr.ID, pr.Status, RunActionApprove, approvedBy, comment, "Payroll approved and locked")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, conflict("payroll run %s changed status while trying to %s it; reload and retry", pr.ID, RunActionApprove)
+	}
+	pr.Status = t.target(pr.Status)
+
+	progress.Status = pr.Status
+	return progress, nil
+}
+
+// RejectPayroll records a rejection at the run's next pending level and sends
+// the run back to in_progress. The next finalization starts a new round.
+func (s *PayrollService) RejectPayroll(payrollRunID string, rejectedBy string, comment string) (*ApprovalProgress, error) {
+	if comment == "" {
+		return nil, invalidInput("a comment is required to reject a payroll run")
+	}
+
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.checkRunTransition(pr, RunActionReject, rejectedBy); err != nil {
+		return nil, err
+	}
+
+	progress, err := s.approvalProgress(pr)
+	if err != nil {
+		return nil, err
+	}
+	if progress.NextLevel == nil {
+		return nil, conflict("payroll run %s is fully approved", pr.ID)
+	}
+
+	if _, err := s.recordDecision(progress, rejectedBy, ApprovalRejected, comment); err != nil {
+		return nil, err
+	}
+
+	if err := s.transitionRun(pr, RunActionReject, rejectedBy, comment); err != nil {
+		return nil, err
+	}
+
+	progress.Status = pr.Status
+	progress.NextLevel = nil
+	return progress, nil
+}
+
+// ApprovalInbox lists an organization's finalized runs awaiting a decision
+// that approverID may make
+func (s *PayrollService) ApprovalInbox(orgID, approverID string) ([]ApprovalProgress, error) {
+	runs, err := s.repo.GetPayrollRuns(orgID, map[string]interface{}{"status": RunStatusFinalized})
+	if err != nil {
+		return nil, err
+	}
+
+	inbox := []ApprovalProgress{}
+	for i := range runs {
+		progress, err := s.approvalProgress(&runs[i])
+		if err != nil {
+			return nil, err
+		}
+		if progress.NextLevel != nil && canDecide(progress, approverID) == nil {
+			inbox = append(inbox, *progress)
+		}
+	}
+
+	return inbox, nil
+}
+
+// approvalProgress loads the run's current approval round: the levels its net
+// pay requires, the decisions made since it was last finalized and the next
+// level to decide
+func (s *PayrollService) approvalProgress(pr *models.PayrollRun) (*ApprovalProgress, error) {
+	progress := &ApprovalProgress{
+		PayrollRunID: pr.ID,
+		PayrollMonth: pr.PayrollMonth,
+		Status:       pr.Status,
+		Approvals:    []models.PayrollRunApproval{},
+	}
+
+	components, err := s.repo.GetPayrollComponents(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	for _, comp := range components {
+		progress.NetAmount += comp.NetPay
+	}
+	progress.NetAmount = math.Round(progress.NetAmount*100) / 100
+
+	levels, err := s.approvalRepo.GetApprovalLevels(pr.OrgID)
+	if err != nil {
+		return nil, err
+	}
+	if len(levels) == 0 {
+		levels = []models.PayrollApprovalLevel{defaultApprovalLevel}
+	}
+	for _, l := range levels {
+		if l.MinNetAmount == 0 || progress.NetAmount > l.MinNetAmount {
+			progress.RequiredLevels = append(progress.RequiredLevels, l)
+		}
+	}
+
+	finalized, err := s.repo.GetLatestPayrollRunTransition(pr.ID, RunActionFinalize)
+	if err != nil {
+		return nil, err
+	}
+	if finalized == nil {
+		if pr.Status == RunStatusFinalized {
+			return nil, conflict("payroll run %s was finalized before approvals were tracked; initiate and finalize it again", pr.ID)
+		}
+		return progress, nil
+	}
+	progress.finalizeTransitionID = finalized.ID
+
+	// Whoever last calculated or finalized the run made it
+	for _, action := range []string{RunActionInitiate, RunActionRecalc} {
+		made, err := s.repo.GetLatestPayrollRunTransition(pr.ID, action)
+		if err != nil {
+			return nil, err
+		}
+		if made != nil && !isMaker(progress, made.Actor) {
+			progress.Makers = append(progress.Makers, made.Actor)
+		}
+	}
+	if !isMaker(progress, finalized.Actor) {
+		progress.Makers = append(progress.Makers, finalized.Actor)
+	}
+
+	approvals, err := s.approvalRepo.GetRunApprovals(finalized.ID)
+	if err != nil {
+		return nil, err
+	}
+	if approvals != nil {
+		progress.Approvals = approvals
+	}
+
+	if pr.Status == RunStatusFinalized {
+		progress.NextLevel = nextApprovalLevel(progress)
+	}
+
+	return progress, nil
+}
+
+// nextApprovalLevel is the first required level without an approval in the
+// round
+func nextApprovalLevel(progress *ApprovalProgress) *models.PayrollApprovalLevel {
+	approved := map[int]bool{}
+	for _, a := range progress.Approvals {
+		if a.Decision == ApprovalApproved {
+			approved[a.Level] = true
+		}
+	}
+
+	for i := range progress.RequiredLevels {
+		if !approved[progress.RequiredLevels[i].Level] {
+			return &progress.RequiredLevels[i]
+		}
+	}
+	return nil
+}
+
+// canDecide checks that actor may approve or reject at the run's next level:
+// they must not be a maker of the run, must be one of the level's approvers
+// and must not have decided at another level of the round
+func canDecide(progress *ApprovalProgress, actor string) error {
+	level := progress.NextLevel
+
+	if isMaker(progress, actor) {
+		return forbidden("%s prepared payroll run %s and cannot approve it", actor, progress.PayrollRunID)
+	}
+
+	if len(level.ApproverIDs) > 0 {
+		allowed := false
+		for _, id := range level.ApproverIDs {
+			if id == actor {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return forbidden("%s is not an approver at level %d (%s)", actor, level.Level, level.Name)
+		}
+	}
+
+	for _, a := range progress.Approvals {
+		if a.Approver == actor {
+			return forbidden("%s already approved payroll run %s at level %d", actor, progress.PayrollRunID, a.Level)
+		}
+	}
+
+	return nil
+}
+
+func isMaker(progress *ApprovalProgress, actor string) bool {
+	for _, maker := range progress.Makers {
+		if maker == actor {
+			return true
+		}
+	}
+	return false
+}
+
+// recordDecision stores actor's decision at the next level and advances the
+// progress
+func (s *PayrollService) recordDecision(progress *ApprovalProgress, actor, decision, comment string) (*models.PayrollRunApproval, error) {
+	if err := canDecide(progress, actor); err != nil {
+		return nil, err
+	}
+
+	approval := &models.PayrollRunApproval{
+		PayrollRunID:         progress.PayrollRunID,
+		FinalizeTransitionID: progress.finalizeTransitionID,
+		Level:                progress.NextLevel.Level,
+		LevelName:            progress.NextLevel.Name,
+		Approver:             actor,
+		Decision:             decision,
+	}
+	if comment != "" {
+		approval.Comment = &comment
+	}
+
+	if err := s.approvalRepo.CreateRunApproval(approval); err != nil {
+		return nil, err
+	}
+
+	progress.Approvals = append(progress.Approvals, *approval)
+	progress.NextLevel = nextApprovalLevel(progress)
+	return approval, nil
+}
+
+// requireApprovalChain guards locking: every level the run's net pay requires
+// must have approved in the current round
+func requireApprovalChain(s *PayrollService, pr *models.PayrollRun) error {
+	progress, err := s.approvalProgress(pr)
+	if err != nil {
+		return err
+	}
+	if progress.NextLevel != nil {
+		return conflict("payroll run %s awaits approval at level %d (%s)", pr.ID, progress.NextLevel.Level, progress.NextLevel.Name)
+	}
+	return nil
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+)
+
+// DryRunResult is what a dry run calculated: the components an initiation
+// would store, the employees it could not calculate cleanly and what changed
+// since the previous dry run
+type DryRunResult struct {
+	DryRun     models.PayrollDryRun      `json:"dry_run"`
+	Components []models.PayrollComponent `json:"components"`
+	Findings   []DryRunFinding           `json:"findings"`
+	Diff       *DryRunDiff               `json:"diff"` // Nil for a run's first dry run
+}
+
+// DryRunFinding is an employee a dry run skipped, failed, or calculated with
+// validation warnings
+type DryRunFinding struct {
+	EmployeeID   string                       `json:"employee_id"`
+	Status       string                       `json:"status"` // success, skipped, failed
+	ErrorCode    *string                      `json:"error_code"`
+	ErrorMessage *string                      `json:"error_message"`
+	Validation   []calculator.ValidationError `json:"validation,omitempty"`
+}
+
+// DryRunDiff compares a dry run's components with those of the run's
+// previous dry run
+type DryRunDiff struct {
+	PreviousDryRunNumber int            `json:"previous_dry_run_number"`
+	NetPayBefore         float64        `json:"net_pay_before"`
+	NetPayAfter          float64        `json:"net_pay_after"`
+	Employees            []EmployeeDiff `json:"employees"` // Only employees with changes
+}
+
+// dryRunSandbox keeps the components a dry run calculates, and their
+// validation findings, instead of storing them. Workers add to it at once.
+type dryRunSandbox struct {
+	mu         sync.Mutex
+	components map[string]*models.PayrollComponent
+	validation map[string][]calculator.ValidationError
+}
+
+func newDryRunSandbox() *dryRunSandbox {
+	return &dryRunSandbox{
+		components: map[string]*models.PayrollComponent{},
+		validation: map[string][]calculator.ValidationError{},
+	}
+}
+
+func (b *dryRunSandbox) add(pc *models.PayrollComponent, validationErrors []calculator.ValidationError) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.components[pc.EmployeeID] = pc
+	if len(validationErrors) > 0 {
+		b.validation[pc.EmployeeID] = validationErrors
+	}
+}
+
+// DryRunPayroll calculates the whole run in a sandbox, storing no
+// components, and keeps the result as the run's next dry run. Dry runs may be
+// requested by a user or by the system. The result lists validation findings
+// and what changed since the previous dry run. Cancelling through progress
+// stops the dry run and keeps nothing.
+func (s *PayrollService) DryRunPayroll(payrollRunID, stateCode, requestedBy, comment string, progress JobProgress) (*DryRunResult, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.checkRunTransition(pr, RunActionDryRun, requestedBy); err != nil {
+		return nil, err
+	}
+
+	sandbox := newDryRunSandbox()
+	var outcomes []models.PayrollRunOutcome
+	if pr.RunType == RunTypeReversal {
+		outcomes, err = s.reverseEmployees(pr, requestedBy, nil, sandbox)
+	} else {
+		var rc *runCalculation
+		var employees []models.Employee
+		rc, employees, err = s.prepareRunCalculation(pr, pr.OrgID, stateCode, requestedBy, nil)
+		if err != nil {
+			return nil, err
+		}
+		rc.progress = progress
+		rc.sandbox = sandbox
+		outcomes, err = s.calculateEmployees(rc, employees)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	dr, components, findings, err := dryRunRecord(pr, requestedBy, outcomes, sandbox)
+	if err != nil {
+		return nil, err
+	}
+
+	// The run moves to dry_run and counts the dry run as it is stored
+	ok, err := s.dryRunRepo.CreatePayrollDryRun(dr, pr.Status, comment)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, conflict("payroll run %s changed status while trying to %s it; reload and retry", pr.ID, RunActionDryRun)
+	}
+	pr.Status = RunStatusDryRun
+	pr.DryRunCount = dr.DryRunNumber
+
+	return s.dryRunResult(dr, components, findings)
+}
+
+// GetPayrollDryRuns lists a run's dry runs, latest first
+func (s *PayrollService) GetPayrollDryRuns(payrollRunID string) ([]models.PayrollDryRun, error) {
+	if _, err := s.repo.GetPayrollRunByID(payrollRunID); err != nil {
+		return nil, err
+	}
+
+	return s.dryRunRepo.GetPayrollDryRuns(payrollRunID)
+}
+
+// GetPayrollDryRun fetches a dry run of a run with its components, findings
+// and the diff against the dry run before it
+func (s *PayrollService) GetPayrollDryRun(payrollRunID string, dryRunNumber int) (*DryRunResult, error) {
+	dr, err := s.dryRunRepo.GetPayrollDryRun(payrollRunID, dryRunNumber)
+	if err != nil {
+		return nil, err
+	}
+
+	var components []models.PayrollComponent
+	if err := json.Unmarshal([]byte(dr.Snapshot), &components); err != nil {
+		return nil, fmt.Errorf("failed to read dry run snapshot: %w", err)
+	}
+	var findings []DryRunFinding
+	if err := json.Unmarshal([]byte(dr.Findings), &findings); err != nil {
+		return nil, fmt.Errorf("failed to read dry run findings: %w", err)
+	}
+
+	return s.dryRunResult(dr, components, findings)
+}
+
+// dryRunResult completes a dry run's result with the diff against the
+// previous dry run of the run
+func (s *PayrollService) dryRunResult(dr *models.PayrollDryRun, components []models.PayrollComponent, findings []DryRunFinding) (*DryRunResult, error) {
+	result := &DryRunResult{DryRun: *dr, Components: components, Findings: findings}
+
+	previous, err := s.dryRunRepo.GetPreviousPayrollDryRun(dr.PayrollRunID, dr.DryRunNumber)
+	if err != nil || previous == nil {
+		return result, err
+	}
+
+	var before []models.PayrollComponent
+	if err := json.Unmarshal([]byte(previous.Snapshot), &before); err != nil {
+		return nil, fmt.Errorf("failed to read previous dry run snapshot: %w", err)
+	}
+
+	diff := diffComponents("", dr.PayrollRunID, before, components)
+	result.Diff = &DryRunDiff{
+		PreviousDryRunNumber: previous.DryRunNumber,
+		NetPayBefore:         diff.NetPayBefore,
+		NetPayAfter:          diff.NetPayAfter,
+		Employees:            diff.Employees,
+	}
+	return result, nil
+}
+
+// dryRunRecord builds a dry run from its outcomes and the components kept in
+// its sandbox. Only the components of employees calculated successfully are
+// part of the result; the validation errors of the others are in their
+// findings.
+func dryRunRecord(pr *models.PayrollRun, requestedBy string, outcomes []models.PayrollRunOutcome, sandbox *dryRunSandbox) (*models.PayrollDryRun, []models.PayrollComponent, []DryRunFinding, error) {
+	dr := &models.PayrollDryRun{
+		OrgID:        pr.OrgID,
+		PayrollRunID: pr.ID,
+		RequestedBy:  requestedBy,
+	}
+
+	components := []models.PayrollComponent{}
+	findings := []DryRunFinding{}
+	for _, o := range outcomes {
+		validation := sandbox.validation[o.EmployeeID]
+		switch o.Status {
+		case OutcomeSuccess:
+			dr.EmployeesSucceeded++
+			pc := sandbox.components[o.EmployeeID]
+			components = append(components, *pc)
+			dr.TotalGrossAmount += pc.GrossAmount
+			dr.TotalDeductions += pc.TotalDeductions
+			dr.TotalNetPay += pc.NetPay
+		case OutcomeSkipped:
+			dr.EmployeesSkipped++
+		case OutcomeFailed:
+			dr.EmployeesFailed++
+		}
+
+		if o.Status != OutcomeSuccess || len(validation) > 0 {
+			findings = append(findings, DryRunFinding{
+				EmployeeID:   o.EmployeeID,
+				Status:       o.Status,
+				ErrorCode:    o.ErrorCode,
+				ErrorMessage: o.ErrorMessage,
+				Validation:   validation,
+			})
+		}
+	}
+
+	sort.Slice(components, func(i, j int) bool { return components[i].EmployeeID < components[j].EmployeeID })
+	sort.Slice(findings, func(i, j int) bool { return findings[i].EmployeeID < findings[j].EmployeeID })
+
+	dr.TotalGrossAmount = math.Round(dr.TotalGrossAmount*100) / 100
+	dr.TotalDeductions = math.Round(dr.TotalDeductions*100) / 100
+	dr.TotalNetPay = math.Round(dr.TotalNetPay*100) / 100
+
+	snapshot, err := json.Marshal(components)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to snapshot dry run components: %w", err)
+	}
+	findingsJSON, err := json.Marshal(findings)
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to encode dry run findings: %w", err)
+	}
+	dr.Snapshot = string(snapshot)
+	dr.Findings = string(findingsJSON)
+
+	return dr, components, findings, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"payroll-service/internal/models"
+)
+
+// initiationPayload is the payload of a payroll_initiate job
+type initiationPayload struct {
+	PayrollRunID string `json:"payroll_run_id"`
+	StateCode    string `json:"state_code"`
+	InitiatedBy  string `json:"initiated_by"`
+	Comment      string `json:"comment"`
+}
+
+// recalculationPayload is the payload of a payroll_recalculate job
+type recalculationPayload struct {
+	PayrollRunID   string   `json:"payroll_run_id"`
+	StateCode      string   `json:"state_code"`
+	RecalculatedBy string   `json:"recalculated_by"`
+	EmployeeIDs    []string `json:"employee_ids"`
+}
+
+// dryRunPayload is the payload of a payroll_dry_run job
+type dryRunPayload struct {
+	PayrollRunID string `json:"payroll_run_id"`
+	StateCode    string `json:"state_code"`
+	RequestedBy  string `json:"requested_by"`
+	Comment      string `json:"comment"`
+}
+
+// EnqueuePayrollInitiation queues the initiation of a run after checking it
+// can be initiated. Only one calculation of a run is queued or running at a
+// time.
+func (s *PayrollService) EnqueuePayrollInitiation(orgID, payrollRunID, stateCode, initiatedBy, comment string) (*models.Job, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+	if pr.OrgID != orgID {
+		return nil, invalidInput("payroll run %s does not belong to organization %s", pr.ID, orgID)
+	}
+
+	if _, err := s.checkRunTransition(pr, RunActionInitiate, initiatedBy); err != nil {
+		return nil, err
+	}
+
+	payload := initiationPayload{PayrollRunID: pr.ID, StateCode: stateCode, InitiatedBy: initiatedBy, Comment: comment}
+	return enqueueJob(s.jobRepo, pr.OrgID, JobTypePayrollInitiate, runLockKey(pr.ID), payload, initiatedBy)
+}
+
+// EnqueueRecalculation queues the recalculation of some employees of a run
+// after checking they can be recalculated
+func (s *PayrollService) EnqueueRecalculation(payrollRunID, stateCode, recalculatedBy string, employeeIDs []string) (*models.Job, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, _, _, err := s.recalculationScope(pr, recalculatedBy, employeeIDs); err != nil {
+		return nil, err
+	}
+
+	payload := recalculationPayload{PayrollRunID: pr.ID, StateCode: stateCode, RecalculatedBy: recalculatedBy, EmployeeIDs: employeeIDs}
+	return enqueueJob(s.jobRepo, pr.OrgID, JobTypePayrollRecalculate, runLockKey(pr.ID), payload, recalculatedBy)
+}
+
+// EnqueueDryRun queues a dry run of a run after checking one is allowed
+func (s *PayrollService) EnqueueDryRun(payrollRunID, stateCode, requestedBy, comment string) (*models.Job, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.checkRunTransition(pr, RunActionDryRun, requestedBy); err != nil {
+		return nil, err
+	}
+
+	payload := dryRunPayload{PayrollRunID: pr.ID, StateCode: stateCode, RequestedBy: requestedBy, Comment: comment}
+	return enqueueJob(s.jobRepo, pr.OrgID, JobTypePayrollDryRun, runLockKey(pr.ID), payload, requestedBy)
+}
+
+// JobHandlers returns the handlers of payroll calculation jobs
+func (s *PayrollService) JobHandlers() map[string]JobHandler {
+	return map[string]JobHandler{
+		JobTypePayrollInitiate: func(job *models.Job, progress JobProgress) (interface{}, error) {
+			var p initiationPayload
+			if err := json.Unmarshal(job.Payload, &p); err != nil {
+				return nil, invalidInput("invalid %s payload: %v", job.JobType, err)
+			}
+			return s.InitiatePayrollRun(job.OrgID, p.PayrollRunID, p.StateCode, p.InitiatedBy, p.Comment, progress)
+		},
+		JobTypePayrollRecalculate: func(job *models.Job, progress JobProgress) (interface{}, error) {
+			var p recalculationPayload
+			if err := json.Unmarshal(job.Payload, &p); err != nil {
+				return nil, invalidInput("invalid %s payload: %v", job.JobType, err)
+			}
+			return s.RecalculateEmployees(p.PayrollRunID, p.StateCode, p.RecalculatedBy, p.EmployeeIDs, progress)
+		},
+		JobTypePayrollDryRun: func(job *models.Job, progress JobProgress) (interface{}, error) {
+			var p dryRunPayload
+			if err := json.Unmarshal(job.Payload, &p); err != nil {
+				return nil, invalidInput("invalid %s payload: %v", job.JobType, err)
+			}
+			return s.DryRunPayroll(p.PayrollRunID, p.StateCode, p.RequestedBy, p.Comment, progress)
+		},
+	}
+}
+
+// requireNoActiveCalculation guards transitions that would be undone by a
+// queued or running job calculating the run
+func requireNoActiveCalculation(s *PayrollService, pr *models.PayrollRun) error {
+	job, err := s.jobRepo.GetActiveJob(runLockKey(pr.ID))
+	if err != nil {
+		return err
+	}
+	if job != nil {
+		return conflict("%s job %s is %s for payroll run %s; wait for it to finish or cancel it", job.JobType, job.ID, job.Status, pr.ID)
+	}
+	return nil
+}
+
+// runLockKey keeps jobs that calculate a run from overlapping
+func runLockKey(payrollRunID string) string {
+	return fmt.Sprintf("payroll_run:%s", payrollRunID)
+}
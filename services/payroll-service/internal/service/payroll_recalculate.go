@@ -0,0 +1,193 @@
+package service
+
+import (
+	"fmt"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+)
+
+// RecalculationResult reports a recalculation of some employees of a run
+type RecalculationResult struct {
+	PayrollRunID string             `json:"payroll_run_id"`
+	Recalculated int                `json:"recalculated"`
+	Failed       int                `json:"failed"`         // See the run's outcomes for why
+	Run          *models.PayrollRun `json:"run"`            // With updated totals
+	Diff         *ReopenDiff        `json:"diff,omitempty"` // Against the locked snapshot, for reopened runs
+}
+
+// RecalculateEmployees recalculates selected employees of an in-progress or
+// reopened run, replacing their components and keeping manual overrides. In a
+// reopened run only the employees of the approved reopen request can be
+// recalculated, all of them when employeeIDs is empty.
+func (s *PayrollService) RecalculateEmployees(payrollRunID, stateCode, recalculatedBy string, employeeIDs []string, progress JobProgress) (*RecalculationResult, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	transition, reopen, only, err := s.recalculationScope(pr, recalculatedBy, employeeIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	counts, err := s.calculateRun(pr, pr.OrgID, stateCode, recalculatedBy, only, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	comment := fmt.Sprintf("Recalculated %d employees", counts.Succeeded)
+	if reopen != nil {
+		comment += " for reopen request " + reopen.ID
+	}
+	if err := s.applyRunTransition(pr, RunActionRecalc, transition.target(pr.Status), recalculatedBy, comment); err != nil {
+		return nil, err
+	}
+
+	result := &RecalculationResult{PayrollRunID: pr.ID, Recalculated: counts.Succeeded, Failed: counts.Failed}
+	if result.Run, err = s.repo.GetPayrollRunByID(pr.ID); err != nil {
+		return nil, err
+	}
+	if reopen != nil {
+		if result.Diff, err = s.reopenDiff(reopen, pr.ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// recalculationScope checks a run can be recalculated and resolves the
+// employees to recalculate, with the reopen request that covers them in a
+// reopened run
+func (s *PayrollService) recalculationScope(pr *models.PayrollRun, recalculatedBy string, employeeIDs []string) (runTransition, *models.PayrollReopenRequest, map[string]bool, error) {
+	transition, err := s.checkRunTransition(pr, RunActionRecalc, recalculatedBy)
+	if err != nil {
+		return runTransition{}, nil, nil, err
+	}
+
+	var reopen *models.PayrollReopenRequest
+	if pr.Status == RunStatusReopened {
+		reopen, err = s.approvedReopenRequest(pr)
+		if err != nil {
+			return runTransition{}, nil, nil, err
+		}
+		if len(employeeIDs) == 0 {
+			employeeIDs = reopen.EmployeeIDs
+		}
+	}
+
+	if len(employeeIDs) == 0 {
+		return runTransition{}, nil, nil, invalidInput("employee_ids is required; initiate the run to calculate everyone")
+	}
+
+	only := map[string]bool{}
+	for _, id := range employeeIDs {
+		if reopen != nil && !coversEmployee(reopen, id) {
+			return runTransition{}, nil, nil, forbidden("employee %s is not covered by reopen request %s", id, reopen.ID)
+		}
+		only[id] = true
+	}
+
+	return transition, reopen, only, nil
+}
+
+// GetComponentOverrides lists the manual overrides of a run
+func (s *PayrollService) GetComponentOverrides(payrollRunID string) ([]models.PayrollComponentOverride, error) {
+	if _, err := s.repo.GetPayrollRunByID(payrollRunID); err != nil {
+		return nil, err
+	}
+	return s.overrideRepo.GetComponentOverrides(payrollRunID)
+}
+
+// SaveComponentOverride validates and stores an employee's manual overrides.
+// They take effect when the employee is next calculated.
+func (s *PayrollService) SaveComponentOverride(o *models.PayrollComponentOverride) error {
+	if o.Reason == "" {
+		return invalidInput("a reason is required for a manual override")
+	}
+	if o.UnpaidLeaveDays == nil && o.AdvanceRecovery == nil && o.LoanRecovery == nil &&
+		o.OtherDeductions == nil && o.CourtOrderDeduction == nil {
+		return invalidInput("at least one override value is required")
+	}
+	if o.UnpaidLeaveDays != nil && *o.UnpaidLeaveDays < 0 {
+		return invalidInput("unpaid_leave_days cannot be negative")
+	}
+	for _, amount := range []*float64{o.AdvanceRecovery, o.LoanRecovery, o.OtherDeductions, o.CourtOrderDeduction} {
+		if amount != nil && *amount < 0 {
+			return invalidInput("override amounts cannot be negative")
+		}
+	}
+
+	pr, err := s.editableRun(o.PayrollRunID, o.EmployeeID)
+	if err != nil {
+		return err
+	}
+	if pr.RunType == RunTypeReversal {
+		return invalidInput("reversal runs copy the reversed components and take no overrides")
+	}
+
+	if o.UnpaidLeaveDays != nil && *o.UnpaidLeaveDays > daysInPayrollMonth(pr.PayrollMonth) {
+		return invalidInput("unpaid_leave_days cannot exceed the days in %s", pr.PayrollMonth)
+	}
+
+	o.OrgID = pr.OrgID
+	return s.overrideRepo.UpsertComponentOverride(o)
+}
+
+// DeleteComponentOverride removes an employee's manual overrides; the
+// calculated inputs apply again from the next calculation
+func (s *PayrollService) DeleteComponentOverride(payrollRunID, employeeID string) error {
+	if _, err := s.editableRun(payrollRunID, employeeID); err != nil {
+		return err
+	}
+	return s.overrideRepo.DeleteComponentOverride(payrollRunID, employeeID)
+}
+
+// editableRun loads a run whose inputs may still change for the employee:
+// one not yet finalized, or reopened for that employee
+func (s *PayrollService) editableRun(payrollRunID, employeeID string) (*models.PayrollRun, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case RunStatusDraft, RunStatusInProgress, RunStatusDryRun:
+		return pr, nil
+	case RunStatusReopened:
+		reopen, err := s.approvedReopenRequest(pr)
+		if err != nil {
+			return nil, err
+		}
+		if !coversEmployee(reopen, employeeID) {
+			return nil, forbidden("employee %s is not covered by reopen request %s", employeeID, reopen.ID)
+		}
+		return pr, nil
+	default:
+		return nil, conflict("payroll run %s is %s; its inputs can no longer change", pr.ID, pr.Status)
+	}
+}
+
+// applyComponentOverride replaces calculated inputs with an employee's manual
+// overrides
+func applyComponentOverride(input *calculator.PayrollInput, o *models.PayrollComponentOverride) {
+	if o == nil {
+		return
+	}
+	if o.UnpaidLeaveDays != nil {
+		input.UnpaidLeaveDays = *o.UnpaidLeaveDays
+	}
+	if o.AdvanceRecovery != nil {
+		input.AdvanceRecovery = *o.AdvanceRecovery
+	}
+	if o.LoanRecovery != nil {
+		input.LoanRecovery = *o.LoanRecovery
+	}
+	if o.OtherDeductions != nil {
+		input.OtherDeductions = *o.OtherDeductions
+	}
+	if o.CourtOrderDeduction != nil {
+		input.CourtOrderDeduction = *o.CourtOrderDeduction
+	}
+}
@@ -0,0 +1,398 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+
+	"payroll-service/internal/models"
+)
+
+// Reopen request statuses
+const (
+	ReopenPending   = "pending"
+	ReopenApproved  = "approved"
+	ReopenRejected  = "rejected"
+	ReopenCompleted = "completed" // Run approved and locked again
+)
+
+// ComponentChange is an amount of an employee's component that differs from
+// the locked snapshot
+type ComponentChange struct {
+	Field      string  `json:"field"`
+	Before     float64 `json:"before"`
+	After      float64 `json:"after"`
+	Difference float64 `json:"difference"`
+}
+
+// EmployeeDiff lists the changes to one employee's component
+type EmployeeDiff struct {
+	EmployeeID string            `json:"employee_id"`
+	Added      bool              `json:"added,omitempty"`   // No component when the run was locked
+	Removed    bool              `json:"removed,omitempty"` // Component no longer in the run
+	Changes    []ComponentChange `json:"changes"`
+}
+
+// ReopenDiff compares a reopened run's components with the snapshot taken
+// when it was unlocked
+type ReopenDiff struct {
+	ReopenRequestID string         `json:"reopen_request_id"`
+	PayrollRunID    string         `json:"payroll_run_id"`
+	NetPayBefore    float64        `json:"net_pay_before"`
+	NetPayAfter     float64        `json:"net_pay_after"`
+	Employees       []EmployeeDiff `json:"employees"` // Only employees with changes
+}
+
+// diffFields are the component amounts compared against the snapshot
+var diffFields = []struct {
+	name  string
+	value func(pc *models.PayrollComponent) float64
+}{
+	{"basic_pay", func(pc *models.PayrollComponent) float64 { return pc.BasicPay }},
+	{"dearness_allowance", func(pc *models.PayrollComponent) float64 { return pc.DAAmount }},
+	{"house_rent_allowance", func(pc *models.PayrollComponent) float64 { return pc.HRAAmount }},
+	{"other_allowances", func(pc *models.PayrollComponent) float64 { return pc.OtherAllowances }},
+	{"shift_allowance", func(pc *models.PayrollComponent) float64 { return pc.ShiftAllowance }},
+	{"lop_amount", func(pc *models.PayrollComponent) float64 { return pc.LOPAmount }},
+	{"gross_amount", func(pc *models.PayrollComponent) float64 { return pc.GrossAmount }},
+	{"pf_employee", func(pc *models.PayrollComponent) float64 { return pc.PFEmployee }},
+	{"pf_employer", func(pc *models.PayrollComponent) float64 { return pc.PFEmployer }},
+	{"esi_employee", func(pc *models.PayrollComponent) float64 { return pc.ESIEmployee }},
+	{"esi_employer", func(pc *models.PayrollComponent) float64 { return pc.ESIEmployer }},
+	{"professional_tax", func(pc *models.PayrollComponent) float64 { return pc.ProfessionalTax }},
+	{"tds", func(pc *models.PayrollComponent) float64 { return pc.TDS }},
+	{"total_deductions", func(pc *models.PayrollComponent) float64 { return pc.TotalDeductions }},
+	{"net_pay", func(pc *models.PayrollComponent) float64 { return pc.NetPay }},
+}
+
+// RequestReopen asks to reopen a locked run so the components of the given
+// employees can be corrected
+func (s *PayrollService) RequestReopen(payrollRunID, requestedBy, reason string, employeeIDs []string) (*models.PayrollReopenRequest, error) {
+	if reason == "" {
+		return nil, invalidInput("a reason is required to reopen a payroll run")
+	}
+	if len(employeeIDs) == 0 {
+		return nil, invalidInput("at least one employee to recalculate is required")
+	}
+
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only locked runs can be reopened; released runs have been paid
+	if _, err := s.checkRunTransition(pr, RunActionReopen, requestedBy); err != nil {
+		return nil, err
+	}
+	if err := requireNoBankFile(pr); err != nil {
+		return nil, err
+	}
+
+	open, err := s.reopenRepo.GetOpenReopenRequest(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	if open != nil {
+		return nil, conflict("payroll run %s already has a %s reopen request %s", pr.ID, open.Status, open.ID)
+	}
+
+	components, err := s.repo.GetPayrollComponents(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	inRun := map[string]bool{}
+	for _, comp := range components {
+		inRun[comp.EmployeeID] = true
+	}
+
+	seen := map[string]bool{}
+	var employees []string
+	for _, id := range employeeIDs {
+		if !inRun[id] {
+			return nil, invalidInput("employee %s has no component in payroll run %s", id, pr.ID)
+		}
+		if !seen[id] {
+			seen[id] = true
+			employees = append(employees, id)
+		}
+	}
+
+	req := &models.PayrollReopenRequest{
+		OrgID:        pr.OrgID,
+		PayrollRunID: pr.ID,
+		Reason:       reason,
+		EmployeeIDs:  employees,
+		RequestedBy:  requestedBy,
+	}
+
+	if err := s.reopenRepo.CreateReopenRequest(req); err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// GetReopenRequests lists a run's reopen requests, newest first
+func (s *PayrollService) GetReopenRequests(payrollRunID string) ([]models.PayrollReopenRequest, error) {
+	if _, err := s.repo.GetPayrollRunByID(payrollRunID); err != nil {
+		return nil, err
+	}
+	return s.reopenRepo.GetReopenRequests(payrollRunID)
+}
+
+// ApproveReopenRequest approves a pending request: the locked components are
+// snapshotted, the run's lock is deactivated and the run is reopened
+func (s *PayrollService) ApproveReopenRequest(requestID, approvedBy, comment string) (*models.PayrollReopenRequest, error) {
+	req, pr, err := s.pendingReopenRequest(requestID, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	t, err := s.checkRunTransition(pr, RunActionReopen, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := requireNoBankFile(pr); err != nil {
+		return nil, err
+	}
+
+	components, err := s.repo.GetPayrollComponents(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	snapshotJSON, err := json.Marshal(components)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal payroll run snapshot: %w", err)
+	}
+	snapshot := string(snapshotJSON)
+
+	req.Status = ReopenApproved
+	req.DecidedBy = &approvedBy
+	req.Snapshot = &snapshot
+	if comment != "" {
+		req.DecisionComment = &comment
+	}
+
+	// The approval itself satisfies the reopen guard, so the decision, the
+	// transition and the unlock are applied together
+	ok, err := s.reopenRepo.ApproveReopenRequest(req, pr.Status, t.target(pr.Status), RunActionReopen, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, conflict("reopen request %s has already been decided or payroll run %s changed status or was sent to the bank; reload and retry", req.ID, pr.ID)
+	}
+	pr.Status = t.target(pr.Status)
+
+	return req, nil
+}
+
+// RejectReopenRequest rejects a pending request, leaving the run locked
+func (s *PayrollService) RejectReopenRequest(requestID, rejectedBy, comment string) (*models.PayrollReopenRequest, error) {
+	if comment == "" {
+		return nil, invalidInput("a comment is required to reject a reopen request")
+	}
+
+	req, _, err := s.pendingReopenRequest(requestID, rejectedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Status = ReopenRejected
+	req.DecidedBy = &rejectedBy
+	req.DecisionComment = &comment
+
+	ok, err := s.reopenRepo.DecideReopenRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, conflict("reopen request %s has already been decided", req.ID)
+	}
+
+	return req, nil
+}
+
+// pendingReopenRequest loads a request awaiting a decision and checks that
+// actor may decide it: someone other than the requester and, when the
+// organization has an approval chain, one of its approvers
+func (s *PayrollService) pendingReopenRequest(requestID, actor string) (*models.PayrollReopenRequest, *models.PayrollRun, error) {
+	if actor == "" {
+		return nil, nil, invalidInput("an approver is required to decide a reopen request")
+	}
+
+	req, err := s.reopenRepo.GetReopenRequestByID(requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if req.Status != ReopenPending {
+		return nil, nil, conflict("reopen request %s is %s", req.ID, req.Status)
+	}
+	if req.RequestedBy == actor {
+		return nil, nil, forbidden("%s requested reopen request %s and cannot decide it", actor, req.ID)
+	}
+
+	pr, err := s.repo.GetPayrollRunByID(req.PayrollRunID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	levels, err := s.approvalRepo.GetApprovalLevels(pr.OrgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(levels) > 0 {
+		allowed := false
+		for _, l := range levels {
+			for _, id := range l.ApproverIDs {
+				if id == actor {
+					allowed = true
+				}
+			}
+		}
+		if !allowed {
+			return nil, nil, forbidden("%s is not a payroll approver", actor)
+		}
+	}
+
+	return req, pr, nil
+}
+
+// GetReopenDiff compares a run's components with the snapshot taken when the
+// reopen request was approved
+func (s *PayrollService) GetReopenDiff(requestID string) (*ReopenDiff, error) {
+	req, err := s.reopenRepo.GetReopenRequestByID(requestID)
+	if err != nil {
+		return nil, err
+	}
+	return s.reopenDiff(req, req.PayrollRunID)
+}
+
+func (s *PayrollService) reopenDiff(req *models.PayrollReopenRequest, payrollRunID string) (*ReopenDiff, error) {
+	if req.Snapshot == nil {
+		return nil, conflict("reopen request %s is %s and has no snapshot", req.ID, req.Status)
+	}
+
+	var before []models.PayrollComponent
+	if err := json.Unmarshal([]byte(*req.Snapshot), &before); err != nil {
+		return nil, fmt.Errorf("failed to read payroll run snapshot: %w", err)
+	}
+
+	after, err := s.repo.GetPayrollComponents(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	return diffComponents(req.ID, payrollRunID, before, after), nil
+}
+
+// diffComponents lists the employees whose components differ between two
+// versions of a run
+func diffComponents(requestID, payrollRunID string, before, after []models.PayrollComponent) *ReopenDiff {
+	diff := &ReopenDiff{
+		ReopenRequestID: requestID,
+		PayrollRunID:    payrollRunID,
+		Employees:       []EmployeeDiff{},
+	}
+
+	previous := map[string]*models.PayrollComponent{}
+	for i := range before {
+		previous[before[i].EmployeeID] = &before[i]
+		diff.NetPayBefore += before[i].NetPay
+	}
+
+	var zero models.PayrollComponent
+	current := map[string]bool{}
+	for i := range after {
+		pc := &after[i]
+		current[pc.EmployeeID] = true
+		diff.NetPayAfter += pc.NetPay
+
+		old, ok := previous[pc.EmployeeID]
+		if !ok {
+			old = &zero
+		}
+		if changes := componentChanges(old, pc); len(changes) > 0 || !ok {
+			diff.Employees = append(diff.Employees, EmployeeDiff{EmployeeID: pc.EmployeeID, Added: !ok, Changes: changes})
+		}
+	}
+
+	for i := range before {
+		if !current[before[i].EmployeeID] {
+			diff.Employees = append(diff.Employees, EmployeeDiff{
+				EmployeeID: before[i].EmployeeID,
+				Removed:    true,
+				Changes:    componentChanges(&before[i], &zero),
+			})
+		}
+	}
+
+	sort.Slice(diff.Employees, func(i, j int) bool { return diff.Employees[i].EmployeeID < diff.Employees[j].EmployeeID })
+	diff.NetPayBefore = math.Round(diff.NetPayBefore*100) / 100
+	diff.NetPayAfter = math.Round(diff.NetPayAfter*100) / 100
+	return diff
+}
+
+func componentChanges(before, after *models.PayrollComponent) []ComponentChange {
+	changes := []ComponentChange{}
+	for _, f := range diffFields {
+		b, a := f.value(before), f.value(after)
+		if math.Abs(a-b) >= 0.005 {
+			changes = append(changes, ComponentChange{
+				Field:      f.name,
+				Before:     b,
+				After:      a,
+				Difference: math.Round((a-b)*100) / 100,
+			})
+		}
+	}
+	return changes
+}
+
+// requireApprovedReopen guards reopening: the run needs an approved reopen
+// request
+func requireApprovedReopen(s *PayrollService, pr *models.PayrollRun) error {
+	_, err := s.approvedReopenRequest(pr)
+	return err
+}
+
+// requireNoBankFile refuses to reopen a run whose salaries were sent to the
+// bank; corrections to paid salaries go through a supplementary or reversal
+// run
+func requireNoBankFile(pr *models.PayrollRun) error {
+	if pr.BankFileGeneratedAt != nil {
+		return conflict("payroll run %s was sent to the bank on %s; correct it with a supplementary or reversal run",
+			pr.ID, pr.BankFileGeneratedAt.Format("2006-01-02"))
+	}
+	return nil
+}
+
+// approvedReopenRequest fetches the approved reopen request of a run
+func (s *PayrollService) approvedReopenRequest(pr *models.PayrollRun) (*models.PayrollReopenRequest, error) {
+	req, err := s.reopenRepo.GetOpenReopenRequest(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	if req == nil || req.Status != ReopenApproved {
+		return nil, conflict("payroll run %s has no approved reopen request", pr.ID)
+	}
+	return req, nil
+}
+
+func coversEmployee(req *models.PayrollReopenRequest, employeeID string) bool {
+	for _, id := range req.EmployeeIDs {
+		if id == employeeID {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPayrollLockEvents lists a run's lock and unlock history
+func (s *PayrollService) GetPayrollLockEvents(payrollRunID string) ([]models.PayrollLockEvent, error) {
+	if _, err := s.repo.GetPayrollRunByID(payrollRunID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPayrollLockEvents(payrollRunID)
+}
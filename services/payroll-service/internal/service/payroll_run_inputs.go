@@ -0,0 +1,158 @@
+package service
+
+import (
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+)
+
+// runInputs holds what a run's employees are calculated with from other runs
+// and modules, loaded once for the whole run and keyed by employee ID.
+// Employees with nothing to carry in are missing from the maps.
+type runInputs struct {
+	calendars           map[string]*calculator.WorkingCalendar // Keyed by location
+	lopReversals        map[string][]calculator.LOPReversal
+	deductions          map[string][]models.EmployeeDeduction
+	deductionBalances   map[string]map[string]float64
+	roundingBalances    map[string]float64
+	shifts              map[string][]models.RosterShift
+	monthToDate         map[string]*models.MonthToDateTotals
+	nps                 map[string]*models.NPSEnrolment
+	retirementYTD       map[string]float64
+	fbpDeclarations     map[string][]models.FBPDeclaration
+	fbpClaims           map[string][]models.FBPClaim
+	fbpBalances         map[string]float64
+	perquisites         map[string][]models.EquityEvent
+	deferredPerquisites map[string][]models.EquityEvent
+	taxableIncomeYTD    map[string]float64
+	tdsYTD              map[string]float64
+	previousEmployments map[string][]models.PreviousEmployment
+}
+
+// loadRunInputs loads the inputs of the given employees of a run with one
+// query per input rather than per employee. Salary inputs (LOP reversals,
+// shifts, flexible benefits and equity) are skipped for bonus runs, and
+// deduction schedules are raised by regular runs only. Working calendars are
+// loaded when workingDays is set, for employees without attendance.
+func (s *PayrollService) loadRunInputs(pr *models.PayrollRun, orgID string, employees []models.Employee, shiftPolicies, workingDays bool) (*runInputs, error) {
+	in := &runInputs{}
+	if len(employees) == 0 {
+		return in, nil
+	}
+
+	var employeeIDs, exitingIDs []string
+	for _, emp := range employees {
+		employeeIDs = append(employeeIDs, emp.ID)
+		if finalSettlement(&emp, pr) {
+			exitingIDs = append(exitingIDs, emp.ID)
+		}
+	}
+
+	salary := pr.RunType != RunTypeBonus
+	fyStart := calculator.FinancialYearStart(pr.PayrollPeriodEnd)
+	var err error
+
+	if workingDays && salary {
+		in.calendars, err = s.workingCalendars(orgID, employees, pr.PayrollPeriodStart, pr.PayrollPeriodEnd)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if salary {
+		reversals, err := s.lopRepo.GetPayableLOPReversals(employeeIDs, pr.ID)
+		if err != nil {
+			return nil, err
+		}
+		in.lopReversals, err = s.lopReversalInputs(reversals)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if pr.RunType == RunTypeRegular {
+		in.deductions, err = s.deductionRepo.GetDueEmployeeDeductions(employeeIDs, pr.ID, pr.PayrollMonth)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	in.deductionBalances, err = s.repo.GetDeductionBalances(employeeIDs, pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	in.roundingBalances, err = s.repo.GetRoundingBalances(employeeIDs, pr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if shiftPolicies && salary {
+		shifts, err := s.rosterRepo.GetShifts(orgID, map[string]interface{}{
+			"employee_ids": employeeIDs,
+			"status":       "active",
+			"from":         pr.PayrollPeriodStart,
+			"to":           pr.PayrollPeriodEnd,
+		})
+		if err != nil {
+			return nil, err
+		}
+		in.shifts = map[string][]models.RosterShift{}
+		for _, shift := range shifts {
+			in.shifts[shift.EmployeeID] = append(in.shifts[shift.EmployeeID], shift)
+		}
+	}
+
+	in.monthToDate, err = s.repo.GetMonthToDate(employeeIDs, pr.PayrollMonth, pr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	in.nps, err = s.npsRepo.GetNPSEnrolmentsInForce(employeeIDs, pr.PayrollPeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	in.retirementYTD, err = s.repo.GetEmployerRetirementYTD(employeeIDs, fyStart.Format("2006-01"), pr.PayrollMonth, pr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if salary {
+		in.fbpDeclarations, err = s.fbpRepo.GetEmployeesFBPDeclarations(employeeIDs, fyStart.Year())
+		if err != nil {
+			return nil, err
+		}
+		in.fbpClaims, err = s.fbpRepo.GetPayableFBPClaims(employeeIDs, pr.ID)
+		if err != nil {
+			return nil, err
+		}
+		in.fbpBalances, err = s.fbpRepo.GetFBPBalances(employeeIDs, fyStart.Format("2006-01"), pr.PayrollMonth, pr.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		in.perquisites, err = s.equityRepo.GetPayablePerquisites(employeeIDs, pr.ID, pr.PayrollPeriodEnd)
+		if err != nil {
+			return nil, err
+		}
+		in.deferredPerquisites, err = s.equityRepo.GetDueDeferredPerquisites(employeeIDs, pr.ID, pr.PayrollPeriodEnd, exitingIDs)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	in.taxableIncomeYTD, in.tdsYTD, err = s.repo.GetTaxYTD(employeeIDs, fyStart.Format("2006-01"), pr.PayrollMonth, pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	in.previousEmployments, err = s.prevEmpRepo.GetEmployeesPreviousEmployments(employeeIDs, fyStart.Year())
+	if err != nil {
+		return nil, err
+	}
+
+	return in, nil
+}
+
+// finalSettlement reports whether a run settles an exiting employee, who is
+// paid exactly and whose deferred tax falls due
+func finalSettlement(emp *models.Employee, pr *models.PayrollRun) bool {
+	return emp.DateOfExit != nil && !emp.DateOfExit.After(pr.PayrollPeriodEnd)
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+)
+
+// Outcome statuses of an employee in a run
+const (
+	OutcomeSuccess = "success"
+	OutcomeSkipped = "skipped"
+	OutcomeFailed  = "failed"
+)
+
+// Error codes of skipped and failed outcomes. Critical validation errors
+// report the validator's own code.
+const (
+	OutcomeNotEmployed       = "not_employed"
+	OutcomeNoSalaryStructure = "no_salary_structure"
+	OutcomeInputsUnavailable = "inputs_unavailable"
+	OutcomeCalculationFailed = "calculation_failed"
+	OutcomeSaveFailed        = "save_failed"
+)
+
+// CalculationCounts counts the outcomes of calculating a run's employees
+type CalculationCounts struct {
+	Succeeded int `json:"succeeded"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+}
+
+// calculationWorkers bounds how many employees of a run are calculated at
+// once, and so the database connections a run holds
+const calculationWorkers = 8
+
+// runCalculation holds what every employee of a run is calculated with. It
+// is only read once calculation starts, so workers share it. A dry run's
+// sandbox guards itself.
+type runCalculation struct {
+	pr            *models.PayrollRun
+	orgID         string
+	calculatedBy  string
+	calc          *calculator.PayrollCalculator
+	validator     *calculator.PayrollValidator
+	settings      *models.PayrollSettings
+	shiftPolicies []models.ShiftAllowancePolicy
+	subMonthly    bool
+	selected      map[string]*models.PayrollRunEmployee
+	overrides     map[string]*models.PayrollComponentOverride
+	structures    map[string]*models.SalaryStructure
+	attendance    map[string]*models.AttendanceSummary
+	leave         map[string]*models.LeaveSummary
+	inputs        *runInputs
+	progress      JobProgress
+	sandbox       *dryRunSandbox // Set for dry runs, which store nothing
+}
+
+// calculateEmployees calculates employees on a bounded pool of workers and
+// returns their outcomes in the order given, reporting progress after each.
+// Once the job is cancelled no further employees are started, and only the
+// outcomes of those calculated are returned with ErrJobCancelled.
+func (s *PayrollService) calculateEmployees(rc *runCalculation, employees []models.Employee) ([]models.PayrollRunOutcome, error) {
+	if err := rc.progress.report(0, len(employees)); err != nil {
+		return nil, err
+	}
+
+	outcomes := make([]models.PayrollRunOutcome, len(employees))
+	next := make(chan int)
+
+	var mu sync.Mutex
+	var done int
+	var cancelled error
+
+	var wg sync.WaitGroup
+	for w := 0; w < calculationWorkers && w < len(employees); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				outcomes[i] = s.calculateEmployeeSafely(rc, &employees[i])
+
+				mu.Lock()
+				done++
+				if err := rc.progress.report(done, len(employees)); err != nil && cancelled == nil {
+					cancelled = err
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range employees {
+		mu.Lock()
+		stop := cancelled != nil
+		mu.Unlock()
+		if stop {
+			break
+		}
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+
+	if cancelled != nil {
+		var calculated []models.PayrollRunOutcome
+		for _, o := range outcomes {
+			if o.EmployeeID != "" {
+				calculated = append(calculated, o)
+			}
+		}
+		return calculated, cancelled
+	}
+
+	return outcomes, nil
+}
+
+// calculateEmployeeSafely reports a panic while calculating an employee as a
+// failure rather than taking down the service from a worker
+func (s *PayrollService) calculateEmployeeSafely(rc *runCalculation, emp *models.Employee) (outcome models.PayrollRunOutcome) {
+	defer func() {
+		if r := recover(); r != nil {
+			outcome = failedOutcome(rc.pr, emp.ID, OutcomeCalculationFailed, fmt.Errorf("panic: %v", r))
+		}
+	}()
+	return s.calculateEmployee(rc, emp)
+}
+
+// recordRunOutcomes stores the outcomes of calculating a run, updates the
+// run totals and counts the outcomes. With replaceAll set, the outcomes
+// replace every earlier one of the run.
+func (s *PayrollService) recordRunOutcomes(pr *models.PayrollRun, outcomes []models.PayrollRunOutcome, replaceAll bool) (CalculationCounts, error) {
+	var counts CalculationCounts
+	for _, o := range outcomes {
+		switch o.Status {
+		case OutcomeSuccess:
+			counts.Succeeded++
+		case OutcomeSkipped:
+			counts.Skipped++
+		case OutcomeFailed:
+			counts.Failed++
+		}
+	}
+
+	if err := s.outcomeRepo.SavePayrollRunOutcomes(pr.ID, outcomes, replaceAll); err != nil {
+		return counts, err
+	}
+
+	if err := s.repo.UpdatePayrollRunTotals(pr.ID); err != nil {
+		return counts, err
+	}
+
+	return counts, nil
+}
+
+// GetPayrollRunOutcomes fetches the per-employee outcomes of a run,
+// optionally of one status
+func (s *PayrollService) GetPayrollRunOutcomes(payrollRunID, status string) ([]models.PayrollRunOutcome, error) {
+	if status != "" && status != OutcomeSuccess && status != OutcomeSkipped && status != OutcomeFailed {
+		return nil, invalidInput("status must be success, skipped or failed")
+	}
+
+	if _, err := s.repo.GetPayrollRunByID(payrollRunID); err != nil {
+		return nil, err
+	}
+
+	return s.outcomeRepo.GetPayrollRunOutcomes(payrollRunID, status)
+}
+
+func successOutcome(pr *models.PayrollRun, employeeID, componentID string) models.PayrollRunOutcome {
+	return models.PayrollRunOutcome{
+		OrgID:              pr.OrgID,
+		PayrollRunID:       pr.ID,
+		EmployeeID:         employeeID,
+		Status:             OutcomeSuccess,
+		PayrollComponentID: &componentID,
+	}
+}
+
+func skippedOutcome(pr *models.PayrollRun, employeeID, code, format string, args ...interface{}) models.PayrollRunOutcome {
+	message := fmt.Sprintf(format, args...)
+	return models.PayrollRunOutcome{
+		OrgID:        pr.OrgID,
+		PayrollRunID: pr.ID,
+		EmployeeID:   employeeID,
+		Status:       OutcomeSkipped,
+		ErrorCode:    &code,
+		ErrorMessage: &message,
+	}
+}
+
+func failedOutcome(pr *models.PayrollRun, employeeID, code string, err error) models.PayrollRunOutcome {
+	message := err.Error()
+	return models.PayrollRunOutcome{
+		OrgID:        pr.OrgID,
+		PayrollRunID: pr.ID,
+		EmployeeID:   employeeID,
+		Status:       OutcomeFailed,
+		ErrorCode:    &code,
+		ErrorMessage: &message,
+	}
+}
+
+// validationFailedOutcome reports the critical validation errors of a
+// component under the code of the first
+func validationFailedOutcome(pr *models.PayrollRun, employeeID string, validationErrors []calculator.ValidationError) models.PayrollRunOutcome {
+	var code string
+	var messages []string
+	for _, v := range validationErrors {
+		if v.Severity != "error" {
+			continue
+		}
+		if code == "" {
+			code = v.Code
+		}
+		messages = append(messages, v.Message)
+	}
+	return failedOutcome(pr, employeeID, code, fmt.Errorf("%s", strings.Join(messages, "; ")))
+}
@@ -0,0 +1,248 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"payroll-service/internal/models"
+)
+
+// Payroll run statuses
+const (
+	RunStatusDraft      = "draft"
+	RunStatusDryRun     = "dry_run"
+	RunStatusInProgress = "in_progress"
+	RunStatusFinalized  = "finalized"
+	RunStatusLocked     = "locked"
+	RunStatusReopened   = "reopened"
+	RunStatusReleased   = "released"
+)
+
+// Payroll run lifecycle actions, as recorded in the transition history
+const (
+	RunActionCreate   = "create"
+	RunActionInitiate = "initiate"
+	RunActionDryRun   = "dry_run"
+	RunActionFinalize = "finalize"
+	RunActionApprove  = "approve"
+	RunActionReject   = "reject"
+	RunActionRelease  = "release"
+	RunActionReopen   = "reopen"
+	RunActionRecalc   = "recalculate"
+)
+
+// SystemActor is the actor of automated transitions
+const SystemActor = "system"
+
+// runTransition is one lifecycle action: the statuses it may start from, the
+// status it leads to and an optional guard checked before it is applied
+type runTransition struct {
+	from        []string
+	to          string // Empty when the run stays in its status
+	systemActor bool   // Whether the action may run without a user
+	guard       func(s *PayrollService, pr *models.PayrollRun) error
+}
+
+// runTransitions is the payroll run state machine. Initiating recalculates
+// the run, so it is allowed only before finalization; dry runs calculate the
+// run without storing it, report validation findings rather than being
+// refused over them, and never follow finalization. Finalizing needs valid
+// components and every unexplained variance acknowledged, and neither
+// finalizing nor approving may overlap a job calculating the run. A finalized run is
+// locked once every required level of its approval chain approved it, and
+// goes back to in_progress when any level rejects it. A locked run is
+// reopened only through an approved reopen request; it can then have
+// selected employees recalculated and goes through finalization and approval
+// again. Recalculating employees leaves the run in its status.
+var runTransitions = map[string]runTransition{
+	RunActionInitiate: {
+		from: []string{RunStatusDraft, RunStatusDryRun, RunStatusInProgress},
+		to:   RunStatusInProgress,
+	},
+	RunActionDryRun: {
+		from:        []string{RunStatusDraft, RunStatusInProgress, RunStatusDryRun},
+		to:          RunStatusDryRun,
+		systemActor: true,
+	},
+	RunActionFinalize: {
+		from:  []string{RunStatusInProgress, RunStatusDryRun, RunStatusReopened},
+		to:    RunStatusFinalized,
+		guard: allGuards(requireNoActiveCalculation, requireValidComponents("payroll"), requireAcknowledgedVariances),
+	},
+	RunActionApprove: {
+		from:  []string{RunStatusFinalized},
+		to:    RunStatusLocked,
+		guard: allGuards(requireNoActiveCalculation, requireApprovalChain),
+	},
+	RunActionReject: {
+		from: []string{RunStatusFinalized},
+		to:   RunStatusInProgress,
+	},
+	RunActionRelease: {
+		from: []string{RunStatusLocked},
+		to:   RunStatusReleased,
+	},
+	RunActionReopen: {
+		from:  []string{RunStatusLocked},
+		to:    RunStatusReopened,
+		guard: requireApprovedReopen,
+	},
+	RunActionRecalc: {
+		from: []string{RunStatusInProgress, RunStatusDryRun, RunStatusReopened},
+	},
+}
+
+// target is the status the transition leads to from a status
+func (t runTransition) target(from string) string {
+	if t.to == "" {
+		return from
+	}
+	return t.to
+}
+
+// TransitionError is returned when an action is not allowed from a run's
+// current status
+type TransitionError struct {
+	PayrollRunID string
+	Status       string
+	Action       string
+	Allowed      []string // Statuses reachable from Status
+}
+
+func (e *TransitionError) Error() string {
+	allowed := "none"
+	if len(e.Allowed) > 0 {
+		allowed = strings.Join(e.Allowed, ", ")
+	}
+	return fmt.Sprintf("%s: cannot %s payroll run %s in status %s; allowed next states: %s",
+		ErrConflict, e.Action, e.PayrollRunID, e.Status, allowed)
+}
+
+func (e *TransitionError) Unwrap() error {
+	return ErrConflict
+}
+
+// allowedNextStatuses lists the statuses a run can move to from status
+func allowedNextStatuses(status string) []string {
+	seen := map[string]bool{}
+	var next []string
+	for _, t := range runTransitions {
+		for _, from := range t.from {
+			if to := t.target(from); from == status && !seen[to] {
+				seen[to] = true
+				next = append(next, to)
+			}
+		}
+	}
+	sort.Strings(next)
+	return next
+}
+
+// checkRunTransition verifies that actor may apply action to the run in its
+// current status. The transition's guard is left to transitionRun.
+func (s *PayrollService) checkRunTransition(pr *models.PayrollRun, action, actor string) (runTransition, error) {
+	t, ok := runTransitions[action]
+	if !ok {
+		return runTransition{}, fmt.Errorf("unknown payroll run action %s", action)
+	}
+
+	if actor == "" {
+		return runTransition{}, invalidInput("an actor is required to %s a payroll run", action)
+	}
+	if actor == SystemActor && !t.systemActor {
+		return runTransition{}, invalidInput("%s must be performed by a user", action)
+	}
+
+	allowed := false
+	for _, from := range t.from {
+		if from == pr.Status {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return runTransition{}, &TransitionError{
+			PayrollRunID: pr.ID,
+			Status:       pr.Status,
+			Action:       action,
+			Allowed:      allowedNextStatuses(pr.Status),
+		}
+	}
+
+	return t, nil
+}
+
+// transitionRun checks and applies a lifecycle action, running its guard and
+// recording it in the run's transition history. The status update only
+// succeeds if no one else moved the run in the meantime.
+func (s *PayrollService) transitionRun(pr *models.PayrollRun, action, actor, comment string) error {
+	t, err := s.checkRunTransition(pr, action, actor)
+	if err != nil {
+		return err
+	}
+
+	if t.guard != nil {
+		if err := t.guard(s, pr); err != nil {
+			return err
+		}
+	}
+
+	return s.applyRunTransition(pr, action, t.target(pr.Status), actor, comment)
+}
+
+func (s *PayrollService) applyRunTransition(pr *models.PayrollRun, action, to, actor, comment string) error {
+	ok, err := s.repo.TransitionPayrollRun(pr.ID, pr.Status, to, action, actor, comment)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return conflict("payroll run %s changed status while trying to %s it; reload and retry", pr.ID, action)
+	}
+
+	pr.Status = to
+	return nil
+}
+
+// allGuards checks guards in order, stopping at the first that refuses
+func allGuards(guards ...func(s *PayrollService, pr *models.PayrollRun) error) func(s *PayrollService, pr *models.PayrollRun) error {
+	return func(s *PayrollService, pr *models.PayrollRun) error {
+		for _, guard := range guards {
+			if err := guard(s, pr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+}
+
+// requireValidComponents guards transitions that need a calculated run whose
+// components pass validation
+func requireValidComponents(what string) func(s *PayrollService, pr *models.PayrollRun) error {
+	return func(s *PayrollService, pr *models.PayrollRun) error {
+		components, err := s.repo.GetPayrollComponents(pr.ID)
+		if err != nil {
+			return err
+		}
+		if len(components) == 0 {
+			return conflict("payroll run %s has no calculated components", pr.ID)
+		}
+
+		errors, err := s.ValidatePayroll(pr.ID)
+		if err != nil {
+			return err
+		}
+		if len(errors) > 0 {
+			return conflict("%s validation failed with %d errors", what, len(errors))
+		}
+		return nil
+	}
+}
+
+// GetPayrollRunTransitions returns a run's status history, oldest first
+func (s *PayrollService) GetPayrollRunTransitions(payrollRunID string) ([]models.PayrollRunTransition, error) {
+	if _, err := s.repo.GetPayrollRunByID(payrollRunID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPayrollRunTransitions(payrollRunID)
+}
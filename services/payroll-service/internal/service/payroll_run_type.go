@@ -0,0 +1,351 @@
+package service
+
+import (
+	"fmt"
+	"time"
+
+	"payroll-service/internal/models"
+)
+
+// Payroll run types. A pay group period has one regular run; off-cycle runs
+// share its period and payroll month, and the monthly PF, ESI, PT and TDS
+// limits are applied across all of them.
+const (
+	RunTypeRegular       = "regular"        // Every active employee of the pay group
+	RunTypeSupplementary = "supplementary"  // Missed joiners and corrections; salary for days not yet paid in the month
+	RunTypeBonus         = "bonus"          // Only the selected amounts, no salary
+	RunTypeFullAndFinal  = "full_and_final" // Final settlement of employees who have left
+	RunTypeReversal      = "reversal"       // Negates another run's components
+)
+
+// CreateOffCyclePayrollRun creates a supplementary, bonus, full and final or
+// reversal run for the selected employees. A reversal run takes its period
+// from the run it reverses and covers all of that run's employees not yet
+// reversed when none are selected.
+func (s *PayrollService) CreateOffCyclePayrollRun(orgID, payGroupID, periodKey string, startDate, endDate *time.Time, runType, reversesRunID string, employees []models.PayrollRunEmployee, createdBy string) (*models.PayrollRun, error) {
+	pr := &models.PayrollRun{
+		OrgID:     orgID,
+		RunType:   runType,
+		Status:    RunStatusDraft,
+		CreatedBy: &createdBy,
+	}
+
+	var err error
+	switch runType {
+	case RunTypeSupplementary, RunTypeBonus, RunTypeFullAndFinal:
+		if reversesRunID != "" {
+			return nil, invalidInput("reverses_payroll_run_id is only for reversal runs")
+		}
+		err = s.offCyclePeriod(pr, payGroupID, periodKey, startDate, endDate, employees)
+	case RunTypeReversal:
+		employees, err = s.reversalPeriod(pr, reversesRunID, employees)
+	case RunTypeRegular:
+		return nil, invalidInput("regular runs cover the whole pay group; create them without employees")
+	default:
+		return nil, invalidInput("run_type must be regular, supplementary, bonus, full_and_final or reversal")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.createRun(pr, employees); err != nil {
+		return nil, err
+	}
+
+	return pr, nil
+}
+
+// GetPayrollRunEmployees lists the employees selected for an off-cycle run
+func (s *PayrollService) GetPayrollRunEmployees(payrollRunID string) ([]models.PayrollRunEmployee, error) {
+	if _, err := s.repo.GetPayrollRunByID(payrollRunID); err != nil {
+		return nil, err
+	}
+	return s.repo.GetPayrollRunEmployees(payrollRunID)
+}
+
+// offCyclePeriod resolves the period of a supplementary, bonus or full and
+// final run and checks its selected employees
+func (s *PayrollService) offCyclePeriod(pr *models.PayrollRun, payGroupID, periodKey string, startDate, endDate *time.Time, employees []models.PayrollRunEmployee) error {
+	group, period, err := s.runPeriod(pr.OrgID, payGroupID, periodKey, startDate, endDate)
+	if err != nil {
+		return err
+	}
+
+	pr.PayGroupID = group.ID
+	pr.PeriodKey = period.PeriodKey
+	pr.PayrollPeriodStart = period.PeriodStart
+	pr.PayrollPeriodEnd = period.PeriodEnd
+	pr.PayrollMonth = period.PayrollMonth
+
+	if len(employees) == 0 {
+		return invalidInput("%s runs need at least one employee", pr.RunType)
+	}
+
+	members, err := s.empRepo.GetEmployees(pr.OrgID, map[string]interface{}{
+		"pay_group_id":       group.ID,
+		"include_unassigned": group.IsDefault,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to fetch employees: %w", err)
+	}
+	inGroup := make(map[string]*models.Employee, len(members))
+	for i := range members {
+		inGroup[members[i].ID] = &members[i]
+	}
+
+	for _, e := range employees {
+		emp := inGroup[e.EmployeeID]
+		if emp == nil {
+			return invalidInput("employee %s is not in pay group %s", e.EmployeeID, group.Name)
+		}
+		if pr.RunType == RunTypeBonus && e.Amount == nil {
+			return invalidInput("employee %s needs a bonus amount", e.EmployeeID)
+		}
+		if e.Amount != nil && *e.Amount <= 0 {
+			return invalidInput("amounts must be positive")
+		}
+		if pr.RunType == RunTypeFullAndFinal && (emp.DateOfExit == nil || emp.DateOfExit.After(pr.PayrollPeriodEnd)) {
+			return invalidInput("employee %s has not left by %s", e.EmployeeID, pr.PayrollPeriodEnd.Format("2006-01-02"))
+		}
+	}
+
+	return nil
+}
+
+// reversalPeriod copies the period of the run being reversed and picks the
+// employees to reverse. Only locked and released runs can be reversed, and
+// each employee only once.
+func (s *PayrollService) reversalPeriod(pr *models.PayrollRun, reversesRunID string, employees []models.PayrollRunEmployee) ([]models.PayrollRunEmployee, error) {
+	if reversesRunID == "" {
+		return nil, invalidInput("reverses_payroll_run_id is required for reversal runs")
+	}
+
+	original, err := s.repo.GetPayrollRunByID(reversesRunID)
+	if err != nil {
+		return nil, err
+	}
+	if original.OrgID != pr.OrgID {
+		return nil, invalidInput("payroll run %s belongs to a different organization", original.ID)
+	}
+	if original.RunType == RunTypeReversal {
+		return nil, conflict("payroll run %s is itself a reversal", original.ID)
+	}
+	if original.Status != RunStatusLocked && original.Status != RunStatusReleased {
+		return nil, conflict("payroll run %s is %s; only locked or released runs can be reversed", original.ID, original.Status)
+	}
+
+	pr.ReversesRunID = &original.ID
+	pr.PayGroupID = original.PayGroupID
+	pr.PeriodKey = original.PeriodKey
+	pr.PayrollPeriodStart = original.PayrollPeriodStart
+	pr.PayrollPeriodEnd = original.PayrollPeriodEnd
+	pr.PayrollMonth = original.PayrollMonth
+
+	components, err := s.repo.GetPayrollComponents(original.ID)
+	if err != nil {
+		return nil, err
+	}
+	paid := make(map[string]bool, len(components))
+	for _, pc := range components {
+		paid[pc.EmployeeID] = true
+	}
+
+	reversed, err := s.repo.GetReversedEmployees(original.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(employees) == 0 {
+		for _, pc := range components {
+			if reversed[pc.EmployeeID] == "" {
+				employees = append(employees, models.PayrollRunEmployee{EmployeeID: pc.EmployeeID})
+			}
+		}
+		if len(employees) == 0 {
+			return nil, conflict("every employee of payroll run %s is already reversed", original.ID)
+		}
+		return employees, nil
+	}
+
+	for _, e := range employees {
+		if !paid[e.EmployeeID] {
+			return nil, invalidInput("employee %s was not paid by payroll run %s", e.EmployeeID, original.ID)
+		}
+		if runID := reversed[e.EmployeeID]; runID != "" {
+			return nil, conflict("employee %s is already reversed by payroll run %s", e.EmployeeID, runID)
+		}
+		if e.Amount != nil {
+			return nil, invalidInput("reversal runs take no amounts")
+		}
+	}
+
+	return employees, nil
+}
+
+// runSelection returns the selected employees of an off-cycle run by ID, or
+// nil for a regular run
+func (s *PayrollService) runSelection(pr *models.PayrollRun) (map[string]*models.PayrollRunEmployee, error) {
+	if pr.RunType == RunTypeRegular {
+		return nil, nil
+	}
+
+	employees, err := s.repo.GetPayrollRunEmployees(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	selected := make(map[string]*models.PayrollRunEmployee, len(employees))
+	for i := range employees {
+		selected[employees[i].EmployeeID] = &employees[i]
+	}
+	return selected, nil
+}
+
+// reverseRun stores the negation of the reversed run's component for each
+// selected employee, so month-to-date and year-to-date totals net it off.
+// Recovered and deferred deductions are reversed in the ledger; LOP
+// reversals, flexible benefit claims and equity perquisites stay applied to
+// the original run.
+func (s *PayrollService) reverseRun(pr *models.PayrollRun, reversedBy string, only map[string]bool) (CalculationCounts, error) {
+	outcomes, err := s.reverseEmployees(pr, reversedBy, only, nil)
+	if err != nil {
+		return CalculationCounts{}, err
+	}
+
+	return s.recordRunOutcomes(pr, outcomes, only == nil)
+}
+
+// reverseEmployees negates the original components of a reversal run's
+// selected employees, or just those of only, and reports the outcomes. With
+// a sandbox the reversed components are kept there instead of stored.
+func (s *PayrollService) reverseEmployees(pr *models.PayrollRun, reversedBy string, only map[string]bool, sandbox *dryRunSandbox) ([]models.PayrollRunOutcome, error) {
+	if pr.ReversesRunID == nil {
+		return nil, fmt.Errorf("reversal run %s has no run to reverse", pr.ID)
+	}
+
+	selected, err := s.runSelection(pr)
+	if err != nil {
+		return nil, err
+	}
+	for id := range only {
+		if selected[id] == nil {
+			return nil, invalidInput("employee %s is not selected for reversal run %s", id, pr.ID)
+		}
+	}
+
+	components, err := s.repo.GetPayrollComponents(*pr.ReversesRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	var outcomes []models.PayrollRunOutcome
+	for i := range components {
+		original := &components[i]
+		if selected[original.EmployeeID] == nil || (only != nil && !only[original.EmployeeID]) {
+			continue
+		}
+
+		pc := reversedComponent(original, pr.ID, reversedBy)
+		if sandbox != nil {
+			sandbox.add(pc, nil)
+			outcomes = append(outcomes, successOutcome(pr, original.EmployeeID, ""))
+			continue
+		}
+
+		if err := s.saveReversedComponent(original, pc); err != nil {
+			outcomes = append(outcomes, failedOutcome(pr, original.EmployeeID, OutcomeSaveFailed, err))
+			continue
+		}
+
+		outcomes = append(outcomes, successOutcome(pr, original.EmployeeID, pc.ID))
+	}
+
+	return outcomes, nil
+}
+
+// saveReversedComponent stores a reversal component with the opposite of the
+// original's deduction ledger entries and its rounding adjustment, in one
+// transaction
+func (s *PayrollService) saveReversedComponent(original, pc *models.PayrollComponent) error {
+	tx, err := s.repo.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.repo.UpsertPayrollComponent(tx, pc); err != nil {
+		return err
+	}
+
+	if err := s.repo.ReverseDeductionLedgerEntries(tx, original.ID, pc); err != nil {
+		return err
+	}
+
+	if err := s.repo.RecordRoundingAdjustment(tx, pc); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// reversedComponent negates the days and amounts of a component for a
+// reversal run
+func reversedComponent(pc *models.PayrollComponent, payrollRunID, reversedBy string) *models.PayrollComponent {
+	return &models.PayrollComponent{
+		OrgID:                    pc.OrgID,
+		PayrollRunID:             payrollRunID,
+		EmployeeID:               pc.EmployeeID,
+		SalaryStructureID:        pc.SalaryStructureID,
+		DaysWorked:               -pc.DaysWorked,
+		DaysAbsent:               -pc.DaysAbsent,
+		DaysLeave:                -pc.DaysLeave,
+		DaysInMonth:              pc.DaysInMonth,
+		BasicPay:                 -pc.BasicPay,
+		DAAmount:                 -pc.DAAmount,
+		HRAAmount:                -pc.HRAAmount,
+		OtherAllowances:          -pc.OtherAllowances,
+		ShiftAllowance:           -pc.ShiftAllowance,
+		LOPDays:                  -pc.LOPDays,
+		LOPAmount:                -pc.LOPAmount,
+		LOPReversal:              -pc.LOPReversal,
+		FBPFixed:                 -pc.FBPFixed,
+		FBPClaims:                -pc.FBPClaims,
+		FBPUnclaimedPayout:       -pc.FBPUnclaimedPayout,
+		FBPAccrued:               -pc.FBPAccrued,
+		FBPExempt:                -pc.FBPExempt,
+		OneTimePayment:           -pc.OneTimePayment,
+		GrossAmount:              -pc.GrossAmount,
+		PFEmployee:               -pc.PFEmployee,
+		PFEmployer:               -pc.PFEmployer,
+		ESIEmployee:              -pc.ESIEmployee,
+		ESIEmployer:              -pc.ESIEmployer,
+		ProfessionalTax:          -pc.ProfessionalTax,
+		NPSEmployee:              -pc.NPSEmployee,
+		NPSEmployer:              -pc.NPSEmployer,
+		NPS80CCD2:                -pc.NPS80CCD2,
+		SuperannuationEmployer:   -pc.SuperannuationEmployer,
+		RetirementPerquisite:     -pc.RetirementPerquisite,
+		EquityPerquisite:         -pc.EquityPerquisite,
+		EquityPerquisiteDeferred: -pc.EquityPerquisiteDeferred,
+		EquityDeferredTaxed:      -pc.EquityDeferredTaxed,
+		TDS:                      -pc.TDS,
+		AdvanceRecovery:          -pc.AdvanceRecovery,
+		LoanRecovery:             -pc.LoanRecovery,
+		OtherDeductions:          -pc.OtherDeductions,
+		CourtOrderDeduction:      -pc.CourtOrderDeduction,
+		CarryForwardRecovered:    -pc.CarryForwardRecovered,
+		DeductionShortfall:       -pc.DeductionShortfall,
+		RoundingAdjustment:       -pc.RoundingAdjustment,
+		PFWage:                   -pc.PFWage,
+		ESIWage:                  -pc.ESIWage,
+		TaxableIncome:            -pc.TaxableIncome,
+		TotalDeductions:          -pc.TotalDeductions,
+		NetPay:                   -pc.NetPay,
+		IsValidated:              true,
+		CreatedBy:                &reversedBy,
+	}
+}
@@ -14,6 +14,24 @@ import (
 type PayrollService struct {
 	repo             *repository.PayrollRepository
 	empRepo          *repository.EmployeeRepository
+	settingsRepo     *repository.PayrollSettingsRepository
+	payGroupRepo     *repository.PayGroupRepository
+	rosterRepo       *repository.RosterRepository
+	lopRepo          *repository.LOPReversalRepository
+	calendarRepo     *repository.WorkingCalendarRepository
+	npsRepo          *repository.NPSRepository
+	fbpRepo          *repository.FBPRepository
+	equityRepo       *repository.EquityRepository
+	prevEmpRepo      *repository.PreviousEmploymentRepository
+	incomeTaxRepo    *repository.IncomeTaxRepository
+	approvalRepo     *repository.PayrollApprovalRepository
+	reopenRepo       *repository.PayrollReopenRepository
+	overrideRepo     *repository.ComponentOverrideRepository
+	outcomeRepo      *repository.PayrollRunOutcomeRepository
+	jobRepo          *repository.JobRepository
+	dryRunRepo       *repository.PayrollDryRunRepository
+	varianceRepo     *repository.PayrollVarianceRepository
+	deductionRepo    *repository.EmployeeDeductionRepository
 	calculatorFactory *calculator.CalculatorFactory
 }
 
@@ -21,10 +39,34 @@ func NewPayrollService(db *sql.DB) *PayrollService {
 	return &PayrollService{
 		repo:              repository.NewPayrollRepository(db),
 		empRepo:           repository.NewEmployeeRepository(db),
+		settingsRepo:      repository.NewPayrollSettingsRepository(db),
+		payGroupRepo:      repository.NewPayGroupRepository(db),
+		rosterRepo:        repository.NewRosterRepository(db),
+		lopRepo:           repository.NewLOPReversalRepository(db),
+		calendarRepo:      repository.NewWorkingCalendarRepository(db),
+		npsRepo:           repository.NewNPSRepository(db),
+		fbpRepo:           repository.NewFBPRepository(db),
+		equityRepo:        repository.NewEquityRepository(db),
+		prevEmpRepo:       repository.NewPreviousEmploymentRepository(db),
+		incomeTaxRepo:     repository.NewIncomeTaxRepository(db),
+		approvalRepo:      repository.NewPayrollApprovalRepository(db),
+		reopenRepo:        repository.NewPayrollReopenRepository(db),
+		overrideRepo:      repository.NewComponentOverrideRepository(db),
+		outcomeRepo:       repository.NewPayrollRunOutcomeRepository(db),
+		jobRepo:           repository.NewJobRepository(db),
+		dryRunRepo:        repository.NewPayrollDryRunRepository(db),
+		varianceRepo:      repository.NewPayrollVarianceRepository(db),
+		deductionRepo:     repository.NewEmployeeDeductionRepository(db),
 		calculatorFactory: calculator.NewCalculatorFactory(repository.NewPayrollRepository(db)),
 	}
 }
 
+// CalculatorFactory exposes the shared factory so rule changes can
+// invalidate its cache
+func (s *PayrollService) CalculatorFactory() *calculator.CalculatorFactory {
+	return s.calculatorFactory
+}
+
 // GetPayrollRuns fetches all payroll runs for an organization
 func (s *PayrollService) GetPayrollRuns(orgID string, filters map[string]interface{}) ([]models.PayrollRun, error) {
 	return s.repo.GetPayrollRuns(orgID, filters)
@@ -42,295 +84,892 @@ func (s *PayrollService) GetPayrollRunDetail(payrollRunID string) (map[string]in
 		return nil, err
 	}
 
-	return map[string]interface{}{
+	detail := map[string]interface{}{
 		"payroll_run":  pr,
 		"components":   components,
 		"total_count":  len(components),
-	}, nil
+	}
+
+	// Off-cycle runs also list the employees selected for them
+	if pr.RunType != RunTypeRegular {
+		employees, err := s.repo.GetPayrollRunEmployees(payrollRunID)
+		if err != nil {
+			return nil, err
+		}
+		detail["employees"] = employees
+	}
+
+	return detail, nil
 }
 
-// CreatePayrollRun creates a new payroll cycle
-func (s *PayrollService) CreatePayrollRun(orgID string, startDate, endDate time.Time, createdBy string) (*models.PayrollRun, error) {
-	// Validate dates
-	if startDate.After(endDate) {
-		return nil, fmt.Errorf("start date must be before end date")
+// CreatePayrollRun creates a new payroll cycle for one period of a pay group.
+// Without a pay group the organization's default group is used; the period is
+// picked by key or by its start and end dates.
+func (s *PayrollService) CreatePayrollRun(orgID, payGroupID, periodKey string, startDate, endDate *time.Time, createdBy string) (*models.PayrollRun, error) {
+	group, period, err := s.runPeriod(orgID, payGroupID, periodKey, startDate, endDate)
+	if err != nil {
+		return nil, err
 	}
 
-	// Generate payroll month (YYYY-MM)
-	payrollMonth := startDate.Format("2006-01")
+	existing, err := s.repo.GetPayrollRuns(orgID, map[string]interface{}{
+		"pay_group_id": group.ID,
+		"period_key":   period.PeriodKey,
+		"run_type":     RunTypeRegular,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) > 0 {
+		return nil, conflict("payroll run %s already exists for period %s of pay group %s", existing[0].ID, period.PeriodKey, group.Name)
+	}
 
 	pr := &models.PayrollRun{
 		OrgID:              orgID,
-		PayrollPeriodStart: startDate,
-		PayrollPeriodEnd:   endDate,
-		PayrollMonth:       payrollMonth,
-		Status:             "draft",
+		PayGroupID:         group.ID,
+		PeriodKey:          period.PeriodKey,
+		RunType:            RunTypeRegular,
+		PayrollPeriodStart: period.PeriodStart,
+		PayrollPeriodEnd:   period.PeriodEnd,
+		PayrollMonth:       period.PayrollMonth,
+		Status:             RunStatusDraft,
 		DryRunCount:        0,
 		TotalEmployees:     0,
 		CreatedBy:          &createdBy,
 	}
 
-	if err := s.repo.CreatePayrollRun(pr); err != nil {
+	if err := s.createRun(pr, nil); err != nil {
 		return nil, err
 	}
 
 	return pr, nil
 }
 
-// InitiatePayrollRun initializes payroll components for all employees
-func (s *PayrollService) InitiatePayrollRun(orgID, payrollRunID, stateCode string, initiatedBy string) error {
-	// Get all active employees
-	employees, err := s.empRepo.GetEmployees(orgID, map[string]interface{}{
-		"employment_status": "active",
-	})
+// runPeriod resolves the pay group and period a new run covers
+func (s *PayrollService) runPeriod(orgID, payGroupID, periodKey string, startDate, endDate *time.Time) (*models.PayGroup, *models.PayGroupPeriod, error) {
+	var group *models.PayGroup
+	var err error
+
+	if payGroupID != "" {
+		group, err = s.payGroupRepo.GetPayGroupByID(payGroupID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if group.OrgID != orgID {
+			return nil, nil, invalidInput("pay group belongs to a different organization")
+		}
+	} else {
+		group, err = defaultPayGroup(s.payGroupRepo, orgID)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if !group.IsActive {
+		return nil, nil, conflict("pay group %s is inactive", group.Name)
+	}
+
+	period, err := s.resolvePayPeriod(group, periodKey, startDate, endDate)
 	if err != nil {
-		return fmt.Errorf("failed to fetch employees: %w", err)
+		return nil, nil, err
 	}
 
+	return group, period, nil
+}
+
+// createRun stores a new run with its employee selection and records its
+// creation
+func (s *PayrollService) createRun(pr *models.PayrollRun, employees []models.PayrollRunEmployee) error {
+	if err := s.repo.CreatePayrollRun(pr); err != nil {
+		return err
+	}
+
+	for i := range employees {
+		employees[i].PayrollRunID = pr.ID
+	}
+	if err := s.repo.CreatePayrollRunEmployees(employees); err != nil {
+		return err
+	}
+
+	return s.repo.RecordPayrollRunCreated(pr.ID, pr.Status, *pr.CreatedBy)
+}
+
+// resolvePayPeriod finds the pay group period a run covers. A key must be in
+// the group's generated calendar; dates must match a period exactly, except
+// that monthly groups accept any range and attribute it to its start month.
+func (s *PayrollService) resolvePayPeriod(group *models.PayGroup, periodKey string, startDate, endDate *time.Time) (*models.PayGroupPeriod, error) {
+	if periodKey != "" {
+		period, err := s.payGroupRepo.GetPayGroupPeriod(group.ID, periodKey)
+		if err != nil {
+			return nil, invalidInput("%v; generate the pay group's periods first", err)
+		}
+		return period, nil
+	}
+
+	if startDate == nil || endDate == nil {
+		return nil, invalidInput("period_key or start_date and end_date are required")
+	}
+
+	if startDate.After(*endDate) {
+		return nil, invalidInput("start date must be before end date")
+	}
+
+	periods, err := payGroupPeriods(group, *startDate, *startDate)
+	if err != nil {
+		return nil, invalidInput("%v", err)
+	}
+
+	for _, p := range periods {
+		if p.PeriodStart.Equal(*startDate) && p.PeriodEnd.Equal(*endDate) {
+			return &p, nil
+		}
+	}
+
+	if group.Frequency == calculator.FrequencyMonthly {
+		month := startDate.Format("2006-01")
+		return &models.PayGroupPeriod{
+			PayGroupID:   group.ID,
+			PeriodKey:    month,
+			PeriodStart:  *startDate,
+			PeriodEnd:    *endDate,
+			PayrollMonth: month,
+		}, nil
+	}
+
+	return nil, invalidInput("%s to %s is not a %s period of pay group %s",
+		startDate.Format("2006-01-02"), endDate.Format("2006-01-02"), group.Frequency, group.Name)
+}
+
+// InitiationResult reports how the employees of an initiated run were
+// calculated; the run's outcomes say why any were skipped or failed
+type InitiationResult struct {
+	PayrollRunID string `json:"payroll_run_id"`
+	CalculationCounts
+}
+
+// InitiatePayrollRun initializes payroll components for all employees,
+// reporting progress as employees are calculated. Employees that fail do not
+// stop the run; they are counted in the result.
+func (s *PayrollService) InitiatePayrollRun(orgID, payrollRunID, stateCode string, initiatedBy string, comment string, progress JobProgress) (*InitiationResult, error) {
 	// Get payroll run details
 	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	// Move the run to in_progress before touching components, so a run
+	// finalized or approved in the meantime is refused rather than overwritten
+	if err := s.transitionRun(pr, RunActionInitiate, initiatedBy, comment); err != nil {
+		return nil, err
+	}
+
+	counts, err := s.calculateRun(pr, orgID, stateCode, initiatedBy, nil, progress)
+	if err != nil {
+		return nil, err
+	}
+
+	return &InitiationResult{PayrollRunID: pr.ID, CalculationCounts: counts}, nil
+}
+
+// calculateRun calculates and stores the components of a run, replacing
+// existing ones, and updates the run totals. With only set, just those
+// employees are calculated. Manual overrides apply on every calculation.
+// Cancelling through progress stops calculation between employees and
+// returns ErrJobCancelled; the components already stored are kept.
+func (s *PayrollService) calculateRun(pr *models.PayrollRun, orgID, stateCode, calculatedBy string, only map[string]bool, progress JobProgress) (CalculationCounts, error) {
+	// Reversal runs negate the components of the run they reverse
+	if pr.RunType == RunTypeReversal {
+		return s.reverseRun(pr, calculatedBy, only)
+	}
+
+	rc, employees, err := s.prepareRunCalculation(pr, orgID, stateCode, calculatedBy, only)
+	if err != nil {
+		return CalculationCounts{}, err
+	}
+	rc.progress = progress
+
+	if err := s.repo.RecordRunStatutoryRules(pr.ID, rc.calc.Rules().RuleIDs()); err != nil {
+		return CalculationCounts{}, err
+	}
+
+	outcomes, err := s.calculateEmployees(rc, employees)
+	counts, saveErr := s.recordRunOutcomes(pr, outcomes, only == nil && rc.selected == nil && err == nil)
+	if err != nil {
+		return counts, err
+	}
+	return counts, saveErr
+}
+
+// prepareRunCalculation resolves the employees of a run to calculate, or
+// just those of only, and loads what they are calculated with. It stores
+// nothing, so dry runs share it.
+func (s *PayrollService) prepareRunCalculation(pr *models.PayrollRun, orgID, stateCode, calculatedBy string, only map[string]bool) (*runCalculation, []models.Employee, error) {
+	payrollRunID := pr.ID
+
+	// Off-cycle runs cover only their selected employees
+	selected, err := s.runSelection(pr)
+	if err != nil {
+		return nil, nil, err
+	}
+	if selected != nil {
+		if only == nil {
+			only = map[string]bool{}
+			for id := range selected {
+				only[id] = true
+			}
+		}
+		for id := range only {
+			if selected[id] == nil {
+				return nil, nil, invalidInput("employee %s is not selected for %s run %s", id, pr.RunType, pr.ID)
+			}
+		}
+	}
+
+	// Get all active employees of the run's pay group; employees without a
+	// pay group belong to the default one. Runs that predate pay groups cover everyone.
+	employeeFilters := map[string]interface{}{
+		"employment_status": "active",
+	}
+	if only != nil {
+		// Employees recalculated in a reopened run, or settled in an
+		// off-cycle run, may have left since
+		delete(employeeFilters, "employment_status")
+	}
+	subMonthly := false
+	if pr.PayGroupID != "" {
+		group, err := s.payGroupRepo.GetPayGroupByID(pr.PayGroupID)
+		if err != nil {
+			return nil, nil, err
+		}
+		employeeFilters["pay_group_id"] = group.ID
+		employeeFilters["include_unassigned"] = group.IsDefault
+		subMonthly = group.Frequency != calculator.FrequencyMonthly
+	}
+
+	employees, err := s.empRepo.GetEmployees(orgID, employeeFilters)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch employees: %w", err)
+	}
+
+	if only != nil {
+		inGroup := map[string]bool{}
+		for _, emp := range employees {
+			inGroup[emp.ID] = true
+		}
+		for id := range only {
+			if !inGroup[id] {
+				return nil, nil, invalidInput("employee %s is not in the run's pay group", id)
+			}
+		}
+	}
+
+	overrides, err := s.overrideRepo.GetComponentOverrides(payrollRunID)
+	if err != nil {
+		return nil, nil, err
+	}
+	overridesByEmployee := map[string]*models.PayrollComponentOverride{}
+	for i := range overrides {
+		overridesByEmployee[overrides[i].EmployeeID] = &overrides[i]
 	}
 
 	if stateCode == "" {
 		stateCode = "MH" // Default to Maharashtra
 	}
 
-	// Create calculator factory
-	calc, err := s.calculatorFactory.CreateCalculator(stateCode)
+	// Resolve the statutory rules in force for the payroll period
+	calc, err := s.calculatorFactory.CreateCalculator(orgID, stateCode, pr.PayrollPeriodStart)
 	if err != nil {
-		return fmt.Errorf("failed to create calculator: %w", err)
+		return nil, nil, fmt.Errorf("failed to create calculator: %w", err)
 	}
 
-	validator, err := s.calculatorFactory.CreateValidator(stateCode)
+	validator, err := s.calculatorFactory.CreateValidator(orgID, stateCode, pr.PayrollPeriodStart)
 	if err != nil {
-		return fmt.Errorf("failed to create validator: %w", err)
+		return nil, nil, fmt.Errorf("failed to create validator: %w", err)
 	}
 
-	successCount := 0
-	failureCount := 0
+	settings, err := s.settingsRepo.GetPayrollSettings(orgID)
+	if err != nil {
+		return nil, nil, err
+	}
+	calc.SetRoundingPolicy(roundingPolicy(settings))
+	calc.SetProrationPolicy(prorationPolicy(settings))
+
+	shiftPolicies, err := s.rosterRepo.GetShiftAllowancePolicies(orgID, pr.PayrollPeriodStart)
+	if err != nil {
+		return nil, nil, err
+	}
+	calc.SetShiftAllowancePolicies(shiftAllowancePolicies(shiftPolicies))
+
+	// Annual slabs and surcharges of the financial year, by regime
+	financialYear := calculator.FinancialYearStart(pr.PayrollPeriodEnd).Year()
+	taxSlabs, err := s.incomeTaxRepo.GetIncomeTaxSlabs(financialYear, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	taxSurcharges, err := s.incomeTaxRepo.GetIncomeTaxSurcharges(financialYear, "")
+	if err != nil {
+		return nil, nil, err
+	}
+	calc.SetIncomeTaxTables(incomeTaxTables(taxSlabs, taxSurcharges))
 
-	// Create payroll components for each employee
+	// Load salary structures, attendance and leave of the whole run at once
+	var calculated []models.Employee
+	var employeeIDs []string
 	for _, emp := range employees {
-		// Get employee's salary structure
-		ss, err := s.empRepo.GetSalaryStructure(emp.ID)
-		if err != nil {
-			failureCount++
+		if only != nil && !only[emp.ID] {
 			continue
 		}
+		calculated = append(calculated, emp)
+		employeeIDs = append(employeeIDs, emp.ID)
+	}
 
-		// Get attendance data
-		attendance, err := s.empRepo.GetAttendanceSummary(emp.ID, pr.PayrollMonth)
+	structures, err := s.empRepo.GetSalaryStructures(employeeIDs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Sub-monthly periods and bonus runs do not use monthly attendance and leave
+	var attendance map[string]*models.AttendanceSummary
+	var leave map[string]*models.LeaveSummary
+	if !subMonthly && pr.RunType != RunTypeBonus {
+		attendance, err = s.empRepo.GetAttendanceSummaries(employeeIDs, pr.PayrollMonth)
 		if err != nil {
-			failureCount++
-			continue
+			return nil, nil, err
 		}
-
-		// Get leave data
-		leave, err := s.empRepo.GetLeaveSummary(emp.ID, pr.PayrollMonth)
+		leave, err = s.empRepo.GetLeaveSummaries(employeeIDs, pr.PayrollMonth)
 		if err != nil {
-			failureCount++
-			continue
+			return nil, nil, err
 		}
+	}
 
-		// Determine days worked
-		daysWorked := 30
-		daysAbsent := 0
-		daysLeave := 0
+	// Load what the employees carry in from other runs and modules at once
+	workingDays := !subMonthly && settings.ProrationBasis == calculator.ProrationWorkingDays
+	inputs, err := s.loadRunInputs(pr, orgID, calculated, len(shiftPolicies) > 0, workingDays)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rc := &runCalculation{
+		pr:            pr,
+		orgID:         orgID,
+		calculatedBy:  calculatedBy,
+		calc:          calc,
+		validator:     validator,
+		settings:      settings,
+		shiftPolicies: shiftPolicies,
+		subMonthly:    subMonthly,
+		selected:      selected,
+		overrides:     overridesByEmployee,
+		structures:    structures,
+		attendance:    attendance,
+		leave:         leave,
+		inputs:        inputs,
+	}
+
+	return rc, calculated, nil
+}
 
-		if attendance != nil {
-			daysWorked = attendance.WorkingDaysActual
+// calculateEmployee calculates, validates and stores an employee's component
+// of a run and reports the outcome. In a dry run nothing is stored.
+func (s *PayrollService) calculateEmployee(rc *runCalculation, emp *models.Employee) models.PayrollRunOutcome {
+	pr, orgID, payrollRunID := rc.pr, rc.orgID, rc.pr.ID
+
+	// Regular runs skip employees not employed during the period
+	if rc.selected == nil && (emp.DateOfJoining.After(pr.PayrollPeriodEnd) ||
+		(emp.DateOfExit != nil && emp.DateOfExit.Before(pr.PayrollPeriodStart))) {
+		return skippedOutcome(pr, emp.ID, OutcomeNotEmployed, "not employed during %s to %s",
+			pr.PayrollPeriodStart.Format("2006-01-02"), pr.PayrollPeriodEnd.Format("2006-01-02"))
+	}
+
+	// Get employee's salary structure
+	ss := rc.structures[emp.ID]
+	if ss == nil {
+		return failedOutcome(pr, emp.ID, OutcomeNoSalaryStructure, fmt.Errorf("salary structure not found"))
+	}
+
+	// Bonus runs pay only the selected amount, no salary for the period
+	runEmployee := rc.selected[emp.ID]
+	salary := pr.RunType != RunTypeBonus
+
+	// Determine days worked; the calculator takes loss of pay off these
+	daysWorked := 30
+	daysAbsent := 0
+	daysLeave := 0
+	daysInMonth := 30
+	unpaidLeaveDays := 0
+	workingDays := 0
+
+	if !salary {
+		daysWorked = 0
+		daysInMonth = daysInPayrollMonth(pr.PayrollMonth)
+	} else if rc.subMonthly {
+		// Sub-monthly periods are paid for their calendar days against the
+		// days of the month they are attributed to; monthly attendance and
+		// leave summaries do not apply to a single period
+		daysWorked = int(pr.PayrollPeriodEnd.Sub(pr.PayrollPeriodStart).Hours()/24) + 1
+		daysInMonth = daysInPayrollMonth(pr.PayrollMonth)
+	} else {
+		daysInMonth = daysInPayrollMonth(pr.PayrollMonth)
+		daysWorked = daysInMonth
+
+		if attendance := rc.attendance[emp.ID]; attendance != nil {
 			daysAbsent = attendance.AbsentDays
 			daysLeave = attendance.LeaveDays
+			workingDays = attendance.WorkingDaysExpected
 		}
 
-		daysInMonth := 30
-
-		// Prepare input for calculator
-		payrollInput := &calculator.PayrollInput{
-			DaysWorked:      daysWorked,
-			DaysAbsent:      daysAbsent,
-			DaysLeave:       daysLeave,
-			DaysInMonth:     daysInMonth,
-			AdvanceRecovery: 0,
-			LoanRecovery:    0,
-			OtherDeductions: 0,
+		if leave := rc.leave[emp.ID]; leave != nil {
+			unpaidLeaveDays = leave.UnpaidLeaveTaken
 		}
 
-		if leave != nil && leave.LossOfPay > 0 {
-			payrollInput.OtherDeductions += leave.LossOfPay
+		// Without attendance, working days come from the employee's location calendar
+		if wc := rc.inputs.calendars[emp.Location.String]; workingDays == 0 && wc != nil {
+			workingDays = wc.WorkingDays(pr.PayrollPeriodStart, pr.PayrollPeriodEnd).WorkingDays
 		}
+	}
 
-		// Calculate payroll using the calculator engine
-		calcResult, err := calc.CalculatePayroll(&emp, ss, payrollInput)
-		if err != nil {
-			failureCount++
-			continue
-		}
+	// Prepare input for calculator
+	payrollInput := &calculator.PayrollInput{
+		DaysWorked:      daysWorked,
+		DaysAbsent:      daysAbsent,
+		DaysLeave:       daysLeave,
+		DaysInMonth:     daysInMonth,
+		UnpaidLeaveDays: unpaidLeaveDays,
+		WorkingDays:     workingDays,
+	}
+	if runEmployee != nil && runEmployee.Amount != nil {
+		payrollInput.OneTimePayment = *runEmployee.Amount
+	}
 
-		// Validate the calculated result
-		pc := calculator.ConvertCalculationResultToComponent(
-			calcResult,
-			orgID, payrollRunID, emp.ID, &ss.ID,
-			daysInMonth,
-			daysWorked, daysAbsent, daysLeave,
-		)
-
-		pc.CreatedBy = &initiatedBy
-
-		// Validate component
-		validationErrors := validator.ValidatePayrollComponent(pc, &emp, ss)
-		if len(validationErrors) > 0 {
-			// Store validation errors
-			errJSON, _ := json.Marshal(validationErrors)
-			pc.ValidationErrors.String = string(errJSON)
-			pc.ValidationErrors.Valid = true
-
-			// Only fail if there are critical errors
-			if calculator.HasCriticalErrors(validationErrors) {
-				failureCount++
-				continue
-			}
-		}
+	// Manual inputs entered for the run replace the calculated ones
+	applyComponentOverride(payrollInput, rc.overrides[emp.ID])
 
-		// Create the component in database
-		if err := s.repo.CreatePayrollComponent(pc); err != nil {
-			failureCount++
-			continue
-		}
+	if err := s.loadEmployeeInputs(rc, emp, payrollInput, salary); err != nil {
+		return failedOutcome(pr, emp.ID, OutcomeInputsUnavailable, err)
+	}
 
-		successCount++
+	// Calculate payroll using the calculator engine
+	calcResult, err := rc.calc.CalculatePayroll(emp, ss, payrollInput)
+	if err != nil {
+		return failedOutcome(pr, emp.ID, OutcomeCalculationFailed, err)
 	}
 
-	// Update payroll run status to in_progress
-	if err := s.repo.UpdatePayrollRunStatus(payrollRunID, "in_progress", initiatedBy); err != nil {
-		return err
+	// Validate the calculated result
+	pc := calculator.ConvertCalculationResultToComponent(
+		calcResult,
+		orgID, payrollRunID, emp.ID, &ss.ID,
+		daysInMonth,
+		calcResult.DaysPaid, daysAbsent, daysLeave,
+	)
+
+	pc.CreatedBy = &rc.calculatedBy
+
+	// Validate component
+	validationErrors := rc.validator.ValidatePayrollComponent(pc, emp, ss)
+	if len(validationErrors) > 0 {
+		// Store validation errors
+		errJSON, _ := json.Marshal(validationErrors)
+		pc.ValidationErrors.String = string(errJSON)
+		pc.ValidationErrors.Valid = true
+
 	}
 
-	if failureCount > 0 {
-		return fmt.Errorf("payroll initiated with errors: %d succeeded, %d failed", successCount, failureCount)
+	// Dry runs keep the component and its findings instead of storing them
+	if rc.sandbox != nil {
+		rc.sandbox.add(pc, validationErrors)
 	}
 
-	return nil
+	// Only fail if there are critical errors
+	if calculator.HasCriticalErrors(validationErrors) {
+		return validationFailedOutcome(pr, emp.ID, validationErrors)
+	}
+
+	if rc.sandbox != nil {
+		return successOutcome(pr, emp.ID, "")
+	}
+
+	// Keep the working behind the component for later explanation
+	trail := calculator.FormatCalculationAuditTrail(
+		emp.ID, pr.PayrollMonth, calcResult, validationErrors, rc.calculatedBy,
+		ss, payrollInput, rc.calc.Rules(),
+	)
+
+	// Create the component in database, or replace it when recalculating
+	if err := s.saveComponent(pc, calcResult, &trail); err != nil {
+		return failedOutcome(pr, emp.ID, OutcomeSaveFailed, err)
+	}
+
+	return successOutcome(pr, emp.ID, pc.ID)
 }
 
-// ValidatePayroll validates all components in a payroll run
-func (s *PayrollService) ValidatePayroll(payrollRunID string) ([]string, error) {
-	var errors []string
+// loadEmployeeInputs fills in the inputs an employee's calculation reads from
+// other runs and modules: reversals, carried-forward deductions, month and
+// year to date totals, NPS, flexible benefits and equity. They are looked up
+// in what loadRunInputs loaded for the whole run.
+func (s *PayrollService) loadEmployeeInputs(rc *runCalculation, emp *models.Employee, payrollInput *calculator.PayrollInput, salary bool) error {
+	pr, in := rc.pr, rc.inputs
+	var err error
 
-	components, err := s.repo.GetPayrollComponents(payrollRunID)
-	if err != nil {
-		return nil, err
+	// Pay back loss of pay reversed from earlier months
+	if salary {
+		payrollInput.LOPReversals = in.lopReversals[emp.ID]
 	}
 
-	for _, comp := range components {
-		// Validation rules
-		if comp.GrossAmount < 0 {
-			errors = append(errors, fmt.Sprintf("Employee %s: Negative gross amount", comp.EmployeeID))
-		}
+	// Raise the instalments of loan, advance and other deduction schedules due
+	// this month, except in categories entered manually for the run
+	if pr.RunType == RunTypeRegular {
+		payrollInput.DeductionInstalments = deductionInstalments(in.deductions[emp.ID], rc.overrides[emp.ID])
+	}
 
-		if comp.NetPay < 0 {
-			errors = append(errors, fmt.Sprintf("Employee %s: Negative net pay", comp.EmployeeID))
-		}
+	// Pick up deductions left unrecovered by earlier runs
+	payrollInput.CarriedForward = in.deductionBalances[emp.ID]
+	if payrollInput.CarriedForward == nil {
+		payrollInput.CarriedForward = map[string]float64{}
+	}
 
-		if comp.DaysWorked > comp.DaysInMonth {
-			errors = append(errors, fmt.Sprintf("Employee %s: Days worked exceeds days in month", comp.EmployeeID))
-		}
+	// Net off earlier rounding; an exiting employee's final settlement is paid exactly
+	payrollInput.RoundingBalance = in.roundingBalances[emp.ID]
+	payrollInput.FinalSettlement = finalSettlement(emp, pr)
+
+	// Pay shift allowance for the shifts rostered in the period
+	if len(rc.shiftPolicies) > 0 && salary {
+		payrollInput.Shifts = rosterShifts(in.shifts[emp.ID])
 	}
 
-	return errors, nil
+	// Apply monthly limits across earlier runs attributed to the same month
+	payrollInput.MonthToDate = monthToDate(in.monthToDate[emp.ID])
+
+	// Corporate NPS, and employer retirement contributions so far this
+	// financial year for the yearly cap
+	payrollInput.TaxRegime = emp.TaxRegime
+	payrollInput.NoValidPAN = !calculator.HasValidPAN(emp)
+	payrollInput.Age = calculator.AgeAtFinancialYearEnd(emp.DateOfBirth, calculator.FinancialYearStart(pr.PayrollPeriodEnd))
+	payrollInput.NPS = npsContribution(in.nps[emp.ID])
+	payrollInput.EmployerRetirementYTD = in.retirementYTD[emp.ID]
+
+	// Flexible benefits declared for the financial year, approved claims
+	// and, at year end or exit, the balance left unclaimed
+	fyStart := calculator.FinancialYearStart(pr.PayrollPeriodEnd)
+	if salary {
+		payrollInput.FBP = fbpAllocations(in.fbpDeclarations[emp.ID])
+		payrollInput.FBPClaims = fbpClaims(in.fbpClaims[emp.ID])
+		payrollInput.FBPBalance = in.fbpBalances[emp.ID]
+		payrollInput.FBPYearEnd = payrollInput.FinalSettlement || !pr.PayrollPeriodEnd.Before(fyStart.AddDate(1, 0, -1))
+	}
+
+	// ESOP exercises and RSU vestings, and deferred perquisites whose
+	// deferral ends this period
+	if salary {
+		payrollInput.EquityPerquisites = equityPerquisites(in.perquisites[emp.ID], payrollInput.FinalSettlement)
+		payrollInput.DeferredPerquisites = equityPerquisites(in.deferredPerquisites[emp.ID], false)
+	}
+
+	// Project TDS over the financial year, counting earlier employers
+	payrollInput.TaxYear, err = taxYearToDate(pr, in.taxableIncomeYTD[emp.ID], in.tdsYTD[emp.ID],
+		in.previousEmployments[emp.ID], payrollInput.FinalSettlement)
+	return err
 }
 
-// FinalizePayroll finalizes a payroll run (makes it ready for approval)
-func (s *PayrollService) FinalizePayroll(payrollRunID string, finalizedBy string) error {
-	// Run validation first
-	errors, err := s.ValidatePayroll(payrollRunID)
+// saveComponent stores a component together with its audit trail and what it
+// recovered, rounded and paid against other modules, in one transaction so a
+// failed write leaves none of them behind
+func (s *PayrollService) saveComponent(pc *models.PayrollComponent, calcResult *calculator.CalculationResult, trail *calculator.CalculationAuditTrail) error {
+	tx, err := s.repo.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	if len(errors) > 0 {
-		return fmt.Errorf("payroll validation failed with %d errors", len(errors))
+	if err := s.repo.UpsertPayrollComponent(tx, pc); err != nil {
+		return err
 	}
 
-	// Update status to finalized
-	if err := s.repo.UpdatePayrollRunStatus(payrollRunID, "finalized", finalizedBy); err != nil {
+	if err := s.saveComponentEffects(tx, pc, calcResult); err != nil {
 		return err
 	}
 
+	if err := s.saveCalculationAudit(tx, pc, trail); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
 	return nil
 }
 
-// ApprovePayroll approves a payroll run
-func (s *PayrollService) ApprovePayroll(payrollRunID string, approvedBy string) error {
-	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
-	if err != nil {
+// saveComponentEffects records what a stored component recovered, rounded
+// and paid against other modules
+func (s *PayrollService) saveComponentEffects(tx *sql.Tx, pc *models.PayrollComponent, calcResult *calculator.CalculationResult) error {
+	if err := s.repo.CreateDeductionLedgerEntries(tx, deductionLedgerEntries(pc, calcResult.DeductionRecoveries)); err != nil {
+		return err
+	}
+
+	if err := s.repo.RecordRoundingAdjustment(tx, pc); err != nil {
 		return err
 	}
 
-	if pr.Status != "finalized" {
-		return fmt.Errorf("payroll must be finalized before approval")
+	if err := s.recordDeductionInstalments(tx, pc, calcResult.DeductionInstalments); err != nil {
+		return err
 	}
 
-	// Update status to approved
-	if err := s.repo.UpdatePayrollRunStatus(payrollRunID, "locked", approvedBy); err != nil {
+	if err := s.applyLOPReversals(tx, pc, calcResult.LOPReversals); err != nil {
 		return err
 	}
 
-	// Lock the payroll run
-	if err := s.repo.LockPayrollRun(payrollRunID, approvedBy, "Payroll approved and locked"); err != nil {
+	if err := s.applyFBPClaims(tx, pc, calcResult.FBPClaimsPaid); err != nil {
 		return err
 	}
 
+	return s.applyEquityPerquisites(tx, pc, calcResult)
+}
+
+// recordDeductionInstalments records the scheduled instalments a component
+// raised
+func (s *PayrollService) recordDeductionInstalments(tx *sql.Tx, pc *models.PayrollComponent, raised []calculator.DeductionInstalment) error {
+	for _, inst := range raised {
+		if err := s.deductionRepo.RecordDeductionInstalment(tx, inst.ID, pc.PayrollRunID, pc.ID, inst.Amount); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// ReleasePayroll releases payroll (marks as released for payment)
-func (s *PayrollService) ReleasePayroll(payrollRunID string, releasedBy string) error {
+// applyLOPReversals marks the reversals a component paid back as applied
+func (s *PayrollService) applyLOPReversals(tx *sql.Tx, pc *models.PayrollComponent, paid []calculator.LOPReversal) error {
+	for _, rev := range paid {
+		if err := s.lopRepo.ApplyLOPReversal(tx, rev.ID, pc.PayrollRunID, pc.ID, rev.Amount); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyFBPClaims marks the FBP claims a component paid
+func (s *PayrollService) applyFBPClaims(tx *sql.Tx, pc *models.PayrollComponent, paid []calculator.FBPClaim) error {
+	for _, claim := range paid {
+		if err := s.fbpRepo.MarkFBPClaimPaid(tx, claim.ID, pc.PayrollRunID, pc.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applyEquityPerquisites records the equity perquisites a component reported
+// and the deferred ones it taxed
+func (s *PayrollService) applyEquityPerquisites(tx *sql.Tx, pc *models.PayrollComponent, result *calculator.CalculationResult) error {
+	for _, p := range result.EquityPerquisitesReported {
+		if err := s.equityRepo.MarkPerquisiteReported(tx, p.ID, pc.PayrollRunID, pc.ID, p.TaxDeferred); err != nil {
+			return err
+		}
+	}
+	for _, p := range result.EquityDeferredReleased {
+		if err := s.equityRepo.MarkDeferredPerquisiteTaxed(tx, p.ID, pc.PayrollRunID, pc.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// monthToDate converts stored month-to-date totals for the calculator.
+// Returns nil when no other run of the month paid the employee.
+func monthToDate(totals *models.MonthToDateTotals) *calculator.MonthToDate {
+	if totals == nil || totals.Components == 0 {
+		return nil
+	}
+
+	return &calculator.MonthToDate{
+		Periods:         totals.Components,
+		DaysPaid:        totals.DaysWorked,
+		GrossAmount:     totals.GrossAmount,
+		OneTimePayment:  totals.OneTimePayment,
+		PFWage:          totals.PFWage,
+		ESIWage:         totals.ESIWage,
+		ESIEmployee:     totals.ESIEmployee,
+		ProfessionalTax: totals.ProfessionalTax,
+		TaxableIncome:   totals.TaxableIncome,
+		TDS:             totals.TDS,
+	}
+}
+
+// daysInPayrollMonth returns the calendar days of a YYYY-MM month
+func daysInPayrollMonth(payrollMonth string) int {
+	month, err := time.Parse("2006-01", payrollMonth)
+	if err != nil {
+		return 30
+	}
+	return month.AddDate(0, 1, -1).Day()
+}
+
+// deductionLedgerEntries turns a component's deduction recoveries into ledger
+// movements: carried-forward balance cleared and new shortfall deferred
+func deductionLedgerEntries(pc *models.PayrollComponent, recoveries []calculator.DeductionRecovery) []models.DeductionLedgerEntry {
+	var entries []models.DeductionLedgerEntry
+
+	for _, rec := range recoveries {
+		entry := models.DeductionLedgerEntry{
+			OrgID:              pc.OrgID,
+			EmployeeID:         pc.EmployeeID,
+			PayrollRunID:       pc.PayrollRunID,
+			PayrollComponentID: pc.ID,
+			Category:           rec.Category,
+		}
+
+		if rec.CarryForwardRecovered > 0 {
+			entry.EntryType = "recovered"
+			entry.Amount = rec.CarryForwardRecovered
+			entries = append(entries, entry)
+		}
+
+		if rec.Deferred > 0 {
+			entry.EntryType = "deferred"
+			entry.Amount = rec.Deferred
+			entries = append(entries, entry)
+		}
+	}
+
+	return entries
+}
+
+// saveCalculationAudit persists the audit trail of a payroll component in the
+// component's transaction
+func (s *PayrollService) saveCalculationAudit(tx *sql.Tx, pc *models.PayrollComponent, trail *calculator.CalculationAuditTrail) error {
+	inputsJSON, err := json.Marshal(trail.Inputs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal calculation inputs: %w", err)
+	}
+
+	rulesJSON, err := json.Marshal(trail.RuleVersions)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rule versions: %w", err)
+	}
+
+	stepsJSON, err := json.Marshal(trail.Steps)
+	if err != nil {
+		return fmt.Errorf("failed to marshal calculation steps: %w", err)
+	}
+
+	audit := &models.CalculationAudit{
+		OrgID:              pc.OrgID,
+		PayrollRunID:       pc.PayrollRunID,
+		PayrollComponentID: pc.ID,
+		EmployeeID:         pc.EmployeeID,
+		Inputs:             string(inputsJSON),
+		RuleVersions:       string(rulesJSON),
+		Steps:              string(stepsJSON),
+		Status:             trail.Status,
+		CalculatedBy:       pc.CreatedBy,
+		CalculatedAt:       time.Now(),
+	}
+
+	if len(trail.Validations) > 0 {
+		validationsJSON, err := json.Marshal(trail.Validations)
+		if err != nil {
+			return fmt.Errorf("failed to marshal validations: %w", err)
+		}
+		audit.Validations.String = string(validationsJSON)
+		audit.Validations.Valid = true
+	}
+
+	return s.repo.CreateCalculationAudit(tx, audit)
+}
+
+// ExplainPayrollComponent returns the stored step-by-step working for an
+// employee's payroll component in a run
+func (s *PayrollService) ExplainPayrollComponent(payrollRunID, employeeID string) (*calculator.CalculationAuditTrail, error) {
 	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+
+	audit, err := s.repo.GetCalculationAudit(payrollRunID, employeeID)
+	if err != nil {
+		return nil, err
 	}
 
-	if pr.Status != "locked" {
-		return fmt.Errorf("payroll must be locked before release")
+	trail := &calculator.CalculationAuditTrail{
+		EmployeeID:   audit.EmployeeID,
+		PayrollMonth: pr.PayrollMonth,
+		Status:       audit.Status,
+		CalculatedAt: audit.CalculatedAt.Format(time.RFC3339),
 	}
 
-	// Update status to released
-	if err := s.repo.UpdatePayrollRunStatus(payrollRunID, "released", releasedBy); err != nil {
-		return err
+	if audit.CalculatedBy != nil {
+		trail.CalculatedBy = *audit.CalculatedBy
 	}
 
-	return nil
+	if err := json.Unmarshal([]byte(audit.Inputs), &trail.Inputs); err != nil {
+		return nil, fmt.Errorf("failed to parse calculation inputs: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(audit.RuleVersions), &trail.RuleVersions); err != nil {
+		return nil, fmt.Errorf("failed to parse rule versions: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(audit.Steps), &trail.Steps); err != nil {
+		return nil, fmt.Errorf("failed to parse calculation steps: %w", err)
+	}
+
+	if audit.Validations.Valid {
+		if err := json.Unmarshal([]byte(audit.Validations.String), &trail.Validations); err != nil {
+			return nil, fmt.Errorf("failed to parse validations: %w", err)
+		}
+	}
+
+	return trail, nil
 }
 
-// DryRunPayroll performs a dry run of payroll (for testing)
-func (s *PayrollService) DryRunPayroll(payrollRunID string) error {
+// ValidatePayroll validates all components in a payroll run
+func (s *PayrollService) ValidatePayroll(payrollRunID string) ([]string, error) {
+	var errors []string
+
 	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Increment dry run count
-	pr.DryRunCount++
+	components, err := s.repo.GetPayrollComponents(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
 
-	// Validate
-	errors, err := s.ValidatePayroll(payrollRunID)
+	for _, comp := range components {
+		// Validation rules; reversal runs negate paid components
+		if comp.GrossAmount < 0 && pr.RunType != RunTypeReversal {
+			errors = append(errors, fmt.Sprintf("Employee %s: Negative gross amount", comp.EmployeeID))
+		}
+
+		if comp.NetPay < 0 && pr.RunType != RunTypeReversal {
+			errors = append(errors, fmt.Sprintf("Employee %s: Negative net pay", comp.EmployeeID))
+		}
+
+		if comp.DaysWorked > comp.DaysInMonth {
+			errors = append(errors, fmt.Sprintf("Employee %s: Days worked exceeds days in month", comp.EmployeeID))
+		}
+	}
+
+	return errors, nil
+}
+
+// FinalizePayroll finalizes a payroll run (makes it ready for approval)
+func (s *PayrollService) FinalizePayroll(payrollRunID string, finalizedBy string, comment string) error {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
 	if err != nil {
 		return err
 	}
 
-	if len(errors) > 0 {
-		return fmt.Errorf("dry run validation failed with %d errors", len(errors))
-	}
+	// Validation and the variance check run as the transition's guard
+	return s.transitionRun(pr, RunActionFinalize, finalizedBy, comment)
+}
 
-	// Update status to dry_run
-	if err := s.repo.UpdatePayrollRunStatus(payrollRunID, "dry_run", "system"); err != nil {
+// ReleasePayroll releases payroll (marks as released for payment)
+func (s *PayrollService) ReleasePayroll(payrollRunID string, releasedBy string, comment string) error {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return s.transitionRun(pr, RunActionRelease, releasedBy, comment)
 }
 
 // GetPayrollSummary gets financial summary for a payroll run
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"database/sql"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+type PayrollSettingsService struct {
+	repo *repository.PayrollSettingsRepository
+}
+
+func NewPayrollSettingsService(db *sql.DB) *PayrollSettingsService {
+	return &PayrollSettingsService{
+		repo: repository.NewPayrollSettingsRepository(db),
+	}
+}
+
+// GetPayrollSettings fetches an organization's payroll settings
+func (s *PayrollSettingsService) GetPayrollSettings(orgID string) (*models.PayrollSettings, error) {
+	return s.repo.GetPayrollSettings(orgID)
+}
+
+// UpdatePayrollSettings validates and saves an organization's payroll settings
+func (s *PayrollSettingsService) UpdatePayrollSettings(settings *models.PayrollSettings) error {
+	if err := roundingPolicy(settings).Validate(); err != nil {
+		return invalidInput("%v", err)
+	}
+
+	if err := prorationPolicy(settings).Validate(); err != nil {
+		return invalidInput("%v", err)
+	}
+
+	if settings.VariancePercentThreshold < 0 || settings.VarianceAmountThreshold < 0 {
+		return invalidInput("variance thresholds cannot be negative")
+	}
+
+	return s.repo.UpsertPayrollSettings(settings)
+}
+
+// roundingPolicy converts stored settings to the calculator's rounding policy
+func roundingPolicy(settings *models.PayrollSettings) calculator.RoundingPolicy {
+	return calculator.RoundingPolicy{
+		Mode: settings.RoundingMode,
+		Unit: settings.RoundingUnit,
+	}
+}
+
+// prorationPolicy converts stored settings to the calculator's proration policy
+func prorationPolicy(settings *models.PayrollSettings) calculator.ProrationPolicy {
+	return calculator.ProrationPolicy{
+		Basis: settings.ProrationBasis,
+	}
+}
@@ -0,0 +1,458 @@
+package service
+
+import (
+	"fmt"
+	"math"
+
+	"payroll-service/internal/models"
+)
+
+// Likely causes of an employee's variance from the previous released run
+const (
+	VarianceCauseNewJoiner   = "new_joiner"
+	VarianceCauseExit        = "exit"
+	VarianceCauseLOPDays     = "lop_days"
+	VarianceCauseLOPReversal = "lop_reversal"
+	VarianceCauseRevision    = "salary_revision"
+	VarianceCauseBonus       = "bonus"
+	VarianceCauseOverride    = "manual_override"
+)
+
+// VarianceReport compares a run's components with those of the previous
+// released run of the same type and pay group
+type VarianceReport struct {
+	PayrollRunID            string             `json:"payroll_run_id"`
+	PreviousPayrollRunID    *string            `json:"previous_payroll_run_id"` // Nil when no run was released before
+	PreviousPayrollMonth    *string            `json:"previous_payroll_month"`
+	PercentThreshold        float64            `json:"percent_threshold"`
+	AmountThreshold         float64            `json:"amount_threshold"`
+	NetPayBefore            float64            `json:"net_pay_before"`
+	NetPayAfter             float64            `json:"net_pay_after"`
+	FlaggedEmployees        int                `json:"flagged_employees"`
+	UnexplainedEmployees    int                `json:"unexplained_employees"`
+	UnacknowledgedEmployees int                `json:"unacknowledged_employees"` // Block finalization
+	Employees               []EmployeeVariance `json:"employees"`                // Only employees with changes
+}
+
+// EmployeeVariance is the change in one employee's component since the
+// previous released run, with its likely causes. A variance is explained when
+// the amounts attributed to its causes account for the net pay change, short
+// of a remainder within the variance thresholds. A flagged variance that is
+// not explained blocks finalization until acknowledged.
+type EmployeeVariance struct {
+	EmployeeID   string              `json:"employee_id"`
+	Added        bool                `json:"added,omitempty"`   // Not paid in the previous run
+	Removed      bool                `json:"removed,omitempty"` // Not paid in this run
+	NetPayBefore float64             `json:"net_pay_before"`
+	NetPayAfter  float64             `json:"net_pay_after"`
+	Unexplained  float64             `json:"unexplained"` // Net pay change no cause accounts for
+	Flagged      bool                `json:"flagged"`
+	Explained    bool                `json:"explained"`
+	Acknowledged bool                `json:"acknowledged"`
+	Causes       []VarianceCause     `json:"causes"`
+	Changes      []ComponentVariance `json:"changes"`
+}
+
+// ComponentVariance is one amount's change, as a percentage of the previous
+// amount when there was one
+type ComponentVariance struct {
+	ComponentChange
+	Percent *float64 `json:"percent"`
+	Flagged bool     `json:"flagged"`
+}
+
+// VarianceCause is a likely reason for an employee's variance and the change
+// in pay it accounts for. Manual overrides are reported without an amount:
+// they say how the component was calculated, not why it changed.
+type VarianceCause struct {
+	Code        string  `json:"code"`
+	Amount      float64 `json:"amount"`
+	Description string  `json:"description"`
+}
+
+// blocking reports whether a variance holds up finalization
+func (v *EmployeeVariance) blocking() bool {
+	return v.Flagged && !v.Explained && !v.Acknowledged
+}
+
+// GetVarianceReport compares a run with the previous released run at
+// employee and component level, flagging changes above the organization's
+// variance thresholds. With flaggedOnly set, only flagged employees are
+// listed; the counts cover every employee.
+func (s *PayrollService) GetVarianceReport(payrollRunID string, flaggedOnly bool) (*VarianceReport, error) {
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	report, err := s.varianceReport(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	if flaggedOnly {
+		flagged := []EmployeeVariance{}
+		for _, v := range report.Employees {
+			if v.Flagged {
+				flagged = append(flagged, v)
+			}
+		}
+		report.Employees = flagged
+	}
+
+	return report, nil
+}
+
+// AcknowledgeVariances accepts the flagged variances of some employees of a
+// run so it can be finalized. Each acknowledgement holds for the employee's
+// current net pay.
+func (s *PayrollService) AcknowledgeVariances(payrollRunID, acknowledgedBy, reason string, employeeIDs []string) ([]models.PayrollVarianceAcknowledgement, error) {
+	if acknowledgedBy == "" || acknowledgedBy == SystemActor {
+		return nil, invalidInput("variances must be acknowledged by a user")
+	}
+	if reason == "" {
+		return nil, invalidInput("a reason is required to acknowledge variances")
+	}
+	if len(employeeIDs) == 0 {
+		return nil, invalidInput("at least one employee is required")
+	}
+
+	pr, err := s.repo.GetPayrollRunByID(payrollRunID)
+	if err != nil {
+		return nil, err
+	}
+
+	switch pr.Status {
+	case RunStatusLocked, RunStatusReleased:
+		return nil, conflict("payroll run %s is %s; its variances can no longer be acknowledged", pr.ID, pr.Status)
+	}
+
+	report, err := s.varianceReport(pr)
+	if err != nil {
+		return nil, err
+	}
+
+	variances := map[string]*EmployeeVariance{}
+	for i := range report.Employees {
+		variances[report.Employees[i].EmployeeID] = &report.Employees[i]
+	}
+
+	acks := make([]models.PayrollVarianceAcknowledgement, 0, len(employeeIDs))
+	for _, id := range employeeIDs {
+		v := variances[id]
+		if v == nil || !v.Flagged {
+			return nil, invalidInput("employee %s has no flagged variance in payroll run %s", id, pr.ID)
+		}
+		acks = append(acks, models.PayrollVarianceAcknowledgement{
+			OrgID:          pr.OrgID,
+			PayrollRunID:   pr.ID,
+			EmployeeID:     id,
+			NetPay:         v.NetPayAfter,
+			Reason:         reason,
+			AcknowledgedBy: acknowledgedBy,
+		})
+	}
+
+	if err := s.varianceRepo.SaveVarianceAcknowledgements(acks); err != nil {
+		return nil, err
+	}
+
+	return acks, nil
+}
+
+// GetVarianceAcknowledgements lists the variance acknowledgements of a run
+func (s *PayrollService) GetVarianceAcknowledgements(payrollRunID string) ([]models.PayrollVarianceAcknowledgement, error) {
+	if _, err := s.repo.GetPayrollRunByID(payrollRunID); err != nil {
+		return nil, err
+	}
+
+	return s.varianceRepo.GetVarianceAcknowledgements(payrollRunID)
+}
+
+// requireAcknowledgedVariances guards finalization: every flagged variance
+// its causes do not explain needs an acknowledgement
+func requireAcknowledgedVariances(s *PayrollService, pr *models.PayrollRun) error {
+	report, err := s.varianceReport(pr)
+	if err != nil {
+		return err
+	}
+	if report.UnacknowledgedEmployees > 0 {
+		return conflict("%d employees of payroll run %s have unexplained variances from the previous released run; acknowledge them before finalizing",
+			report.UnacknowledgedEmployees, pr.ID)
+	}
+	return nil
+}
+
+// varianceReport builds the variance report of a run with every employee
+// whose component changed
+func (s *PayrollService) varianceReport(pr *models.PayrollRun) (*VarianceReport, error) {
+	settings, err := s.settingsRepo.GetPayrollSettings(pr.OrgID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &VarianceReport{
+		PayrollRunID:     pr.ID,
+		PercentThreshold: settings.VariancePercentThreshold,
+		AmountThreshold:  settings.VarianceAmountThreshold,
+		Employees:        []EmployeeVariance{},
+	}
+
+	previous, err := s.previousReleasedRun(pr)
+	if err != nil || previous == nil {
+		return report, err
+	}
+	report.PreviousPayrollRunID = &previous.ID
+	report.PreviousPayrollMonth = &previous.PayrollMonth
+
+	before, err := s.repo.GetPayrollComponents(previous.ID)
+	if err != nil {
+		return nil, err
+	}
+	after, err := s.repo.GetPayrollComponents(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	employees, err := s.empRepo.GetEmployees(pr.OrgID, map[string]interface{}{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch employees: %w", err)
+	}
+	employeesByID := map[string]*models.Employee{}
+	for i := range employees {
+		employeesByID[employees[i].ID] = &employees[i]
+	}
+
+	overrides, err := s.overrideRepo.GetComponentOverrides(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	overridden := map[string]bool{}
+	for _, o := range overrides {
+		overridden[o.EmployeeID] = true
+	}
+
+	acks, err := s.varianceRepo.GetVarianceAcknowledgements(pr.ID)
+	if err != nil {
+		return nil, err
+	}
+	acknowledged := map[string]float64{}
+	for _, a := range acks {
+		acknowledged[a.EmployeeID] = a.NetPay
+	}
+
+	beforeByEmployee := componentsByEmployee(before)
+	afterByEmployee := componentsByEmployee(after)
+
+	// Structures revised in place keep their ID, so the structures paid on
+	// are loaded to compare their dates with the previous run's period
+	var structureIDs []string
+	for _, pc := range after {
+		if pc.SalaryStructureID != nil {
+			structureIDs = append(structureIDs, *pc.SalaryStructureID)
+		}
+	}
+	structures, err := s.empRepo.GetSalaryStructuresByID(structureIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := diffComponents("", pr.ID, before, after)
+	report.NetPayBefore = diff.NetPayBefore
+	report.NetPayAfter = diff.NetPayAfter
+
+	for _, d := range diff.Employees {
+		v := EmployeeVariance{
+			EmployeeID: d.EmployeeID,
+			Added:      d.Added,
+			Removed:    d.Removed,
+			Causes:     []VarianceCause{},
+			Changes:    make([]ComponentVariance, 0, len(d.Changes)),
+		}
+		if pc := beforeByEmployee[d.EmployeeID]; pc != nil {
+			v.NetPayBefore = pc.NetPay
+		}
+		if pc := afterByEmployee[d.EmployeeID]; pc != nil {
+			v.NetPayAfter = pc.NetPay
+		}
+
+		for _, c := range d.Changes {
+			cv := ComponentVariance{ComponentChange: c, Flagged: exceedsVarianceThresholds(c, settings)}
+			if c.Before != 0 {
+				percent := math.Round(c.Difference/math.Abs(c.Before)*10000) / 100
+				cv.Percent = &percent
+			}
+			v.Flagged = v.Flagged || cv.Flagged
+			v.Changes = append(v.Changes, cv)
+		}
+
+		v.Causes = varianceCauses(pr, previous, employeesByID[d.EmployeeID],
+			beforeByEmployee[d.EmployeeID], afterByEmployee[d.EmployeeID], structures, overridden[d.EmployeeID])
+		v.Unexplained = v.NetPayAfter - v.NetPayBefore
+		for _, c := range v.Causes {
+			v.Unexplained -= c.Amount
+		}
+		v.Unexplained = math.Round(v.Unexplained*100) / 100
+		v.Explained = withinVarianceTolerance(v.Unexplained, v.NetPayBefore, settings)
+
+		if netPay, ok := acknowledged[d.EmployeeID]; ok {
+			v.Acknowledged = math.Abs(netPay-v.NetPayAfter) < 0.005
+		}
+
+		if v.Flagged {
+			report.FlaggedEmployees++
+			if !v.Explained {
+				report.UnexplainedEmployees++
+			}
+		}
+		if v.blocking() {
+			report.UnacknowledgedEmployees++
+		}
+
+		report.Employees = append(report.Employees, v)
+	}
+
+	return report, nil
+}
+
+// previousReleasedRun finds the latest released run of the same type and pay
+// group as a run that ended before it started. Returns nil when there is none.
+func (s *PayrollService) previousReleasedRun(pr *models.PayrollRun) (*models.PayrollRun, error) {
+	runs, err := s.repo.GetPayrollRuns(pr.OrgID, map[string]interface{}{
+		"status":   RunStatusReleased,
+		"run_type": pr.RunType,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Runs come latest first
+	for i := range runs {
+		if runs[i].PayGroupID == pr.PayGroupID && runs[i].PayrollPeriodEnd.Before(pr.PayrollPeriodStart) {
+			return &runs[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// exceedsVarianceThresholds reports whether a change is above either
+// threshold. Amounts that were zero are above any percentage.
+func exceedsVarianceThresholds(c ComponentChange, settings *models.PayrollSettings) bool {
+	change := math.Abs(c.Difference)
+	if settings.VarianceAmountThreshold > 0 && change > settings.VarianceAmountThreshold {
+		return true
+	}
+	if settings.VariancePercentThreshold > 0 {
+		return c.Before == 0 || change*100/math.Abs(c.Before) > settings.VariancePercentThreshold
+	}
+	return false
+}
+
+// withinVarianceTolerance reports whether the part of a net pay change no
+// cause accounts for is small enough to leave the variance explained: no
+// larger than the organization's variance thresholds allow. Statutory
+// deductions that follow an explained change in earnings stay within it.
+func withinVarianceTolerance(unexplained, netPayBefore float64, settings *models.PayrollSettings) bool {
+	if math.Abs(unexplained) < 0.005 {
+		return true
+	}
+	return !exceedsVarianceThresholds(ComponentChange{Before: netPayBefore, Difference: unexplained}, settings)
+}
+
+// varianceCauses lists the likely causes of the change in an employee's
+// component between two runs, each with the change in pay it accounts for.
+// Either component is nil when the employee was not paid in that run, and a
+// joiner's or leaver's whole net pay is then attributed to joining or
+// leaving. A salary revision is a change of structure, or a structure that
+// took effect or was updated after the previous run's period; it accounts for
+// the change in pay due before loss of pay, as joining or leaving does for a
+// part period paid in both runs.
+func varianceCauses(pr, previous *models.PayrollRun, emp *models.Employee, before, after *models.PayrollComponent,
+	structures map[string]*models.SalaryStructure, overridden bool) []VarianceCause {
+	causes := []VarianceCause{}
+	add := func(code string, amount float64, format string, args ...interface{}) {
+		causes = append(causes, VarianceCause{
+			Code:        code,
+			Amount:      math.Round(amount*100) / 100,
+			Description: fmt.Sprintf(format, args...),
+		})
+	}
+
+	joined := emp != nil && emp.DateOfJoining.After(previous.PayrollPeriodStart) && !emp.DateOfJoining.After(pr.PayrollPeriodEnd)
+	left := emp != nil && emp.DateOfExit != nil && !emp.DateOfExit.Before(previous.PayrollPeriodStart) && !emp.DateOfExit.After(pr.PayrollPeriodEnd)
+
+	if before == nil || after == nil {
+		if joined && before == nil {
+			add(VarianceCauseNewJoiner, after.NetPay, "joined on %s", emp.DateOfJoining.Format("2006-01-02"))
+		}
+		if left && after == nil {
+			add(VarianceCauseExit, -before.NetPay, "left on %s", emp.DateOfExit.Format("2006-01-02"))
+		}
+		return causes
+	}
+
+	// Pay due for the days employed, before loss of pay; the revision, or
+	// else joining or leaving, accounts for its change
+	payDue := payBeforeLOP(after) - payBeforeLOP(before)
+
+	revision := ""
+	if before.SalaryStructureID != nil && after.SalaryStructureID != nil && *before.SalaryStructureID != *after.SalaryStructureID {
+		revision = "paid on a revised salary structure"
+	} else if after.SalaryStructureID != nil && structures[*after.SalaryStructureID] != nil {
+		ss := structures[*after.SalaryStructureID]
+		previousEnd := previous.PayrollPeriodEnd.AddDate(0, 0, 1)
+		if !ss.EffectiveFrom.Before(previousEnd) {
+			revision = fmt.Sprintf("salary structure effective from %s", ss.EffectiveFrom.Format("2006-01-02"))
+		} else if !ss.UpdatedAt.Before(previousEnd) {
+			revision = fmt.Sprintf("salary structure revised on %s", ss.UpdatedAt.Format("2006-01-02"))
+		}
+	}
+
+	if joined {
+		add(VarianceCauseNewJoiner, attributeOnce(&payDue, revision == ""), "joined on %s", emp.DateOfJoining.Format("2006-01-02"))
+	}
+	if left {
+		add(VarianceCauseExit, attributeOnce(&payDue, revision == ""), "left on %s", emp.DateOfExit.Format("2006-01-02"))
+	}
+	if before.LOPDays != after.LOPDays || before.LOPAmount != after.LOPAmount {
+		add(VarianceCauseLOPDays, before.LOPAmount-after.LOPAmount, "loss of pay days changed from %d to %d", before.LOPDays, after.LOPDays)
+	}
+	if before.LOPReversal != after.LOPReversal {
+		add(VarianceCauseLOPReversal, after.LOPReversal-before.LOPReversal, "loss of pay paid back changed from %.2f to %.2f", before.LOPReversal, after.LOPReversal)
+	}
+	if revision != "" {
+		add(VarianceCauseRevision, attributeOnce(&payDue, true), "%s", revision)
+	}
+	if before.OneTimePayment != after.OneTimePayment {
+		add(VarianceCauseBonus, after.OneTimePayment-before.OneTimePayment, "one-time payment changed from %.2f to %.2f", before.OneTimePayment, after.OneTimePayment)
+	}
+	if overridden {
+		add(VarianceCauseOverride, 0, "calculated with manual inputs")
+	}
+
+	return causes
+}
+
+// attributeOnce hands out an amount to the first cause that claims it
+func attributeOnce(amount *float64, claim bool) float64 {
+	if !claim {
+		return 0
+	}
+	claimed := *amount
+	*amount = 0
+	return claimed
+}
+
+// payBeforeLOP is a component's salary structure earnings, fixed flexible
+// benefits included, before loss of pay was left out of them
+func payBeforeLOP(pc *models.PayrollComponent) float64 {
+	return pc.BasicPay + pc.DAAmount + pc.HRAAmount + pc.OtherAllowances + pc.FBPFixed + pc.LOPAmount
+}
+
+func componentsByEmployee(components []models.PayrollComponent) map[string]*models.PayrollComponent {
+	byEmployee := map[string]*models.PayrollComponent{}
+	for i := range components {
+		byEmployee[components[i].EmployeeID] = &components[i]
+	}
+	return byEmployee
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"database/sql"
+	"regexp"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+// tanPattern matches a Tax Deduction Account Number, e.g. MUMA12345B
+var tanPattern = regexp.MustCompile(`^[A-Z]{4}[0-9]{5}[A-Z]$`)
+
+type PreviousEmploymentService struct {
+	repo    *repository.PreviousEmploymentRepository
+	empRepo *repository.EmployeeRepository
+}
+
+func NewPreviousEmploymentService(db *sql.DB) *PreviousEmploymentService {
+	return &PreviousEmploymentService{
+		repo:    repository.NewPreviousEmploymentRepository(db),
+		empRepo: repository.NewEmployeeRepository(db),
+	}
+}
+
+// GetPreviousEmployments fetches an employee's earlier employers in a financial year
+func (s *PreviousEmploymentService) GetPreviousEmployments(employeeID string, financialYear int) ([]models.PreviousEmployment, error) {
+	return s.repo.GetPreviousEmployments(employeeID, financialYear)
+}
+
+// SavePreviousEmployment validates and records salary and tax from an
+// earlier employer in the financial year the employee joined
+func (s *PreviousEmploymentService) SavePreviousEmployment(p *models.PreviousEmployment) error {
+	if !tanPattern.MatchString(p.EmployerTAN) {
+		return invalidInput("employer_tan must be 4 letters, 5 digits and a letter")
+	}
+
+	if p.PeriodTo.Before(p.PeriodFrom) {
+		return invalidInput("period_to cannot be before period_from")
+	}
+
+	if calculator.FinancialYearStart(p.PeriodFrom).Year() != p.FinancialYear ||
+		calculator.FinancialYearStart(p.PeriodTo).Year() != p.FinancialYear {
+		return invalidInput("period must fall in financial year %d", p.FinancialYear)
+	}
+
+	if p.GrossSalary < 0 || p.Exemptions < 0 || p.PFEmployee < 0 || p.ProfessionalTax < 0 || p.TDS < 0 {
+		return invalidInput("amounts cannot be negative")
+	}
+
+	if p.Exemptions > p.GrossSalary {
+		return invalidInput("exemptions cannot exceed gross_salary")
+	}
+
+	emp, err := s.empRepo.GetEmployeeByID(p.EmployeeID)
+	if err != nil || emp.OrgID != p.OrgID {
+		return invalidInput("employee %s not found in organization", p.EmployeeID)
+	}
+
+	if !p.PeriodTo.Before(emp.DateOfJoining) {
+		return invalidInput("previous employment must end before the date of joining")
+	}
+
+	return s.repo.UpsertPreviousEmployment(p)
+}
+
+// DeletePreviousEmployment removes an earlier employer recorded in error
+func (s *PreviousEmploymentService) DeletePreviousEmployment(id string) error {
+	return s.repo.DeletePreviousEmployment(id)
+}
+
+// previousEmploymentIncome returns the salary taxed by earlier employers, on
+// the same basis as payroll (gross less exemptions, PF and professional tax),
+// and the tax they deducted
+func previousEmploymentIncome(employments []models.PreviousEmployment) (income, tds float64) {
+	for _, p := range employments {
+		income += p.GrossSalary - p.Exemptions - p.PFEmployee - p.ProfessionalTax
+		tds += p.TDS
+	}
+	return income, tds
+}
+
+// taxYearToDate combines the financial year's income and tax outside the
+// run's payroll month, and with earlier employers, for the TDS projection
+func taxYearToDate(pr *models.PayrollRun, taxable, tds float64, employments []models.PreviousEmployment, finalSettlement bool) (*calculator.TaxYearToDate, error) {
+	fyStart := calculator.FinancialYearStart(pr.PayrollPeriodEnd)
+
+	ytd := &calculator.TaxYearToDate{TaxableIncome: taxable, TDS: tds, RemainingMonths: 1}
+	ytd.PreviousEmployerIncome, ytd.PreviousEmployerTDS = previousEmploymentIncome(employments)
+
+	// Months from the payroll month through March; nothing is projected past an exit
+	if !finalSettlement {
+		month, err := time.Parse("2006-01", pr.PayrollMonth)
+		if err != nil {
+			return nil, invalidInput("invalid payroll month %q", pr.PayrollMonth)
+		}
+		fyEnd := fyStart.AddDate(1, 0, 0)
+		ytd.RemainingMonths = (fyEnd.Year()-month.Year())*12 + int(fyEnd.Month()) - int(month.Month())
+		if ytd.RemainingMonths < 1 {
+			ytd.RemainingMonths = 1
+		}
+	}
+
+	return ytd, nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"database/sql"
+	"strings"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+type RosterService struct {
+	repo    *repository.RosterRepository
+	empRepo *repository.EmployeeRepository
+}
+
+func NewRosterService(db *sql.DB) *RosterService {
+	return &RosterService{
+		repo:    repository.NewRosterRepository(db),
+		empRepo: repository.NewEmployeeRepository(db),
+	}
+}
+
+// IngestShifts validates and stores roster shifts. The roster marks removed
+// assignments "inactive"; they are kept as cancelled so they stop earning
+// an allowance.
+func (s *RosterService) IngestShifts(orgID string, shifts []models.RosterShift) error {
+	if len(shifts) == 0 {
+		return invalidInput("no shifts to ingest")
+	}
+
+	checked := map[string]bool{}
+	for i := range shifts {
+		shift := &shifts[i]
+
+		if shift.EmployeeID == "" || shift.ShiftType == "" {
+			return invalidInput("shift %d: employee_id and shift_type are required", i+1)
+		}
+
+		start, err := calculator.ParseShiftTime(shift.StartTime)
+		if err != nil {
+			return invalidInput("shift %d: %v", i+1, err)
+		}
+		end, err := calculator.ParseShiftTime(shift.EndTime)
+		if err != nil {
+			return invalidInput("shift %d: %v", i+1, err)
+		}
+		shift.StartTime = start.Format("15:04")
+		shift.EndTime = end.Format("15:04")
+
+		switch strings.ToLower(shift.Status) {
+		case "", "active":
+			shift.Status = "active"
+		case "inactive", "cancelled":
+			shift.Status = "cancelled"
+		default:
+			return invalidInput("shift %d: status must be active or cancelled", i+1)
+		}
+
+		if !checked[shift.EmployeeID] {
+			emp, err := s.empRepo.GetEmployeeByID(shift.EmployeeID)
+			if err != nil || emp.OrgID != orgID {
+				return invalidInput("shift %d: employee %s not found in organization", i+1, shift.EmployeeID)
+			}
+			checked[shift.EmployeeID] = true
+		}
+
+		shift.OrgID = orgID
+	}
+
+	return s.repo.UpsertShifts(orgID, shifts)
+}
+
+// GetShifts fetches roster shifts for an organization
+func (s *RosterService) GetShifts(orgID string, filters map[string]interface{}) ([]models.RosterShift, error) {
+	return s.repo.GetShifts(orgID, filters)
+}
+
+// GetShiftAllowancePolicies fetches the shift allowance policies in force on a date
+func (s *RosterService) GetShiftAllowancePolicies(orgID string, asOf time.Time) ([]models.ShiftAllowancePolicy, error) {
+	return s.repo.GetShiftAllowancePolicies(orgID, asOf)
+}
+
+// SaveShiftAllowancePolicy validates and saves a shift type's policy version
+func (s *RosterService) SaveShiftAllowancePolicy(policy *models.ShiftAllowancePolicy) error {
+	if policy.ShiftType == "" {
+		return invalidInput("shift_type is required")
+	}
+
+	if policy.AmountPerShift < 0 || policy.NightPremium < 0 || policy.MinShifts < 0 {
+		return invalidInput("amounts and minimum shifts cannot be negative")
+	}
+
+	if policy.EffectiveTill != nil && policy.EffectiveTill.Before(policy.EffectiveFrom) {
+		return invalidInput("effective_till cannot be before effective_from")
+	}
+
+	return s.repo.UpsertShiftAllowancePolicy(policy)
+}
+
+// shiftAllowancePolicies converts stored policies for the calculator
+func shiftAllowancePolicies(policies []models.ShiftAllowancePolicy) []calculator.ShiftAllowancePolicy {
+	converted := make([]calculator.ShiftAllowancePolicy, len(policies))
+	for i, p := range policies {
+		converted[i] = calculator.ShiftAllowancePolicy{
+			ShiftType:      p.ShiftType,
+			AmountPerShift: p.AmountPerShift,
+			NightPremium:   p.NightPremium,
+			MinShifts:      p.MinShifts,
+		}
+	}
+	return converted
+}
+
+// rosterShifts converts stored roster shifts for the calculator
+func rosterShifts(shifts []models.RosterShift) []calculator.RosterShift {
+	converted := make([]calculator.RosterShift, len(shifts))
+	for i, s := range shifts {
+		converted[i] = calculator.RosterShift{
+			Date:      s.ShiftDate,
+			ShiftType: s.ShiftType,
+			StartTime: s.StartTime,
+			EndTime:   s.EndTime,
+		}
+	}
+	return converted
+}
@@ -0,0 +1,267 @@
+package service
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+type StatutoryRuleService struct {
+	repo              *repository.StatutoryRuleRepository
+	calculatorFactory *calculator.CalculatorFactory
+}
+
+func NewStatutoryRuleService(db *sql.DB, calculatorFactory *calculator.CalculatorFactory) *StatutoryRuleService {
+	return &StatutoryRuleService{
+		repo:              repository.NewStatutoryRuleRepository(db),
+		calculatorFactory: calculatorFactory,
+	}
+}
+
+// GetStatutoryRules lists statutory rules
+func (s *StatutoryRuleService) GetStatutoryRules(filters map[string]interface{}) ([]models.StatutoryRule, error) {
+	return s.repo.GetStatutoryRules(filters)
+}
+
+// GetStatutoryRule fetches a single rule row
+func (s *StatutoryRuleService) GetStatutoryRule(ruleID string) (*models.StatutoryRule, error) {
+	return s.repo.GetStatutoryRuleByID(ruleID)
+}
+
+// CreateRuleVersion creates a new rule version. All rows must share rule
+// family, org, state and effective window; PT and TDS versions carry one
+// row per slab.
+func (s *StatutoryRuleService) CreateRuleVersion(rows []models.StatutoryRule) ([]models.StatutoryRule, error) {
+	if err := calculator.ValidateRuleVersion(rows); err != nil {
+		return nil, invalidInput("%v", err)
+	}
+
+	if err := s.checkOverlap(rows[0], nil); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.CreateRuleVersion(rows); err != nil {
+		return nil, err
+	}
+
+	s.calculatorFactory.InvalidateRules()
+
+	return rows, nil
+}
+
+// UpdateStatutoryRule changes the values of a rule row and the effective_till
+// of its version. Versions used by a locked payroll run cannot be changed.
+func (s *StatutoryRuleService) UpdateStatutoryRule(ruleID string, update models.StatutoryRule) (*models.StatutoryRule, error) {
+	existing, version, err := s.loadVersion(ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.ensureNotLocked(version); err != nil {
+		return nil, err
+	}
+
+	merged := *existing
+	merged.PFEmployeeRate, merged.PFEmployerRate, merged.PFCeiling = update.PFEmployeeRate, update.PFEmployerRate, update.PFCeiling
+	merged.ESIEmployeeRate, merged.ESIEmployerRate = update.ESIEmployeeRate, update.ESIEmployerRate
+	merged.ESIWageCeiling, merged.ESIThresholdSalary = update.ESIWageCeiling, update.ESIThresholdSalary
+	merged.PTSlabMin, merged.PTSlabMax, merged.PTAmount = update.PTSlabMin, update.PTSlabMax, update.PTAmount
+	merged.TDSSlabMin, merged.TDSSlabMax, merged.TDSRate = update.TDSSlabMin, update.TDSSlabMax, update.TDSRate
+	merged.GratuityRatePerYear, merged.GratuityCompletionMonths = update.GratuityRatePerYear, update.GratuityCompletionMonths
+	merged.EffectiveTill = update.EffectiveTill
+
+	candidate := make([]models.StatutoryRule, len(version))
+	versionIDs := make([]string, len(version))
+	for i, row := range version {
+		if row.ID == merged.ID {
+			row = merged
+		}
+		row.EffectiveTill = merged.EffectiveTill
+		candidate[i] = row
+		versionIDs[i] = row.ID
+	}
+
+	if err := calculator.ValidateRuleVersion(candidate); err != nil {
+		return nil, invalidInput("%v", err)
+	}
+
+	if err := s.checkOverlap(merged, versionIDs); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.UpdateStatutoryRule(&merged, versionIDs); err != nil {
+		return nil, err
+	}
+
+	s.calculatorFactory.InvalidateRules()
+
+	return &merged, nil
+}
+
+// DeleteStatutoryRule deactivates a rule row. The slabs left in its version
+// must still be continuous.
+func (s *StatutoryRuleService) DeleteStatutoryRule(ruleID string) error {
+	existing, version, err := s.loadVersion(ruleID)
+	if err != nil {
+		return err
+	}
+
+	if err := s.ensureNotLocked(version); err != nil {
+		return err
+	}
+
+	var remaining []models.StatutoryRule
+	for _, row := range version {
+		if row.ID != existing.ID {
+			remaining = append(remaining, row)
+		}
+	}
+
+	if len(remaining) > 0 {
+		if err := calculator.ValidateRuleVersion(remaining); err != nil {
+			return invalidInput("removing this row would leave an invalid version: %v", err)
+		}
+	}
+
+	if err := s.repo.DeactivateStatutoryRule(ruleID); err != nil {
+		return err
+	}
+
+	s.calculatorFactory.InvalidateRules()
+
+	return nil
+}
+
+// SupersedeRuleVersion corrects a rule by closing its version the day before
+// the new rows take effect. This is the only way to change a version used by
+// a locked payroll run, and the new version must start after every locked
+// period that used the old one.
+func (s *StatutoryRuleService) SupersedeRuleVersion(ruleID string, rows []models.StatutoryRule) ([]models.StatutoryRule, error) {
+	existing, version, err := s.loadVersion(ruleID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows) == 0 {
+		return nil, invalidInput("replacement version has no rows")
+	}
+
+	effectiveFrom := rows[0].EffectiveFrom
+	if !effectiveFrom.After(existing.EffectiveFrom) {
+		return nil, invalidInput("replacement must take effect after %s", existing.EffectiveFrom.Format("2006-01-02"))
+	}
+
+	versionIDs := make([]string, len(version))
+	for i, row := range version {
+		versionIDs[i] = row.ID
+	}
+
+	latest, err := s.repo.GetLatestLockedUsage(versionIDs)
+	if err != nil {
+		return nil, err
+	}
+	if latest != nil && !effectiveFrom.After(*latest) {
+		return nil, conflict("rule was used by a locked payroll run up to %s; replacement must take effect after that date", latest.Format("2006-01-02"))
+	}
+
+	for i := range rows {
+		rows[i].RuleType = existing.RuleType
+		rows[i].OrgID = existing.OrgID
+		rows[i].StateCode = existing.StateCode
+	}
+
+	if err := calculator.ValidateRuleVersion(rows); err != nil {
+		return nil, invalidInput("%v", err)
+	}
+
+	if err := s.checkOverlap(rows[0], versionIDs); err != nil {
+		return nil, err
+	}
+
+	// Only shorten the old window, never extend it
+	closeOn := effectiveFrom.AddDate(0, 0, -1)
+	if existing.EffectiveTill != nil && existing.EffectiveTill.Before(closeOn) {
+		closeOn = *existing.EffectiveTill
+	}
+
+	if err := s.repo.SupersedeRuleVersion(versionIDs, closeOn, rows); err != nil {
+		return nil, err
+	}
+
+	s.calculatorFactory.InvalidateRules()
+
+	return rows, nil
+}
+
+// loadVersion fetches an active rule and the rows of its version
+func (s *StatutoryRuleService) loadVersion(ruleID string) (*models.StatutoryRule, []models.StatutoryRule, error) {
+	existing, err := s.repo.GetStatutoryRuleByID(ruleID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !existing.IsActive {
+		return nil, nil, conflict("statutory rule %s is inactive", ruleID)
+	}
+
+	version, err := s.repo.GetRuleVersion(calculator.RuleFamilyTypes(existing.RuleType), existing.OrgID, existing.StateCode, existing.EffectiveFrom)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return existing, version, nil
+}
+
+// ensureNotLocked rejects changes to versions used by locked payroll runs
+func (s *StatutoryRuleService) ensureNotLocked(version []models.StatutoryRule) error {
+	ids := make([]string, len(version))
+	for i, row := range version {
+		ids[i] = row.ID
+	}
+
+	latest, err := s.repo.GetLatestLockedUsage(ids)
+	if err != nil {
+		return err
+	}
+
+	if latest != nil {
+		return conflict("rule version was used by a locked payroll run up to %s; supersede it with a new effective-dated version instead", latest.Format("2006-01-02"))
+	}
+
+	return nil
+}
+
+// checkOverlap rejects a window that overlaps another active version of the
+// same rule family, org scope and state. Rows in excludeIDs are ignored.
+func (s *StatutoryRuleService) checkOverlap(rule models.StatutoryRule, excludeIDs []string) error {
+	overlapping, err := s.repo.GetOverlappingRules(calculator.RuleFamilyTypes(rule.RuleType), rule.OrgID, rule.StateCode, rule.EffectiveFrom, rule.EffectiveTill)
+	if err != nil {
+		return err
+	}
+
+	excluded := make(map[string]bool, len(excludeIDs))
+	for _, id := range excludeIDs {
+		excluded[id] = true
+	}
+
+	for _, other := range overlapping {
+		if excluded[other.ID] {
+			continue
+		}
+		return conflict("effective window overlaps %s version effective from %s%s",
+			other.RuleType, other.EffectiveFrom.Format("2006-01-02"), formatTill(other.EffectiveTill))
+	}
+
+	return nil
+}
+
+func formatTill(till *time.Time) string {
+	if till == nil {
+		return " (open-ended)"
+	}
+	return fmt.Sprintf(" till %s", till.Format("2006-01-02"))
+}
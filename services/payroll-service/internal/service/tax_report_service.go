@@ -0,0 +1,365 @@
+package service
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/reports"
+	"payroll-service/internal/repository"
+)
+
+type TaxReportService struct {
+	payrollRepo *repository.PayrollRepository
+	empRepo     *repository.EmployeeRepository
+	orgRepo     *repository.OrganizationRepository
+	equityRepo  *repository.EquityRepository
+	prevEmpRepo *repository.PreviousEmploymentRepository
+	taxRepo     *repository.IncomeTaxRepository
+	jobRepo     *repository.JobRepository
+}
+
+func NewTaxReportService(db *sql.DB) *TaxReportService {
+	return &TaxReportService{
+		payrollRepo: repository.NewPayrollRepository(db),
+		empRepo:     repository.NewEmployeeRepository(db),
+		orgRepo:     repository.NewOrganizationRepository(db),
+		equityRepo:  repository.NewEquityRepository(db),
+		prevEmpRepo: repository.NewPreviousEmploymentRepository(db),
+		taxRepo:     repository.NewIncomeTaxRepository(db),
+		jobRepo:     repository.NewJobRepository(db),
+	}
+}
+
+// GenerateForm12BA generates an employee's statement of perquisites for a
+// financial year from locked and released runs
+func (s *TaxReportService) GenerateForm12BA(employeeID string, financialYear int) (*reports.Form12BAData, error) {
+	emp, org, err := s.employeeAndOrganization(employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	months, err := s.salaryMonths(employeeID, financialYear)
+	if err != nil {
+		return nil, err
+	}
+
+	var retirementPerquisite float64
+	for _, m := range months {
+		retirementPerquisite += m.RetirementPerquisite
+	}
+
+	stockOptions, err := s.stockOptions(emp, financialYear)
+	if err != nil {
+		return nil, err
+	}
+
+	generator := reports.NewStatutoryReportGenerator(org.ID, fiscalYear(financialYear))
+	return generator.GenerateForm12BA(emp, stockOptions, retirementPerquisite, organizationDetails(org)), nil
+}
+
+// GenerateForm16 generates an employee's Form 16 for a financial year from
+// locked and released runs
+func (s *TaxReportService) GenerateForm16(employeeID string, financialYear int) (*reports.Form16Data, error) {
+	emp, org, err := s.employeeAndOrganization(employeeID)
+	if err != nil {
+		return nil, err
+	}
+
+	months, err := s.salaryMonths(employeeID, financialYear)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(months) == 0 {
+		return nil, invalidInput("no locked payroll for financial year %d", financialYear)
+	}
+
+	data := reports.AnnualSalaryData{EmployeeID: emp.ID}
+	for _, m := range months {
+		data.TotalGross += m.GrossAmount
+		data.TotalBasic += m.BasicPay
+		data.TotalDA += m.DAAmount
+		data.TotalDeductions += m.TotalDeductions
+		data.TotalPerquisites += m.RetirementPerquisite + m.EquityPerquisite
+		data.DeferredPerquisites += m.EquityPerquisiteDeferred
+
+		month, _ := time.Parse("2006-01", m.PayrollMonth)
+		data.MonthlyData = append(data.MonthlyData, reports.MonthlySalaryData{
+			Month:      month.Format("Jan-2006"),
+			Gross:      m.GrossAmount,
+			TDS:        m.TDS,
+			PF:         m.PFEmployee,
+			ESI:        m.ESIEmployee,
+			PT:         m.ProfessionalTax,
+			NetPay:     m.NetPay,
+			DaysWorked: m.DaysWorked,
+		})
+	}
+
+	employments, err := s.prevEmpRepo.GetPreviousEmployments(employeeID, financialYear)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, p := range employments {
+		income, _ := previousEmploymentIncome([]models.PreviousEmployment{p})
+		data.PreviousEmployers = append(data.PreviousEmployers, reports.PreviousEmployerDetail{
+			EmployerName:  p.EmployerName,
+			EmployerTAN:   p.EmployerTAN,
+			PeriodFrom:    p.PeriodFrom.Format("02-Jan-2006"),
+			PeriodTo:      p.PeriodTo.Format("02-Jan-2006"),
+			GrossSalary:   p.GrossSalary,
+			Exemptions:    p.Exemptions,
+			PF:            p.PFEmployee,
+			PT:            p.ProfessionalTax,
+			TaxableIncome: income,
+			TDS:           p.TDS,
+		})
+	}
+
+	// The year's slabs and surcharges for the employee's regime; age picks
+	// the senior citizen slabs
+	taxRegime := emp.TaxRegime
+	if taxRegime == "" {
+		taxRegime = calculator.TaxRegimeNew
+	}
+
+	slabs, err := s.taxRepo.GetIncomeTaxSlabs(financialYear, taxRegime)
+	if err != nil {
+		return nil, err
+	}
+	if len(slabs) == 0 {
+		return nil, invalidInput("no income tax slabs for financial year %d under the %s regime", financialYear, taxRegime)
+	}
+
+	surcharges, err := s.taxRepo.GetIncomeTaxSurcharges(financialYear, taxRegime)
+	if err != nil {
+		return nil, err
+	}
+
+	data.IncomeTax = &incomeTaxTables(slabs, surcharges)[0]
+	data.Age = calculator.AgeAtFinancialYearEnd(emp.DateOfBirth, time.Date(financialYear, time.April, 1, 0, 0, 0, 0, time.UTC))
+
+	generator := reports.NewStatutoryReportGenerator(org.ID, fiscalYear(financialYear))
+	return generator.GenerateForm16(emp, data, organizationDetails(org)), nil
+}
+
+// GeneratePFECR generates an organization's PF return for a payroll month
+// from every locked and released run of the month, regular or off-cycle
+func (s *TaxReportService) GeneratePFECR(orgID, payrollMonth string) (*reports.PFECRData, error) {
+	org, totals, names, err := s.statutoryMonth(orgID, payrollMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	contributions := map[string]reports.PFEmployeeDetail{}
+	for _, t := range totals {
+		if t.PFEmployee == 0 && t.PFEmployer == 0 {
+			continue
+		}
+		contributions[t.EmployeeID] = reports.PFEmployeeDetail{
+			EmployeeID:           t.EmployeeID,
+			EmployeeName:         names[t.EmployeeID],
+			EmployeeContribution: t.PFEmployee,
+			EmployerContribution: t.PFEmployer,
+			TotalContribution:    t.PFEmployee + t.PFEmployer,
+		}
+	}
+
+	month, _ := time.Parse("2006-01", payrollMonth)
+	generator := reports.NewStatutoryReportGenerator(org.ID, fiscalYear(calculator.FinancialYearStart(month).Year()))
+	return generator.GeneratePFECR(payrollMonth, organizationDetails(org), contributions, reports.ChallanDetails{}), nil
+}
+
+// GenerateESIChallan generates an organization's ESI challan for a payroll
+// month from every locked and released run of the month, regular or off-cycle
+func (s *TaxReportService) GenerateESIChallan(orgID, payrollMonth string) (*reports.ESIChallanData, error) {
+	org, totals, _, err := s.statutoryMonth(orgID, payrollMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	var summary reports.ESIContributionSummary
+	for _, t := range totals {
+		if t.ESIEmployee == 0 && t.ESIEmployer == 0 {
+			continue
+		}
+		summary.TotalEmployees++
+		summary.EmployeeContribution += t.ESIEmployee
+		summary.EmployerContribution += t.ESIEmployer
+	}
+	summary.TotalContribution = summary.EmployeeContribution + summary.EmployerContribution
+
+	month, _ := time.Parse("2006-01", payrollMonth)
+	generator := reports.NewStatutoryReportGenerator(org.ID, fiscalYear(calculator.FinancialYearStart(month).Year()))
+	return generator.GenerateESIChallan(payrollMonth, organizationDetails(org), summary, reports.PaymentDetails{}), nil
+}
+
+// statutoryMonth loads an organization, its employees' contribution totals
+// for a payroll month and their names
+func (s *TaxReportService) statutoryMonth(orgID, payrollMonth string) (*models.Organization, []models.StatutoryMonthTotals, map[string]string, error) {
+	if _, err := time.Parse("2006-01", payrollMonth); err != nil {
+		return nil, nil, nil, invalidInput("payroll_month must be YYYY-MM")
+	}
+
+	org, err := s.orgRepo.GetOrganizationByID(orgID)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	totals, err := s.payrollRepo.GetStatutoryMonthTotals(orgID, payrollMonth)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if len(totals) == 0 {
+		return nil, nil, nil, invalidInput("no locked payroll for %s", payrollMonth)
+	}
+
+	employees, err := s.empRepo.GetEmployees(orgID, map[string]interface{}{})
+	if err != nil {
+		return nil, nil, nil, fmt.Errorf("failed to fetch employees: %w", err)
+	}
+	names := make(map[string]string, len(employees))
+	for _, emp := range employees {
+		names[emp.ID] = emp.FirstName + " " + emp.LastName
+	}
+
+	return org, totals, names, nil
+}
+
+func (s *TaxReportService) employeeAndOrganization(employeeID string) (*models.Employee, *models.Organization, error) {
+	emp, err := s.empRepo.GetEmployeeByID(employeeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	org, err := s.orgRepo.GetOrganizationByID(emp.OrgID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return emp, org, nil
+}
+
+func (s *TaxReportService) salaryMonths(employeeID string, financialYear int) ([]models.SalaryMonthTotals, error) {
+	if financialYear < 2000 || financialYear > 2100 {
+		return nil, invalidInput("financial_year must be between 2000 and 2100")
+	}
+
+	return s.payrollRepo.GetSalaryMonths(employeeID, fmt.Sprintf("%d-04", financialYear), fmt.Sprintf("%d-03", financialYear+1))
+}
+
+// stockOptions lists the ESOP exercises and RSU vestings of the financial
+// year that payroll has reported
+func (s *TaxReportService) stockOptions(emp *models.Employee, financialYear int) ([]reports.StockOptionDetail, error) {
+	from := time.Date(financialYear, time.April, 1, 0, 0, 0, 0, time.UTC)
+	events, err := s.equityRepo.GetEquityEvents(emp.OrgID, map[string]interface{}{
+		"employee_id": emp.ID,
+		"from":        from,
+		"to":          from.AddDate(1, 0, -1),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	grants, err := s.equityRepo.GetEquityGrants(emp.OrgID, map[string]interface{}{"employee_id": emp.ID})
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]*models.EquityGrant, len(grants))
+	for i := range grants {
+		byID[grants[i].ID] = &grants[i]
+	}
+
+	var details []reports.StockOptionDetail
+	for _, e := range events {
+		grant := byID[e.GrantID]
+		if grant == nil || !calculator.IsPerquisiteEvent(grant.GrantType, e.EventType) {
+			continue
+		}
+		if e.Status != "deferred" && e.Status != "taxed" {
+			continue // Not yet reported by payroll
+		}
+
+		detail := reports.StockOptionDetail{
+			GrantNumber:   grant.GrantNumber,
+			GrantType:     grant.GrantType,
+			EventDate:     e.EventDate.Format("02-Jan-2006"),
+			Units:         e.Units,
+			FMV:           e.FMV,
+			ExercisePrice: e.ExercisePrice,
+			Value:         e.PerquisiteValue,
+			Startup:       e.TaxDeferred,
+			TaxDeferred:   e.Status == "deferred",
+		}
+		if e.TaxDueDate != nil {
+			detail.TaxDueDate = e.TaxDueDate.Format("02-Jan-2006")
+		}
+		details = append(details, detail)
+	}
+
+	return details, nil
+}
+
+// fiscalYear formats a financial year as the report generators expect, e.g.
+// "2024-2025" for 2024
+func fiscalYear(financialYear int) string {
+	return fmt.Sprintf("%d-%d", financialYear, financialYear+1)
+}
+
+func organizationDetails(org *models.Organization) reports.OrganizationDetails {
+	return reports.OrganizationDetails{
+		ID:                    org.ID,
+		Name:                  org.Name,
+		Code:                  org.EntityCode,
+		PAN:                   org.PAN,
+		NPSRegistrationNumber: org.NPSRegistrationNumber.String,
+	}
+}
+
+// statutoryReportPayload is the payload of pf_ecr and esi_challan jobs
+type statutoryReportPayload struct {
+	PayrollMonth string `json:"payroll_month"`
+}
+
+// EnqueueStatutoryReport queues the generation of an organization's PF ECR
+// or ESI challan for a payroll month
+func (s *TaxReportService) EnqueueStatutoryReport(orgID, jobType, payrollMonth, requestedBy string) (*models.Job, error) {
+	if jobType != JobTypePFECR && jobType != JobTypeESIChallan {
+		return nil, invalidInput("unknown statutory report %s", jobType)
+	}
+	if _, err := time.Parse("2006-01", payrollMonth); err != nil {
+		return nil, invalidInput("payroll_month must be YYYY-MM")
+	}
+	if _, err := s.orgRepo.GetOrganizationByID(orgID); err != nil {
+		return nil, err
+	}
+
+	payload := statutoryReportPayload{PayrollMonth: payrollMonth}
+	return enqueueJob(s.jobRepo, orgID, jobType, "", payload, requestedBy)
+}
+
+// JobHandlers returns the handlers of statutory report jobs
+func (s *TaxReportService) JobHandlers() map[string]JobHandler {
+	return map[string]JobHandler{
+		JobTypePFECR: func(job *models.Job, progress JobProgress) (interface{}, error) {
+			var p statutoryReportPayload
+			if err := json.Unmarshal(job.Payload, &p); err != nil {
+				return nil, invalidInput("invalid %s payload: %v", job.JobType, err)
+			}
+			return s.GeneratePFECR(job.OrgID, p.PayrollMonth)
+		},
+		JobTypeESIChallan: func(job *models.Job, progress JobProgress) (interface{}, error) {
+			var p statutoryReportPayload
+			if err := json.Unmarshal(job.Payload, &p); err != nil {
+				return nil, invalidInput("invalid %s payload: %v", job.JobType, err)
+			}
+			return s.GenerateESIChallan(job.OrgID, p.PayrollMonth)
+		},
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"database/sql"
+	"time"
+
+	"payroll-service/internal/calculator"
+	"payroll-service/internal/models"
+	"payroll-service/internal/repository"
+)
+
+// maxWorkingDaysRange caps how many days one working-days query may cover
+const maxWorkingDaysRange = 400
+
+type WorkingCalendarService struct {
+	repo    *repository.WorkingCalendarRepository
+	empRepo *repository.EmployeeRepository
+}
+
+func NewWorkingCalendarService(db *sql.DB) *WorkingCalendarService {
+	return &WorkingCalendarService{
+		repo:    repository.NewWorkingCalendarRepository(db),
+		empRepo: repository.NewEmployeeRepository(db),
+	}
+}
+
+// GetHolidayCalendars fetches an organization's holiday calendars
+func (s *WorkingCalendarService) GetHolidayCalendars(orgID string, filters map[string]interface{}) ([]models.HolidayCalendar, error) {
+	return s.repo.GetHolidayCalendars(orgID, filters)
+}
+
+// GetHolidayCalendar fetches a holiday calendar with its holidays
+func (s *WorkingCalendarService) GetHolidayCalendar(id string) (*models.HolidayCalendar, error) {
+	return s.repo.GetHolidayCalendarByID(id)
+}
+
+// CreateHolidayCalendar validates and creates a location's holiday calendar for a year
+func (s *WorkingCalendarService) CreateHolidayCalendar(cal *models.HolidayCalendar) error {
+	if cal.CalendarYear < 2000 || cal.CalendarYear > 2100 {
+		return invalidInput("calendar_year must be between 2000 and 2100")
+	}
+
+	if cal.Name == "" {
+		return invalidInput("name is required")
+	}
+
+	if err := validateHolidays(cal.CalendarYear, cal.Holidays); err != nil {
+		return err
+	}
+
+	return s.repo.CreateHolidayCalendar(cal)
+}
+
+// ReplaceHolidays validates and replaces all holidays of a calendar
+func (s *WorkingCalendarService) ReplaceHolidays(calendarID string, holidays []models.Holiday) (*models.HolidayCalendar, error) {
+	cal, err := s.repo.GetHolidayCalendarByID(calendarID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateHolidays(cal.CalendarYear, holidays); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.ReplaceHolidays(calendarID, holidays); err != nil {
+		return nil, err
+	}
+
+	cal.Holidays = holidays
+	return cal, nil
+}
+
+// validateHolidays checks each holiday falls in the calendar's year and has a known type
+func validateHolidays(year int, holidays []models.Holiday) error {
+	for i := range holidays {
+		h := &holidays[i]
+
+		if h.HolidayDate.Year() != year {
+			return invalidInput("%s is not in %d", h.HolidayDate.Format("2006-01-02"), year)
+		}
+
+		if h.Name == "" {
+			return invalidInput("holiday on %s needs a name", h.HolidayDate.Format("2006-01-02"))
+		}
+
+		switch h.HolidayType {
+		case "":
+			h.HolidayType = calculator.HolidayMandatory
+		case calculator.HolidayMandatory, calculator.HolidayRestricted:
+		default:
+			return invalidInput("holiday_type must be mandatory or restricted")
+		}
+	}
+
+	return nil
+}
+
+// GetWeeklyOffPolicy fetches the weekly off policy in force at a location.
+// Organizations without a policy have Sundays off.
+func (s *WorkingCalendarService) GetWeeklyOffPolicy(orgID, location string) (*models.WeeklyOffPolicy, error) {
+	policy, err := s.repo.GetWeeklyOffPolicy(orgID, location)
+	if err != nil {
+		return nil, err
+	}
+
+	if policy == nil {
+		policy = &models.WeeklyOffPolicy{
+			OrgID:   orgID,
+			Pattern: calculator.WeeklyOffSundayOnly,
+		}
+	}
+
+	return policy, nil
+}
+
+// SaveWeeklyOffPolicy validates and saves a location's weekly off policy
+func (s *WorkingCalendarService) SaveWeeklyOffPolicy(policy *models.WeeklyOffPolicy) error {
+	if err := weeklyOffPolicy(policy).Validate(); err != nil {
+		return invalidInput("%v", err)
+	}
+
+	return s.repo.UpsertWeeklyOffPolicy(policy)
+}
+
+// GetWorkingDays classifies every day of a period at a location. An
+// employee's location is used when employeeID is given.
+func (s *WorkingCalendarService) GetWorkingDays(orgID, location, employeeID string, from, to time.Time) (*calculator.WorkingDaysSummary, error) {
+	if to.Before(from) {
+		return nil, invalidInput("to cannot be before from")
+	}
+
+	if to.Sub(from).Hours()/24 >= maxWorkingDaysRange {
+		return nil, invalidInput("the period cannot be longer than %d days", maxWorkingDaysRange)
+	}
+
+	if employeeID != "" {
+		emp, err := s.empRepo.GetEmployeeByID(employeeID)
+		if err != nil || emp.OrgID != orgID {
+			return nil, invalidInput("employee %s not found in organization", employeeID)
+		}
+		location = emp.Location.String
+	}
+
+	wc, err := workingCalendar(s.repo, orgID, location, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := wc.WorkingDays(from, to)
+	return &summary, nil
+}
+
+// workingCalendar builds the working calendar of a location for a period
+func workingCalendar(repo *repository.WorkingCalendarRepository, orgID, location string, from, to time.Time) (*calculator.WorkingCalendar, error) {
+	policy, err := repo.GetWeeklyOffPolicy(orgID, location)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := repo.GetHolidays(orgID, location, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	return newWorkingCalendar(policy, stored), nil
+}
+
+// workingCalendars builds the working calendar of each of the employees'
+// locations for a period, keyed by location, with one query for the weekly
+// off policies and one for the holidays
+func (s *PayrollService) workingCalendars(orgID string, employees []models.Employee, from, to time.Time) (map[string]*calculator.WorkingCalendar, error) {
+	seen := map[string]bool{}
+	var locations []string
+	for _, emp := range employees {
+		if !seen[emp.Location.String] {
+			seen[emp.Location.String] = true
+			locations = append(locations, emp.Location.String)
+		}
+	}
+
+	policies, err := s.calendarRepo.GetWeeklyOffPolicies(orgID, locations)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays, err := s.calendarRepo.GetHolidaysByLocation(orgID, locations, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	calendars := map[string]*calculator.WorkingCalendar{}
+	for _, location := range locations {
+		calendars[location] = newWorkingCalendar(policies[location], holidays[location])
+	}
+	return calendars, nil
+}
+
+// newWorkingCalendar converts a location's stored weekly off policy and
+// holidays for the calculator. Without a policy, Sundays are off.
+func newWorkingCalendar(policy *models.WeeklyOffPolicy, stored []models.Holiday) *calculator.WorkingCalendar {
+	weeklyOff := calculator.WeeklyOffPolicy{Pattern: calculator.WeeklyOffSundayOnly}
+	if policy != nil {
+		weeklyOff = weeklyOffPolicy(policy)
+	}
+
+	holidays := make([]calculator.Holiday, len(stored))
+	for i, h := range stored {
+		holidays[i] = calculator.Holiday{
+			Date: h.HolidayDate,
+			Name: h.Name,
+			Type: h.HolidayType,
+		}
+	}
+
+	return calculator.NewWorkingCalendar(weeklyOff, holidays)
+}
+
+// weeklyOffPolicy converts a stored weekly off policy for the calculator
+func weeklyOffPolicy(policy *models.WeeklyOffPolicy) calculator.WeeklyOffPolicy {
+	converted := calculator.WeeklyOffPolicy{
+		Pattern:        policy.Pattern,
+		RotationAnchor: policy.RotationAnchor,
+	}
+	for _, week := range policy.SaturdayWeeks {
+		converted.SaturdayWeeks = append(converted.SaturdayWeeks, int(week))
+	}
+	if policy.RotationCycle != nil {
+		converted.RotationCycle = *policy.RotationCycle
+	}
+	for _, day := range policy.RotationOffDays {
+		converted.RotationOffDays = append(converted.RotationOffDays, int(day))
+	}
+	return converted
+}